@@ -27,6 +27,41 @@ func InitCouchdbServer(ctx context.Context, server *yaccc.Server) error {
 	return nil
 }
 
+// SetupDatabase connects to the database or creates it if it doesn't exist
+// yet. The design documents are then created or updated so the views added
+// since the database creation are available.
+func SetupDatabase(ctx context.Context, server *yaccc.Server, name string, designDocuments map[string]yaccc.DesignDocument) (*yaccc.Database, error) {
+	database, err := server.ConnectDatabase(ctx, name)
+	if err != nil {
+		database, err = server.CreateDatabase(ctx, &yaccc.CreateDatabaseCmd{
+			Name: name,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the database")
+		}
+	}
+
+	for docName, designDocument := range designDocuments {
+		var existing yaccc.DesignDocument
+
+		rev, err := database.Get(ctx, "_design/"+docName, &existing)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the design document %q", docName)
+		}
+
+		if rev != "" && reflect.DeepEqual(existing, designDocument) {
+			continue
+		}
+
+		_, err = database.Set(ctx, "_design/"+docName, rev, &designDocument)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to save the design document %q", docName)
+		}
+	}
+
+	return database, nil
+}
+
 func NewCouchdbDriver(bucket *yaccc.Database) *CouchdbDriver {
 	return &CouchdbDriver{
 		bucket: bucket,
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+)
+
+// ExpiringStorage is implemented by the storages indexing their documents
+// by expiration date.
+type ExpiringStorage interface {
+	// FindExpired returns the ids and the revisions of the documents expired
+	// at the given date.
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+	Delete(ctx context.Context, id string, rev string) error
+}
+
+// DeleteExpired deletes at most limit documents expired at the given date.
+// It returns the number of deleted documents.
+func DeleteExpired(ctx context.Context, storage ExpiringStorage, now time.Time, limit uint) (int, error) {
+	revs, err := storage.FindExpired(ctx, now, limit)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to find the expired documents")
+	}
+
+	var deleted int
+	for id, rev := range revs {
+		err = storage.Delete(ctx, id, rev)
+		if err != nil {
+			return deleted, errors.Wrapf(err, "failed to delete the document %q", id)
+		}
+
+		deleted++
+	}
+
+	return deleted, nil
+}
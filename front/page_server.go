@@ -54,13 +54,14 @@ func (t *PageServer) Register(w http.ResponseWriter, r *http.Request) {
 
 	err := r.ParseForm()
 	if err != nil {
-		errors.IntoResponse(w, errors.Errorf(errors.BadRequest, err.Error()))
+		errors.IntoResponse(w, errors.New(errors.BadRequest, err.Error()))
 		return
 	}
 
 	_, err = t.user.Create(r.Context(), &user.CreateCmd{
 		Username: r.PostForm.Get("username"),
 		Password: r.PostForm.Get("password"),
+		Email:    r.PostForm.Get("email"),
 		Role:     user.Dev,
 	})
 	if err != nil {
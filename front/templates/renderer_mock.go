@@ -0,0 +1,15 @@
+package templates
+
+import (
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RendererMock struct {
+	mock.Mock
+}
+
+func (t *RendererMock) Render(w io.Writer, templateName string, params interface{}) error {
+	return t.Called(templateName, params).Error(0)
+}
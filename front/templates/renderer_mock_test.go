@@ -0,0 +1,33 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RendererMock_Render(t *testing.T) {
+	mock := new(RendererMock)
+
+	mock.On("Render", "auth.html", nil).Return(nil).Once()
+
+	err := mock.Render(new(bytes.Buffer), "auth.html", nil)
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_RendererMock_Render_with_error(t *testing.T) {
+	mock := new(RendererMock)
+
+	mock.On("Render", "auth.html", nil).Return(fmt.Errorf("some-error")).Once()
+
+	err := mock.Render(new(bytes.Buffer), "auth.html", nil)
+
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
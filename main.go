@@ -3,23 +3,41 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/halium-project/go-server-utils/endpoint"
 	"github.com/halium-project/go-server-utils/env"
 	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/password"
 	"github.com/halium-project/go-server-utils/server"
+	"github.com/halium-project/go-server-utils/uuid"
 	"github.com/halium-project/server/db"
 	"github.com/halium-project/server/front"
 	"github.com/halium-project/server/front/templates"
 	"github.com/halium-project/server/resource/accesstoken"
 	"github.com/halium-project/server/resource/authorizationcode"
 	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/consent"
 	"github.com/halium-project/server/resource/contact"
+	"github.com/halium-project/server/resource/devicecode"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/passwordreset"
+	"github.com/halium-project/server/resource/personaltoken"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/securityevent"
+	"github.com/halium-project/server/resource/signingkey"
 	"github.com/halium-project/server/resource/todo"
 	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/saga/keyrotation"
 	"github.com/halium-project/server/saga/oauth2"
+	"github.com/halium-project/server/saga/reaper"
+	"github.com/halium-project/server/saga/recovery"
+	"github.com/halium-project/server/saga/registration"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/mailer"
 	"github.com/halium-project/server/utils/permission"
 	"gitlab.com/Peltoche/yaccc"
 )
@@ -51,9 +69,27 @@ func main() {
 
 	router := mux.NewRouter()
 
+	oauth2Config := oauth2.DefaultConfig
+	if issuer, ok := os.LookupEnv("ISSUER_URL"); ok {
+		oauth2Config.Issuer = issuer
+	}
+	oauth2Config.JWTAccessTokens = os.Getenv("JWT_ACCESS_TOKENS") == "true"
+
 	// Set the permission handler.
 	accessTokenController := accesstoken.InitController(ctx, couchdb)
-	perm := permission.NewController(ctx, accessTokenController)
+	signingKeyController := signingkey.InitController(ctx, couchdb)
+	revocationController := revocation.InitController(ctx, couchdb)
+
+	// The JWT access tokens are checked without any storage lookup.
+	var verifier permission.TokenVerifier
+	if oauth2Config.JWTAccessTokens {
+		jwtVerifier := permission.NewVerifier(signingKeyController, revocationController)
+		go jwtVerifier.Run(ctx, permission.DefaultRefreshInterval)
+		verifier = jwtVerifier
+	}
+	userController := user.InitController(ctx, couchdb)
+	personalTokenController := personaltoken.InitController(ctx, couchdb, userController)
+	perm := permission.NewController(ctx, accessTokenController, verifier, personalTokenController)
 
 	// Expose the Client resource.
 	clientController := client.InitController(ctx, couchdb)
@@ -61,10 +97,13 @@ func main() {
 	clientHTTPHandler.RegisterRoutes(router, perm)
 
 	// Expose the User resource.
-	userController := user.InitController(ctx, couchdb)
 	userHTTPHandler := user.NewHTTPHandler(userController)
 	userHTTPHandler.RegisterRoutes(router, perm)
 
+	// Expose the personal access tokens of the users.
+	personalTokenHTTPHandler := personaltoken.NewHTTPHandler(personalTokenController)
+	personalTokenHTTPHandler.RegisterRoutes(router, perm)
+
 	// Expose the Contact resource.
 	contactController := contact.InitController(ctx, couchdb)
 	contactHTTPHandler := contact.NewHTTPHandler(contactController)
@@ -75,13 +114,89 @@ func main() {
 	todoHTTPHandler := todo.NewHTTPHandler(todoController)
 	todoHTTPHandler.RegisterRoutes(router, perm)
 
+	// The contacts and the todos created before being bound to their owner
+	// are only listed by the admins. Set ORPHANS_OWNER_ID to the ID of the
+	// user receiving them at the next start.
+	if ownerID, ok := os.LookupEnv("ORPHANS_OWNER_ID"); ok {
+		count, err := contactController.AdoptOrphans(ctx, &contact.AdoptOrphansCmd{OwnerID: ownerID})
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "failed to give the contacts without owner"))
+		}
+		log.Printf("%d contacts given to the user %q", count, ownerID)
+
+		count, err = todoController.AdoptOrphans(ctx, &todo.AdoptOrphansCmd{OwnerID: ownerID})
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "failed to give the todos without owner"))
+		}
+		log.Printf("%d todos given to the user %q", count, ownerID)
+	}
+
 	// Expose the OAuth2 endpoint.
 	authorizationCodeController := authorizationcode.InitController(ctx, couchdb)
-	osinStorageController := oauth2.NewStorageController(clientController, authorizationCodeController, accessTokenController)
-	oauth2SagaController := oauth2.InitController(ctx, couchdb, templateRenderer, userController, osinStorageController)
-	router.HandleFunc("/oauth2/token", oauth2SagaController.Token)
-	router.HandleFunc("/oauth2/auth", oauth2SagaController.Authorize)
-	router.HandleFunc("/oauth2/info", oauth2SagaController.Info)
+	consentController := consent.InitController(ctx, couchdb)
+	securityEventController := securityevent.InitController(ctx, couchdb)
+	loginSessionController := loginsession.InitController(ctx, couchdb)
+	loginAttemptController := loginattempt.InitController(ctx, couchdb)
+	deviceCodeController := devicecode.InitController(ctx, couchdb)
+	osinStorageController := oauth2.NewStorageController(clientController, authorizationCodeController, accessTokenController, userController, password.NewPasswordHasher(), revocationController, securityEventController, oauth2Config)
+	oauth2SagaController := oauth2.InitController(ctx, couchdb, templateRenderer, userController, consentController, loginSessionController, loginAttemptController, deviceCodeController, clientController, osinStorageController, accessTokenController, perm, signingKeyController, oauth2Config)
+	router.HandleFunc(oauth2.TokenPath, oauth2SagaController.Token)
+	router.HandleFunc(oauth2.AuthorizePath, oauth2SagaController.Authorize)
+	router.HandleFunc(oauth2.InfoPath, oauth2SagaController.Info)
+	router.HandleFunc(oauth2.RevokePath, oauth2SagaController.Revoke)
+	router.HandleFunc(oauth2.IntrospectPath, oauth2SagaController.Introspect)
+	router.HandleFunc(oauth2.UserInfoPath, oauth2SagaController.UserInfo).Methods("GET", "POST")
+	router.HandleFunc(oauth2.JWKSPath, oauth2SagaController.JWKS).Methods("GET")
+	router.HandleFunc(oauth2.DiscoveryPath, oauth2SagaController.Discovery).Methods("GET")
+	router.HandleFunc(oauth2.MetadataPath, oauth2SagaController.Metadata).Methods("GET")
+	router.HandleFunc(oauth2.LogoutPath, oauth2SagaController.Logout).Methods("GET", "POST")
+	router.HandleFunc(oauth2.DeviceAuthorizationPath, oauth2SagaController.DeviceAuthorization).Methods("POST")
+	router.HandleFunc(oauth2.DevicePath, oauth2SagaController.Device).Methods("GET", "POST")
+
+	// Expose the dynamic client registration. It requires the initial access
+	// token unless the registration is open to anyone.
+	registrationConfig := registration.DefaultConfig
+	registrationConfig.BaseURL = oauth2Config.Issuer
+	registrationConfig.OpenRegistration = os.Getenv("OPEN_REGISTRATION") == "true"
+	registrationConfig.InitialAccessToken = os.Getenv("REGISTRATION_INITIAL_ACCESS_TOKEN")
+	registrationController := registration.NewController(clientController, uuid.NewGoUUID(), registrationConfig)
+	registrationController.RegisterRoutes(router)
+
+	// Expose the lockouts of the accounts after too many failed logins.
+	loginAttemptHTTPHandler := loginattempt.NewHTTPHandler(loginAttemptController)
+	loginAttemptHTTPHandler.RegisterRoutes(router, perm)
+
+	// Rotate the signing keys in background.
+	keyRotationController := keyrotation.NewController(signingKeyController, clock.NewDefault(), keyrotation.DefaultMaxAge, keyrotation.DefaultRetention)
+	go keyRotationController.Run(ctx, keyrotation.DefaultInterval)
+
+	// Expose the password reset pages. The emails contain the reset links so
+	// they are only written in the logs if it is explicitly asked for the
+	// development.
+	var mailSender mailer.Mailer
+	if smtpAddr, ok := os.LookupEnv("SMTP_ADDR"); ok {
+		mailSender = mailer.NewSMTP(smtpAddr, env.MustGetEnv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	} else if os.Getenv("MAIL_LOG") == "true" {
+		mailSender = mailer.NewLog(os.Stdout)
+	} else {
+		log.Fatal("SMTP_ADDR is required unless MAIL_LOG is set to true")
+	}
+
+	recoveryConfig := recovery.DefaultConfig
+	recoveryConfig.BaseURL = oauth2Config.Issuer
+	passwordResetController := passwordreset.InitController(ctx, couchdb)
+	recoveryController := recovery.NewController(templateRenderer, userController, passwordResetController, loginAttemptController, mailSender, recoveryConfig)
+	recoveryController.RegisterRoutes(router)
+
+	// Purge the expired tokens, authorization codes, revocations, login
+	// sessions, login attempts, device codes, password resets, consents,
+	// security events and personal tokens in background.
+	reaperController := reaper.NewController(accessTokenController, authorizationCodeController, revocationController, loginSessionController, loginAttemptController, deviceCodeController, passwordResetController, consentController, securityEventController, personalTokenController, clock.NewDefault(), reaper.DefaultBatchSize)
+	go reaperController.Run(ctx, reaper.DefaultInterval)
+
+	// Expose the reaper counters to the admins.
+	reaperHTTPHandler := reaper.NewHTTPHandler(reaperController)
+	reaperHTTPHandler.RegisterRoutes(router, perm)
 
 	// Expose the Web Pages
 	pageServer := front.NewPageServer(templateRenderer, userController)
@@ -14,6 +14,10 @@ import (
 	"gitlab.com/Peltoche/yaccc"
 )
 
+// maxTokenLength is the maximum length of the access tokens. The JWT access
+// tokens are far longer than the random ones.
+const maxTokenLength = 2048
+
 type Controller struct {
 	uuid     uuid.Producer
 	storage  StorageInterface
@@ -24,16 +28,15 @@ type StorageInterface interface {
 	Set(ctx context.Context, id string, rev string, value *AccessToken) (string, error)
 	Get(ctx context.Context, code string) (string, *AccessToken, error)
 	Delete(ctx context.Context, code string, rev string) error
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
 	FindOneByRefreshToken(ctx context.Context, refreshToken string) (string, string, *AccessToken, error)
+	FindAllByFamily(ctx context.Context, familyID string) ([]string, error)
 }
 
 func InitController(ctx context.Context, server *yaccc.Server) *Controller {
-	database, err := server.ConnectDatabase(ctx, BucketName)
+	database, err := SetupStorage(ctx, server)
 	if err != nil {
-		database, err = SetupStorage(ctx, server)
-		if err != nil {
-			log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
-		}
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
 	}
 
 	storage := NewStorage(db.NewCouchdbDriver(database))
@@ -60,9 +63,13 @@ func NewController(
 func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) error {
 	err := validator.New().
 		CheckString("clientId", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
-		CheckString("accessToken", cmd.AccessToken, is.Required, is.StringInRange(10, 50)).
+		CheckString("userID", cmd.UserID, is.Optional, is.ID).
+		CheckString("role", cmd.Role, is.Optional, is.OnOfString(Admin, Dev)).
+		CheckString("accessToken", cmd.AccessToken, is.Required, is.StringInRange(10, maxTokenLength)).
 		CheckString("refreshToken", cmd.RefreshToken, is.Optional, is.StringInRange(10, 50)).
 		CheckNumber("expiresIn", cmd.ExpiresIn, is.Required, is.NumberPositif).
+		CheckNumber("refreshExpiresIn", cmd.RefreshExpiresIn, is.Optional, is.NumberPositif).
+		CheckString("familyID", cmd.FamilyID, is.Optional, is.ID).
 		CheckArray("scopes", cmd.Scopes, is.ArrayInRange(1, 50)).
 		CheckEachString("scopes", cmd.Scopes, is.StringInRange(5, 120)).
 		Run()
@@ -70,14 +77,23 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) error {
 		return err
 	}
 
+	familyID := cmd.FamilyID
+	if familyID == "" && cmd.RefreshToken != "" {
+		familyID = t.uuid.New()
+	}
+
 	// Save the document
 	_, err = t.storage.Set(ctx, cmd.AccessToken, "", &AccessToken{
-		ClientID:     cmd.ClientID,
-		AccessToken:  cmd.AccessToken,
-		RefreshToken: cmd.RefreshToken,
-		ExpiresIn:    cmd.ExpiresIn,
-		Scopes:       cmd.Scopes,
-		CreatedAt:    time.Now(),
+		ClientID:         cmd.ClientID,
+		UserID:           cmd.UserID,
+		Role:             cmd.Role,
+		AccessToken:      cmd.AccessToken,
+		RefreshToken:     cmd.RefreshToken,
+		ExpiresIn:        cmd.ExpiresIn,
+		RefreshExpiresIn: cmd.RefreshExpiresIn,
+		FamilyID:         familyID,
+		Scopes:           cmd.Scopes,
+		CreatedAt:        time.Now(),
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed to save the accessToken")
@@ -88,7 +104,7 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) error {
 
 func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*AccessToken, error) {
 	err := validator.New().
-		CheckString("accessToken", cmd.AccessToken, is.Required, is.StringInRange(8, 256)).
+		CheckString("accessToken", cmd.AccessToken, is.Required, is.StringInRange(8, maxTokenLength)).
 		Run()
 	if err != nil {
 		return nil, err
@@ -118,9 +134,59 @@ func (t *Controller) FindOneByRefreshToken(ctx context.Context, cmd *FindOneByRe
 	return id, accessToken, nil
 }
 
+// MarkRefreshTokenUsed flags the refresh token of the given access token as
+// exchanged. Both tokens can't be used anymore but the document is kept in
+// order to detect a reuse of the refresh token.
+//
+// It fails with a conflict if the token has been updated concurrently, thus
+// a refresh token can't be exchanged twice.
+func (t *Controller) MarkRefreshTokenUsed(ctx context.Context, cmd *MarkRefreshTokenUsedCmd) error {
+	err := validator.New().
+		CheckString("accessToken", cmd.AccessToken, is.Required, is.StringInRange(8, maxTokenLength)).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, accessToken, err := t.storage.Get(ctx, cmd.AccessToken)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the accessToken")
+	}
+
+	if accessToken == nil {
+		return errors.New(errors.NotFound, "accessToken not found")
+	}
+
+	accessToken.RefreshTokenUsed = true
+
+	_, err = t.storage.Set(ctx, cmd.AccessToken, rev, accessToken)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the accessToken")
+	}
+
+	return nil
+}
+
+// FindAllByFamily returns the access tokens of all the tokens of the family.
+func (t *Controller) FindAllByFamily(ctx context.Context, cmd *FindAllByFamilyCmd) ([]string, error) {
+	err := validator.New().
+		CheckString("familyID", cmd.FamilyID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokens, err := t.storage.FindAllByFamily(ctx, cmd.FamilyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the tokens of the family")
+	}
+
+	return accessTokens, nil
+}
+
 func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	err := validator.New().
-		CheckString("accessToken", cmd.AccessToken, is.Required, is.StringInRange(8, 256)).
+		CheckString("accessToken", cmd.AccessToken, is.Required, is.StringInRange(8, maxTokenLength)).
 		Run()
 	if err != nil {
 		return err
@@ -140,3 +206,9 @@ func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 
 	return nil
 }
+
+// DeleteExpired deletes the tokens expired at the given date. It returns the
+// number of deleted tokens.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
@@ -25,3 +25,41 @@ func (t *ControllerMock) Get(ctx context.Context, cmd *GetCmd) (*AccessToken, er
 
 	return args.Get(0).(*AccessToken), args.Error(1)
 }
+
+func (t *ControllerMock) FindOneByRefreshToken(ctx context.Context, cmd *FindOneByRefreshTokenCmd) (string, *AccessToken, error) {
+	args := t.Called(cmd)
+
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+
+	return args.String(0), args.Get(1).(*AccessToken), args.Error(2)
+}
+
+func (t *ControllerMock) MarkRefreshTokenUsed(ctx context.Context, cmd *MarkRefreshTokenUsedCmd) error {
+	args := t.Called(cmd)
+
+	return args.Error(0)
+}
+
+func (t *ControllerMock) FindAllByFamily(ctx context.Context, cmd *FindAllByFamilyCmd) ([]string, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (t *ControllerMock) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	args := t.Called(cmd)
+
+	return args.Error(0)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,6 +14,8 @@ func Test_AccessToken_ControllerMock_Create(t *testing.T) {
 
 	mock.On("Create", &CreateCmd{
 		ClientID:     ValidAccessToken.ClientID,
+		UserID:       ValidAccessToken.UserID,
+		Role:         ValidAccessToken.Role,
 		AccessToken:  ValidAccessToken.AccessToken,
 		RefreshToken: ValidAccessToken.RefreshToken,
 		ExpiresIn:    ValidAccessToken.ExpiresIn,
@@ -21,6 +24,8 @@ func Test_AccessToken_ControllerMock_Create(t *testing.T) {
 
 	err := mock.Create(context.Background(), &CreateCmd{
 		ClientID:     ValidAccessToken.ClientID,
+		UserID:       ValidAccessToken.UserID,
+		Role:         ValidAccessToken.Role,
 		AccessToken:  ValidAccessToken.AccessToken,
 		RefreshToken: ValidAccessToken.RefreshToken,
 		ExpiresIn:    ValidAccessToken.ExpiresIn,
@@ -37,6 +42,8 @@ func Test_AccessToken_ControllerMock_Create_with_error(t *testing.T) {
 
 	mock.On("Create", &CreateCmd{
 		ClientID:     ValidAccessToken.ClientID,
+		UserID:       ValidAccessToken.UserID,
+		Role:         ValidAccessToken.Role,
 		AccessToken:  ValidAccessToken.AccessToken,
 		RefreshToken: ValidAccessToken.RefreshToken,
 		ExpiresIn:    ValidAccessToken.ExpiresIn,
@@ -45,6 +52,8 @@ func Test_AccessToken_ControllerMock_Create_with_error(t *testing.T) {
 
 	err := mock.Create(context.Background(), &CreateCmd{
 		ClientID:     ValidAccessToken.ClientID,
+		UserID:       ValidAccessToken.UserID,
+		Role:         ValidAccessToken.Role,
 		AccessToken:  ValidAccessToken.AccessToken,
 		RefreshToken: ValidAccessToken.RefreshToken,
 		ExpiresIn:    ValidAccessToken.ExpiresIn,
@@ -89,3 +98,119 @@ func Test_AccessToken_ControllerMock_Get_with_error(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_AccessToken_ControllerMock_FindOneByRefreshToken(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("FindOneByRefreshToken", &FindOneByRefreshTokenCmd{
+		RefreshToken: "some-refresh-token",
+	}).Return("some-access-token", &ValidAccessToken, nil).Once()
+
+	id, res, err := mock.FindOneByRefreshToken(context.Background(), &FindOneByRefreshTokenCmd{
+		RefreshToken: "some-refresh-token",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-access-token", id)
+	assert.EqualValues(t, &ValidAccessToken, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_ControllerMock_FindOneByRefreshToken_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("FindOneByRefreshToken", &FindOneByRefreshTokenCmd{
+		RefreshToken: "some-refresh-token",
+	}).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	id, res, err := mock.FindOneByRefreshToken(context.Background(), &FindOneByRefreshTokenCmd{
+		RefreshToken: "some-refresh-token",
+	})
+
+	assert.Empty(t, id)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_ControllerMock_Delete(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Delete", &DeleteCmd{
+		AccessToken: "some-access-token",
+	}).Return(nil).Once()
+
+	err := mock.Delete(context.Background(), &DeleteCmd{
+		AccessToken: "some-access-token",
+	})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_ControllerMock_MarkRefreshTokenUsed(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("MarkRefreshTokenUsed", &MarkRefreshTokenUsedCmd{
+		AccessToken: "some-access-token",
+	}).Return(nil).Once()
+
+	err := mock.MarkRefreshTokenUsed(context.Background(), &MarkRefreshTokenUsedCmd{
+		AccessToken: "some-access-token",
+	})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_ControllerMock_FindAllByFamily(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("FindAllByFamily", &FindAllByFamilyCmd{
+		FamilyID: ValidAccessToken.FamilyID,
+	}).Return([]string{"some-access-token"}, nil).Once()
+
+	res, err := mock.FindAllByFamily(context.Background(), &FindAllByFamilyCmd{
+		FamilyID: ValidAccessToken.FamilyID,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some-access-token"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_ControllerMock_FindAllByFamily_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("FindAllByFamily", &FindAllByFamilyCmd{
+		FamilyID: ValidAccessToken.FamilyID,
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.FindAllByFamily(context.Background(), &FindAllByFamilyCmd{
+		FamilyID: ValidAccessToken.FamilyID,
+	})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/halium-project/go-server-utils/password"
 	"github.com/halium-project/go-server-utils/uuid"
@@ -19,11 +20,15 @@ func Test_AccessToken_Controller_Create(t *testing.T) {
 	storageMock.On("Set", "some-access-token", "", &ValidAccessToken).Return("some-rev", nil).Once()
 
 	err := controller.Create(context.Background(), &CreateCmd{
-		ClientID:     ValidAccessToken.ClientID,
-		AccessToken:  ValidAccessToken.AccessToken,
-		RefreshToken: ValidAccessToken.RefreshToken,
-		ExpiresIn:    ValidAccessToken.ExpiresIn,
-		Scopes:       ValidAccessToken.Scopes,
+		ClientID:         ValidAccessToken.ClientID,
+		UserID:           ValidAccessToken.UserID,
+		Role:             ValidAccessToken.Role,
+		AccessToken:      ValidAccessToken.AccessToken,
+		RefreshToken:     ValidAccessToken.RefreshToken,
+		ExpiresIn:        ValidAccessToken.ExpiresIn,
+		RefreshExpiresIn: ValidAccessToken.RefreshExpiresIn,
+		FamilyID:         ValidAccessToken.FamilyID,
+		Scopes:           ValidAccessToken.Scopes,
 	})
 
 	assert.NoError(t, err)
@@ -40,11 +45,15 @@ func Test_AccessToken_Controller_Create_with_validation_error(t *testing.T) {
 	controller := NewController(uuidMock, passwordMock, storageMock)
 
 	err := controller.Create(context.Background(), &CreateCmd{
-		ClientID:     ValidAccessToken.ClientID,
-		AccessToken:  "fjdk", // too short
-		RefreshToken: ValidAccessToken.RefreshToken,
-		ExpiresIn:    ValidAccessToken.ExpiresIn,
-		Scopes:       ValidAccessToken.Scopes,
+		ClientID:         ValidAccessToken.ClientID,
+		UserID:           ValidAccessToken.UserID,
+		Role:             ValidAccessToken.Role,
+		AccessToken:      "fjdk", // too short
+		RefreshToken:     ValidAccessToken.RefreshToken,
+		ExpiresIn:        ValidAccessToken.ExpiresIn,
+		RefreshExpiresIn: ValidAccessToken.RefreshExpiresIn,
+		FamilyID:         ValidAccessToken.FamilyID,
+		Scopes:           ValidAccessToken.Scopes,
 	})
 
 	assert.JSONEq(t, `{
@@ -68,11 +77,15 @@ func Test_AccessToken_Controller_Create_storage_error(t *testing.T) {
 	storageMock.On("Set", "some-access-token", "", &ValidAccessToken).Return("", fmt.Errorf("some-error")).Once()
 
 	err := controller.Create(context.Background(), &CreateCmd{
-		ClientID:     ValidAccessToken.ClientID,
-		AccessToken:  ValidAccessToken.AccessToken,
-		RefreshToken: ValidAccessToken.RefreshToken,
-		ExpiresIn:    ValidAccessToken.ExpiresIn,
-		Scopes:       ValidAccessToken.Scopes,
+		ClientID:         ValidAccessToken.ClientID,
+		UserID:           ValidAccessToken.UserID,
+		Role:             ValidAccessToken.Role,
+		AccessToken:      ValidAccessToken.AccessToken,
+		RefreshToken:     ValidAccessToken.RefreshToken,
+		ExpiresIn:        ValidAccessToken.ExpiresIn,
+		RefreshExpiresIn: ValidAccessToken.RefreshExpiresIn,
+		FamilyID:         ValidAccessToken.FamilyID,
+		Scopes:           ValidAccessToken.Scopes,
 	})
 
 	assert.JSONEq(t, `{
@@ -327,3 +340,291 @@ func Test_AccessToken_Controller_FindOnebyRefreshToken_with_storage_error(t *tes
 	uuidMock.AssertExpectations(t)
 	passwordMock.AssertExpectations(t)
 }
+
+func Test_AccessToken_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_DeleteExpired_with_delete_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id": "some-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the document \"some-id\"",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_Create_with_a_new_family(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	uuidMock.On("New").Return(ValidAccessToken.FamilyID).Once()
+	storageMock.On("Set", "some-access-token", "", &ValidAccessToken).Return("some-rev", nil).Once()
+
+	err := controller.Create(context.Background(), &CreateCmd{
+		ClientID:         ValidAccessToken.ClientID,
+		UserID:           ValidAccessToken.UserID,
+		Role:             ValidAccessToken.Role,
+		AccessToken:      ValidAccessToken.AccessToken,
+		RefreshToken:     ValidAccessToken.RefreshToken,
+		ExpiresIn:        ValidAccessToken.ExpiresIn,
+		RefreshExpiresIn: ValidAccessToken.RefreshExpiresIn,
+		Scopes:           ValidAccessToken.Scopes,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_Create_without_refresh_token(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Set", "some-access-token", "", &AccessToken{
+		ClientID:    ValidAccessToken.ClientID,
+		AccessToken: ValidAccessToken.AccessToken,
+		ExpiresIn:   ValidAccessToken.ExpiresIn,
+		Scopes:      ValidAccessToken.Scopes,
+		CreatedAt:   ValidAccessToken.CreatedAt,
+	}).Return("some-rev", nil).Once()
+
+	err := controller.Create(context.Background(), &CreateCmd{
+		ClientID:    ValidAccessToken.ClientID,
+		AccessToken: ValidAccessToken.AccessToken,
+		ExpiresIn:   ValidAccessToken.ExpiresIn,
+		Scopes:      ValidAccessToken.Scopes,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_MarkRefreshTokenUsed(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	token := ValidAccessToken
+	usedToken := ValidAccessToken
+	usedToken.RefreshTokenUsed = true
+
+	storageMock.On("Get", "some-access-token").Return("some-rev", &token, nil).Once()
+	storageMock.On("Set", "some-access-token", "some-rev", &usedToken).Return("some-new-rev", nil).Once()
+
+	err := controller.MarkRefreshTokenUsed(context.Background(), &MarkRefreshTokenUsedCmd{
+		AccessToken: "some-access-token",
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_MarkRefreshTokenUsed_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", "some-access-token").Return("", nil, nil).Once()
+
+	err := controller.MarkRefreshTokenUsed(context.Background(), &MarkRefreshTokenUsedCmd{
+		AccessToken: "some-access-token",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"accessToken not found"
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_MarkRefreshTokenUsed_with_set_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	token := ValidAccessToken
+
+	storageMock.On("Get", "some-access-token").Return("some-rev", &token, nil).Once()
+	storageMock.On("Set", "some-access-token", "some-rev", &token).Return("", fmt.Errorf("some-error")).Once()
+
+	err := controller.MarkRefreshTokenUsed(context.Background(), &MarkRefreshTokenUsedCmd{
+		AccessToken: "some-access-token",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the accessToken",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_FindAllByFamily(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("FindAllByFamily", ValidAccessToken.FamilyID).Return([]string{"some-access-token", "some-other-token"}, nil).Once()
+
+	res, err := controller.FindAllByFamily(context.Background(), &FindAllByFamilyCmd{
+		FamilyID: ValidAccessToken.FamilyID,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some-access-token", "some-other-token"}, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_FindAllByFamily_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	res, err := controller.FindAllByFamily(context.Background(), &FindAllByFamilyCmd{
+		FamilyID: "invalid-id",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"familyID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AccessToken_Controller_FindAllByFamily_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("FindAllByFamily", ValidAccessToken.FamilyID).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.FindAllByFamily(context.Background(), &FindAllByFamilyCmd{
+		FamilyID: ValidAccessToken.FamilyID,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the tokens of the family",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
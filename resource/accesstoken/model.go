@@ -1,6 +1,9 @@
 package accesstoken
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 const (
 	Admin = "admin"
@@ -11,6 +14,13 @@ type AccessToken struct {
 	// Client information
 	ClientID string `json:"clientID"`
 
+	// User owning the token. Empty for the tokens delivered to a client
+	// without any user (client_credentials grant).
+	UserID string `json:"userID,omitempty"`
+
+	// Role of the user at the time the token was delivered.
+	Role string `json:"role,omitempty"`
+
 	// AccessToken token
 	AccessToken string `json:"accessToken"`
 
@@ -20,6 +30,19 @@ type AccessToken struct {
 	// Token expiration in seconds
 	ExpiresIn int `json:"expiresIn"`
 
+	// Refresh token expiration in seconds. The refresh token never expires
+	// if zero.
+	RefreshExpiresIn int `json:"refreshExpiresIn,omitempty"`
+
+	// ID shared by all the tokens obtained by refreshing the same grant.
+	// Empty for the tokens without refresh token.
+	FamilyID string `json:"familyID,omitempty"`
+
+	// Set once the refresh token has been exchanged for a new token. The
+	// document is kept until the refresh token expiration in order to detect
+	// a reuse.
+	RefreshTokenUsed bool `json:"refreshTokenUsed,omitempty"`
+
 	// Requested scope
 	Scopes []string `json:"scopes"`
 
@@ -27,12 +50,92 @@ type AccessToken struct {
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// ExpireAt returns the date after which the access token is not valid
+// anymore.
+func (t *AccessToken) ExpireAt() time.Time {
+	return t.CreatedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// IsExpiredAt returns true if the access token is expired at the given date.
+// An access token also expires as soon as its refresh token is used.
+func (t *AccessToken) IsExpiredAt(now time.Time) bool {
+	return t.RefreshTokenUsed || t.ExpireAt().Before(now)
+}
+
+// RefreshExpireAt returns the date after which the refresh token is not valid
+// anymore. It returns a zero time if the refresh token never expires.
+func (t *AccessToken) RefreshExpireAt() time.Time {
+	if t.RefreshExpiresIn == 0 {
+		return time.Time{}
+	}
+
+	return t.CreatedAt.Add(time.Duration(t.RefreshExpiresIn) * time.Second)
+}
+
+// IsRefreshExpiredAt returns true if the refresh token is expired at the given
+// date. A refresh token can only be used once.
+func (t *AccessToken) IsRefreshExpiredAt(now time.Time) bool {
+	if t.RefreshTokenUsed {
+		return true
+	}
+
+	if t.RefreshExpiresIn == 0 {
+		return false
+	}
+
+	return t.RefreshExpireAt().Before(now)
+}
+
+// Claims is the content of the access tokens delivered as signed JWT. They
+// carry enough information to check the token without any storage lookup.
+type Claims struct {
+	Issuer string `json:"iss"`
+
+	// User owning the token. Empty for the client_credentials grant.
+	Subject  string `json:"sub,omitempty"`
+	ClientID string `json:"client_id"`
+
+	// Space separated list of the granted scopes.
+	Scope string `json:"scope"`
+	Role  string `json:"role,omitempty"`
+
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+
+	// Unique ID of the token, used by the revocation list.
+	TokenID string `json:"jti"`
+}
+
+// AccessToken returns the AccessToken described by the claims. There is no
+// refresh token in it.
+func (t *Claims) AccessToken(token string) *AccessToken {
+	return &AccessToken{
+		ClientID:    t.ClientID,
+		UserID:      t.Subject,
+		Role:        t.Role,
+		AccessToken: token,
+		ExpiresIn:   int(t.ExpiresAt - t.IssuedAt),
+		Scopes:      strings.Fields(t.Scope),
+		CreatedAt:   time.Unix(t.IssuedAt, 0),
+	}
+}
+
 type CreateCmd struct {
 	ClientID     string
+	UserID       string
+	Role         string
 	AccessToken  string
 	RefreshToken string
 	ExpiresIn    int
-	Scopes       []string
+
+	// Refresh token expiration in seconds. Zero for no expiration.
+	RefreshExpiresIn int
+
+	// Family of the refreshed token. A new family is created if empty and
+	// if there is a refresh token.
+	FamilyID string
+
+	Scopes []string
 }
 
 type GetCmd struct {
@@ -43,15 +146,35 @@ type FindOneByRefreshTokenCmd struct {
 	RefreshToken string
 }
 
+type MarkRefreshTokenUsedCmd struct {
+	AccessToken string
+}
+
+type FindAllByFamilyCmd struct {
+	FamilyID string
+}
+
 type DeleteCmd struct {
 	AccessToken string
 }
 
+type DeleteExpiredCmd struct {
+	// Date used to decide if a token is expired.
+	Now time.Time
+
+	// Maximum number of tokens deleted.
+	Limit uint
+}
+
 var ValidAccessToken = AccessToken{
-	ClientID:     "my-web-application",
-	AccessToken:  "some-access-token",
-	RefreshToken: "some-refresh-token",
-	ExpiresIn:    3600,
-	Scopes:       []string{"users", "foobar", "client", "contacts", "todos"},
-	CreatedAt:    time.Now().Round(time.Millisecond),
+	ClientID:         "my-web-application",
+	UserID:           "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	Role:             Admin,
+	AccessToken:      "some-access-token",
+	RefreshToken:     "some-refresh-token",
+	ExpiresIn:        3600,
+	RefreshExpiresIn: 30 * 24 * 3600,
+	FamilyID:         "4f1b8e7a-2c3d-4e5f-8a9b-0c1d2e3f4a5b",
+	Scopes:           []string{"users", "account", "foobar", "clients", "contacts", "todos"},
+	CreatedAt:        time.Now().UTC().Round(time.Millisecond),
 }
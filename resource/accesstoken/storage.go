@@ -2,6 +2,8 @@ package accesstoken
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/halium-project/go-server-utils/errors"
 	"github.com/halium-project/server/db"
@@ -15,28 +17,48 @@ type Storage struct {
 }
 
 func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
-	db, err := server.CreateDatabase(ctx, &yaccc.CreateDatabaseCmd{
-		Name: BucketName,
-		DesignDocuments: map[string]yaccc.DesignDocument{
-			"default": {
-				Language: yaccc.Javascript,
-				Views: map[string]yaccc.View{
-					"by_refresh_token": {
-						Map: `function (doc, meta) {
-							if (doc.refreshToken) {
-								emit(doc.refreshToken, null);
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_refresh_token": {
+					Map: `function (doc, meta) {
+						if (doc.refreshToken) {
+							emit(doc.refreshToken, null);
+						}
+					}`,
+				},
+				"by_family": {
+					Map: `function (doc, meta) {
+						if (doc.familyID) {
+							emit(doc.familyID, null);
+						}
+					}`,
+				},
+				"by_expiration": {
+					// Emit the date (in ms) after which neither the access
+					// token nor the refresh token can be used. The refresh
+					// tokens without expiration are never emitted.
+					Map: `function (doc, meta) {
+						if (!doc.createdAt || !doc.expiresIn) {
+							return;
+						}
+
+						var expiresIn = doc.expiresIn;
+						if (doc.refreshToken) {
+							if (!doc.refreshExpiresIn) {
+								return;
 							}
-						}`,
-					},
+
+							expiresIn = Math.max(expiresIn, doc.refreshExpiresIn);
+						}
+
+						emit(Date.parse(doc.createdAt) + expiresIn * 1000, doc._rev);
+					}`,
 				},
 			},
 		},
 	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create the database")
-	}
-
-	return db, nil
 }
 
 func NewStorage(driver db.Driver) *Storage {
@@ -92,6 +114,53 @@ func (t *Storage) FindOneByRefreshToken(ctx context.Context, refreshToken string
 	return res[0].ID, rev, &accessToken, nil
 }
 
+// FindAllByFamily returns the ids of all the tokens of the given family.
+func (t *Storage) FindAllByFamily(ctx context.Context, familyID string) ([]string, error) {
+	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_family",
+		Equals:    []interface{}{familyID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	ids := make([]string, len(res))
+	for i, row := range res {
+		ids[i] = row.ID
+	}
+
+	return ids, nil
+}
+
+// FindExpired returns the ids and the revisions of the tokens which can't be
+// used anymore at the given date.
+func (t *Storage) FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error) {
+	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_expiration",
+		Limit:     limit,
+		Range: &db.Range{
+			Start: 0,
+			End:   now.UnixNano() / int64(time.Millisecond),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	revs := make(map[string]string, len(res))
+	for _, row := range res {
+		var rev string
+		err = json.Unmarshal(row.Value, &rev)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the revision of %q", row.ID)
+		}
+
+		revs[row.ID] = rev
+	}
+
+	return revs, nil
+}
+
 func (t *Storage) Delete(ctx context.Context, code string, rev string) error {
 	err := t.driver.Delete(ctx, code, rev)
 	if err != nil {
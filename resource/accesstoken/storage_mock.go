@@ -2,6 +2,7 @@ package accesstoken
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -41,3 +42,23 @@ func (t *StorageMock) FindOneByRefreshToken(ctx context.Context, refreshToken st
 func (t *StorageMock) Delete(_ context.Context, code string, rev string) error {
 	return t.Called(code, rev).Error(0)
 }
+
+func (t *StorageMock) FindExpired(_ context.Context, now time.Time, limit uint) (map[string]string, error) {
+	args := t.Called(now, limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func (t *StorageMock) FindAllByFamily(_ context.Context, familyID string) ([]string, error) {
+	args := t.Called(familyID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -3,6 +3,7 @@ package accesstoken
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -87,3 +88,57 @@ func Test_AccessToken_StorageMock_FindOneByRefreshToken_with_error(t *testing.T)
 
 	mock.AssertExpectations(t)
 }
+
+func Test_AccessToken_StorageMock_FindExpired(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(map[string]string{"some-id": "some-rev"}, nil).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"some-id": "some-rev"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_StorageMock_FindExpired_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(nil, errors.New("some-error")).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_StorageMock_FindAllByFamily(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByFamily", ValidAccessToken.FamilyID).Return([]string{"some-access-token"}, nil).Once()
+
+	res, err := mock.FindAllByFamily(context.Background(), ValidAccessToken.FamilyID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some-access-token"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AccessToken_StorageMock_FindAllByFamily_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByFamily", ValidAccessToken.FamilyID).Return(nil, errors.New("some-error")).Once()
+
+	res, err := mock.FindAllByFamily(context.Background(), ValidAccessToken.FamilyID)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/halium-project/server/db"
 	"github.com/pkg/errors"
@@ -231,3 +232,106 @@ func Test_AccessToken_Storage_FindOneByRefreshToken_with_get_error(t *testing.T)
 
 	dbDriver.AssertExpectations(t)
 }
+
+func Test_AccessToken_Storage_FindExpired(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	accessToken := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return([]db.ViewRow{
+		{ID: "some-id", Value: []byte(`"some-rev"`)},
+		{ID: "some-other-id", Value: []byte(`"some-other-rev"`)},
+	}, nil).Once()
+
+	res, err := accessToken.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_AccessToken_Storage_FindExpired_with_query_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	accessToken := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := accessToken.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_AccessToken_Storage_FindAllByFamily(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	accessToken := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_family",
+		Equals:    []interface{}{ValidAccessToken.FamilyID},
+	}).Return([]db.ViewRow{
+		{ID: "some-access-token"},
+		{ID: "some-other-token"},
+	}, nil).Once()
+
+	res, err := accessToken.FindAllByFamily(context.Background(), ValidAccessToken.FamilyID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some-access-token", "some-other-token"}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_AccessToken_Storage_FindAllByFamily_with_query_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	accessToken := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_family",
+		Equals:    []interface{}{ValidAccessToken.FamilyID},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := accessToken.FindAllByFamily(context.Background(), ValidAccessToken.FamilyID)
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
@@ -24,15 +24,13 @@ type StorageInterface interface {
 	Set(ctx context.Context, id string, rev string, value *AuthorizationCode) (string, error)
 	Get(ctx context.Context, code string) (string, *AuthorizationCode, error)
 	Delete(ctx context.Context, code string, rev string) error
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
 }
 
 func InitController(ctx context.Context, server *yaccc.Server) *Controller {
-	database, err := server.ConnectDatabase(ctx, BucketName)
+	database, err := SetupStorage(ctx, server)
 	if err != nil {
-		database, err = SetupStorage(ctx, server)
-		if err != nil {
-			log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
-		}
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
 	}
 
 	storage := NewStorage(db.NewCouchdbDriver(database))
@@ -59,12 +57,14 @@ func NewController(
 func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) error {
 	err := validator.New().
 		CheckString("clientId", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
 		CheckString("code", cmd.Code, is.Required, is.StringInRange(8, 256)).
 		CheckNumber("expiresIn", cmd.ExpiresIn, is.Required, is.NumberPositif).
 		CheckString("redirectURI", cmd.RedirectURI, is.Required, is.StringInRange(3, 512)).
 		CheckString("state", cmd.RedirectURI, is.Optional).
 		CheckString("codeChallenge", cmd.CodeChallenge, is.Optional).
 		CheckString("codeChallengeMethod", cmd.CodeChallengeMethod, is.Optional).
+		CheckString("nonce", cmd.Nonce, is.Optional, is.StringInRange(1, 512)).
 		Run()
 	if err != nil {
 		return err
@@ -73,6 +73,7 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) error {
 	// Save the document
 	_, err = t.storage.Set(ctx, cmd.Code, "", &AuthorizationCode{
 		ClientID:            cmd.ClientID,
+		UserID:              cmd.UserID,
 		ExpiresIn:           cmd.ExpiresIn,
 		Scopes:              cmd.Scopes,
 		RedirectURI:         cmd.RedirectURI,
@@ -80,6 +81,7 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) error {
 		CreatedAt:           time.Now(),
 		CodeChallenge:       cmd.CodeChallenge,
 		CodeChallengeMethod: cmd.CodeChallengeMethod,
+		Nonce:               cmd.Nonce,
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed to save the user")
@@ -126,3 +128,9 @@ func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 
 	return nil
 }
+
+// DeleteExpired deletes the codes expired at the given date. It returns the
+// number of deleted codes.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
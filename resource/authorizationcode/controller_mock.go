@@ -25,3 +25,15 @@ func (t *ControllerMock) Get(ctx context.Context, cmd *GetCmd) (*AuthorizationCo
 
 	return args.Get(0).(*AuthorizationCode), args.Error(1)
 }
+
+func (t *ControllerMock) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	args := t.Called(cmd)
+
+	return args.Error(0)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,6 +14,7 @@ func Test_AuthorizationCode_ControllerMock_Create(t *testing.T) {
 
 	mock.On("Create", &CreateCmd{
 		ClientID:            ValidAuthorizationCode.ClientID,
+		UserID:              ValidAuthorizationCode.UserID,
 		Code:                "some-authorization-code",
 		ExpiresIn:           ValidAuthorizationCode.ExpiresIn,
 		Scopes:              ValidAuthorizationCode.Scopes,
@@ -24,6 +26,7 @@ func Test_AuthorizationCode_ControllerMock_Create(t *testing.T) {
 
 	err := mock.Create(context.Background(), &CreateCmd{
 		ClientID:            ValidAuthorizationCode.ClientID,
+		UserID:              ValidAuthorizationCode.UserID,
 		Code:                "some-authorization-code",
 		ExpiresIn:           ValidAuthorizationCode.ExpiresIn,
 		Scopes:              ValidAuthorizationCode.Scopes,
@@ -43,6 +46,7 @@ func Test_AuthorizationCode_ControllerMock_Create_with_error(t *testing.T) {
 
 	mock.On("Create", &CreateCmd{
 		ClientID:            ValidAuthorizationCode.ClientID,
+		UserID:              ValidAuthorizationCode.UserID,
 		Code:                "some-authorization-code",
 		ExpiresIn:           ValidAuthorizationCode.ExpiresIn,
 		Scopes:              ValidAuthorizationCode.Scopes,
@@ -54,6 +58,7 @@ func Test_AuthorizationCode_ControllerMock_Create_with_error(t *testing.T) {
 
 	err := mock.Create(context.Background(), &CreateCmd{
 		ClientID:            ValidAuthorizationCode.ClientID,
+		UserID:              ValidAuthorizationCode.UserID,
 		Code:                "some-authorization-code",
 		ExpiresIn:           ValidAuthorizationCode.ExpiresIn,
 		Scopes:              ValidAuthorizationCode.Scopes,
@@ -101,3 +106,33 @@ func Test_AuthorizationCode_ControllerMock_Get_with_error(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_AuthorizationCode_ControllerMock_Delete(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Delete", &DeleteCmd{
+		Code: "some-authorization-code",
+	}).Return(nil).Once()
+
+	err := mock.Delete(context.Background(), &DeleteCmd{
+		Code: "some-authorization-code",
+	})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AuthorizationCode_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/halium-project/go-server-utils/password"
 	"github.com/halium-project/go-server-utils/uuid"
@@ -20,6 +21,7 @@ func Test_AuthorizationCode_Controller_Create(t *testing.T) {
 
 	err := controller.Create(context.Background(), &CreateCmd{
 		ClientID:            ValidAuthorizationCode.ClientID,
+		UserID:              ValidAuthorizationCode.UserID,
 		Code:                "some-authorization-code",
 		ExpiresIn:           ValidAuthorizationCode.ExpiresIn,
 		Scopes:              ValidAuthorizationCode.Scopes,
@@ -27,6 +29,7 @@ func Test_AuthorizationCode_Controller_Create(t *testing.T) {
 		State:               ValidAuthorizationCode.State,
 		CodeChallenge:       ValidAuthorizationCode.CodeChallenge,
 		CodeChallengeMethod: ValidAuthorizationCode.CodeChallengeMethod,
+		Nonce:               ValidAuthorizationCode.Nonce,
 	})
 
 	assert.NoError(t, err)
@@ -44,6 +47,7 @@ func Test_AuthorizationCode_Controller_Create_with_validation_error(t *testing.T
 
 	err := controller.Create(context.Background(), &CreateCmd{
 		ClientID:            ValidAuthorizationCode.ClientID,
+		UserID:              ValidAuthorizationCode.UserID,
 		Code:                "some-authorization-code",
 		ExpiresIn:           -1, // should be positif
 		Scopes:              ValidAuthorizationCode.Scopes,
@@ -51,6 +55,7 @@ func Test_AuthorizationCode_Controller_Create_with_validation_error(t *testing.T
 		State:               ValidAuthorizationCode.State,
 		CodeChallenge:       ValidAuthorizationCode.CodeChallenge,
 		CodeChallengeMethod: ValidAuthorizationCode.CodeChallengeMethod,
+		Nonce:               ValidAuthorizationCode.Nonce,
 	})
 
 	assert.JSONEq(t, `{
@@ -75,6 +80,7 @@ func Test_AuthorizationCode_Controller_Create_storage_error(t *testing.T) {
 
 	err := controller.Create(context.Background(), &CreateCmd{
 		ClientID:            ValidAuthorizationCode.ClientID,
+		UserID:              ValidAuthorizationCode.UserID,
 		Code:                "some-authorization-code",
 		ExpiresIn:           ValidAuthorizationCode.ExpiresIn,
 		Scopes:              ValidAuthorizationCode.Scopes,
@@ -82,6 +88,7 @@ func Test_AuthorizationCode_Controller_Create_storage_error(t *testing.T) {
 		State:               ValidAuthorizationCode.State,
 		CodeChallenge:       ValidAuthorizationCode.CodeChallenge,
 		CodeChallengeMethod: ValidAuthorizationCode.CodeChallengeMethod,
+		Nonce:               ValidAuthorizationCode.Nonce,
 	})
 
 	assert.JSONEq(t, `{
@@ -263,3 +270,91 @@ func Test_AuthorizationCode_Controller_Delete_driver_delete_error(t *testing.T)
 	uuidMock.AssertExpectations(t)
 	passwordMock.AssertExpectations(t)
 }
+
+func Test_AuthorizationCode_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AuthorizationCode_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_AuthorizationCode_Controller_DeleteExpired_with_delete_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id": "some-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the document \"some-id\"",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
@@ -11,6 +11,9 @@ type AuthorizationCode struct {
 	// Client information
 	ClientID string `json:"clientID"`
 
+	// User who authorized the client.
+	UserID string `json:"userID"`
+
 	// Token expiration in seconds
 	ExpiresIn int `json:"exiresIn"`
 
@@ -30,11 +33,27 @@ type AuthorizationCode struct {
 	CodeChallenge string `json:"codeChallenge,omitempty"`
 	// Optional code_challenge_method as described in rfc7636
 	CodeChallengeMethod string `json:"codeChallengeMethod,omitempty"`
+
+	// Optional OpenID Connect nonce, copied into the ID token
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// ExpireAt returns the date after which the code can't be exchanged anymore.
+func (t *AuthorizationCode) ExpireAt() time.Time {
+	return t.CreatedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// IsExpiredAt returns true if the code is expired at the given date.
+func (t *AuthorizationCode) IsExpiredAt(now time.Time) bool {
+	return t.ExpireAt().Before(now)
 }
 
 type CreateCmd struct {
 	ClientID string
 
+	// User who authorized the client
+	UserID string
+
 	// Authorization code
 	Code string
 
@@ -54,6 +73,9 @@ type CreateCmd struct {
 	CodeChallenge string
 	// Optional code_challenge_method as described in rfc7636
 	CodeChallengeMethod string
+
+	// Optional OpenID Connect nonce
+	Nonce string
 }
 
 type GetCmd struct {
@@ -64,13 +86,23 @@ type DeleteCmd struct {
 	Code string
 }
 
+type DeleteExpiredCmd struct {
+	// Date used to decide if a code is expired.
+	Now time.Time
+
+	// Maximum number of codes deleted.
+	Limit uint
+}
+
 var ValidAuthorizationCode = AuthorizationCode{
 	ClientID:            "my-web-application",
+	UserID:              "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
 	ExpiresIn:           10,
 	Scopes:              []string{"foobar"},
 	RedirectURI:         "http://some-url",
 	State:               "some-ramdom-string",
-	CreatedAt:           time.Now().Round(time.Millisecond),
+	CreatedAt:           time.Now().UTC().Round(time.Millisecond),
 	CodeChallenge:       "",
 	CodeChallengeMethod: "",
+	Nonce:               "some-nonce",
 }
@@ -2,6 +2,8 @@ package authorizationcode
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/halium-project/go-server-utils/errors"
 	"github.com/halium-project/server/db"
@@ -15,14 +17,22 @@ type Storage struct {
 }
 
 func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
-	db, err := server.CreateDatabase(ctx, &yaccc.CreateDatabaseCmd{
-		Name: BucketName,
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_expiration": {
+					// Emit the date (in ms) after which the code can't be
+					// exchanged anymore.
+					Map: `function (doc, meta) {
+						if (doc.createdAt && doc.exiresIn) {
+							emit(Date.parse(doc.createdAt) + doc.exiresIn * 1000, doc._rev);
+						}
+					}`,
+				},
+			},
+		},
 	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create the database")
-	}
-
-	return db, nil
 }
 
 func NewStorage(driver db.Driver) *Storage {
@@ -55,6 +65,35 @@ func (t *Storage) Get(ctx context.Context, code string) (string, *AuthorizationC
 	return rev, &authorization, nil
 }
 
+// FindExpired returns the ids and the revisions of the codes expired at the
+// given date.
+func (t *Storage) FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error) {
+	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_expiration",
+		Limit:     limit,
+		Range: &db.Range{
+			Start: 0,
+			End:   now.UnixNano() / int64(time.Millisecond),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	revs := make(map[string]string, len(res))
+	for _, row := range res {
+		var rev string
+		err = json.Unmarshal(row.Value, &rev)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the revision of %q", row.ID)
+		}
+
+		revs[row.ID] = rev
+	}
+
+	return revs, nil
+}
+
 func (t *Storage) Delete(ctx context.Context, code string, rev string) error {
 	err := t.driver.Delete(ctx, code, rev)
 	if err != nil {
@@ -2,6 +2,7 @@ package authorizationcode
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -31,3 +32,13 @@ func (t *StorageMock) Get(_ context.Context, code string) (string, *Authorizatio
 func (t *StorageMock) Delete(_ context.Context, code string, rev string) error {
 	return t.Called(code, rev).Error(0)
 }
+
+func (t *StorageMock) FindExpired(_ context.Context, now time.Time, limit uint) (map[string]string, error) {
+	args := t.Called(now, limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]string), args.Error(1)
+}
@@ -3,6 +3,7 @@ package authorizationcode
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -57,3 +58,31 @@ func Test_AuthorizationCode_StorageMock_Delete(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_AuthorizationCode_StorageMock_FindExpired(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(map[string]string{"some-id": "some-rev"}, nil).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"some-id": "some-rev"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_AuthorizationCode_StorageMock_FindExpired_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(nil, errors.New("some-error")).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
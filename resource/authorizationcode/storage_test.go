@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/halium-project/server/db"
 	"github.com/pkg/errors"
@@ -129,3 +130,62 @@ func Test_AuthorizationCode_Storage_Delete_with_driver_error(t *testing.T) {
 
 	dbDriver.AssertExpectations(t)
 }
+
+func Test_AuthorizationCode_Storage_FindExpired(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	authorizationCode := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return([]db.ViewRow{
+		{ID: "some-id", Value: []byte(`"some-rev"`)},
+		{ID: "some-other-id", Value: []byte(`"some-other-rev"`)},
+	}, nil).Once()
+
+	res, err := authorizationCode.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_AuthorizationCode_Storage_FindExpired_with_query_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	authorizationCode := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := authorizationCode.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
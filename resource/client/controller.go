@@ -14,6 +14,10 @@ import (
 	"gitlab.com/Peltoche/yaccc"
 )
 
+// ErrRevisionMismatch is returned when a client has been modified since the
+// revision given for an update.
+var ErrRevisionMismatch = errors.NewValidationError().AddError("rev", is.UnexpectedValue).IntoError()
+
 type Controller struct {
 	uuid     uuid.Producer
 	password password.HashManager
@@ -29,16 +33,13 @@ type StorageInterface interface {
 }
 
 func InitController(ctx context.Context, server *yaccc.Server) *Controller {
-	var requireBootstrap bool
+	// The bootstrap client is only created with a new database.
+	_, err := server.ConnectDatabase(ctx, BucketName)
+	requireBootstrap := err != nil
 
-	database, err := server.ConnectDatabase(ctx, BucketName)
+	database, err := SetupStorage(ctx, server)
 	if err != nil {
-		database, err = SetupStorage(ctx, server)
-		if err != nil {
-			log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
-		}
-
-		requireBootstrap = true
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
 	}
 
 	storage := NewStorage(db.NewCouchdbDriver(database))
@@ -56,9 +57,9 @@ func InitController(ctx context.Context, server *yaccc.Server) *Controller {
 			ID:            "controle-panel",
 			Name:          "Controle Panel",
 			RedirectURIs:  []string{"http://localhost:8081"},
-			GrantTypes:    []string{"implicit", "refresh_token"},
+			GrantTypes:    []string{"authorize_code", "implicit", "refresh_token"},
 			ResponseTypes: []string{"token", "code"},
-			Scopes:        []string{"users", "clients"},
+			Scopes:        []string{"users", "account", "tokens", "clients"},
 			Public:        true,
 		})
 		if err != nil {
@@ -88,7 +89,7 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, string
 		CheckArray("redirectURIs", cmd.RedirectURIs, is.ArrayInRange(0, 20)).
 		CheckEachString("redirectURIs", cmd.RedirectURIs, is.URL).
 		CheckArray("grantTypes", cmd.GrantTypes, is.ArrayInRange(1, 50)).
-		CheckEachString("grantTypes", cmd.GrantTypes, is.OnOfString("client_credentials", "authorize_code", "implicit", "refresh_token", "password")).
+		CheckEachString("grantTypes", cmd.GrantTypes, is.OnOfString("client_credentials", "authorize_code", "implicit", "refresh_token", "password", "device_code")).
 		CheckEachString("responseTypes", cmd.ResponseTypes, is.OnOfString("code", "token")).
 		CheckArray("scopes", cmd.Scopes, is.ArrayInRange(0, 50)).
 		CheckEachString("scopes", cmd.Scopes, is.MatchingString(`[a-zA-Z0-9\.]+`), is.StringInRange(3, 50)).
@@ -117,14 +118,16 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, string
 	}
 
 	client := Client{
-		ID:            cmd.ID,
-		Secret:        hash,
-		Name:          cmd.Name,
-		RedirectURIs:  cmd.RedirectURIs,
-		GrantTypes:    cmd.GrantTypes,
-		ResponseTypes: cmd.ResponseTypes,
-		Scopes:        cmd.Scopes,
-		Public:        cmd.Public,
+		ID:                cmd.ID,
+		Secret:            hash,
+		Name:              cmd.Name,
+		RedirectURIs:      cmd.RedirectURIs,
+		GrantTypes:        cmd.GrantTypes,
+		ResponseTypes:     cmd.ResponseTypes,
+		Scopes:            cmd.Scopes,
+		Public:            cmd.Public,
+		AllowPlainPKCE:    cmd.AllowPlainPKCE,
+		RegistrationToken: cmd.RegistrationToken,
 	}
 
 	_, err = t.storage.Set(ctx, cmd.ID, "", &client)
@@ -135,20 +138,155 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, string
 	return cmd.ID, secret, nil
 }
 
+// Update replaces the settings of a client. The name must stay unique.
+//
+// The client is saved with the revision read from the storage so a
+// concurrent update fails instead of being overwritten.
+func (t *Controller) Update(ctx context.Context, cmd *UpdateCmd) error {
+	err := validator.New().
+		CheckString("clientID", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
+		CheckString("name", cmd.Name, is.Required, is.StringInRange(3, 50)).
+		CheckArray("redirectURIs", cmd.RedirectURIs, is.ArrayInRange(0, 20)).
+		CheckEachString("redirectURIs", cmd.RedirectURIs, is.URL).
+		CheckArray("grantTypes", cmd.GrantTypes, is.ArrayInRange(1, 50)).
+		CheckEachString("grantTypes", cmd.GrantTypes, is.OnOfString("client_credentials", "authorize_code", "implicit", "refresh_token", "password", "device_code")).
+		CheckEachString("responseTypes", cmd.ResponseTypes, is.OnOfString("code", "token")).
+		CheckArray("scopes", cmd.Scopes, is.ArrayInRange(0, 50)).
+		CheckEachString("scopes", cmd.Scopes, is.MatchingString(`[a-zA-Z0-9\.]+`), is.StringInRange(3, 50)).
+		CheckString("rev", cmd.Rev, is.Required).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, client, err := t.storage.Get(ctx, cmd.ClientID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a client")
+	}
+
+	if client == nil {
+		return errors.Errorf(errors.NotFound, "client %q not found", cmd.ClientID)
+	}
+
+	if cmd.Rev != rev {
+		return ErrRevisionMismatch
+	}
+
+	existingID, _, existingClient, err := t.storage.FindOneByName(ctx, cmd.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to check if the name is already taken")
+	}
+
+	if existingClient != nil && existingID != cmd.ClientID {
+		return errors.NewValidationError().AddError("name", is.AlreadyUsed).IntoError()
+	}
+
+	updated := *client
+	updated.Name = cmd.Name
+	updated.RedirectURIs = cmd.RedirectURIs
+	updated.GrantTypes = cmd.GrantTypes
+	updated.ResponseTypes = cmd.ResponseTypes
+	updated.Scopes = cmd.Scopes
+	updated.AllowPlainPKCE = cmd.AllowPlainPKCE
+
+	_, err = t.storage.Set(ctx, cmd.ClientID, rev, &updated)
+	if err != nil {
+		return errors.Wrap(err, "failed to save a client")
+	}
+
+	return nil
+}
+
+// UpdateRegistration replaces the metadata of a client created with the
+// dynamic registration (RFC 7592). The name must stay unique.
+func (t *Controller) UpdateRegistration(ctx context.Context, cmd *UpdateRegistrationCmd) error {
+	rev, client, err := t.GetWithRevision(ctx, &GetCmd{
+		ClientID: cmd.ClientID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The clients created by an admin can't be managed with the dynamic
+	// registration.
+	if client == nil || client.RegistrationToken == "" {
+		return errors.Errorf(errors.NotFound, "client %q not found", cmd.ClientID)
+	}
+
+	return t.Update(ctx, &UpdateCmd{
+		ClientID:       cmd.ClientID,
+		Name:           cmd.Name,
+		RedirectURIs:   cmd.RedirectURIs,
+		GrantTypes:     cmd.GrantTypes,
+		ResponseTypes:  cmd.ResponseTypes,
+		Scopes:         cmd.Scopes,
+		AllowPlainPKCE: cmd.AllowPlainPKCE,
+		Rev:            rev,
+	})
+}
+
+// RotateSecret replaces the secret of a confidential client. The new secret is
+// returned in cleartext and is never made available again.
+func (t *Controller) RotateSecret(ctx context.Context, cmd *RotateSecretCmd) (string, error) {
+	err := validator.New().
+		CheckString("clientID", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
+		Run()
+	if err != nil {
+		return "", err
+	}
+
+	rev, client, err := t.storage.Get(ctx, cmd.ClientID)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get a client")
+	}
+
+	if client == nil {
+		return "", errors.New(errors.NotFound, "")
+	}
+
+	if client.Public {
+		return "", errors.New(errors.BadRequest, "a public client doesn't have a secret")
+	}
+
+	secret := t.uuid.New()
+	hash, err := t.password.Hash(secret)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash password")
+	}
+
+	updated := *client
+	updated.Secret = hash
+
+	_, err = t.storage.Set(ctx, cmd.ClientID, rev, &updated)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to save a client")
+	}
+
+	return secret, nil
+}
+
 func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*Client, error) {
+	_, client, err := t.GetWithRevision(ctx, cmd)
+
+	return client, err
+}
+
+// GetWithRevision returns the client and its current revision. The revision
+// is required to update the client without overwriting a concurrent update.
+func (t *Controller) GetWithRevision(ctx context.Context, cmd *GetCmd) (string, *Client, error) {
 	err := validator.New().
 		CheckString("clientID", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
 		Run()
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	_, client, err := t.storage.Get(ctx, cmd.ClientID)
+	rev, client, err := t.storage.Get(ctx, cmd.ClientID)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get a client")
+		return "", nil, errors.Wrap(err, "failed to get a client")
 	}
 
-	return client, nil
+	return rev, client, nil
 }
 
 func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Client, error) {
@@ -26,6 +26,16 @@ func (t *ControllerMock) Get(ctx context.Context, cmd *GetCmd) (*Client, error)
 	return args.Get(0).(*Client), args.Error(1)
 }
 
+func (t *ControllerMock) GetWithRevision(ctx context.Context, cmd *GetCmd) (string, *Client, error) {
+	args := t.Called(cmd)
+
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+
+	return args.String(0), args.Get(1).(*Client), args.Error(2)
+}
+
 func (t *ControllerMock) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Client, error) {
 	args := t.Called(cmd)
 
@@ -39,3 +49,17 @@ func (t *ControllerMock) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string
 func (t *ControllerMock) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	return t.Called(cmd).Error(0)
 }
+
+func (t *ControllerMock) RotateSecret(ctx context.Context, cmd *RotateSecretCmd) (string, error) {
+	args := t.Called(cmd)
+
+	return args.String(0), args.Error(1)
+}
+
+func (t *ControllerMock) Update(ctx context.Context, cmd *UpdateCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) UpdateRegistration(ctx context.Context, cmd *UpdateRegistrationCmd) error {
+	return t.Called(cmd).Error(0)
+}
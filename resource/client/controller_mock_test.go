@@ -55,6 +55,24 @@ func Test_Client_ControllerMock_Get(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+func Test_Client_ControllerMock_GetWithRevision(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetWithRevision", &GetCmd{
+		ClientID: "some-client-id",
+	}).Return("some-rev", &ValidClient, nil).Once()
+
+	rev, client, err := mock.GetWithRevision(context.Background(), &GetCmd{
+		ClientID: "some-client-id",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidClient, client)
+
+	mock.AssertExpectations(t)
+}
+
 func Test_Client_ControllerMock_Get_with_error(t *testing.T) {
 	mock := new(ControllerMock)
 
@@ -113,3 +131,40 @@ func Test_Client_ControllerMock_Delete(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_Client_ControllerMock_RotateSecret(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("RotateSecret", &RotateSecretCmd{ClientID: "some-id"}).Return("some-secret", nil).Once()
+
+	secret, err := mock.RotateSecret(context.Background(), &RotateSecretCmd{ClientID: "some-id"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-secret", secret)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Client_ControllerMock_Update(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Update", &UpdateCmd{ClientID: "some-id", Name: "some-name"}).Return(nil).Once()
+
+	err := mock.Update(context.Background(), &UpdateCmd{ClientID: "some-id", Name: "some-name"})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Client_ControllerMock_UpdateRegistration(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("UpdateRegistration", &UpdateRegistrationCmd{ClientID: "some-id", Name: "some-name"}).Return(nil).Once()
+
+	err := mock.UpdateRegistration(context.Background(), &UpdateRegistrationCmd{ClientID: "some-id", Name: "some-name"})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
@@ -214,6 +214,458 @@ func Test_Client_Controller_Create_with_storage_error(t *testing.T) {
 	storageMock.AssertExpectations(t)
 }
 
+func Test_Client_Controller_Update(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	expected := ValidClient
+	expected.Name = "My New Name"
+	expected.RedirectURIs = []string{"http://mydomain/oauth/new-callback"}
+	expected.GrantTypes = []string{"authorize_code"}
+	expected.ResponseTypes = []string{"code"}
+	expected.Scopes = []string{"user"}
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &ValidClient, nil).Once()
+	storageMock.On("FindOneByName", "My New Name").Return("", "", nil, nil).Once()
+	storageMock.On("Set", ValidClient.ID, "some-rev", &expected).Return("some-rev-2", nil).Once()
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          "My New Name",
+		RedirectURIs:  []string{"http://mydomain/oauth/new-callback"},
+		GrantTypes:    []string{"authorize_code"},
+		ResponseTypes: []string{"code"},
+		Scopes:        []string{"user"},
+		Rev:           "some-rev",
+	})
+
+	assert.NoError(t, err)
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_Update_without_a_revision(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          ValidClient.Name,
+		RedirectURIs:  ValidClient.RedirectURIs,
+		GrantTypes:    ValidClient.GrantTypes,
+		ResponseTypes: ValidClient.ResponseTypes,
+		Scopes:        ValidClient.Scopes,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"rev":"MISSING_FIELD"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_Update_with_a_revision_mismatch(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev-2", &ValidClient, nil).Once()
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          ValidClient.Name,
+		RedirectURIs:  ValidClient.RedirectURIs,
+		GrantTypes:    ValidClient.GrantTypes,
+		ResponseTypes: ValidClient.ResponseTypes,
+		Scopes:        ValidClient.Scopes,
+		Rev:           "some-rev",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"rev":"UNEXPECTED_VALUE"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_Update_keeping_its_name(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &ValidClient, nil).Once()
+	storageMock.On("FindOneByName", ValidClient.Name).Return(ValidClient.ID, "some-rev", &ValidClient, nil).Once()
+	storageMock.On("Set", ValidClient.ID, "some-rev", &ValidClient).Return("some-rev-2", nil).Once()
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          ValidClient.Name,
+		RedirectURIs:  ValidClient.RedirectURIs,
+		GrantTypes:    ValidClient.GrantTypes,
+		ResponseTypes: ValidClient.ResponseTypes,
+		Scopes:        ValidClient.Scopes,
+		Rev:           "some-rev",
+	})
+
+	assert.NoError(t, err)
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_Update_with_validationError(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          ValidClient.Name,
+		RedirectURIs:  []string{"not an url"},
+		GrantTypes:    ValidClient.GrantTypes,
+		ResponseTypes: ValidClient.ResponseTypes,
+		Scopes:        ValidClient.Scopes,
+		Rev:           "some-rev",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"redirectURIs[0]": "INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_Update_with_client_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", ValidClient.ID).Return("", nil, nil).Once()
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          ValidClient.Name,
+		RedirectURIs:  ValidClient.RedirectURIs,
+		GrantTypes:    ValidClient.GrantTypes,
+		ResponseTypes: ValidClient.ResponseTypes,
+		Scopes:        ValidClient.Scopes,
+		Rev:           "some-rev",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"client \"my-web-application\" not found"
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_Update_with_name_already_taken(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &ValidClient, nil).Once()
+	storageMock.On("FindOneByName", "Another Application").Return("another-application", "some-rev", &ValidClient, nil).Once()
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          "Another Application",
+		RedirectURIs:  ValidClient.RedirectURIs,
+		GrantTypes:    ValidClient.GrantTypes,
+		ResponseTypes: ValidClient.ResponseTypes,
+		Scopes:        ValidClient.Scopes,
+		Rev:           "some-rev",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"name": "ALREADY_USED"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_Update_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &ValidClient, nil).Once()
+	storageMock.On("FindOneByName", ValidClient.Name).Return(ValidClient.ID, "some-rev", &ValidClient, nil).Once()
+	storageMock.On("Set", ValidClient.ID, "some-rev", &ValidClient).Return("", fmt.Errorf("some-error")).Once()
+
+	err := handler.Update(context.Background(), &UpdateCmd{
+		ClientID:      ValidClient.ID,
+		Name:          ValidClient.Name,
+		RedirectURIs:  ValidClient.RedirectURIs,
+		GrantTypes:    ValidClient.GrantTypes,
+		ResponseTypes: ValidClient.ResponseTypes,
+		Scopes:        ValidClient.Scopes,
+		Rev:           "some-rev",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save a client",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+var registeredClient = func() Client {
+	client := ValidClient
+	client.RegistrationToken = "some-hashed-token"
+
+	return client
+}()
+
+func Test_Client_Controller_UpdateRegistration(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	expected := registeredClient
+	expected.Name = "My New Name"
+
+	storageMock.On("Get", registeredClient.ID).Return("some-rev", &registeredClient, nil).Twice()
+	storageMock.On("FindOneByName", "My New Name").Return("", "", nil, nil).Once()
+	storageMock.On("Set", registeredClient.ID, "some-rev", &expected).Return("some-rev-2", nil).Once()
+
+	err := handler.UpdateRegistration(context.Background(), &UpdateRegistrationCmd{
+		ClientID:      registeredClient.ID,
+		Name:          "My New Name",
+		RedirectURIs:  registeredClient.RedirectURIs,
+		GrantTypes:    registeredClient.GrantTypes,
+		ResponseTypes: registeredClient.ResponseTypes,
+		Scopes:        registeredClient.Scopes,
+	})
+
+	assert.NoError(t, err)
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_UpdateRegistration_with_client_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", registeredClient.ID).Return("", nil, nil).Once()
+
+	err := handler.UpdateRegistration(context.Background(), &UpdateRegistrationCmd{
+		ClientID: registeredClient.ID,
+		Name:     registeredClient.Name,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"client \"my-web-application\" not found"
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_UpdateRegistration_with_a_client_created_by_an_admin(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &ValidClient, nil).Once()
+
+	err := handler.UpdateRegistration(context.Background(), &UpdateRegistrationCmd{
+		ClientID: ValidClient.ID,
+		Name:     ValidClient.Name,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"client \"my-web-application\" not found"
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_RotateSecret(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	expected := ValidClient
+	expected.Secret = "some-new-hashed-secret"
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &ValidClient, nil).Once()
+	uuidMock.On("New").Return(validSecret).Once()
+	passwordMock.On("Hash", validSecret).Return("some-new-hashed-secret", nil).Once()
+	storageMock.On("Set", ValidClient.ID, "some-rev", &expected).Return("some-rev-2", nil).Once()
+
+	secret, err := handler.RotateSecret(context.Background(), &RotateSecretCmd{
+		ClientID: ValidClient.ID,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, validSecret, secret)
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_RotateSecret_with_validationError(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	secret, err := handler.RotateSecret(context.Background(), &RotateSecretCmd{
+		ClientID: "",
+	})
+
+	assert.Empty(t, secret)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"clientID":"MISSING_FIELD"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_RotateSecret_with_client_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", ValidClient.ID).Return("", nil, nil).Once()
+
+	secret, err := handler.RotateSecret(context.Background(), &RotateSecretCmd{
+		ClientID: ValidClient.ID,
+	})
+
+	assert.Empty(t, secret)
+	assert.JSONEq(t, `{
+		"kind":"notFound"
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_RotateSecret_with_a_public_client(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	publicClient := ValidClient
+	publicClient.Secret = ""
+	publicClient.Public = true
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &publicClient, nil).Once()
+
+	secret, err := handler.RotateSecret(context.Background(), &RotateSecretCmd{
+		ClientID: ValidClient.ID,
+	})
+
+	assert.Empty(t, secret)
+	assert.JSONEq(t, `{
+		"kind":"badRequest",
+		"message":"a public client doesn't have a secret"
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Client_Controller_RotateSecret_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	expected := ValidClient
+	expected.Secret = "some-new-hashed-secret"
+
+	storageMock.On("Get", ValidClient.ID).Return("some-rev", &ValidClient, nil).Once()
+	uuidMock.On("New").Return(validSecret).Once()
+	passwordMock.On("Hash", validSecret).Return("some-new-hashed-secret", nil).Once()
+	storageMock.On("Set", ValidClient.ID, "some-rev", &expected).Return("", fmt.Errorf("some-error")).Once()
+
+	secret, err := handler.RotateSecret(context.Background(), &RotateSecretCmd{
+		ClientID: ValidClient.ID,
+	})
+
+	assert.Empty(t, secret)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save a client",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
 func Test_Client_Controller_Get(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
@@ -234,6 +686,27 @@ func Test_Client_Controller_Get(t *testing.T) {
 	storageMock.AssertExpectations(t)
 }
 
+func Test_Client_Controller_GetWithRevision(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, passwordMock, storageMock)
+
+	storageMock.On("Get", validSecret).Return("some-rev", &ValidClient, nil).Once()
+
+	rev, res, err := handler.GetWithRevision(context.Background(), &GetCmd{
+		ClientID: validSecret,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidClient, res)
+
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
 func Test_Client_Controller_Get_with_validationError(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
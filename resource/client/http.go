@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/halium-project/go-server-utils/errors"
@@ -17,7 +19,9 @@ type HTTPHandler struct {
 
 type ControllerInterface interface {
 	Create(ctx context.Context, cmd *CreateCmd) (string, string, error)
-	Get(ctx context.Context, cmd *GetCmd) (*Client, error)
+	GetWithRevision(ctx context.Context, cmd *GetCmd) (string, *Client, error)
+	Update(ctx context.Context, cmd *UpdateCmd) error
+	RotateSecret(ctx context.Context, cmd *RotateSecretCmd) (string, error)
 	GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Client, error)
 	Delete(ctx context.Context, cmd *DeleteCmd) error
 }
@@ -32,18 +36,21 @@ func (t *HTTPHandler) RegisterRoutes(router *mux.Router, perm *permission.Contro
 	router.HandleFunc("/clients", perm.Check("clients.write", t.Create)).Methods("POST")
 	router.HandleFunc("/clients", perm.Check("clients.read", t.GetAll)).Methods("GET")
 	router.HandleFunc("/clients/{clientID}", perm.Check("clients.read", t.Get)).Methods("GET")
+	router.HandleFunc("/clients/{clientID}", perm.Check("clients.write", t.Update)).Methods("PUT")
 	router.HandleFunc("/clients/{clientID}", perm.Check("clients.write", t.Delete)).Methods("Delete")
+	router.HandleFunc("/clients/{clientID}/secret", perm.Check("clients.write", t.RotateSecret)).Methods("POST")
 }
 
 func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 	type request struct {
-		ID            string   `json:"id"`
-		Name          string   `json:"name"`
-		RedirectURIs  []string `json:"redirectURIs"`
-		GrantTypes    []string `json:"grantTypes"`
-		ResponseTypes []string `json:"responseTypes"`
-		Scopes        []string `json:"scopes"`
-		Public        bool     `json:"public"`
+		ID             string   `json:"id"`
+		Name           string   `json:"name"`
+		RedirectURIs   []string `json:"redirectURIs"`
+		GrantTypes     []string `json:"grantTypes"`
+		ResponseTypes  []string `json:"responseTypes"`
+		Scopes         []string `json:"scopes"`
+		Public         bool     `json:"public"`
+		AllowPlainPKCE bool     `json:"allowPlainPKCE"`
 	}
 
 	type responseBody struct {
@@ -59,13 +66,14 @@ func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, secret, err := t.client.Create(r.Context(), &CreateCmd{
-		ID:            req.ID,
-		Name:          req.Name,
-		RedirectURIs:  req.RedirectURIs,
-		ResponseTypes: req.ResponseTypes,
-		GrantTypes:    req.GrantTypes,
-		Scopes:        req.Scopes,
-		Public:        req.Public,
+		ID:             req.ID,
+		Name:           req.Name,
+		RedirectURIs:   req.RedirectURIs,
+		ResponseTypes:  req.ResponseTypes,
+		GrantTypes:     req.GrantTypes,
+		Scopes:         req.Scopes,
+		Public:         req.Public,
+		AllowPlainPKCE: req.AllowPlainPKCE,
 	})
 	if err != nil {
 		errors.IntoResponse(w, err)
@@ -78,9 +86,33 @@ func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (t *HTTPHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	type responseBody struct {
+		ClientID     string `json:"clientID"`
+		ClientSecret string `json:"clientSecret"`
+	}
+
+	clientID := mux.Vars(r)["clientID"]
+
+	secret, err := t.client.RotateSecret(r.Context(), &RotateSecretCmd{
+		ClientID: clientID,
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusOK, &responseBody{
+		ClientID:     clientID,
+		ClientSecret: secret,
+	})
+}
+
+// Get returns a client. Its revision is given in the "ETag" header and must
+// be sent back in the "If-Match" header of an update.
 func (t *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
 	clientID := mux.Vars(r)["clientID"]
-	client, err := t.client.Get(r.Context(), &GetCmd{
+	rev, client, err := t.client.GetWithRevision(r.Context(), &GetCmd{
 		ClientID: clientID,
 	})
 
@@ -96,10 +128,50 @@ func (t *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	// Do not return the secret
 	client.Secret = ""
+	client.RegistrationToken = ""
 
+	w.Header().Set("ETag", strconv.Quote(rev))
 	response.Write(w, http.StatusOK, &client)
 }
 
+// Update replaces the settings of a client. The "If-Match" header is required
+// and the update is rejected if the client has been modified since the
+// revision it gives.
+func (t *HTTPHandler) Update(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Name           string   `json:"name"`
+		RedirectURIs   []string `json:"redirectURIs"`
+		GrantTypes     []string `json:"grantTypes"`
+		ResponseTypes  []string `json:"responseTypes"`
+		Scopes         []string `json:"scopes"`
+		AllowPlainPKCE bool     `json:"allowPlainPKCE"`
+	}
+
+	var req request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		errors.IntoResponse(w, errors.New(errors.InvalidJSON, err.Error()))
+		return
+	}
+
+	err = t.client.Update(r.Context(), &UpdateCmd{
+		ClientID:       mux.Vars(r)["clientID"],
+		Name:           req.Name,
+		RedirectURIs:   req.RedirectURIs,
+		GrantTypes:     req.GrantTypes,
+		ResponseTypes:  req.ResponseTypes,
+		Scopes:         req.Scopes,
+		AllowPlainPKCE: req.AllowPlainPKCE,
+		Rev:            strings.Trim(strings.TrimPrefix(r.Header.Get("If-Match"), "W/"), `"`),
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusOK, &struct{}{})
+}
+
 func (t *HTTPHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	clients, err := t.client.GetAll(r.Context(), &GetAllCmd{})
 	if err != nil {
@@ -107,9 +179,11 @@ func (t *HTTPHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, client := range clients {
+	for id, client := range clients {
 		// Do not return the secret
 		client.Secret = ""
+		client.RegistrationToken = ""
+		clients[id] = client
 	}
 
 	response.Write(w, http.StatusOK, clients)
@@ -18,7 +18,7 @@ import (
 
 func Test_Client_HTTPHandler_Create_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -65,7 +65,7 @@ func Test_Client_HTTPHandler_Create_success(t *testing.T) {
 
 func Test_Client_HTTPHandler_Create_with_an_invalid_json(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -96,7 +96,7 @@ func Test_Client_HTTPHandler_Create_with_an_invalid_json(t *testing.T) {
 
 func Test_Client_HTTPHandler_Create_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -141,9 +141,74 @@ func Test_Client_HTTPHandler_Create_with_an_error_from_the_usecase(t *testing.T)
 	accessTokenControllerMock.AssertExpectations(t)
 }
 
+func Test_Client_HTTPHandler_RotateSecret_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("RotateSecret", &RotateSecretCmd{ClientID: "some-client-id"}).Return("some-secret", nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/clients/some-client-id/secret", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{
+		"clientID": "some-client-id",
+		"clientSecret": "some-secret"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_Client_HTTPHandler_RotateSecret_with_an_error_from_the_usecase(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("RotateSecret", &RotateSecretCmd{ClientID: "some-client-id"}).Return("", errors.New(errors.NotFound, "")).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/clients/some-client-id/secret", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "notFound"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
 func Test_Client_HTTPHandler_Get_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -152,7 +217,7 @@ func Test_Client_HTTPHandler_Get_success(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Get", &GetCmd{ClientID: "some-client-id"}).Return(&ValidClient, nil).Once()
+	controllerMock.On("GetWithRevision", &GetCmd{ClientID: "some-client-id"}).Return("some-rev", &ValidClient, nil).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/clients/some-client-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -165,6 +230,7 @@ func Test_Client_HTTPHandler_Get_success(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"some-rev"`, res.Header.Get("ETag"))
 	assert.JSONEq(t, `{
 		"id": "my-web-application",
 		"name": "My Web Application",
@@ -172,16 +238,51 @@ func Test_Client_HTTPHandler_Get_success(t *testing.T) {
 		"grantTypes": ["client_credentials", "authorize_code", "implicit", "refresh_token", "password"],
 		"responseTypes": ["code", "token"],
 		"scopes": ["user", "admin"],
-		"public": false
+		"public": false,
+		"allowPlainPKCE": false
 	}`, string(body))
 
 	controllerMock.AssertExpectations(t)
 	accessTokenControllerMock.AssertExpectations(t)
 }
 
+func Test_Client_HTTPHandler_Get_a_registered_client(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	registeredClient := ValidClient
+	registeredClient.RegistrationToken = "some-hashed-token"
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("GetWithRevision", &GetCmd{ClientID: "some-client-id"}).Return("some-rev", &registeredClient, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/clients/some-client-id", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotContains(t, string(body), "registrationToken")
+	assert.NotContains(t, string(body), "secret")
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
 func Test_Client_HTTPHandler_Get_with_the_client_not_found(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -190,7 +291,7 @@ func Test_Client_HTTPHandler_Get_with_the_client_not_found(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Get", &GetCmd{ClientID: "some-client-id"}).Return(nil, nil).Once()
+	controllerMock.On("GetWithRevision", &GetCmd{ClientID: "some-client-id"}).Return("", nil, nil).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/clients/some-client-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -214,7 +315,7 @@ func Test_Client_HTTPHandler_Get_with_the_client_not_found(t *testing.T) {
 
 func Test_Client_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -223,7 +324,7 @@ func Test_Client_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Get", &GetCmd{ClientID: "some-client-id"}).Return(nil, errors.New(errors.Internal, "some error")).Once()
+	controllerMock.On("GetWithRevision", &GetCmd{ClientID: "some-client-id"}).Return("", nil, errors.New(errors.Internal, "some error")).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/clients/some-client-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -245,9 +346,155 @@ func Test_Client_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock.AssertExpectations(t)
 }
 
+func Test_Client_HTTPHandler_Update_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("Update", &UpdateCmd{
+		ClientID:      "my-app",
+		Name:          "My App",
+		RedirectURIs:  []string{"url-1"},
+		GrantTypes:    []string{"authorize_code"},
+		ResponseTypes: []string{"code"},
+		Scopes:        []string{"scope-1"},
+		Rev:           "some-rev",
+	}).Return(nil).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/clients/my-app", strings.NewReader(`{
+		"name": "My App",
+		"redirectURIs": ["url-1"],
+		"grantTypes": ["authorize_code"],
+		"responseTypes": ["code"],
+		"scopes": ["scope-1"]
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	r.Header.Add("If-Match", `"some-rev"`)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_Client_HTTPHandler_Update_with_an_invalid_json(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/clients/my-app", strings.NewReader(`{"name": `))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_Client_HTTPHandler_Update_with_a_revision_mismatch(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("Update", &UpdateCmd{
+		ClientID: "my-app",
+		Name:     "My App",
+		Rev:      "some-rev",
+	}).Return(ErrRevisionMismatch).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/clients/my-app", strings.NewReader(`{"name": "My App"}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	r.Header.Add("If-Match", `W/"some-rev"`)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "validationError",
+		"errors": {
+			"rev": "UNEXPECTED_VALUE"
+		}
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_Client_HTTPHandler_Update_with_an_error_from_the_usecase(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("Update", &UpdateCmd{
+		ClientID: "my-app",
+		Name:     "My App",
+	}).Return(errors.New(errors.Internal, "some error")).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/clients/my-app", strings.NewReader(`{"name": "My App"}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "internalError",
+		"message": "some error"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
 func Test_Client_HTTPHandler_GetAll_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -277,7 +524,8 @@ func Test_Client_HTTPHandler_GetAll_success(t *testing.T) {
 			"grantTypes": ["client_credentials", "authorize_code", "implicit", "refresh_token", "password"],
 			"responseTypes": ["code", "token"],
 			"scopes": ["user", "admin"],
-			"public": false
+			"public": false,
+			"allowPlainPKCE": false
 		}
 	}`, string(body))
 
@@ -287,7 +535,7 @@ func Test_Client_HTTPHandler_GetAll_success(t *testing.T) {
 
 func Test_Client_HTTPHandler_GetAll_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -320,7 +568,7 @@ func Test_Client_HTTPHandler_GetAll_with_an_error_from_the_usecase(t *testing.T)
 
 func Test_Client_HTTPHandler_Delete_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -350,7 +598,7 @@ func Test_Client_HTTPHandler_Delete_success(t *testing.T) {
 
 func Test_Client_HTTPHandler_Delete_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -27,7 +27,7 @@ type Client struct {
 
 	// GrantTypes is an array of grant types the client is allowed to use.
 	//
-	// Pattern: client_credentials|authorize_code|implicit|refresh_token|password
+	// Pattern: client_credentials|authorize_code|implicit|refresh_token|password|device_code
 	GrantTypes []string `json:"grantTypes"`
 
 	// ResponseTypes is an array of the OAuth 2.0 response type strings that the client can
@@ -46,6 +46,40 @@ type Client struct {
 	// Public is a boolean that identifies this client as public, meaning that it
 	// does not have a secret. It will disable the client_credentials grant type for this client if set.
 	Public bool `json:"public"`
+
+	// AllowPlainPKCE is a boolean allowing the client to use the "plain" PKCE
+	// method (RFC 7636). Only the "S256" method is accepted otherwise.
+	//
+	// PKCE is required for the public clients and optional for the others.
+	AllowPlainPKCE bool `json:"allowPlainPKCE"`
+
+	// RegistrationToken is the SHA-256 hash of the token given to manage a
+	// client created with the dynamic registration (RFC 7592). It is empty
+	// for the other clients.
+	RegistrationToken string `json:"registrationToken,omitempty"`
+}
+
+// GrantTypeNames maps the grant types saved for the clients to their name in
+// the OAuth2 specifications (RFC 6749 and RFC 8628).
+var GrantTypeNames = map[string]string{
+	"authorize_code":     "authorization_code",
+	"implicit":           "implicit",
+	"refresh_token":      "refresh_token",
+	"password":           "password",
+	"client_credentials": "client_credentials",
+	"device_code":        "urn:ietf:params:oauth:grant-type:device_code",
+}
+
+// GrantTypeFromName returns the grant type saved for the clients matching a
+// grant type name of the OAuth2 specifications.
+func GrantTypeFromName(name string) (string, bool) {
+	for grantType, grantTypeName := range GrantTypeNames {
+		if grantTypeName == name {
+			return grantType, true
+		}
+	}
+
+	return "", false
 }
 
 type GetAllCmd struct{}
@@ -58,6 +92,10 @@ type DeleteCmd struct {
 	ClientID string
 }
 
+type RotateSecretCmd struct {
+	ClientID string
+}
+
 type ValidateCmd struct {
 	ClientID     string
 	ClientSecret string
@@ -68,13 +106,43 @@ type GetByNameCmd struct {
 }
 
 type CreateCmd struct {
-	ID            string
-	Name          string
-	RedirectURIs  []string
-	GrantTypes    []string
-	ResponseTypes []string
-	Scopes        []string
-	Public        bool
+	ID                string
+	Name              string
+	RedirectURIs      []string
+	GrantTypes        []string
+	ResponseTypes     []string
+	Scopes            []string
+	Public            bool
+	AllowPlainPKCE    bool
+	RegistrationToken string
+}
+
+// UpdateRegistrationCmd replaces the metadata of a registered client. The
+// secret, the kind of client and the registration token are kept.
+type UpdateRegistrationCmd struct {
+	ClientID       string
+	Name           string
+	RedirectURIs   []string
+	GrantTypes     []string
+	ResponseTypes  []string
+	Scopes         []string
+	AllowPlainPKCE bool
+}
+
+// UpdateCmd replaces the settings of a client. The secret, the kind of client
+// and the registration token are kept.
+type UpdateCmd struct {
+	ClientID       string
+	Name           string
+	RedirectURIs   []string
+	GrantTypes     []string
+	ResponseTypes  []string
+	Scopes         []string
+	AllowPlainPKCE bool
+
+	// Rev is the revision read by the caller. It is required and the update is
+	// rejected if the client has been modified since.
+	Rev string
 }
 
 var ValidClient = Client{
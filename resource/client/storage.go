@@ -15,28 +15,20 @@ type Storage struct {
 }
 
 func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
-	db, err := server.CreateDatabase(ctx, &yaccc.CreateDatabaseCmd{
-		Name: BucketName,
-		DesignDocuments: map[string]yaccc.DesignDocument{
-			"default": {
-				Language: yaccc.Javascript,
-				Views: map[string]yaccc.View{
-					"by_name": {
-						Map: `function (doc, meta) {
-							if (doc.name) {
-								emit(doc.name, null);
-							}
-						}`,
-					},
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_name": {
+					Map: `function (doc, meta) {
+						if (doc.name) {
+							emit(doc.name, null);
+						}
+					}`,
 				},
 			},
 		},
 	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create the database")
-	}
-
-	return db, nil
 }
 
 func NewStorage(driver db.Driver) *Storage {
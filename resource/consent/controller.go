@@ -0,0 +1,128 @@
+package consent
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"github.com/halium-project/server/utils/clock"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+type Controller struct {
+	uuid    uuid.Producer
+	clock   clock.Clock
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *Consent) (string, error)
+	Delete(ctx context.Context, id string, rev string) error
+	FindOneByUserAndClient(ctx context.Context, userID string, clientID string) (string, string, *Consent, error)
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+	uuidProducer := uuid.NewGoUUID()
+
+	return NewController(uuidProducer, clock.NewDefault(), storage)
+}
+
+func NewController(
+	uuid uuid.Producer,
+	clock clock.Clock,
+	storage StorageInterface,
+) *Controller {
+	return &Controller{
+		uuid:    uuid,
+		clock:   clock,
+		storage: storage,
+	}
+}
+
+// Get returns the consent given by a user to a client or nil if the user
+// never authorized the client or if the consent is expired.
+func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*Consent, error) {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckString("clientID", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, consent, err := t.storage.FindOneByUserAndClient(ctx, cmd.UserID, cmd.ClientID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the consent")
+	}
+
+	if consent == nil || consent.IsExpiredAt(t.clock.Now()) {
+		return nil, nil
+	}
+
+	return consent, nil
+}
+
+// Grant saves the scopes given by a user to a client. The lifetime of the
+// consent restarts at each grant.
+func (t *Controller) Grant(ctx context.Context, cmd *GrantCmd) error {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckString("clientID", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
+		CheckArray("scopes", cmd.Scopes, is.ArrayInRange(1, 50)).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	id, rev, consent, err := t.storage.FindOneByUserAndClient(ctx, cmd.UserID, cmd.ClientID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the consent")
+	}
+
+	now := t.clock.Now()
+
+	if consent == nil {
+		id = t.uuid.New()
+		consent = &Consent{
+			UserID:   cmd.UserID,
+			ClientID: cmd.ClientID,
+			Scopes:   []string{},
+		}
+	} else if consent.IsExpiredAt(now) {
+		// The scopes of an expired consent are not renewed with the new ones.
+		consent.Scopes = []string{}
+	}
+
+	consent.GrantedAt = now
+
+	for _, scope := range cmd.Scopes {
+		if !contains(consent.Scopes, scope) {
+			consent.Scopes = append(consent.Scopes, scope)
+		}
+	}
+
+	_, err = t.storage.Set(ctx, id, rev, consent)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the consent")
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes the consents expired at the given date. It returns the
+// number of deleted consents.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
@@ -0,0 +1,31 @@
+package consent
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ControllerMock struct {
+	mock.Mock
+}
+
+func (t *ControllerMock) Get(ctx context.Context, cmd *GetCmd) (*Consent, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*Consent), args.Error(1)
+}
+
+func (t *ControllerMock) Grant(ctx context.Context, cmd *GrantCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,70 @@
+package consent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Consent_ControllerMock_Get(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{UserID: ValidConsent.UserID, ClientID: ValidConsent.ClientID}).Return(&ValidConsent, nil).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{UserID: ValidConsent.UserID, ClientID: ValidConsent.ClientID})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidConsent, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_ControllerMock_Get_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{UserID: ValidConsent.UserID, ClientID: ValidConsent.ClientID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{UserID: ValidConsent.UserID, ClientID: ValidConsent.ClientID})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_ControllerMock_Grant(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Grant", &GrantCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+		Scopes:   ValidConsent.Scopes,
+	}).Return(nil).Once()
+
+	err := mock.Grant(context.Background(), &GrantCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+		Scopes:   ValidConsent.Scopes,
+	})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,332 @@
+package consent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Consent_Controller_Get(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return(ValidConsentID, "some-rev", &ValidConsent, nil).Once()
+	clockMock.On("Now").Return(ValidConsent.GrantedAt).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidConsent, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Get_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return("", "", nil, nil).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Get_expired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return(ValidConsentID, "some-rev", &ValidConsent, nil).Once()
+	clockMock.On("Now").Return(ValidConsent.ExpireAt().Add(time.Second)).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Get_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		UserID:   "invalid-id",
+		ClientID: ValidConsent.ClientID,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"userID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Get_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return("", "", nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the consent",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Grant_a_new_consent(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return("", "", nil, nil).Once()
+	clockMock.On("Now").Return(ValidConsent.GrantedAt).Once()
+	uuidMock.On("New").Return(ValidConsentID).Once()
+	storageMock.On("Set", ValidConsentID, "", &ValidConsent).Return("some-rev", nil).Once()
+
+	err := controller.Grant(context.Background(), &GrantCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+		Scopes:   ValidConsent.Scopes,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Grant_merge_with_the_existing_consent(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	existing := ValidConsent
+	existing.Scopes = []string{"contacts"}
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return(ValidConsentID, "some-rev", &existing, nil).Once()
+	clockMock.On("Now").Return(ValidConsent.GrantedAt).Once()
+	storageMock.On("Set", ValidConsentID, "some-rev", &Consent{
+		UserID:    ValidConsent.UserID,
+		ClientID:  ValidConsent.ClientID,
+		Scopes:    []string{"contacts", "todos"},
+		GrantedAt: ValidConsent.GrantedAt,
+	}).Return("some-rev-2", nil).Once()
+
+	err := controller.Grant(context.Background(), &GrantCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+		Scopes:   []string{"todos", "contacts"},
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Grant_replace_an_expired_consent(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	now := ValidConsent.ExpireAt().Add(time.Second)
+	existing := ValidConsent
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return(ValidConsentID, "some-rev", &existing, nil).Once()
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Set", ValidConsentID, "some-rev", &Consent{
+		UserID:    ValidConsent.UserID,
+		ClientID:  ValidConsent.ClientID,
+		Scopes:    []string{"todos"},
+		GrantedAt: now,
+	}).Return("some-rev-2", nil).Once()
+
+	err := controller.Grant(context.Background(), &GrantCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+		Scopes:   []string{"todos"},
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Grant_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	err := controller.Grant(context.Background(), &GrantCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+		Scopes:   []string{},
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"scopes":"TOO_SHORT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_Grant_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserAndClient", ValidConsent.UserID, ValidConsent.ClientID).Return("", "", nil, nil).Once()
+	clockMock.On("Now").Return(ValidConsent.GrantedAt).Once()
+	uuidMock.On("New").Return(ValidConsentID).Once()
+	storageMock.On("Set", ValidConsentID, "", &ValidConsent).Return("", fmt.Errorf("some-error")).Once()
+
+	err := controller.Grant(context.Background(), &GrantCmd{
+		UserID:   ValidConsent.UserID,
+		ClientID: ValidConsent.ClientID,
+		Scopes:   ValidConsent.Scopes,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the consent",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Covers(t *testing.T) {
+	assert.True(t, ValidConsent.Covers([]string{"contacts"}))
+	assert.True(t, ValidConsent.Covers([]string{"todos", "contacts"}))
+	assert.False(t, ValidConsent.Covers([]string{"contacts", "users"}))
+}
+
+func Test_Consent_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Consent_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
@@ -0,0 +1,76 @@
+package consent
+
+import "time"
+
+// Lifetime is the duration after which a consent expires and the user is
+// asked again to authorize the client.
+const Lifetime = 365 * 24 * time.Hour
+
+// Consent contains the scopes a user agreed to give to a client.
+type Consent struct {
+	UserID    string    `json:"userID"`
+	ClientID  string    `json:"clientID"`
+	Scopes    []string  `json:"scopes"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+type GetCmd struct {
+	UserID   string
+	ClientID string
+}
+
+type GrantCmd struct {
+	UserID   string
+	ClientID string
+
+	// Scopes granted to the client. They are added to the scopes already
+	// granted.
+	Scopes []string
+}
+
+// Covers returns true if all the given scopes have already been granted.
+func (t *Consent) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		if !contains(t.Scopes, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExpireAt returns the date after which the consent must be asked again.
+func (t *Consent) ExpireAt() time.Time {
+	return t.GrantedAt.Add(Lifetime)
+}
+
+// IsExpiredAt returns true if the consent is expired at the given date.
+func (t *Consent) IsExpiredAt(now time.Time) bool {
+	return t.ExpireAt().Before(now)
+}
+
+func contains(list []string, value string) bool {
+	for _, elem := range list {
+		if elem == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if a consent is expired.
+	Now time.Time
+
+	// Maximum number of consents deleted.
+	Limit uint
+}
+
+var ValidConsentID = "5a1e4c33-8f3c-4a7c-9a46-0d6d0c7a1c2b"
+var ValidConsent = Consent{
+	UserID:    "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	ClientID:  "my-web-application",
+	Scopes:    []string{"contacts", "todos"},
+	GrantedAt: time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC),
+}
@@ -0,0 +1,120 @@
+package consent
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/db"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+const BucketName = "consents"
+
+type Storage struct {
+	driver db.Driver
+}
+
+func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_user_and_client": {
+					Map: `function (doc, meta) {
+						if (doc.userID && doc.clientID) {
+							emit([doc.userID, doc.clientID], null);
+						}
+					}`,
+				},
+				"by_expiration": {
+					// Emit the date (in ms) after which the consent expires
+					// (see Lifetime). The consents saved without a date are
+					// already expired.
+					Map: `function (doc, meta) {
+						if (doc.userID && doc.clientID) {
+							emit(doc.grantedAt ? Date.parse(doc.grantedAt) + 365 * 24 * 3600 * 1000 : 0, doc._rev);
+						}
+					}`,
+				},
+			},
+		},
+	})
+}
+
+func NewStorage(driver db.Driver) *Storage {
+	return &Storage{
+		driver: driver,
+	}
+}
+
+func (t *Storage) Set(ctx context.Context, id string, rev string, value *Consent) (string, error) {
+	rev, err := t.driver.Set(ctx, id, rev, value)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to set the document into the storage")
+	}
+
+	return rev, nil
+}
+
+func (t *Storage) Delete(ctx context.Context, id string, rev string) error {
+	err := t.driver.Delete(ctx, id, rev)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the document from the storage")
+	}
+
+	return nil
+}
+
+func (t *Storage) FindOneByUserAndClient(ctx context.Context, userID string, clientID string) (string, string, *Consent, error) {
+	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_user_and_client",
+		Limit:     1,
+		Equals:    []interface{}{[]string{userID, clientID}},
+	})
+	if err != nil {
+		return "", "", nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	if len(res) == 0 {
+		return "", "", nil, nil
+	}
+
+	var consent Consent
+	rev, err := t.driver.Get(ctx, res[0].ID, &consent)
+	if err != nil {
+		return "", "", nil, errors.Wrap(err, "failed to get the document")
+	}
+
+	return res[0].ID, rev, &consent, nil
+}
+
+// FindExpired returns the ids and the revisions of the consents expired at the
+// given date.
+func (t *Storage) FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error) {
+	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_expiration",
+		Limit:     limit,
+		Range: &db.Range{
+			Start: 0,
+			End:   now.UnixNano() / int64(time.Millisecond),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	revs := make(map[string]string, len(res))
+	for _, row := range res {
+		var rev string
+		err = json.Unmarshal(row.Value, &rev)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the revision of %q", row.ID)
+		}
+
+		revs[row.ID] = rev
+	}
+
+	return revs, nil
+}
@@ -0,0 +1,42 @@
+package consent
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type StorageMock struct {
+	mock.Mock
+}
+
+func (t *StorageMock) Set(_ context.Context, id string, rev string, value *Consent) (string, error) {
+	args := t.Called(id, rev, value)
+
+	return args.String(0), args.Error(1)
+}
+
+func (t *StorageMock) Delete(_ context.Context, id string, rev string) error {
+	return t.Called(id, rev).Error(0)
+}
+
+func (t *StorageMock) FindOneByUserAndClient(_ context.Context, userID string, clientID string) (string, string, *Consent, error) {
+	args := t.Called(userID, clientID)
+
+	if args.Get(2) == nil {
+		return "", "", nil, args.Error(3)
+	}
+
+	return args.String(0), args.String(1), args.Get(2).(*Consent), args.Error(3)
+}
+
+func (t *StorageMock) FindExpired(_ context.Context, now time.Time, limit uint) (map[string]string, error) {
+	args := t.Called(now, limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]string), args.Error(1)
+}
@@ -0,0 +1,93 @@
+package consent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Consent_StorageMock_Set(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Set", "some-id", "some-rev", &ValidConsent).Return("some-rev-2", nil).Once()
+
+	rev, err := mock.Set(context.Background(), "some-id", "some-rev", &ValidConsent)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev-2", rev)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_StorageMock_Delete(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+
+	err := mock.Delete(context.Background(), "some-id", "some-rev")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_StorageMock_FindOneByUserAndClient(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByUserAndClient", "some-user-id", "some-client-id").Return("some-id", "some-rev", &ValidConsent, nil).Once()
+
+	id, rev, res, err := mock.FindOneByUserAndClient(context.Background(), "some-user-id", "some-client-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-id", id)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidConsent, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_StorageMock_FindOneByUserAndClient_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByUserAndClient", "some-user-id", "some-client-id").Return("", "", nil, fmt.Errorf("some-error")).Once()
+
+	id, rev, res, err := mock.FindOneByUserAndClient(context.Background(), "some-user-id", "some-client-id")
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_StorageMock_FindExpired(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(map[string]string{"some-id": "some-rev"}, nil).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"some-id": "some-rev"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Consent_StorageMock_FindExpired_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
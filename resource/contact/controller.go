@@ -22,18 +22,16 @@ type StorageInterface interface {
 	Set(ctx context.Context, id string, rev string, value *Contact) (string, error)
 	Get(ctx context.Context, id string) (string, *Contact, error)
 	GetAll(ctx context.Context) (map[string]Contact, error)
-	FindOneByName(ctx context.Context, name string) (string, string, *Contact, error)
+	FindAllByOwner(ctx context.Context, ownerID string) (map[string]Contact, error)
+	FindAllWithoutOwner(ctx context.Context) (map[string]Contact, error)
+	FindOneByName(ctx context.Context, ownerID string, name string) (string, string, *Contact, error)
 	Delete(ctx context.Context, id string) error
 }
 
 func InitController(ctx context.Context, server *yaccc.Server) *Controller {
-	database, err := server.ConnectDatabase(ctx, BucketName)
+	database, err := SetupStorage(ctx, server)
 	if err != nil {
-		database, err = SetupStorage(ctx, server)
-		if err != nil {
-			log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
-		}
-
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
 	}
 
 	storage := NewStorage(db.NewCouchdbDriver(database))
@@ -57,13 +55,14 @@ func NewController(
 
 func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
 	err := validator.New().
+		CheckString("ownerID", cmd.OwnerID, is.Required, is.ID).
 		CheckString("name", cmd.Name, is.Required, is.StringInRange(3, 50)).
 		Run()
 	if err != nil {
 		return "", err
 	}
 
-	_, _, existingContact, err := t.storage.FindOneByName(ctx, cmd.Name)
+	_, _, existingContact, err := t.storage.FindOneByName(ctx, cmd.OwnerID, cmd.Name)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to check if the name is already taken")
 	}
@@ -73,7 +72,8 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error)
 	}
 
 	contact := Contact{
-		Name: cmd.Name,
+		OwnerID: cmd.OwnerID,
+		Name:    cmd.Name,
 	}
 
 	// Generate the UUID
@@ -90,6 +90,7 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error)
 func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*Contact, error) {
 	err := validator.New().
 		CheckString("contactID", cmd.ContactID, is.Required, is.StringInRange(3, 100)).
+		CheckString("ownerID", cmd.OwnerID, is.Optional, is.ID).
 		Run()
 	if err != nil {
 		return nil, err
@@ -100,14 +101,31 @@ func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*Contact, error) {
 		return nil, errors.Wrap(err, "failed to get a contact")
 	}
 
+	// Hide the contacts owned by the other users.
+	if contact != nil && cmd.OwnerID != "" && contact.OwnerID != cmd.OwnerID {
+		return nil, nil
+	}
+
 	return contact, nil
 }
 
 func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Contact, error) {
+	err := validator.New().
+		CheckString("ownerID", cmd.OwnerID, is.Optional, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
-	res, err := t.storage.GetAll(ctx)
+	var res map[string]Contact
+	if cmd.OwnerID == "" {
+		res, err = t.storage.GetAll(ctx)
+	} else {
+		res, err = t.storage.FindAllByOwner(ctx, cmd.OwnerID)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get all contacts")
 	}
@@ -118,6 +136,7 @@ func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Con
 func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	err := validator.New().
 		CheckString("contactID", cmd.ContactID, is.Required, is.StringInRange(3, 100)).
+		CheckString("ownerID", cmd.OwnerID, is.Optional, is.ID).
 		Run()
 	if err != nil {
 		return err
@@ -126,6 +145,18 @@ func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
+	if cmd.OwnerID != "" {
+		_, contact, err := t.storage.Get(ctx, cmd.ContactID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the contact")
+		}
+
+		// Act as if the contacts owned by the other users don't exist.
+		if contact == nil || contact.OwnerID != cmd.OwnerID {
+			return nil
+		}
+	}
+
 	err = t.storage.Delete(ctx, cmd.ContactID)
 	if err != nil {
 		return errors.Wrap(err, "failed to delete the contact")
@@ -133,3 +164,48 @@ func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 
 	return nil
 }
+
+// AdoptOrphans gives the contacts without owner to the given user. Those
+// contacts were created before being bound to their owner and only the
+// admins can list them. It returns the number of adopted contacts.
+func (t *Controller) AdoptOrphans(ctx context.Context, cmd *AdoptOrphansCmd) (int, error) {
+	err := validator.New().
+		CheckString("ownerID", cmd.OwnerID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for {
+		orphans, err := t.storage.FindAllWithoutOwner(ctx)
+		if err != nil {
+			return count, errors.Wrap(err, "failed to find the contacts without owner")
+		}
+
+		if len(orphans) == 0 {
+			return count, nil
+		}
+
+		for contactID := range orphans {
+			rev, contact, err := t.storage.Get(ctx, contactID)
+			if err != nil {
+				return count, errors.Wrapf(err, "failed to get the contact %q", contactID)
+			}
+
+			// Deleted since the view query.
+			if contact == nil {
+				continue
+			}
+
+			contact.OwnerID = cmd.OwnerID
+
+			_, err = t.storage.Set(ctx, contactID, rev, contact)
+			if err != nil {
+				return count, errors.Wrapf(err, "failed to save the contact %q", contactID)
+			}
+
+			count++
+		}
+	}
+}
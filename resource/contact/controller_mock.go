@@ -39,3 +39,9 @@ func (t *ControllerMock) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string
 func (t *ControllerMock) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	return t.Called(cmd).Error(0)
 }
+
+func (t *ControllerMock) AdoptOrphans(ctx context.Context, cmd *AdoptOrphansCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
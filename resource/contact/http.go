@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/halium-project/go-server-utils/errors"
 	"github.com/halium-project/go-server-utils/response"
+	"github.com/halium-project/server/resource/user"
 	"github.com/halium-project/server/utils/permission"
 )
 
@@ -44,6 +45,12 @@ func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 		ContactID string `json:"id"`
 	}
 
+	caller := permission.UserFromContext(r.Context())
+	if caller == nil {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "the access token is not bound to any user"))
+		return
+	}
+
 	var req request
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -52,7 +59,8 @@ func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, err := t.contact.Create(r.Context(), &CreateCmd{
-		Name: req.Name,
+		OwnerID: caller.ID,
+		Name:    req.Name,
 	})
 	if err != nil {
 		errors.IntoResponse(w, err)
@@ -65,9 +73,16 @@ func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (t *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := ownerFilter(r)
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
 	contactID := mux.Vars(r)["contactID"]
 	contact, err := t.contact.Get(r.Context(), &GetCmd{
 		ContactID: contactID,
+		OwnerID:   ownerID,
 	})
 
 	if err != nil {
@@ -84,7 +99,15 @@ func (t *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 func (t *HTTPHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	contacts, err := t.contact.GetAll(r.Context(), &GetAllCmd{})
+	ownerID, err := ownerFilter(r)
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	contacts, err := t.contact.GetAll(r.Context(), &GetAllCmd{
+		OwnerID: ownerID,
+	})
 	if err != nil {
 		errors.IntoResponse(w, err)
 		return
@@ -94,10 +117,17 @@ func (t *HTTPHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 }
 
 func (t *HTTPHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := ownerFilter(r)
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
 	contactID := mux.Vars(r)["contactID"]
 
-	err := t.contact.Delete(r.Context(), &DeleteCmd{
+	err = t.contact.Delete(r.Context(), &DeleteCmd{
 		ContactID: contactID,
+		OwnerID:   ownerID,
 	})
 	if err != nil {
 		errors.IntoResponse(w, err)
@@ -107,3 +137,25 @@ func (t *HTTPHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	// Do not return the password and the salt.
 	response.Write(w, http.StatusOK, struct{}{})
 }
+
+// ownerFilter returns the owner used to scope the request: the user bound to
+// the access token.
+//
+// The admins can opt in to access the contacts of all the users with the
+// "all=true" query parameter. In this case an empty owner is returned.
+func ownerFilter(r *http.Request) (string, error) {
+	caller := permission.UserFromContext(r.Context())
+	if caller == nil {
+		return "", errors.New(errors.Forbidden, "the access token is not bound to any user")
+	}
+
+	if r.URL.Query().Get("all") != "true" {
+		return caller.ID, nil
+	}
+
+	if caller.Role != user.Admin {
+		return "", errors.New(errors.Forbidden, "only the admins can access the contacts of all the users")
+	}
+
+	return "", nil
+}
@@ -18,7 +18,7 @@ import (
 
 func Test_Contact_HTTPHandler_Create_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -28,7 +28,8 @@ func Test_Contact_HTTPHandler_Create_success(t *testing.T) {
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
 	controllerMock.On("Create", &CreateCmd{
-		Name: "Jane Doe",
+		OwnerID: accesstoken.ValidAccessToken.UserID,
+		Name:    "Jane Doe",
 	}).Return("some-contact-id", nil).Once()
 
 	r := httptest.NewRequest("POST", "http://example.com/contacts", strings.NewReader(`{
@@ -52,7 +53,7 @@ func Test_Contact_HTTPHandler_Create_success(t *testing.T) {
 
 func Test_Contact_HTTPHandler_Create_with_an_invalid_json(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -83,7 +84,7 @@ func Test_Contact_HTTPHandler_Create_with_an_invalid_json(t *testing.T) {
 
 func Test_Contact_HTTPHandler_Create_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -93,7 +94,8 @@ func Test_Contact_HTTPHandler_Create_with_an_error_from_the_usecase(t *testing.T
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
 	controllerMock.On("Create", &CreateCmd{
-		Name: "Jane Doe",
+		OwnerID: accesstoken.ValidAccessToken.UserID,
+		Name:    "Jane Doe",
 	}).Return("", errors.New(errors.NotFound, "contact not found")).Once()
 
 	r := httptest.NewRequest("POST", "http://example.com/contacts", strings.NewReader(`{
@@ -120,7 +122,7 @@ func Test_Contact_HTTPHandler_Create_with_an_error_from_the_usecase(t *testing.T
 
 func Test_Contact_HTTPHandler_Get_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -129,7 +131,7 @@ func Test_Contact_HTTPHandler_Get_success(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Get", &GetCmd{ContactID: "some-contact-id"}).Return(&ValidContact, nil).Once()
+	controllerMock.On("Get", &GetCmd{ContactID: "some-contact-id", OwnerID: accesstoken.ValidAccessToken.UserID}).Return(&ValidContact, nil).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/contacts/some-contact-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -143,6 +145,7 @@ func Test_Contact_HTTPHandler_Get_success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 	assert.JSONEq(t, `{
+		"ownerID": "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
 		"name": "Jane Doe"
 	}`, string(body))
 
@@ -152,7 +155,7 @@ func Test_Contact_HTTPHandler_Get_success(t *testing.T) {
 
 func Test_Contact_HTTPHandler_Get_with_the_contact_not_found(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -161,7 +164,7 @@ func Test_Contact_HTTPHandler_Get_with_the_contact_not_found(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Get", &GetCmd{ContactID: "some-contact-id"}).Return(nil, nil).Once()
+	controllerMock.On("Get", &GetCmd{ContactID: "some-contact-id", OwnerID: accesstoken.ValidAccessToken.UserID}).Return(nil, nil).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/contacts/some-contact-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -185,7 +188,7 @@ func Test_Contact_HTTPHandler_Get_with_the_contact_not_found(t *testing.T) {
 
 func Test_Contact_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -194,7 +197,7 @@ func Test_Contact_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Get", &GetCmd{ContactID: "some-contact-id"}).Return(nil, errors.New(errors.Internal, "some error")).Once()
+	controllerMock.On("Get", &GetCmd{ContactID: "some-contact-id", OwnerID: accesstoken.ValidAccessToken.UserID}).Return(nil, errors.New(errors.Internal, "some error")).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/contacts/some-contact-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -218,7 +221,7 @@ func Test_Contact_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 
 func Test_Contact_HTTPHandler_GetAll_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -227,7 +230,7 @@ func Test_Contact_HTTPHandler_GetAll_success(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("GetAll", &GetAllCmd{}).Return(map[string]Contact{"some-contact-id": ValidContact}, nil).Once()
+	controllerMock.On("GetAll", &GetAllCmd{OwnerID: accesstoken.ValidAccessToken.UserID}).Return(map[string]Contact{"some-contact-id": ValidContact}, nil).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/contacts", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -242,6 +245,7 @@ func Test_Contact_HTTPHandler_GetAll_success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 	assert.JSONEq(t, `{
 		"some-contact-id": {
+			"ownerID": "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
 			"name": "Jane Doe"
 		}
 	}`, string(body))
@@ -252,7 +256,7 @@ func Test_Contact_HTTPHandler_GetAll_success(t *testing.T) {
 
 func Test_Contact_HTTPHandler_GetAll_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -261,7 +265,7 @@ func Test_Contact_HTTPHandler_GetAll_with_an_error_from_the_usecase(t *testing.T
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("GetAll", &GetAllCmd{}).Return(nil, errors.New(errors.Internal, "some error")).Once()
+	controllerMock.On("GetAll", &GetAllCmd{OwnerID: accesstoken.ValidAccessToken.UserID}).Return(nil, errors.New(errors.Internal, "some error")).Once()
 
 	r := httptest.NewRequest("GET", "http://example.com/contacts", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -283,9 +287,113 @@ func Test_Contact_HTTPHandler_GetAll_with_an_error_from_the_usecase(t *testing.T
 	accessTokenControllerMock.AssertExpectations(t)
 }
 
+func Test_Contact_HTTPHandler_GetAll_for_all_the_users_as_admin(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("GetAll", &GetAllCmd{OwnerID: ""}).Return(map[string]Contact{"some-contact-id": ValidContact}, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/contacts?all=true", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{
+		"some-contact-id": {
+			"ownerID": "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+			"name": "Jane Doe"
+		}
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_Contact_HTTPHandler_GetAll_for_all_the_users_without_being_admin(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/contacts?all=true", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "only the admins can access the contacts of all the users"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_Contact_HTTPHandler_GetAll_with_a_token_without_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.UserID = ""
+	token.Role = ""
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/contacts", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "the access token is not bound to any user"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
 func Test_Contact_HTTPHandler_Delete_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -294,7 +402,7 @@ func Test_Contact_HTTPHandler_Delete_success(t *testing.T) {
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Delete", &DeleteCmd{ContactID: "some-contact-id"}).Return(nil).Once()
+	controllerMock.On("Delete", &DeleteCmd{ContactID: "some-contact-id", OwnerID: accesstoken.ValidAccessToken.UserID}).Return(nil).Once()
 
 	r := httptest.NewRequest("DELETE", "http://example.com/contacts/some-contact-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -315,7 +423,7 @@ func Test_Contact_HTTPHandler_Delete_success(t *testing.T) {
 
 func Test_Contact_HTTPHandler_Delete_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -324,7 +432,7 @@ func Test_Contact_HTTPHandler_Delete_with_an_error_from_the_usecase(t *testing.T
 	// Token introspection
 	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
 
-	controllerMock.On("Delete", &DeleteCmd{ContactID: "some-contact-id"}).Return(errors.New(errors.BadRequest, "some-error")).Once()
+	controllerMock.On("Delete", &DeleteCmd{ContactID: "some-contact-id", OwnerID: accesstoken.ValidAccessToken.UserID}).Return(errors.New(errors.BadRequest, "some-error")).Once()
 
 	r := httptest.NewRequest("DELETE", "http://example.com/contacts/some-contact-id", nil)
 	r.Header.Add("Authorization", "Bearer foobar")
@@ -1,24 +1,46 @@
 package contact
 
 type Contact struct {
+	// ID of the user owning the contact.
+	OwnerID string `json:"ownerID"`
+
 	Name string `json:"name"`
 }
 
-type GetAllCmd struct{}
+type GetAllCmd struct {
+	// Restrict the contacts to the ones owned by this user. All the contacts
+	// are returned if empty.
+	OwnerID string
+}
 
 type GetCmd struct {
 	ContactID string
+
+	// Hide the contact if not owned by this user. No check is done if
+	// empty.
+	OwnerID string
 }
 
 type DeleteCmd struct {
 	ContactID string
+
+	// Don't delete the contact if not owned by this user. No check is done
+	// if empty.
+	OwnerID string
+}
+
+// AdoptOrphansCmd gives the contacts without owner to a user.
+type AdoptOrphansCmd struct {
+	OwnerID string
 }
 
 type CreateCmd struct {
-	Name string
+	OwnerID string
+	Name    string
 }
 
 var ValidContactID = "8c21296d-fbe8-4ddd-aa09-a888a06d66b7"
 var ValidContact = Contact{
-	Name: "Jane Doe",
+	OwnerID: "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	Name:    "Jane Doe",
 }
@@ -15,28 +15,41 @@ type Storage struct {
 }
 
 func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
-	db, err := server.CreateDatabase(ctx, &yaccc.CreateDatabaseCmd{
-		Name: BucketName,
-		DesignDocuments: map[string]yaccc.DesignDocument{
-			"default": {
-				Language: yaccc.Javascript,
-				Views: map[string]yaccc.View{
-					"by_name": {
-						Map: `function (doc, meta) {
-							if (doc.name) {
-								emit(doc.name, null);
-							}
-						}`,
-					},
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_name": {
+					Map: `function (doc, meta) {
+						if (doc.name) {
+							emit(doc.name, null);
+						}
+					}`,
+				},
+				"by_owner": {
+					Map: `function (doc, meta) {
+						if (doc.ownerID) {
+							emit(doc.ownerID, null);
+						}
+					}`,
+				},
+				"without_owner": {
+					Map: `function (doc, meta) {
+						if (!doc.ownerID) {
+							emit(null, null);
+						}
+					}`,
+				},
+				"by_owner_and_name": {
+					Map: `function (doc, meta) {
+						if (doc.ownerID && doc.name) {
+							emit([doc.ownerID, doc.name], null);
+						}
+					}`,
 				},
 			},
 		},
 	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create the database")
-	}
-
-	return db, nil
 }
 
 func NewStorage(driver db.Driver) *Storage {
@@ -98,35 +111,41 @@ func (t *Storage) GetAll(ctx context.Context) (map[string]Contact, error) {
 		return nil, errors.Wrap(err, "failed to query the view")
 	}
 
-	if len(viewResult) == 0 {
-		return map[string]Contact{}, nil
-	}
-
-	contactList := map[string]*Contact{}
-
-	for _, val := range viewResult {
-		contactList[val.ID] = &Contact{}
-	}
+	return t.getMany(ctx, viewResult)
+}
 
-	err = t.driver.GetMany(ctx, contactList)
+func (t *Storage) FindAllByOwner(ctx context.Context, ownerID string) (map[string]Contact, error) {
+	viewResult, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_owner",
+		Limit:     200,
+		Equals:    []interface{}{ownerID},
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get the documents")
+		return nil, errors.Wrap(err, "failed to query the view")
 	}
 
-	res := make(map[string]Contact, len(contactList))
+	return t.getMany(ctx, viewResult)
+}
 
-	for key, value := range contactList {
-		res[key] = *value
+// FindAllWithoutOwner returns the contacts created before the contacts were
+// bound to their owner.
+func (t *Storage) FindAllWithoutOwner(ctx context.Context) (map[string]Contact, error) {
+	viewResult, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "without_owner",
+		Limit:     200,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
 	}
 
-	return res, nil
+	return t.getMany(ctx, viewResult)
 }
 
-func (t *Storage) FindOneByName(ctx context.Context, name string) (string, string, *Contact, error) {
+func (t *Storage) FindOneByName(ctx context.Context, ownerID string, name string) (string, string, *Contact, error) {
 	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
-		IndexName: "by_name",
+		IndexName: "by_owner_and_name",
 		Limit:     1,
-		Equals:    []interface{}{name},
+		Equals:    []interface{}{[]string{ownerID, name}},
 	})
 	if err != nil {
 		return "", "", nil, errors.Wrap(err, "failed to query the view")
@@ -144,3 +163,28 @@ func (t *Storage) FindOneByName(ctx context.Context, name string) (string, strin
 
 	return res[0].ID, rev, &contact, nil
 }
+
+func (t *Storage) getMany(ctx context.Context, viewResult []db.ViewRow) (map[string]Contact, error) {
+	if len(viewResult) == 0 {
+		return map[string]Contact{}, nil
+	}
+
+	contactList := map[string]*Contact{}
+
+	for _, val := range viewResult {
+		contactList[val.ID] = &Contact{}
+	}
+
+	err := t.driver.GetMany(ctx, contactList)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the documents")
+	}
+
+	res := make(map[string]Contact, len(contactList))
+
+	for key, value := range contactList {
+		res[key] = *value
+	}
+
+	return res, nil
+}
@@ -40,8 +40,28 @@ func (t *StorageMock) GetAll(ctx context.Context) (map[string]Contact, error) {
 	return args.Get(0).(map[string]Contact), nil
 }
 
-func (t *StorageMock) FindOneByName(ctx context.Context, name string) (string, string, *Contact, error) {
-	args := t.Called(name)
+func (t *StorageMock) FindAllByOwner(ctx context.Context, ownerID string) (map[string]Contact, error) {
+	args := t.Called(ownerID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]Contact), nil
+}
+
+func (t *StorageMock) FindAllWithoutOwner(ctx context.Context) (map[string]Contact, error) {
+	args := t.Called()
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]Contact), nil
+}
+
+func (t *StorageMock) FindOneByName(ctx context.Context, ownerID string, name string) (string, string, *Contact, error) {
+	args := t.Called(ownerID, name)
 
 	if args.Get(2) == nil {
 		return "", "", nil, args.Error(3)
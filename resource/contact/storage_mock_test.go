@@ -91,12 +91,42 @@ func Test_Contact_StorageMock_GetAll_with_an_error(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+func Test_Contact_StorageMock_FindAllByOwner(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByOwner", "some-owner-id").Return(map[string]Contact{
+		"some-id": ValidContact,
+	}, nil).Once()
+
+	res, err := mock.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Contact{
+		"some-id": ValidContact,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Contact_StorageMock_FindAllByOwner_with_an_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByOwner", "some-owner-id").Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.Empty(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
 func Test_Contact_StorageMock_FindOneByName(t *testing.T) {
 	mock := new(StorageMock)
 
-	mock.On("FindOneByName", "some-name").Return("some-id", "some-rev", &ValidContact, nil)
+	mock.On("FindOneByName", "some-owner-id", "some-name").Return("some-id", "some-rev", &ValidContact, nil)
 
-	id, rev, res, err := mock.FindOneByName(context.Background(), "some-name")
+	id, rev, res, err := mock.FindOneByName(context.Background(), "some-owner-id", "some-name")
 
 	assert.NoError(t, err)
 	assert.Equal(t, "some-id", id)
@@ -109,9 +139,9 @@ func Test_Contact_StorageMock_FindOneByName(t *testing.T) {
 func Test_Contact_StorageMock_FindOneByName_with_error(t *testing.T) {
 	mock := new(StorageMock)
 
-	mock.On("FindOneByName", "some-name").Return("", "", nil, errors.New("some-error"))
+	mock.On("FindOneByName", "some-owner-id", "some-name").Return("", "", nil, errors.New("some-error"))
 
-	id, rev, res, err := mock.FindOneByName(context.Background(), "some-name")
+	id, rev, res, err := mock.FindOneByName(context.Background(), "some-owner-id", "some-name")
 
 	assert.Empty(t, rev)
 	assert.Empty(t, id)
@@ -120,3 +150,33 @@ func Test_Contact_StorageMock_FindOneByName_with_error(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_Contact_StorageMock_FindAllWithoutOwner(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllWithoutOwner").Return(map[string]Contact{
+		"some-id": ValidContact,
+	}, nil).Once()
+
+	res, err := mock.FindAllWithoutOwner(context.Background())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Contact{
+		"some-id": ValidContact,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Contact_StorageMock_FindAllWithoutOwner_with_an_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllWithoutOwner").Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.FindAllWithoutOwner(context.Background())
+
+	assert.Empty(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
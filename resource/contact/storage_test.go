@@ -262,21 +262,72 @@ func Test_Contact_Storage_GetAll_with_GetMany_error(t *testing.T) {
 	dbDriver.AssertExpectations(t)
 }
 
+func Test_Contact_Storage_FindAllByOwner(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_owner",
+		Limit:     200,
+		Equals:    []interface{}{"some-owner-id"},
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+	}, nil).Once()
+
+	dbDriver.On("GetMany", []string{"some-id"}).Return(map[string]Contact{
+		"some-id": ValidContact,
+	}, nil).Once()
+
+	res, err := service.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Contact{
+		"some-id": ValidContact,
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_Contact_Storage_FindAllByOwner_with_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_owner",
+		Limit:     200,
+		Equals:    []interface{}{"some-owner-id"},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := service.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
 func Test_Contact_Storage_FindOneByName(t *testing.T) {
 	dbDriver := new(db.DriverMock)
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_name",
+		IndexName: "by_owner_and_name",
 		Limit:     1,
-		Equals:    []interface{}{"some-username"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-name"}},
 	}).Return([]db.ViewRow{
 		{ID: "some-id"},
 	}, nil).Once()
 
 	dbDriver.On("Get", "some-id").Return("some-rev", &ValidContact, nil).Once()
 
-	id, rev, res, err := user.FindOneByName(context.Background(), "some-username")
+	id, rev, res, err := user.FindOneByName(context.Background(), "some-owner-id", "some-name")
 
 	assert.Equal(t, "some-id", id)
 	assert.Equal(t, "some-rev", rev)
@@ -291,12 +342,12 @@ func Test_Contact_Storage_FindOneByName_with_no_user_found(t *testing.T) {
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_name",
+		IndexName: "by_owner_and_name",
 		Limit:     1,
-		Equals:    []interface{}{"some-username"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-name"}},
 	}).Return(nil, nil).Once()
 
-	id, rev, res, err := user.FindOneByName(context.Background(), "some-username")
+	id, rev, res, err := user.FindOneByName(context.Background(), "some-owner-id", "some-name")
 
 	assert.Empty(t, id)
 	assert.Empty(t, rev)
@@ -311,12 +362,12 @@ func Test_Contact_Storage_FindOneByName_with_query_view_error(t *testing.T) {
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_name",
+		IndexName: "by_owner_and_name",
 		Limit:     1,
-		Equals:    []interface{}{"some-username"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-name"}},
 	}).Return(nil, fmt.Errorf("some-error")).Once()
 
-	id, rev, res, err := user.FindOneByName(context.Background(), "some-username")
+	id, rev, res, err := user.FindOneByName(context.Background(), "some-owner-id", "some-name")
 
 	assert.Empty(t, id)
 	assert.Empty(t, rev)
@@ -338,16 +389,16 @@ func Test_Contact_Storage_FindOneByName_with_get_error(t *testing.T) {
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_name",
+		IndexName: "by_owner_and_name",
 		Limit:     1,
-		Equals:    []interface{}{"some-username"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-name"}},
 	}).Return([]db.ViewRow{
 		{ID: "some-id"},
 	}, nil).Once()
 
 	dbDriver.On("Get", "some-id").Return("", nil, fmt.Errorf("some-error")).Once()
 
-	id, rev, res, err := user.FindOneByName(context.Background(), "some-username")
+	id, rev, res, err := user.FindOneByName(context.Background(), "some-owner-id", "some-name")
 
 	assert.Empty(t, id)
 	assert.Empty(t, rev)
@@ -363,3 +414,55 @@ func Test_Contact_Storage_FindOneByName_with_get_error(t *testing.T) {
 
 	dbDriver.AssertExpectations(t)
 }
+
+func Test_Contact_Storage_FindAllWithoutOwner(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	orphan := ValidContact
+	orphan.OwnerID = ""
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "without_owner",
+		Limit:     200,
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+	}, nil).Once()
+
+	dbDriver.On("GetMany", []string{"some-id"}).Return(map[string]Contact{
+		"some-id": orphan,
+	}, nil).Once()
+
+	res, err := service.FindAllWithoutOwner(context.Background())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Contact{
+		"some-id": orphan,
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_Contact_Storage_FindAllWithoutOwner_with_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "without_owner",
+		Limit:     200,
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := service.FindAllWithoutOwner(context.Background())
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
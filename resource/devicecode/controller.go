@@ -0,0 +1,278 @@
+package devicecode
+
+import (
+	"context"
+	"crypto/rand"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/secret"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+// userCodeCharset contains the characters of the user codes. The vowels are
+// removed in order to avoid any word and the similar characters in order to
+// avoid typing errors (RFC 8628 section 6.1).
+const userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+
+const userCodeLength = 8
+
+// slowDownIncrement is added to the interval of a device polling too fast
+// (RFC 8628 section 3.5).
+const slowDownIncrement = 5
+
+// ErrSlowDown is returned when a device polls faster than its interval.
+var ErrSlowDown = errors.New(errors.BadRequest, "slow down")
+
+type Controller struct {
+	uuid    uuid.Producer
+	clock   clock.Clock
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *DeviceCode) (string, error)
+	Get(ctx context.Context, id string) (string, *DeviceCode, error)
+	Delete(ctx context.Context, id string, rev string) error
+	FindOneByUserCode(ctx context.Context, userCode string) (string, string, *DeviceCode, error)
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+	uuidProducer := uuid.NewGoUUID()
+
+	return NewController(uuidProducer, clock.NewDefault(), storage)
+}
+
+func NewController(
+	uuid uuid.Producer,
+	clock clock.Clock,
+	storage StorageInterface,
+) *Controller {
+	return &Controller{
+		uuid:    uuid,
+		clock:   clock,
+		storage: storage,
+	}
+}
+
+// Create generates a new pending device code and its user code. Only a hash
+// of the device code is saved.
+func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, string, error) {
+	err := validator.New().
+		CheckString("clientID", cmd.ClientID, is.Required).
+		CheckString("scope", cmd.Scope, is.Optional).
+		CheckNumber("expiresIn", cmd.ExpiresIn, is.Required, is.NumberPositif).
+		CheckNumber("interval", cmd.Interval, is.Required, is.NumberPositif).
+		Run()
+	if err != nil {
+		return "", "", err
+	}
+
+	userCode, err := newUserCode()
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to generate the user code")
+	}
+
+	deviceCode := t.uuid.New()
+
+	_, err = t.storage.Set(ctx, secret.Hash(deviceCode), "", &DeviceCode{
+		ClientID:  cmd.ClientID,
+		Scope:     cmd.Scope,
+		UserCode:  userCode,
+		Status:    Pending,
+		CreatedAt: t.clock.Now(),
+		ExpiresIn: cmd.ExpiresIn,
+		Interval:  cmd.Interval,
+	})
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to save the device code")
+	}
+
+	return deviceCode, userCode, nil
+}
+
+// GetByUserCode returns the pending device code matching the user code. It
+// returns nil if the code is unknown, expired or already used.
+func (t *Controller) GetByUserCode(ctx context.Context, cmd *GetByUserCodeCmd) (*DeviceCode, error) {
+	_, _, deviceCode, err := t.findPending(ctx, cmd.UserCode)
+
+	return deviceCode, err
+}
+
+// Approve allows the device to retrieve an access token for the user.
+func (t *Controller) Approve(ctx context.Context, cmd *ApproveCmd) error {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	return t.setStatus(ctx, cmd.UserCode, Approved, cmd.UserID)
+}
+
+// Deny rejects the authorization asked by the device.
+func (t *Controller) Deny(ctx context.Context, cmd *DenyCmd) error {
+	return t.setStatus(ctx, cmd.UserCode, Denied, "")
+}
+
+// Poll is called each time the device asks for its token. It returns nil if
+// the device code is unknown or issued to another client and ErrSlowDown if
+// the device doesn't respect its interval.
+//
+// An approved or denied code is deleted once returned so it can only be used
+// once. The expiration is left to the caller.
+func (t *Controller) Poll(ctx context.Context, cmd *PollCmd) (*DeviceCode, error) {
+	err := validator.New().
+		CheckString("deviceCode", cmd.DeviceCode, is.Required, is.ID).
+		CheckString("clientID", cmd.ClientID, is.Required).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	id := secret.Hash(cmd.DeviceCode)
+
+	rev, deviceCode, err := t.storage.Get(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the device code")
+	}
+
+	if deviceCode == nil || deviceCode.ClientID != cmd.ClientID {
+		return nil, nil
+	}
+
+	now := t.clock.Now()
+	if deviceCode.IsExpiredAt(now) {
+		return deviceCode, nil
+	}
+
+	if deviceCode.Status != Pending {
+		err = t.storage.Delete(ctx, id, rev)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to delete the device code")
+		}
+
+		return deviceCode, nil
+	}
+
+	tooFast := !deviceCode.LastPolledAt.IsZero() && now.Sub(deviceCode.LastPolledAt).Seconds() < float64(deviceCode.Interval)
+	if tooFast {
+		deviceCode.Interval += slowDownIncrement
+	}
+
+	deviceCode.LastPolledAt = now
+	_, err = t.storage.Set(ctx, id, rev, deviceCode)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save the device code")
+	}
+
+	if tooFast {
+		return nil, ErrSlowDown
+	}
+
+	return deviceCode, nil
+}
+
+func (t *Controller) setStatus(ctx context.Context, userCode string, status string, userID string) error {
+	id, rev, deviceCode, err := t.findPending(ctx, userCode)
+	if err != nil {
+		return err
+	}
+
+	if deviceCode == nil {
+		return errors.New(errors.NotFound, "device code not found")
+	}
+
+	deviceCode.Status = status
+	deviceCode.UserID = userID
+
+	_, err = t.storage.Set(ctx, id, rev, deviceCode)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the device code")
+	}
+
+	return nil
+}
+
+func (t *Controller) findPending(ctx context.Context, userCode string) (string, string, *DeviceCode, error) {
+	userCode = NormalizeUserCode(userCode)
+
+	err := validator.New().
+		CheckString("userCode", userCode, is.Required, is.StringInRange(userCodeLength, userCodeLength)).
+		Run()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	id, rev, deviceCode, err := t.storage.FindOneByUserCode(ctx, userCode)
+	if err != nil {
+		return "", "", nil, errors.Wrap(err, "failed to get the device code")
+	}
+
+	if deviceCode == nil || deviceCode.Status != Pending || deviceCode.IsExpiredAt(t.clock.Now()) {
+		return "", "", nil, nil
+	}
+
+	return id, rev, deviceCode, nil
+}
+
+// NormalizeUserCode removes the separators and the case of a user code typed
+// by a user.
+func NormalizeUserCode(userCode string) string {
+	userCode = strings.ToUpper(userCode)
+	userCode = strings.Replace(userCode, "-", "", -1)
+
+	return strings.Join(strings.Fields(userCode), "")
+}
+
+// FormatUserCode returns the user code as displayed to the user
+// ("XXXX-XXXX").
+func FormatUserCode(userCode string) string {
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+
+	return userCode[:4] + "-" + userCode[4:]
+}
+
+func newUserCode() (string, error) {
+	code := make([]byte, 0, userCodeLength)
+	raw := make([]byte, 1)
+
+	for len(code) < userCodeLength {
+		_, err := rand.Read(raw)
+		if err != nil {
+			return "", err
+		}
+
+		// Skip the values creating a bias toward the first characters.
+		if int(raw[0]) >= 256-256%len(userCodeCharset) {
+			continue
+		}
+
+		code = append(code, userCodeCharset[int(raw[0])%len(userCodeCharset)])
+	}
+
+	return string(code), nil
+}
+
+// DeleteExpired deletes the device codes expired at the given date. It returns
+// the number of deleted device codes.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
@@ -0,0 +1,51 @@
+package devicecode
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ControllerMock struct {
+	mock.Mock
+}
+
+func (t *ControllerMock) Create(ctx context.Context, cmd *CreateCmd) (string, string, error) {
+	args := t.Called(cmd)
+
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (t *ControllerMock) GetByUserCode(ctx context.Context, cmd *GetByUserCodeCmd) (*DeviceCode, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*DeviceCode), args.Error(1)
+}
+
+func (t *ControllerMock) Approve(ctx context.Context, cmd *ApproveCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) Deny(ctx context.Context, cmd *DenyCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) Poll(ctx context.Context, cmd *PollCmd) (*DeviceCode, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*DeviceCode), args.Error(1)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
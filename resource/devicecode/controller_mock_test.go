@@ -0,0 +1,124 @@
+package devicecode
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeviceCode_ControllerMock_Create(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Create", &CreateCmd{
+		ClientID:  ValidDeviceCode.ClientID,
+		Scope:     ValidDeviceCode.Scope,
+		ExpiresIn: ValidDeviceCode.ExpiresIn,
+		Interval:  ValidDeviceCode.Interval,
+	}).Return(ValidDeviceCodeToken, ValidDeviceCode.UserCode, nil).Once()
+
+	deviceCode, userCode, err := mock.Create(context.Background(), &CreateCmd{
+		ClientID:  ValidDeviceCode.ClientID,
+		Scope:     ValidDeviceCode.Scope,
+		ExpiresIn: ValidDeviceCode.ExpiresIn,
+		Interval:  ValidDeviceCode.Interval,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidDeviceCodeToken, deviceCode)
+	assert.Equal(t, ValidDeviceCode.UserCode, userCode)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_ControllerMock_GetByUserCode(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetByUserCode", &GetByUserCodeCmd{UserCode: ValidDeviceCode.UserCode}).Return(&ValidDeviceCode, nil).Once()
+
+	res, err := mock.GetByUserCode(context.Background(), &GetByUserCodeCmd{UserCode: ValidDeviceCode.UserCode})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_ControllerMock_GetByUserCode_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetByUserCode", &GetByUserCodeCmd{UserCode: ValidDeviceCode.UserCode}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.GetByUserCode(context.Background(), &GetByUserCodeCmd{UserCode: ValidDeviceCode.UserCode})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_ControllerMock_Approve(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Approve", &ApproveCmd{UserCode: ValidDeviceCode.UserCode, UserID: "some-user-id"}).Return(nil).Once()
+
+	err := mock.Approve(context.Background(), &ApproveCmd{UserCode: ValidDeviceCode.UserCode, UserID: "some-user-id"})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_ControllerMock_Deny(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Deny", &DenyCmd{UserCode: ValidDeviceCode.UserCode}).Return(nil).Once()
+
+	err := mock.Deny(context.Background(), &DenyCmd{UserCode: ValidDeviceCode.UserCode})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_ControllerMock_Poll(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Poll", &PollCmd{DeviceCode: ValidDeviceCodeToken, ClientID: ValidDeviceCode.ClientID}).Return(&ValidDeviceCode, nil).Once()
+
+	res, err := mock.Poll(context.Background(), &PollCmd{DeviceCode: ValidDeviceCodeToken, ClientID: ValidDeviceCode.ClientID})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_ControllerMock_Poll_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Poll", &PollCmd{DeviceCode: ValidDeviceCodeToken, ClientID: ValidDeviceCode.ClientID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.Poll(context.Background(), &PollCmd{DeviceCode: ValidDeviceCodeToken, ClientID: ValidDeviceCode.ClientID})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
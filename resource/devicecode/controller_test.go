@@ -0,0 +1,641 @@
+package devicecode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_DeviceCode_Controller_Create(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	var savedUserCode string
+
+	uuidMock.On("New").Return(ValidDeviceCodeToken).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.CreatedAt).Once()
+	storageMock.On("Set", secret.Hash(ValidDeviceCodeToken), "", mock.MatchedBy(func(deviceCode *DeviceCode) bool {
+		savedUserCode = deviceCode.UserCode
+
+		expected := ValidDeviceCode
+		expected.UserCode = deviceCode.UserCode
+
+		return assert.ObjectsAreEqual(&expected, deviceCode)
+	})).Return("some-rev", nil).Once()
+
+	deviceCode, userCode, err := controller.Create(context.Background(), &CreateCmd{
+		ClientID:  ValidDeviceCode.ClientID,
+		Scope:     ValidDeviceCode.Scope,
+		ExpiresIn: ValidDeviceCode.ExpiresIn,
+		Interval:  ValidDeviceCode.Interval,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidDeviceCodeToken, deviceCode)
+	assert.Equal(t, savedUserCode, userCode)
+	assert.Len(t, userCode, userCodeLength)
+	for _, c := range userCode {
+		assert.True(t, strings.ContainsRune(userCodeCharset, c))
+	}
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Create_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode, userCode, err := controller.Create(context.Background(), &CreateCmd{
+		ClientID:  "",
+		Scope:     ValidDeviceCode.Scope,
+		ExpiresIn: ValidDeviceCode.ExpiresIn,
+		Interval:  ValidDeviceCode.Interval,
+	})
+
+	assert.Empty(t, deviceCode)
+	assert.Empty(t, userCode)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"clientID":"MISSING_FIELD"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Create_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	uuidMock.On("New").Return(ValidDeviceCodeToken).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.CreatedAt).Once()
+	storageMock.On("Set", secret.Hash(ValidDeviceCodeToken), "", mock.Anything).Return("", fmt.Errorf("some-error")).Once()
+
+	deviceCode, userCode, err := controller.Create(context.Background(), &CreateCmd{
+		ClientID:  ValidDeviceCode.ClientID,
+		Scope:     ValidDeviceCode.Scope,
+		ExpiresIn: ValidDeviceCode.ExpiresIn,
+		Interval:  ValidDeviceCode.Interval,
+	})
+
+	assert.Empty(t, deviceCode)
+	assert.Empty(t, userCode)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the device code",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_GetByUserCode(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("some-id", "some-rev", &ValidDeviceCode, nil).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.CreatedAt.Add(time.Minute)).Once()
+
+	res, err := controller.GetByUserCode(context.Background(), &GetByUserCodeCmd{
+		UserCode: "wdjb-mjht ",
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_GetByUserCode_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	res, err := controller.GetByUserCode(context.Background(), &GetByUserCodeCmd{
+		UserCode: "WDJB",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"userCode":"TOO_SHORT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_GetByUserCode_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("", "", nil, nil).Once()
+
+	res, err := controller.GetByUserCode(context.Background(), &GetByUserCodeCmd{
+		UserCode: "WDJB-MJHT",
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_GetByUserCode_expired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("some-id", "some-rev", &ValidDeviceCode, nil).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.ExpireAt().Add(time.Second)).Once()
+
+	res, err := controller.GetByUserCode(context.Background(), &GetByUserCodeCmd{
+		UserCode: "WDJB-MJHT",
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_GetByUserCode_already_approved(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode := ValidDeviceCode
+	deviceCode.Status = Approved
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("some-id", "some-rev", &deviceCode, nil).Once()
+
+	res, err := controller.GetByUserCode(context.Background(), &GetByUserCodeCmd{
+		UserCode: "WDJB-MJHT",
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_GetByUserCode_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("", "", nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.GetByUserCode(context.Background(), &GetByUserCodeCmd{
+		UserCode: "WDJB-MJHT",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the device code",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Approve(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode := ValidDeviceCode
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("some-id", "some-rev", &deviceCode, nil).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.CreatedAt.Add(time.Minute)).Once()
+	storageMock.On("Set", "some-id", "some-rev", mock.MatchedBy(func(res *DeviceCode) bool {
+		return res.Status == Approved && res.UserID == "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb"
+	})).Return("some-rev-2", nil).Once()
+
+	err := controller.Approve(context.Background(), &ApproveCmd{
+		UserCode: "WDJB-MJHT",
+		UserID:   "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Approve_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	err := controller.Approve(context.Background(), &ApproveCmd{
+		UserCode: "WDJB-MJHT",
+		UserID:   "invalid-id",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"userID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Approve_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("", "", nil, nil).Once()
+
+	err := controller.Approve(context.Background(), &ApproveCmd{
+		UserCode: "WDJB-MJHT",
+		UserID:   "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"device code not found"
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Deny(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode := ValidDeviceCode
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("some-id", "some-rev", &deviceCode, nil).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.CreatedAt.Add(time.Minute)).Once()
+	storageMock.On("Set", "some-id", "some-rev", mock.MatchedBy(func(res *DeviceCode) bool {
+		return res.Status == Denied && res.UserID == ""
+	})).Return("some-rev-2", nil).Once()
+
+	err := controller.Deny(context.Background(), &DenyCmd{
+		UserCode: "WDJB-MJHT",
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Deny_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode := ValidDeviceCode
+
+	storageMock.On("FindOneByUserCode", "WDJBMJHT").Return("some-id", "some-rev", &deviceCode, nil).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.CreatedAt.Add(time.Minute)).Once()
+	storageMock.On("Set", "some-id", "some-rev", mock.Anything).Return("", fmt.Errorf("some-error")).Once()
+
+	err := controller.Deny(context.Background(), &DenyCmd{
+		UserCode: "WDJB-MJHT",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the device code",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_pending(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode := ValidDeviceCode
+	now := ValidDeviceCode.CreatedAt.Add(time.Minute)
+
+	storageMock.On("Get", secret.Hash(ValidDeviceCodeToken)).Return("some-rev", &deviceCode, nil).Once()
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Set", secret.Hash(ValidDeviceCodeToken), "some-rev", mock.MatchedBy(func(res *DeviceCode) bool {
+		return res.LastPolledAt.Equal(now) && res.Interval == ValidDeviceCode.Interval
+	})).Return("some-rev-2", nil).Once()
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: ValidDeviceCodeToken,
+		ClientID:   ValidDeviceCode.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Pending, res.Status)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_too_fast(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode := ValidDeviceCode
+	deviceCode.LastPolledAt = ValidDeviceCode.CreatedAt.Add(time.Minute)
+	now := deviceCode.LastPolledAt.Add(2 * time.Second)
+
+	storageMock.On("Get", secret.Hash(ValidDeviceCodeToken)).Return("some-rev", &deviceCode, nil).Once()
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Set", secret.Hash(ValidDeviceCodeToken), "some-rev", mock.MatchedBy(func(res *DeviceCode) bool {
+		return res.LastPolledAt.Equal(now) && res.Interval == ValidDeviceCode.Interval+slowDownIncrement
+	})).Return("some-rev-2", nil).Once()
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: ValidDeviceCodeToken,
+		ClientID:   ValidDeviceCode.ClientID,
+	})
+
+	assert.Nil(t, res)
+	assert.Equal(t, ErrSlowDown, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_approved(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	deviceCode := ValidDeviceCode
+	deviceCode.Status = Approved
+	deviceCode.UserID = "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb"
+
+	storageMock.On("Get", secret.Hash(ValidDeviceCodeToken)).Return("some-rev", &deviceCode, nil).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.CreatedAt.Add(time.Minute)).Once()
+	storageMock.On("Delete", secret.Hash(ValidDeviceCodeToken), "some-rev").Return(nil).Once()
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: ValidDeviceCodeToken,
+		ClientID:   ValidDeviceCode.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &deviceCode, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_expired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidDeviceCodeToken)).Return("some-rev", &ValidDeviceCode, nil).Once()
+	clockMock.On("Now").Return(ValidDeviceCode.ExpireAt().Add(time.Second)).Once()
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: ValidDeviceCodeToken,
+		ClientID:   ValidDeviceCode.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_with_another_client(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidDeviceCodeToken)).Return("some-rev", &ValidDeviceCode, nil).Once()
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: ValidDeviceCodeToken,
+		ClientID:   "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidDeviceCodeToken)).Return("", nil, nil).Once()
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: ValidDeviceCodeToken,
+		ClientID:   ValidDeviceCode.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: "invalid-code",
+		ClientID:   ValidDeviceCode.ClientID,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"deviceCode":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_Poll_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidDeviceCodeToken)).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Poll(context.Background(), &PollCmd{
+		DeviceCode: ValidDeviceCodeToken,
+		ClientID:   ValidDeviceCode.ClientID,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the device code",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_NormalizeUserCode(t *testing.T) {
+	assert.Equal(t, "WDJBMJHT", NormalizeUserCode("wdjb-mjht"))
+	assert.Equal(t, "WDJBMJHT", NormalizeUserCode(" WDJB MJHT "))
+}
+
+func Test_DeviceCode_FormatUserCode(t *testing.T) {
+	assert.Equal(t, "WDJB-MJHT", FormatUserCode("WDJBMJHT"))
+}
+
+func Test_DeviceCode_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
@@ -0,0 +1,89 @@
+package devicecode
+
+import "time"
+
+// The statuses of a device code.
+const (
+	Pending  = "pending"
+	Approved = "approved"
+	Denied   = "denied"
+)
+
+// DeviceCode is created when a device without a browser asks for an
+// authorization (RFC 8628). The user approves it from another device by
+// typing the user code.
+type DeviceCode struct {
+	ClientID string `json:"clientID"`
+	Scope    string `json:"scope"`
+	UserCode string `json:"userCode"`
+	Status   string `json:"status"`
+
+	// UserID is set once the device code is approved.
+	UserID    string    `json:"userID,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Code expiration in seconds.
+	ExpiresIn int `json:"expiresIn"`
+
+	// Minimum delay in seconds between two polls of the device.
+	Interval     int       `json:"interval"`
+	LastPolledAt time.Time `json:"lastPolledAt"`
+}
+
+// ExpireAt returns the date after which the code can't be used.
+func (t *DeviceCode) ExpireAt() time.Time {
+	return t.CreatedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// IsExpiredAt returns true if the code is expired at the given date.
+func (t *DeviceCode) IsExpiredAt(now time.Time) bool {
+	return t.ExpireAt().Before(now)
+}
+
+type CreateCmd struct {
+	ClientID string
+	Scope    string
+
+	// Code expiration in seconds.
+	ExpiresIn int
+
+	// Minimum delay in seconds between two polls.
+	Interval int
+}
+
+type GetByUserCodeCmd struct {
+	UserCode string
+}
+
+type ApproveCmd struct {
+	UserCode string
+	UserID   string
+}
+
+type DenyCmd struct {
+	UserCode string
+}
+
+type PollCmd struct {
+	DeviceCode string
+	ClientID   string
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if a device code is expired.
+	Now time.Time
+
+	// Maximum number of device codes deleted.
+	Limit uint
+}
+
+var ValidDeviceCodeToken = "5f0e8b3a-7c2d-4a61-9e4f-b1d3c6a8e2f7"
+var ValidDeviceCode = DeviceCode{
+	ClientID:  "my-web-application",
+	Scope:     "users.read",
+	UserCode:  "WDJBMJHT",
+	Status:    Pending,
+	CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	ExpiresIn: 600,
+	Interval:  5,
+}
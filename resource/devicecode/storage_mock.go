@@ -0,0 +1,52 @@
+package devicecode
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type StorageMock struct {
+	mock.Mock
+}
+
+func (t *StorageMock) Set(_ context.Context, id string, rev string, value *DeviceCode) (string, error) {
+	args := t.Called(id, rev, value)
+
+	return args.String(0), args.Error(1)
+}
+
+func (t *StorageMock) Get(_ context.Context, id string) (string, *DeviceCode, error) {
+	args := t.Called(id)
+
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+
+	return args.String(0), args.Get(1).(*DeviceCode), args.Error(2)
+}
+
+func (t *StorageMock) Delete(_ context.Context, id string, rev string) error {
+	return t.Called(id, rev).Error(0)
+}
+
+func (t *StorageMock) FindOneByUserCode(_ context.Context, userCode string) (string, string, *DeviceCode, error) {
+	args := t.Called(userCode)
+
+	if args.Get(2) == nil {
+		return args.String(0), args.String(1), nil, args.Error(3)
+	}
+
+	return args.String(0), args.String(1), args.Get(2).(*DeviceCode), args.Error(3)
+}
+
+func (t *StorageMock) FindExpired(_ context.Context, now time.Time, limit uint) (map[string]string, error) {
+	args := t.Called(now, limit)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]string), args.Error(1)
+}
@@ -0,0 +1,121 @@
+package devicecode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeviceCode_StorageMock_Set(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Set", "some-id", "", &ValidDeviceCode).Return("some-rev", nil).Once()
+
+	rev, err := mock.Set(context.Background(), "some-id", "", &ValidDeviceCode)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_StorageMock_Get(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", "some-id").Return("some-rev", &ValidDeviceCode, nil).Once()
+
+	rev, res, err := mock.Get(context.Background(), "some-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_StorageMock_Get_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", "some-id").Return("", nil, errors.New("some-error")).Once()
+
+	rev, res, err := mock.Get(context.Background(), "some-id")
+
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_StorageMock_Delete(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+
+	err := mock.Delete(context.Background(), "some-id", "some-rev")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_StorageMock_FindOneByUserCode(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByUserCode", ValidDeviceCode.UserCode).Return("some-id", "some-rev", &ValidDeviceCode, nil).Once()
+
+	id, rev, res, err := mock.FindOneByUserCode(context.Background(), ValidDeviceCode.UserCode)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-id", id)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_StorageMock_FindOneByUserCode_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByUserCode", ValidDeviceCode.UserCode).Return("", "", nil, errors.New("some-error")).Once()
+
+	id, rev, res, err := mock.FindOneByUserCode(context.Background(), ValidDeviceCode.UserCode)
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_StorageMock_FindExpired(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(map[string]string{"some-id": "some-rev"}, nil).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"some-id": "some-rev"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_DeviceCode_StorageMock_FindExpired_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(nil, errors.New("some-error")).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,261 @@
+package devicecode
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeviceCode_Storage_Set(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Set", "some-id", "", &ValidDeviceCode).Return("some-rev", nil).Once()
+
+	rev, err := storage.Set(context.Background(), "some-id", "", &ValidDeviceCode)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_Set_with_driver_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Set", "some-id", "", &ValidDeviceCode).Return("", fmt.Errorf("some-error")).Once()
+
+	rev, err := storage.Set(context.Background(), "some-id", "", &ValidDeviceCode)
+
+	assert.Empty(t, rev)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to set the document into the storage",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_Get(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", "some-id").Return("some-rev", &ValidDeviceCode, nil).Once()
+
+	rev, res, err := storage.Get(context.Background(), "some-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_Get_not_found(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", "some-id").Return("", nil, nil).Once()
+
+	rev, res, err := storage.Get(context.Background(), "some-id")
+
+	assert.NoError(t, err)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_Get_with_driver_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", "some-id").Return("", nil, fmt.Errorf("some-error")).Once()
+
+	rev, res, err := storage.Get(context.Background(), "some-id")
+
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the document from the storage",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_Delete(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Delete", "some-id", "some-rev").Return(nil).Once()
+
+	err := storage.Delete(context.Background(), "some-id", "some-rev")
+
+	assert.NoError(t, err)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_Delete_with_driver_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Delete", "some-id", "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	err := storage.Delete(context.Background(), "some-id", "some-rev")
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the document from the storage",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_FindOneByUserCode(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_user_code",
+		Limit:     1,
+		Equals:    []interface{}{ValidDeviceCode.UserCode},
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+	}, nil).Once()
+
+	dbDriver.On("Get", "some-id").Return("some-rev", &ValidDeviceCode, nil).Once()
+
+	id, rev, res, err := storage.FindOneByUserCode(context.Background(), ValidDeviceCode.UserCode)
+
+	assert.Equal(t, "some-id", id)
+	assert.Equal(t, "some-rev", rev)
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidDeviceCode, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_FindOneByUserCode_not_found(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_user_code",
+		Limit:     1,
+		Equals:    []interface{}{ValidDeviceCode.UserCode},
+	}).Return(nil, nil).Once()
+
+	id, rev, res, err := storage.FindOneByUserCode(context.Background(), ValidDeviceCode.UserCode)
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_FindOneByUserCode_with_query_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_user_code",
+		Limit:     1,
+		Equals:    []interface{}{ValidDeviceCode.UserCode},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	id, rev, res, err := storage.FindOneByUserCode(context.Background(), ValidDeviceCode.UserCode)
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_FindExpired(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return([]db.ViewRow{
+		{ID: "some-id", Value: []byte(`"some-rev"`)},
+		{ID: "some-other-id", Value: []byte(`"some-other-rev"`)},
+	}, nil).Once()
+
+	res, err := storage.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_DeviceCode_Storage_FindExpired_with_query_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := storage.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
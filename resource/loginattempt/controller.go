@@ -0,0 +1,174 @@
+package loginattempt
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/secret"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+type Controller struct {
+	clock   clock.Clock
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *LoginAttempt) (string, error)
+	Get(ctx context.Context, id string) (string, *LoginAttempt, error)
+	Delete(ctx context.Context, id string, rev string) error
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+}
+
+// key identifies the counter of a username, of an email or of an IP address.
+type key struct {
+	id     string
+	policy policy
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+
+	return NewController(clock.NewDefault(), storage)
+}
+
+func NewController(clock clock.Clock, storage StorageInterface) *Controller {
+	return &Controller{
+		clock:   clock,
+		storage: storage,
+	}
+}
+
+// Check returns the delay before a new attempt is allowed for the username,
+// the email and the IP address. It is zero if the attempt is allowed.
+func (t *Controller) Check(ctx context.Context, cmd *CheckCmd) (time.Duration, error) {
+	now := t.clock.Now()
+
+	var delay time.Duration
+	for _, key := range keys(cmd.Username, cmd.Email, cmd.IP) {
+		_, attempt, err := t.storage.Get(ctx, key.id)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to get the login attempts")
+		}
+
+		if attempt != nil && attempt.LockedUntil.Sub(now) > delay {
+			delay = attempt.LockedUntil.Sub(now)
+		}
+	}
+
+	return delay, nil
+}
+
+// RecordFailure counts a failed login or a password reset request for the
+// username, the email and the IP address and delays the next attempts.
+func (t *Controller) RecordFailure(ctx context.Context, cmd *RecordFailureCmd) error {
+	now := t.clock.Now()
+
+	for _, key := range keys(cmd.Username, cmd.Email, cmd.IP) {
+		rev, attempt, err := t.storage.Get(ctx, key.id)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the login attempts")
+		}
+
+		if attempt == nil || now.Sub(attempt.LastFailureAt) > ResetAfter {
+			attempt = &LoginAttempt{}
+		}
+
+		attempt.Failures++
+		attempt.LastFailureAt = now
+		attempt.LockedUntil = now.Add(key.policy.delay(attempt.Failures))
+
+		_, err = t.storage.Set(ctx, key.id, rev, attempt)
+		if err != nil {
+			return errors.Wrap(err, "failed to save the login attempts")
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess resets the failures of the username. The failures of the IP
+// address are kept, a valid account must not help to attack the others.
+func (t *Controller) RecordSuccess(ctx context.Context, cmd *RecordSuccessCmd) error {
+	return t.reset(ctx, cmd.Username)
+}
+
+// Unlock resets the failures of the username.
+func (t *Controller) Unlock(ctx context.Context, cmd *UnlockCmd) error {
+	err := validator.New().
+		CheckString("username", cmd.Username, is.Required).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	return t.reset(ctx, cmd.Username)
+}
+
+func (t *Controller) reset(ctx context.Context, username string) error {
+	id := usernameKey(username).id
+
+	rev, attempt, err := t.storage.Get(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the login attempts")
+	}
+
+	if attempt == nil {
+		return nil
+	}
+
+	err = t.storage.Delete(ctx, id, rev)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the login attempts")
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes the login attempts expired at the given date. It
+// returns the number of deleted login attempts.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
+
+func keys(username string, email string, ip string) []key {
+	res := []key{}
+
+	if username != "" {
+		res = append(res, usernameKey(username))
+	}
+
+	if email != "" {
+		res = append(res, key{id: hashKey("email", email), policy: emailPolicy})
+	}
+
+	switch {
+	case ip != "" && email != "":
+		res = append(res, key{id: hashKey("reset-ip", ip), policy: resetIPPolicy})
+	case ip != "":
+		res = append(res, key{id: hashKey("ip", ip), policy: ipPolicy})
+	}
+
+	return res
+}
+
+func usernameKey(username string) key {
+	return key{id: hashKey("username", username), policy: usernamePolicy}
+}
+
+// hashKey returns a document ID without the characters forbidden by
+// CouchDB.
+func hashKey(kind string, value string) string {
+	return kind + "-" + secret.Hash(value)
+}
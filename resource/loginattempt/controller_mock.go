@@ -0,0 +1,36 @@
+package loginattempt
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ControllerMock struct {
+	mock.Mock
+}
+
+func (t *ControllerMock) Check(ctx context.Context, cmd *CheckCmd) (time.Duration, error) {
+	args := t.Called(cmd)
+
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (t *ControllerMock) RecordFailure(ctx context.Context, cmd *RecordFailureCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) RecordSuccess(ctx context.Context, cmd *RecordSuccessCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) Unlock(ctx context.Context, cmd *UnlockCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
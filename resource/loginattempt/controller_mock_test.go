@@ -0,0 +1,73 @@
+package loginattempt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginAttempt_ControllerMock_Check(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Check", &CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Minute, nil).Once()
+
+	delay, err := mock.Check(context.Background(), &CheckCmd{Username: "some-username", IP: "192.0.2.1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, delay)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_ControllerMock_RecordFailure(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("RecordFailure", &RecordFailureCmd{Username: "some-username", IP: "192.0.2.1"}).Return(nil).Once()
+
+	err := mock.RecordFailure(context.Background(), &RecordFailureCmd{Username: "some-username", IP: "192.0.2.1"})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_ControllerMock_RecordSuccess(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("RecordSuccess", &RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+
+	err := mock.RecordSuccess(context.Background(), &RecordSuccessCmd{Username: "some-username"})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_ControllerMock_Unlock_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Unlock", &UnlockCmd{Username: "some-username"}).Return(fmt.Errorf("some-error")).Once()
+
+	err := mock.Unlock(context.Background(), &UnlockCmd{Username: "some-username"})
+
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,422 @@
+package loginattempt
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/utils/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+var (
+	usernameID = hashKey("username", "some-username")
+	ipID       = hashKey("ip", "192.0.2.1")
+	emailID    = hashKey("email", "foo@bar.com")
+	resetIPID  = hashKey("reset-ip", "192.0.2.1")
+)
+
+func Test_LoginAttempt_policy_delay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), usernamePolicy.delay(1))
+	assert.Equal(t, time.Duration(0), usernamePolicy.delay(2))
+	assert.Equal(t, time.Second, usernamePolicy.delay(3))
+	assert.Equal(t, 2*time.Second, usernamePolicy.delay(4))
+	assert.Equal(t, 64*time.Second, usernamePolicy.delay(9))
+	assert.Equal(t, LockoutDuration, usernamePolicy.delay(10))
+	assert.Equal(t, LockoutDuration, usernamePolicy.delay(1000))
+
+	assert.Equal(t, time.Duration(0), ipPolicy.delay(9))
+	assert.Equal(t, LockoutDuration, ipPolicy.delay(49))
+
+	assert.Equal(t, time.Second, emailPolicy.delay(1))
+	assert.Equal(t, LockoutDuration, emailPolicy.delay(5))
+
+	assert.Equal(t, time.Duration(0), resetIPPolicy.delay(4))
+	assert.Equal(t, LockoutDuration, resetIPPolicy.delay(20))
+}
+
+func Test_LoginAttempt_hashKey(t *testing.T) {
+	assert.Regexp(t, "^username-[0-9a-f]{64}$", hashKey("username", "some/username"))
+	assert.NotEqual(t, hashKey("username", "some-username"), hashKey("ip", "some-username"))
+}
+
+func Test_LoginAttempt_Controller_Check(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", usernameID).Return("some-rev", &LoginAttempt{
+		Failures:      5,
+		LastFailureAt: now,
+		LockedUntil:   now.Add(4 * time.Second),
+	}, nil).Once()
+	storageMock.On("Get", ipID).Return("some-rev", &LoginAttempt{
+		Failures:      11,
+		LastFailureAt: now,
+		LockedUntil:   now.Add(2 * time.Second),
+	}, nil).Once()
+
+	delay, err := controller.Check(context.Background(), &CheckCmd{
+		Username: "some-username",
+		IP:       "192.0.2.1",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4*time.Second, delay)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_Check_with_an_email(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	// The failed logins of the IP address are not checked.
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", emailID).Return("", nil, nil).Once()
+	storageMock.On("Get", resetIPID).Return("some-rev", &LoginAttempt{
+		Failures:      6,
+		LastFailureAt: now,
+		LockedUntil:   now.Add(2 * time.Second),
+	}, nil).Once()
+
+	delay, err := controller.Check(context.Background(), &CheckCmd{
+		Email: "foo@bar.com",
+		IP:    "192.0.2.1",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, delay)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_Check_without_failures(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", usernameID).Return("", nil, nil).Once()
+	storageMock.On("Get", ipID).Return("some-rev", &LoginAttempt{
+		Failures:      1,
+		LastFailureAt: now.Add(-time.Minute),
+		LockedUntil:   now.Add(-time.Minute),
+	}, nil).Once()
+
+	delay, err := controller.Check(context.Background(), &CheckCmd{
+		Username: "some-username",
+		IP:       "192.0.2.1",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_Check_with_storage_error(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", usernameID).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	delay, err := controller.Check(context.Background(), &CheckCmd{
+		Username: "some-username",
+		IP:       "192.0.2.1",
+	})
+
+	assert.Equal(t, time.Duration(0), delay)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the login attempts",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_RecordFailure(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", usernameID).Return("some-rev", &LoginAttempt{
+		Failures:      3,
+		LastFailureAt: now.Add(-time.Minute),
+		LockedUntil:   now.Add(-59 * time.Second),
+	}, nil).Once()
+	storageMock.On("Set", usernameID, "some-rev", &LoginAttempt{
+		Failures:      4,
+		LastFailureAt: now,
+		LockedUntil:   now.Add(2 * time.Second),
+	}).Return("some-new-rev", nil).Once()
+	storageMock.On("Get", ipID).Return("", nil, nil).Once()
+	storageMock.On("Set", ipID, "", &LoginAttempt{
+		Failures:      1,
+		LastFailureAt: now,
+		LockedUntil:   now,
+	}).Return("some-rev", nil).Once()
+
+	err := controller.RecordFailure(context.Background(), &RecordFailureCmd{
+		Username: "some-username",
+		IP:       "192.0.2.1",
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_RecordFailure_with_an_email(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", emailID).Return("", nil, nil).Once()
+	storageMock.On("Set", emailID, "", &LoginAttempt{
+		Failures:      1,
+		LastFailureAt: now,
+		LockedUntil:   now.Add(time.Second),
+	}).Return("some-rev", nil).Once()
+	storageMock.On("Get", resetIPID).Return("", nil, nil).Once()
+	storageMock.On("Set", resetIPID, "", &LoginAttempt{
+		Failures:      1,
+		LastFailureAt: now,
+		LockedUntil:   now,
+	}).Return("some-rev", nil).Once()
+
+	err := controller.RecordFailure(context.Background(), &RecordFailureCmd{
+		Email: "foo@bar.com",
+		IP:    "192.0.2.1",
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_RecordFailure_after_the_reset_delay(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", usernameID).Return("some-rev", &LoginAttempt{
+		Failures:      9,
+		LastFailureAt: now.Add(-2 * time.Hour),
+		LockedUntil:   now.Add(-2 * time.Hour),
+	}, nil).Once()
+	storageMock.On("Set", usernameID, "some-rev", &LoginAttempt{
+		Failures:      1,
+		LastFailureAt: now,
+		LockedUntil:   now,
+	}).Return("some-new-rev", nil).Once()
+
+	err := controller.RecordFailure(context.Background(), &RecordFailureCmd{
+		Username: "some-username",
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_RecordFailure_with_set_error(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Get", ipID).Return("", nil, nil).Once()
+	storageMock.On("Set", ipID, "", &LoginAttempt{
+		Failures:      1,
+		LastFailureAt: now,
+		LockedUntil:   now,
+	}).Return("", fmt.Errorf("some-error")).Once()
+
+	err := controller.RecordFailure(context.Background(), &RecordFailureCmd{
+		IP: "192.0.2.1",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the login attempts",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_RecordSuccess(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	storageMock.On("Get", usernameID).Return("some-rev", &ValidLoginAttempt, nil).Once()
+	storageMock.On("Delete", usernameID, "some-rev").Return(nil).Once()
+
+	err := controller.RecordSuccess(context.Background(), &RecordSuccessCmd{
+		Username: "some-username",
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_RecordSuccess_without_failures(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	storageMock.On("Get", usernameID).Return("", nil, nil).Once()
+
+	err := controller.RecordSuccess(context.Background(), &RecordSuccessCmd{
+		Username: "some-username",
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_Unlock(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	storageMock.On("Get", usernameID).Return("some-rev", &ValidLoginAttempt, nil).Once()
+	storageMock.On("Delete", usernameID, "some-rev").Return(nil).Once()
+
+	err := controller.Unlock(context.Background(), &UnlockCmd{
+		Username: "some-username",
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_Unlock_with_a_validation_error(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	err := controller.Unlock(context.Background(), &UnlockCmd{
+		Username: "",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"username":"MISSING_FIELD"
+		}
+	}`, err.Error())
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_Unlock_with_delete_error(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	storageMock.On("Get", usernameID).Return("some-rev", &ValidLoginAttempt, nil).Once()
+	storageMock.On("Delete", usernameID, "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	err := controller.Unlock(context.Background(), &UnlockCmd{
+		Username: "some-username",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the login attempts",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_DeleteExpired(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
@@ -0,0 +1,42 @@
+package loginattempt
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/response"
+	"github.com/halium-project/server/utils/permission"
+)
+
+type HTTPHandler struct {
+	loginAttempt ControllerInterface
+}
+
+type ControllerInterface interface {
+	Unlock(ctx context.Context, cmd *UnlockCmd) error
+}
+
+func NewHTTPHandler(loginAttempt ControllerInterface) *HTTPHandler {
+	return &HTTPHandler{
+		loginAttempt: loginAttempt,
+	}
+}
+
+func (t *HTTPHandler) RegisterRoutes(router *mux.Router, perm *permission.Controller) {
+	router.HandleFunc("/lockouts/{username}", perm.Check("users.write", t.Unlock)).Methods("DELETE")
+}
+
+// Unlock removes the lockout of an account after too many failed logins.
+func (t *HTTPHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	err := t.loginAttempt.Unlock(r.Context(), &UnlockCmd{
+		Username: mux.Vars(r)["username"],
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusOK, struct{}{})
+}
@@ -0,0 +1,79 @@
+package loginattempt
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoginAttempt_HTTPHandler_Unlock_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("Unlock", &UnlockCmd{Username: "some-username"}).Return(nil).Once()
+
+	r := httptest.NewRequest("DELETE", "http://example.com/lockouts/some-username", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_HTTPHandler_Unlock_with_an_error_from_the_usecase(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("Unlock", &UnlockCmd{Username: "some-username"}).Return(errors.New(errors.Internal, "some-error")).Once()
+
+	r := httptest.NewRequest("DELETE", "http://example.com/lockouts/some-username", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "internalError",
+		"message": "some-error"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
@@ -0,0 +1,113 @@
+package loginattempt
+
+import "time"
+
+const (
+	// BaseDelay is the delay imposed after the first counted failure. It
+	// doubles at each new failure.
+	BaseDelay = time.Second
+
+	// LockoutDuration is the lockout applied once the failures reach the
+	// threshold. It also caps the backoff delay.
+	LockoutDuration = 15 * time.Minute
+
+	// ResetAfter is the delay after which the failures are forgotten.
+	ResetAfter = time.Hour
+)
+
+// policy describes when the failures of a key start to be delayed and when
+// the key is locked.
+type policy struct {
+	freeAttempts     int
+	lockoutThreshold int
+}
+
+var (
+	// The usernames are locked quickly as they are targeted individually.
+	usernamePolicy = policy{freeAttempts: 3, lockoutThreshold: 10}
+
+	// Several users can share an IP address behind a NAT.
+	ipPolicy = policy{freeAttempts: 10, lockoutThreshold: 50}
+
+	// Each password reset request sends an email, the addresses are locked
+	// quickly to not flood their owners.
+	emailPolicy = policy{freeAttempts: 1, lockoutThreshold: 5}
+
+	// The password reset requests of an IP address are counted apart from
+	// its failed logins: they must not lock the logins behind a shared NAT.
+	resetIPPolicy = policy{freeAttempts: 5, lockoutThreshold: 20}
+)
+
+// delay returns the duration during which no attempt is allowed after the
+// given number of failures.
+func (t policy) delay(failures int) time.Duration {
+	if failures >= t.lockoutThreshold {
+		return LockoutDuration
+	}
+
+	if failures < t.freeAttempts {
+		return 0
+	}
+
+	shift := uint(failures - t.freeAttempts)
+	if shift > 20 {
+		return LockoutDuration
+	}
+
+	delay := BaseDelay << shift
+	if delay > LockoutDuration {
+		return LockoutDuration
+	}
+
+	return delay
+}
+
+// LoginAttempt counts the failed logins of a username or of an IP address and
+// the password reset requests of an email or of an IP address.
+type LoginAttempt struct {
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"lastFailureAt"`
+
+	// No attempt is allowed before this date.
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+type CheckCmd struct {
+	Username string
+
+	// Email is given for the password reset requests. The IP address is then
+	// checked with the counter of the reset requests.
+	Email string
+	IP    string
+}
+
+type RecordFailureCmd struct {
+	Username string
+
+	// Email is given for the password reset requests. The IP address is then
+	// counted with the other reset requests instead of the failed logins.
+	Email string
+	IP    string
+}
+
+type RecordSuccessCmd struct {
+	Username string
+}
+
+type UnlockCmd struct {
+	Username string
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if a login attempt is expired.
+	Now time.Time
+
+	// Maximum number of login attempts deleted.
+	Limit uint
+}
+
+var ValidLoginAttempt = LoginAttempt{
+	Failures:      3,
+	LastFailureAt: time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC),
+	LockedUntil:   time.Date(2019, time.February, 23, 10, 0, 1, 0, time.UTC),
+}
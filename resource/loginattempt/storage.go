@@ -0,0 +1,104 @@
+package loginattempt
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/db"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+const BucketName = "login_attempts"
+
+type Storage struct {
+	driver db.Driver
+}
+
+func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_expiration": {
+					// Emit the date (in ms) after which the failures are
+					// forgotten (see ResetAfter).
+					Map: `function (doc, meta) {
+						if (doc.lastFailureAt) {
+							emit(Date.parse(doc.lastFailureAt) + 3600 * 1000, doc._rev);
+						}
+					}`,
+				},
+			},
+		},
+	})
+}
+
+func NewStorage(driver db.Driver) *Storage {
+	return &Storage{
+		driver: driver,
+	}
+}
+
+func (t *Storage) Set(ctx context.Context, id string, rev string, value *LoginAttempt) (string, error) {
+	rev, err := t.driver.Set(ctx, id, rev, value)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to set the document into the storage")
+	}
+
+	return rev, nil
+}
+
+func (t *Storage) Get(ctx context.Context, id string) (string, *LoginAttempt, error) {
+	var attempt LoginAttempt
+
+	rev, err := t.driver.Get(ctx, id, &attempt)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get the document from the storage")
+	}
+
+	if rev == "" {
+		return "", nil, nil
+	}
+
+	return rev, &attempt, nil
+}
+
+func (t *Storage) Delete(ctx context.Context, id string, rev string) error {
+	err := t.driver.Delete(ctx, id, rev)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the document from the storage")
+	}
+
+	return nil
+}
+
+// FindExpired returns the ids and the revisions of the login attempts expired
+// at the given date.
+func (t *Storage) FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error) {
+	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_expiration",
+		Limit:     limit,
+		Range: &db.Range{
+			Start: 0,
+			End:   now.UnixNano() / int64(time.Millisecond),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	revs := make(map[string]string, len(res))
+	for _, row := range res {
+		var rev string
+		err = json.Unmarshal(row.Value, &rev)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode the revision of %q", row.ID)
+		}
+
+		revs[row.ID] = rev
+	}
+
+	return revs, nil
+}
@@ -0,0 +1,91 @@
+package loginattempt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginAttempt_StorageMock_Set(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Set", validKey, "", &ValidLoginAttempt).Return("some-rev", nil).Once()
+
+	rev, err := mock.Set(context.Background(), validKey, "", &ValidLoginAttempt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_StorageMock_Get(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", validKey).Return("some-rev", &ValidLoginAttempt, nil).Once()
+
+	rev, res, err := mock.Get(context.Background(), validKey)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidLoginAttempt, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_StorageMock_Get_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", validKey).Return("", nil, errors.New("some-error")).Once()
+
+	rev, res, err := mock.Get(context.Background(), validKey)
+
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_StorageMock_Delete(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Delete", validKey, "some-rev").Return(nil).Once()
+
+	err := mock.Delete(context.Background(), validKey, "some-rev")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_StorageMock_FindExpired(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(map[string]string{"some-id": "some-rev"}, nil).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"some-id": "some-rev"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginAttempt_StorageMock_FindExpired_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(nil, errors.New("some-error")).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
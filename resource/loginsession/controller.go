@@ -0,0 +1,117 @@
+package loginsession
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+type Controller struct {
+	uuid    uuid.Producer
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *LoginSession) (string, error)
+	Get(ctx context.Context, id string) (string, *LoginSession, error)
+	Delete(ctx context.Context, id string, rev string) error
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+	uuidProducer := uuid.NewGoUUID()
+
+	return NewController(uuidProducer, storage)
+}
+
+func NewController(
+	uuid uuid.Producer,
+	storage StorageInterface,
+) *Controller {
+	return &Controller{
+		uuid:    uuid,
+		storage: storage,
+	}
+}
+
+// Create opens a new session for the user and returns its ID.
+func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckNumber("expiresIn", cmd.ExpiresIn, is.Required, is.NumberPositif).
+		Run()
+	if err != nil {
+		return "", err
+	}
+
+	id := t.uuid.New()
+
+	_, err = t.storage.Set(ctx, id, "", &LoginSession{
+		UserID:          cmd.UserID,
+		AuthenticatedAt: time.Now(),
+		ExpiresIn:       cmd.ExpiresIn,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to save the session")
+	}
+
+	return id, nil
+}
+
+func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*LoginSession, error) {
+	err := validator.New().
+		CheckString("sessionID", cmd.SessionID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	_, session, err := t.storage.Get(ctx, cmd.SessionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the session")
+	}
+
+	return session, nil
+}
+
+func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	err := validator.New().
+		CheckString("sessionID", cmd.SessionID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, _, err := t.storage.Get(ctx, cmd.SessionID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the session")
+	}
+
+	if rev != "" {
+		err := t.storage.Delete(ctx, cmd.SessionID, rev)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete the session")
+		}
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes the sessions expired at the given date. It returns
+// the number of deleted sessions.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
@@ -0,0 +1,81 @@
+package loginsession
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginSession_ControllerMock_Create(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Create", &CreateCmd{
+		UserID:    ValidLoginSession.UserID,
+		ExpiresIn: ValidLoginSession.ExpiresIn,
+	}).Return(ValidLoginSessionID, nil).Once()
+
+	id, err := mock.Create(context.Background(), &CreateCmd{
+		UserID:    ValidLoginSession.UserID,
+		ExpiresIn: ValidLoginSession.ExpiresIn,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidLoginSessionID, id)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginSession_ControllerMock_Get(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{SessionID: ValidLoginSessionID}).Return(&ValidLoginSession, nil).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{SessionID: ValidLoginSessionID})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidLoginSession, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginSession_ControllerMock_Get_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{SessionID: ValidLoginSessionID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{SessionID: ValidLoginSessionID})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginSession_ControllerMock_Delete(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Delete", &DeleteCmd{SessionID: ValidLoginSessionID}).Return(nil).Once()
+
+	err := mock.Delete(context.Background(), &DeleteCmd{SessionID: ValidLoginSessionID})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_LoginSession_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
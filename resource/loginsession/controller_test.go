@@ -0,0 +1,256 @@
+package loginsession
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginSession_Controller_Create(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	uuidMock.On("New").Return(ValidLoginSessionID).Once()
+	storageMock.On("Set", ValidLoginSessionID, "", &ValidLoginSession).Return("some-rev", nil).Once()
+
+	id, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:    ValidLoginSession.UserID,
+		ExpiresIn: ValidLoginSession.ExpiresIn,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidLoginSessionID, id)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Create_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	id, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:    "invalid-id",
+		ExpiresIn: ValidLoginSession.ExpiresIn,
+	})
+
+	assert.Empty(t, id)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"userID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Create_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	uuidMock.On("New").Return(ValidLoginSessionID).Once()
+	storageMock.On("Set", ValidLoginSessionID, "", &ValidLoginSession).Return("", fmt.Errorf("some-error")).Once()
+
+	id, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:    ValidLoginSession.UserID,
+		ExpiresIn: ValidLoginSession.ExpiresIn,
+	})
+
+	assert.Empty(t, id)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the session",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Get(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidLoginSessionID).Return("some-rev", &ValidLoginSession, nil).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		SessionID: ValidLoginSessionID,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidLoginSession, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Get_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		SessionID: "invalid-id",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"sessionID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Get_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidLoginSessionID).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		SessionID: ValidLoginSessionID,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the session",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Delete(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidLoginSessionID).Return("some-rev", &ValidLoginSession, nil).Once()
+	storageMock.On("Delete", ValidLoginSessionID, "some-rev").Return(nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		SessionID: ValidLoginSessionID,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Delete_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidLoginSessionID).Return("", nil, nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		SessionID: ValidLoginSessionID,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_Delete_with_delete_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidLoginSessionID).Return("some-rev", &ValidLoginSession, nil).Once()
+	storageMock.On("Delete", ValidLoginSessionID, "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		SessionID: ValidLoginSessionID,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the session",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_LoginSession_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
@@ -0,0 +1,56 @@
+package loginsession
+
+import "time"
+
+// LoginSession is opened when a user authenticates on the authorization
+// page. It lets the user authorize other clients without typing the password
+// again. Its ID is kept in a browser cookie.
+type LoginSession struct {
+	UserID string `json:"userID"`
+
+	// Date of the password check.
+	AuthenticatedAt time.Time `json:"authenticatedAt"`
+
+	// Session expiration in seconds.
+	ExpiresIn int `json:"expiresIn"`
+}
+
+// ExpireAt returns the date after which the session is closed.
+func (t *LoginSession) ExpireAt() time.Time {
+	return t.AuthenticatedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// IsExpiredAt returns true if the session is expired at the given date.
+func (t *LoginSession) IsExpiredAt(now time.Time) bool {
+	return t.ExpireAt().Before(now)
+}
+
+type CreateCmd struct {
+	UserID string
+
+	// Session expiration in seconds.
+	ExpiresIn int
+}
+
+type GetCmd struct {
+	SessionID string
+}
+
+type DeleteCmd struct {
+	SessionID string
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if a session is expired.
+	Now time.Time
+
+	// Maximum number of sessions deleted.
+	Limit uint
+}
+
+var ValidLoginSessionID = "b3c1d9e2-7a4f-4b6c-8d2e-5f9a1c3e7b40"
+var ValidLoginSession = LoginSession{
+	UserID:          "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	AuthenticatedAt: time.Now().UTC().Round(time.Millisecond),
+	ExpiresIn:       24 * 3600,
+}
@@ -0,0 +1,190 @@
+package loginsession
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginSession_Storage_Set(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Set", ValidLoginSessionID, "", &ValidLoginSession).Return("some-rev", nil).Once()
+
+	rev, err := storage.Set(context.Background(), ValidLoginSessionID, "", &ValidLoginSession)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_Set_with_driver_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Set", ValidLoginSessionID, "", &ValidLoginSession).Return("", fmt.Errorf("some-error")).Once()
+
+	rev, err := storage.Set(context.Background(), ValidLoginSessionID, "", &ValidLoginSession)
+
+	assert.Empty(t, rev)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to set the document into the storage",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_Get(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", ValidLoginSessionID).Return("some-rev", &ValidLoginSession, nil).Once()
+
+	rev, res, err := storage.Get(context.Background(), ValidLoginSessionID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidLoginSession, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_Get_not_found(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", ValidLoginSessionID).Return("", nil, nil).Once()
+
+	rev, res, err := storage.Get(context.Background(), ValidLoginSessionID)
+
+	assert.NoError(t, err)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_Get_with_driver_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", ValidLoginSessionID).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	rev, res, err := storage.Get(context.Background(), ValidLoginSessionID)
+
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the document from the storage",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_Delete(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Delete", ValidLoginSessionID, "some-rev").Return(nil).Once()
+
+	err := storage.Delete(context.Background(), ValidLoginSessionID, "some-rev")
+
+	assert.NoError(t, err)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_Delete_with_driver_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Delete", ValidLoginSessionID, "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	err := storage.Delete(context.Background(), ValidLoginSessionID, "some-rev")
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the document from the storage",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_FindExpired(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return([]db.ViewRow{
+		{ID: "some-id", Value: []byte(`"some-rev"`)},
+		{ID: "some-other-id", Value: []byte(`"some-other-rev"`)},
+	}, nil).Once()
+
+	res, err := storage.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_LoginSession_Storage_FindExpired_with_query_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := storage.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
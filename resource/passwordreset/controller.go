@@ -0,0 +1,137 @@
+package passwordreset
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/secret"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+type Controller struct {
+	uuid    uuid.Producer
+	clock   clock.Clock
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *PasswordReset) (string, error)
+	Get(ctx context.Context, id string) (string, *PasswordReset, error)
+	Delete(ctx context.Context, id string, rev string) error
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+	uuidProducer := uuid.NewGoUUID()
+
+	return NewController(uuidProducer, clock.NewDefault(), storage)
+}
+
+func NewController(
+	uuid uuid.Producer,
+	clock clock.Clock,
+	storage StorageInterface,
+) *Controller {
+	return &Controller{
+		uuid:    uuid,
+		clock:   clock,
+		storage: storage,
+	}
+}
+
+// Create generates a new reset token for the user. Only a hash of the token
+// is saved.
+func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckNumber("expiresIn", cmd.ExpiresIn, is.Required, is.NumberPositif).
+		Run()
+	if err != nil {
+		return "", err
+	}
+
+	token := t.uuid.New()
+
+	_, err = t.storage.Set(ctx, secret.Hash(token), "", &PasswordReset{
+		UserID:    cmd.UserID,
+		CreatedAt: t.clock.Now(),
+		ExpiresIn: cmd.ExpiresIn,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to save the password reset")
+	}
+
+	return token, nil
+}
+
+// Get returns the password reset matching the token. It returns nil if the
+// token is unknown or expired.
+func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*PasswordReset, error) {
+	err := validator.New().
+		CheckString("token", cmd.Token, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	_, reset, err := t.storage.Get(ctx, secret.Hash(cmd.Token))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the password reset")
+	}
+
+	if reset == nil || reset.IsExpiredAt(t.clock.Now()) {
+		return nil, nil
+	}
+
+	return reset, nil
+}
+
+// Consume returns the password reset matching the token and deletes it with
+// its revision. A token used by two concurrent requests is only returned to
+// the first one, the second one gets a conflict error from the storage. It
+// returns nil if the token is unknown or expired.
+func (t *Controller) Consume(ctx context.Context, cmd *ConsumeCmd) (*PasswordReset, error) {
+	err := validator.New().
+		CheckString("token", cmd.Token, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	id := secret.Hash(cmd.Token)
+
+	rev, reset, err := t.storage.Get(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the password reset")
+	}
+
+	if reset == nil || reset.IsExpiredAt(t.clock.Now()) {
+		return nil, nil
+	}
+
+	err = t.storage.Delete(ctx, id, rev)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to delete the password reset")
+	}
+
+	return reset, nil
+}
+
+// DeleteExpired deletes the password resets expired at the given date. It
+// returns the number of deleted password resets.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
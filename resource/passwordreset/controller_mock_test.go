@@ -0,0 +1,82 @@
+package passwordreset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PasswordReset_ControllerMock_Create(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Create", &CreateCmd{
+		UserID:    ValidPasswordReset.UserID,
+		ExpiresIn: ValidPasswordReset.ExpiresIn,
+	}).Return(ValidToken, nil).Once()
+
+	token, err := mock.Create(context.Background(), &CreateCmd{
+		UserID:    ValidPasswordReset.UserID,
+		ExpiresIn: ValidPasswordReset.ExpiresIn,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidToken, token)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_ControllerMock_Get(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{Token: ValidToken}).Return(&ValidPasswordReset, nil).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{Token: ValidToken})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidPasswordReset, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_ControllerMock_Get_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{Token: ValidToken}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{Token: ValidToken})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_ControllerMock_Consume(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Consume", &ConsumeCmd{Token: ValidToken}).Return(&ValidPasswordReset, nil).Once()
+
+	res, err := mock.Consume(context.Background(), &ConsumeCmd{Token: ValidToken})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ValidPasswordReset, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
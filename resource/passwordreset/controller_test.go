@@ -0,0 +1,350 @@
+package passwordreset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PasswordReset_Controller_Create(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	uuidMock.On("New").Return(ValidToken).Once()
+	clockMock.On("Now").Return(ValidPasswordReset.CreatedAt).Once()
+	storageMock.On("Set", secret.Hash(ValidToken), "", &ValidPasswordReset).Return("some-rev", nil).Once()
+
+	token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:    ValidPasswordReset.UserID,
+		ExpiresIn: ValidPasswordReset.ExpiresIn,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidToken, token)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Create_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:    "invalid-id",
+		ExpiresIn: ValidPasswordReset.ExpiresIn,
+	})
+
+	assert.Empty(t, token)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"userID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Create_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	uuidMock.On("New").Return(ValidToken).Once()
+	clockMock.On("Now").Return(ValidPasswordReset.CreatedAt).Once()
+	storageMock.On("Set", secret.Hash(ValidToken), "", &ValidPasswordReset).Return("", fmt.Errorf("some-error")).Once()
+
+	token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:    ValidPasswordReset.UserID,
+		ExpiresIn: ValidPasswordReset.ExpiresIn,
+	})
+
+	assert.Empty(t, token)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the password reset",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Get(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("some-rev", &ValidPasswordReset, nil).Once()
+	clockMock.On("Now").Return(ValidPasswordReset.CreatedAt.Add(time.Minute)).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		Token: ValidToken,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidPasswordReset, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Get_expired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("some-rev", &ValidPasswordReset, nil).Once()
+	clockMock.On("Now").Return(ValidPasswordReset.ExpireAt().Add(time.Second)).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		Token: ValidToken,
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Get_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("", nil, nil).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		Token: ValidToken,
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Get_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		Token: "invalid-token",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"token":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Get_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		Token: ValidToken,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the password reset",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Consume(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("some-rev", &ValidPasswordReset, nil).Once()
+	clockMock.On("Now").Return(ValidPasswordReset.CreatedAt).Once()
+	storageMock.On("Delete", secret.Hash(ValidToken), "some-rev").Return(nil).Once()
+
+	res, err := controller.Consume(context.Background(), &ConsumeCmd{
+		Token: ValidToken,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ValidPasswordReset, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Consume_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("", nil, nil).Once()
+
+	res, err := controller.Consume(context.Background(), &ConsumeCmd{
+		Token: ValidToken,
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Consume_expired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("some-rev", &ValidPasswordReset, nil).Once()
+	clockMock.On("Now").Return(ValidPasswordReset.ExpireAt().Add(time.Second)).Once()
+
+	res, err := controller.Consume(context.Background(), &ConsumeCmd{
+		Token: ValidToken,
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_Consume_with_delete_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	storageMock.On("Get", secret.Hash(ValidToken)).Return("some-rev", &ValidPasswordReset, nil).Once()
+	clockMock.On("Now").Return(ValidPasswordReset.CreatedAt).Once()
+	storageMock.On("Delete", secret.Hash(ValidToken), "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Consume(context.Background(), &ConsumeCmd{
+		Token: ValidToken,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the password reset",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PasswordReset_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
@@ -0,0 +1,53 @@
+package passwordreset
+
+import "time"
+
+// PasswordReset is created when a user asks to reset a forgotten password.
+// Its token is sent by email and it can only be used once.
+type PasswordReset struct {
+	UserID    string    `json:"userID"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Token expiration in seconds.
+	ExpiresIn int `json:"expiresIn"`
+}
+
+// ExpireAt returns the date after which the token can't be used.
+func (t *PasswordReset) ExpireAt() time.Time {
+	return t.CreatedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// IsExpiredAt returns true if the token is expired at the given date.
+func (t *PasswordReset) IsExpiredAt(now time.Time) bool {
+	return t.ExpireAt().Before(now)
+}
+
+type CreateCmd struct {
+	UserID string
+
+	// Token expiration in seconds.
+	ExpiresIn int
+}
+
+type GetCmd struct {
+	Token string
+}
+
+type ConsumeCmd struct {
+	Token string
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if a password reset is expired.
+	Now time.Time
+
+	// Maximum number of password resets deleted.
+	Limit uint
+}
+
+var ValidToken = "9d4c2f7e-3b1a-4e8d-a6c5-0f2b7e9d1c34"
+var ValidPasswordReset = PasswordReset{
+	UserID:    "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	ExpiresIn: 3600,
+}
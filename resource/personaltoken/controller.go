@@ -0,0 +1,203 @@
+package personaltoken
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/halium-project/server/utils/secret"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+// lastUsedPrecision limits the number of writes made when a token is used by
+// a script in a loop.
+const lastUsedPrecision = time.Minute
+
+type Controller struct {
+	uuid    uuid.Producer
+	clock   clock.Clock
+	user    UserInterface
+	storage StorageInterface
+}
+
+type UserInterface interface {
+	Get(ctx context.Context, cmd *user.GetCmd) (*user.User, error)
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *PersonalToken) (string, error)
+	Get(ctx context.Context, id string) (string, *PersonalToken, error)
+	Delete(ctx context.Context, id string, rev string) error
+	FindOneByHash(ctx context.Context, hash string) (string, string, *PersonalToken, error)
+	FindAllByUser(ctx context.Context, userID string) (map[string]PersonalToken, error)
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+}
+
+func InitController(ctx context.Context, server *yaccc.Server, user UserInterface) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+	uuidProducer := uuid.NewGoUUID()
+
+	return NewController(uuidProducer, clock.NewDefault(), user, storage)
+}
+
+func NewController(
+	uuid uuid.Producer,
+	clock clock.Clock,
+	user UserInterface,
+	storage StorageInterface,
+) *Controller {
+	return &Controller{
+		uuid:    uuid,
+		clock:   clock,
+		user:    user,
+		storage: storage,
+	}
+}
+
+// Create mints a new token for the user. It returns the token ID and the
+// token itself which can't be retrieved afterward.
+//
+// The scopes must be granted to the access token used to create the token.
+func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, string, error) {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckString("name", cmd.Name, is.Required, is.StringInRange(3, 50)).
+		CheckArray("scopes", cmd.Scopes, is.ArrayInRange(1, 50)).
+		CheckEachString("scopes", cmd.Scopes, is.StringInRange(1, 120)).
+		CheckNumber("expiresIn", cmd.ExpiresIn, is.Required, is.NumberPositif).
+		Run()
+	if err != nil {
+		return "", "", err
+	}
+
+	if cmd.ExpiresIn > MaxExpiresIn {
+		return "", "", errors.NewValidationError().AddError("expiresIn", is.UnexpectedValue).IntoError()
+	}
+
+	for _, scope := range cmd.Scopes {
+		if !permission.HasScope(cmd.GrantedScopes, scope) {
+			return "", "", errors.NewValidationError().AddError("scopes", is.UnexpectedValue).IntoError()
+		}
+	}
+
+	tokenID := t.uuid.New()
+	token := permission.PersonalTokenPrefix + strings.Replace(t.uuid.New(), "-", "", -1)
+
+	_, err = t.storage.Set(ctx, tokenID, "", &PersonalToken{
+		UserID:    cmd.UserID,
+		Name:      cmd.Name,
+		Hash:      secret.Hash(token),
+		Scopes:    cmd.Scopes,
+		CreatedAt: t.clock.Now(),
+		ExpiresIn: cmd.ExpiresIn,
+	})
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to save the personal token")
+	}
+
+	return tokenID, token, nil
+}
+
+// GetAll returns the tokens of a user indexed by ID.
+func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]PersonalToken, error) {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := t.storage.FindAllByUser(ctx, cmd.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the personal tokens")
+	}
+
+	return res, nil
+}
+
+// Delete revokes a token. The token must be owned by the given user.
+func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckString("tokenID", cmd.TokenID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, personalToken, err := t.storage.Get(ctx, cmd.TokenID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the personal token")
+	}
+
+	if personalToken == nil || personalToken.UserID != cmd.UserID {
+		return errors.Errorf(errors.NotFound, "personal token %q not found", cmd.TokenID)
+	}
+
+	err = t.storage.Delete(ctx, cmd.TokenID, rev)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the personal token")
+	}
+
+	return nil
+}
+
+// Verify implements permission.TokenVerifier. It returns nil if the token is
+// unknown or if its user doesn't exist anymore. The role is read from the
+// user at each call so a demotion applies to the existing tokens. The
+// expiration is left to the caller.
+func (t *Controller) Verify(ctx context.Context, token string) (*accesstoken.AccessToken, error) {
+	id, rev, personalToken, err := t.storage.FindOneByHash(ctx, secret.Hash(token))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the personal token")
+	}
+
+	if personalToken == nil {
+		return nil, nil
+	}
+
+	owner, err := t.user.Get(ctx, &user.GetCmd{
+		UserID: personalToken.UserID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the user %q", personalToken.UserID)
+	}
+
+	if owner == nil {
+		return nil, nil
+	}
+
+	now := t.clock.Now()
+	if !personalToken.IsExpiredAt(now) && now.Sub(personalToken.LastUsedAt) >= lastUsedPrecision {
+		personalToken.LastUsedAt = now
+
+		// The request must not fail because of the usage tracking.
+		_, err = t.storage.Set(ctx, id, rev, personalToken)
+		if err != nil {
+			log.Println(errors.Wrap(err, "failed to save the personal token last usage"))
+		}
+	}
+
+	return personalToken.AccessToken(token, owner.Role), nil
+}
+
+// DeleteExpired deletes the personal tokens expired at the given date. It
+// returns the number of deleted personal tokens.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
@@ -0,0 +1,48 @@
+package personaltoken
+
+import (
+	"context"
+
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/stretchr/testify/mock"
+)
+
+type ControllerMock struct {
+	mock.Mock
+}
+
+func (t *ControllerMock) Create(ctx context.Context, cmd *CreateCmd) (string, string, error) {
+	args := t.Called(cmd)
+
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (t *ControllerMock) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]PersonalToken, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]PersonalToken), args.Error(1)
+}
+
+func (t *ControllerMock) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) Verify(ctx context.Context, token string) (*accesstoken.AccessToken, error) {
+	args := t.Called(token)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*accesstoken.AccessToken), args.Error(1)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,135 @@
+package personaltoken
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PersonalToken_ControllerMock_Create(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Create", &CreateCmd{
+		UserID:    ValidPersonalToken.UserID,
+		Name:      ValidPersonalToken.Name,
+		Scopes:    ValidPersonalToken.Scopes,
+		ExpiresIn: ValidPersonalToken.ExpiresIn,
+	}).Return(ValidPersonalTokenID, ValidPersonalTokenValue, nil).Once()
+
+	tokenID, token, err := mock.Create(context.Background(), &CreateCmd{
+		UserID:    ValidPersonalToken.UserID,
+		Name:      ValidPersonalToken.Name,
+		Scopes:    ValidPersonalToken.Scopes,
+		ExpiresIn: ValidPersonalToken.ExpiresIn,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidPersonalTokenID, tokenID)
+	assert.Equal(t, ValidPersonalTokenValue, token)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_ControllerMock_GetAll(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetAll", &GetAllCmd{UserID: ValidPersonalToken.UserID}).Return(map[string]PersonalToken{
+		ValidPersonalTokenID: ValidPersonalToken,
+	}, nil).Once()
+
+	res, err := mock.GetAll(context.Background(), &GetAllCmd{UserID: ValidPersonalToken.UserID})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]PersonalToken{
+		ValidPersonalTokenID: ValidPersonalToken,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_ControllerMock_GetAll_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetAll", &GetAllCmd{UserID: ValidPersonalToken.UserID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.GetAll(context.Background(), &GetAllCmd{UserID: ValidPersonalToken.UserID})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_ControllerMock_Delete(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Delete", &DeleteCmd{
+		UserID:  ValidPersonalToken.UserID,
+		TokenID: ValidPersonalTokenID,
+	}).Return(nil).Once()
+
+	err := mock.Delete(context.Background(), &DeleteCmd{
+		UserID:  ValidPersonalToken.UserID,
+		TokenID: ValidPersonalTokenID,
+	})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_ControllerMock_Verify(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Verify", ValidPersonalTokenValue).Return(ValidPersonalToken.AccessToken(ValidPersonalTokenValue, "dev"), nil).Once()
+
+	res, err := mock.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, ValidPersonalToken.AccessToken(ValidPersonalTokenValue, "dev"), res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_ControllerMock_Verify_with_unknown_token(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Verify", ValidPersonalTokenValue).Return(nil, nil).Once()
+
+	res, err := mock.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_ControllerMock_Verify_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Verify", ValidPersonalTokenValue).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,613 @@
+package personaltoken
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PersonalToken_Controller_Create(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	uuidMock.On("New").Return(ValidPersonalTokenID).Once()
+	uuidMock.On("New").Return("7c1e4b9a-2f6d-4e08-a3b5-c9d1e7f2a6b4").Once()
+	clockMock.On("Now").Return(ValidPersonalToken.CreatedAt).Once()
+	storageMock.On("Set", ValidPersonalTokenID, "", &ValidPersonalToken).Return("some-rev", nil).Once()
+
+	tokenID, token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:        ValidPersonalToken.UserID,
+		Name:          ValidPersonalToken.Name,
+		Scopes:        ValidPersonalToken.Scopes,
+		GrantedScopes: []string{"contacts", "todos", "users.read"},
+		ExpiresIn:     ValidPersonalToken.ExpiresIn,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidPersonalTokenID, tokenID)
+	assert.Equal(t, ValidPersonalTokenValue, token)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Create_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	tokenID, token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:        ValidPersonalToken.UserID,
+		Name:          "a",
+		Scopes:        ValidPersonalToken.Scopes,
+		GrantedScopes: []string{"contacts", "todos", "users.read"},
+		ExpiresIn:     ValidPersonalToken.ExpiresIn,
+	})
+
+	assert.Empty(t, tokenID)
+	assert.Empty(t, token)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"name":"TOO_SHORT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Create_with_a_scope_not_granted_to_the_caller(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	tokenID, token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:        ValidPersonalToken.UserID,
+		Name:          ValidPersonalToken.Name,
+		Scopes:        []string{"contacts", "users.write"},
+		GrantedScopes: []string{"contacts", "users.read"},
+		ExpiresIn:     ValidPersonalToken.ExpiresIn,
+	})
+
+	assert.Empty(t, tokenID)
+	assert.Empty(t, token)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"scopes":"UNEXPECTED_VALUE"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Create_with_a_too_long_lifetime(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	tokenID, token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:        ValidPersonalToken.UserID,
+		Name:          ValidPersonalToken.Name,
+		Scopes:        ValidPersonalToken.Scopes,
+		GrantedScopes: []string{"contacts", "todos", "users.read"},
+		ExpiresIn:     MaxExpiresIn + 1,
+	})
+
+	assert.Empty(t, tokenID)
+	assert.Empty(t, token)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"expiresIn":"UNEXPECTED_VALUE"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Create_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	uuidMock.On("New").Return(ValidPersonalTokenID).Once()
+	uuidMock.On("New").Return("7c1e4b9a-2f6d-4e08-a3b5-c9d1e7f2a6b4").Once()
+	clockMock.On("Now").Return(ValidPersonalToken.CreatedAt).Once()
+	storageMock.On("Set", ValidPersonalTokenID, "", &ValidPersonalToken).Return("", fmt.Errorf("some-error")).Once()
+
+	tokenID, token, err := controller.Create(context.Background(), &CreateCmd{
+		UserID:        ValidPersonalToken.UserID,
+		Name:          ValidPersonalToken.Name,
+		Scopes:        ValidPersonalToken.Scopes,
+		GrantedScopes: []string{"contacts", "todos", "users.read"},
+		ExpiresIn:     ValidPersonalToken.ExpiresIn,
+	})
+
+	assert.Empty(t, tokenID)
+	assert.Empty(t, token)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the personal token",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_GetAll(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	storageMock.On("FindAllByUser", ValidPersonalToken.UserID).Return(map[string]PersonalToken{
+		ValidPersonalTokenID: ValidPersonalToken,
+	}, nil).Once()
+
+	res, err := controller.GetAll(context.Background(), &GetAllCmd{
+		UserID: ValidPersonalToken.UserID,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]PersonalToken{
+		ValidPersonalTokenID: ValidPersonalToken,
+	}, res)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_GetAll_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	res, err := controller.GetAll(context.Background(), &GetAllCmd{
+		UserID: "not-an-id",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"userID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Delete(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	storageMock.On("Get", ValidPersonalTokenID).Return("some-rev", &ValidPersonalToken, nil).Once()
+	storageMock.On("Delete", ValidPersonalTokenID, "some-rev").Return(nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		UserID:  ValidPersonalToken.UserID,
+		TokenID: ValidPersonalTokenID,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Delete_a_token_of_another_user(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	storageMock.On("Get", ValidPersonalTokenID).Return("some-rev", &ValidPersonalToken, nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		UserID:  "e16edc95-2063-4fc9-9f46-1431a0ddd6fa",
+		TokenID: ValidPersonalTokenID,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"personal token \"3b9f6d2e-8a41-4c7b-9e05-d2f1a6c8b4e3\" not found"
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Delete_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	storageMock.On("Get", ValidPersonalTokenID).Return("", nil, nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		UserID:  ValidPersonalToken.UserID,
+		TokenID: ValidPersonalTokenID,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"personal token \"3b9f6d2e-8a41-4c7b-9e05-d2f1a6c8b4e3\" not found"
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Delete_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	storageMock.On("Get", ValidPersonalTokenID).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		UserID:  ValidPersonalToken.UserID,
+		TokenID: ValidPersonalTokenID,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the personal token",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	now := ValidPersonalToken.CreatedAt.Add(time.Hour)
+
+	owner := user.ValidUser
+	owner.Role = user.Dev
+	personalToken := ValidPersonalToken
+	updated := ValidPersonalToken
+	updated.LastUsedAt = now
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return(ValidPersonalTokenID, "some-rev", &personalToken, nil).Once()
+	userMock.On("Get", &user.GetCmd{UserID: ValidPersonalToken.UserID}).Return(&owner, nil).Once()
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Set", ValidPersonalTokenID, "some-rev", &updated).Return("some-rev-2", nil).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, ValidPersonalToken.AccessToken(ValidPersonalTokenValue, user.Dev), res)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_recently_used(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	now := ValidPersonalToken.CreatedAt.Add(time.Hour)
+
+	owner := user.ValidUser
+	owner.Role = user.Dev
+	personalToken := ValidPersonalToken
+	personalToken.LastUsedAt = now.Add(-10 * time.Second)
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return(ValidPersonalTokenID, "some-rev", &personalToken, nil).Once()
+	userMock.On("Get", &user.GetCmd{UserID: ValidPersonalToken.UserID}).Return(&owner, nil).Once()
+	clockMock.On("Now").Return(now).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, ValidPersonalToken.AccessToken(ValidPersonalTokenValue, user.Dev), res)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_expired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	owner := user.ValidUser
+	owner.Role = user.Dev
+	personalToken := ValidPersonalToken
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return(ValidPersonalTokenID, "some-rev", &personalToken, nil).Once()
+	userMock.On("Get", &user.GetCmd{UserID: ValidPersonalToken.UserID}).Return(&owner, nil).Once()
+	clockMock.On("Now").Return(ValidPersonalToken.ExpireAt().Add(time.Second)).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	// The expiration is checked by the caller.
+	assert.NoError(t, err)
+	assert.EqualValues(t, ValidPersonalToken.AccessToken(ValidPersonalTokenValue, user.Dev), res)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_with_last_usage_save_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	now := ValidPersonalToken.CreatedAt.Add(time.Hour)
+
+	owner := user.ValidUser
+	owner.Role = user.Dev
+	personalToken := ValidPersonalToken
+	updated := ValidPersonalToken
+	updated.LastUsedAt = now
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return(ValidPersonalTokenID, "some-rev", &personalToken, nil).Once()
+	userMock.On("Get", &user.GetCmd{UserID: ValidPersonalToken.UserID}).Return(&owner, nil).Once()
+	clockMock.On("Now").Return(now).Once()
+	storageMock.On("Set", ValidPersonalTokenID, "some-rev", &updated).Return("", fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, ValidPersonalToken.AccessToken(ValidPersonalTokenValue, user.Dev), res)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_with_a_demoted_user(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	now := ValidPersonalToken.CreatedAt.Add(time.Hour)
+
+	// The token has been minted by an admin.
+	personalToken := ValidPersonalToken
+	personalToken.Scopes = []string{"contacts", "users.write"}
+	personalToken.LastUsedAt = now
+
+	owner := user.ValidUser
+	owner.Role = user.Dev
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return(ValidPersonalTokenID, "some-rev", &personalToken, nil).Once()
+	userMock.On("Get", &user.GetCmd{UserID: ValidPersonalToken.UserID}).Return(&owner, nil).Once()
+	clockMock.On("Now").Return(now).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.Equal(t, user.Dev, res.Role)
+	assert.Equal(t, []string{"contacts"}, res.Scopes)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_with_a_deleted_user(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	personalToken := ValidPersonalToken
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return(ValidPersonalTokenID, "some-rev", &personalToken, nil).Once()
+	userMock.On("Get", &user.GetCmd{UserID: ValidPersonalToken.UserID}).Return(nil, nil).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_with_user_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	personalToken := ValidPersonalToken
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return(ValidPersonalTokenID, "some-rev", &personalToken, nil).Once()
+	userMock.On("Get", &user.GetCmd{UserID: ValidPersonalToken.UserID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the user \"ae6ac8d6-0bcf-4671-a21a-49eab3167cbb\"",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_unknown_token(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return("", "", nil, nil).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_Verify_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	storageMock.On("FindOneByHash", ValidPersonalToken.Hash).Return("", "", nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Verify(context.Background(), ValidPersonalTokenValue)
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the personal token",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	clockMock := new(clock.ClockMock)
+	userMock := new(user.ControllerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, clockMock, userMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	userMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
@@ -0,0 +1,177 @@
+package personaltoken
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/response"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/permission"
+)
+
+type HTTPHandler struct {
+	personalToken ControllerInterface
+}
+
+type ControllerInterface interface {
+	Create(ctx context.Context, cmd *CreateCmd) (string, string, error)
+	GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]PersonalToken, error)
+	Delete(ctx context.Context, cmd *DeleteCmd) error
+}
+
+func NewHTTPHandler(personalToken ControllerInterface) *HTTPHandler {
+	return &HTTPHandler{
+		personalToken: personalToken,
+	}
+}
+
+func (t *HTTPHandler) RegisterRoutes(router *mux.Router, perm *permission.Controller) {
+	// The users manage their own tokens: the caller is checked by the
+	// handlers. The tokens have their own scopes in order to not let any
+	// client mint long-lived tokens.
+	router.HandleFunc("/users/{userID}/tokens", perm.Check("tokens.write", t.Create)).Methods("POST")
+	router.HandleFunc("/users/{userID}/tokens", perm.Check("tokens.read", t.GetAll)).Methods("GET")
+	router.HandleFunc("/users/{userID}/tokens/{tokenID}", perm.Check("tokens.write", t.Delete)).Methods("DELETE")
+}
+
+// Create mints a new token for the caller. The token is only returned by
+// this call.
+func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresIn int      `json:"expiresIn"`
+	}
+
+	type responseBody struct {
+		TokenID string `json:"id"`
+		Token   string `json:"token"`
+	}
+
+	caller := permission.UserFromContext(r.Context())
+	if caller == nil {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "the access token is not bound to any user"))
+		return
+	}
+
+	userID := mux.Vars(r)["userID"]
+	if caller.ID != userID {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "the users can only create their own tokens"))
+		return
+	}
+
+	// A personal token could otherwise be renewed forever by the tokens it
+	// creates.
+	if caller.PersonalToken {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "a personal token can't create other personal tokens"))
+		return
+	}
+
+	var req request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		errors.IntoResponse(w, errors.New(errors.InvalidJSON, err.Error()))
+		return
+	}
+
+	tokenID, token, err := t.personalToken.Create(r.Context(), &CreateCmd{
+		UserID:        userID,
+		Name:          req.Name,
+		Scopes:        req.Scopes,
+		GrantedScopes: caller.Scopes,
+		ExpiresIn:     req.ExpiresIn,
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusCreated, &responseBody{
+		TokenID: tokenID,
+		Token:   token,
+	})
+}
+
+func (t *HTTPHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	type tokenRes struct {
+		Name       string     `json:"name"`
+		Scopes     []string   `json:"scopes"`
+		CreatedAt  time.Time  `json:"createdAt"`
+		ExpiresAt  time.Time  `json:"expiresAt"`
+		LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	}
+
+	userID, ok := checkOwner(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := t.personalToken.GetAll(r.Context(), &GetAllCmd{
+		UserID: userID,
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	tokens := make(map[string]tokenRes, len(res))
+
+	for id, personalToken := range res {
+		// Do not return the hash.
+		token := tokenRes{
+			Name:      personalToken.Name,
+			Scopes:    personalToken.Scopes,
+			CreatedAt: personalToken.CreatedAt,
+			ExpiresAt: personalToken.ExpireAt(),
+		}
+
+		if !personalToken.LastUsedAt.IsZero() {
+			lastUsedAt := personalToken.LastUsedAt
+			token.LastUsedAt = &lastUsedAt
+		}
+
+		tokens[id] = token
+	}
+
+	response.Write(w, http.StatusOK, tokens)
+}
+
+func (t *HTTPHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := checkOwner(w, r)
+	if !ok {
+		return
+	}
+
+	err := t.personalToken.Delete(r.Context(), &DeleteCmd{
+		UserID:  userID,
+		TokenID: mux.Vars(r)["tokenID"],
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusOK, &struct{}{})
+}
+
+// checkOwner returns the user ID of the route if the caller is this user or
+// an admin. The error is written otherwise.
+func checkOwner(w http.ResponseWriter, r *http.Request) (string, bool) {
+	caller := permission.UserFromContext(r.Context())
+	if caller == nil {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "the access token is not bound to any user"))
+		return "", false
+	}
+
+	userID := mux.Vars(r)["userID"]
+	if caller.Role != user.Admin && caller.ID != userID {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "only the admins can manage the tokens of another user"))
+		return "", false
+	}
+
+	return userID, true
+}
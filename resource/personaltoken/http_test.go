@@ -0,0 +1,473 @@
+package personaltoken
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PersonalToken_HTTPHandler_Create_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("Create", &CreateCmd{
+		UserID:        token.UserID,
+		Name:          "backup script",
+		Scopes:        []string{"contacts", "todos"},
+		GrantedScopes: []string{"tokens", "contacts", "todos"},
+		ExpiresIn:     3600,
+	}).Return(ValidPersonalTokenID, ValidPersonalTokenValue, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+token.UserID+"/tokens", strings.NewReader(`{
+		"name": "backup script",
+		"scopes": ["contacts", "todos"],
+		"expiresIn": 3600
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.JSONEq(t, `{
+		"id": "3b9f6d2e-8a41-4c7b-9e05-d2f1a6c8b4e3",
+		"token": "hpat_7c1e4b9a2f6d4e08a3b5c9d1e7f2a6b4"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Create_without_the_tokens_scope(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// The "users" scope is not enough to mint a token.
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"users", "contacts", "todos"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+token.UserID+"/tokens", strings.NewReader(`{
+		"name": "backup script",
+		"scopes": ["contacts"],
+		"expiresIn": 3600
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Create_for_another_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/tokens", strings.NewReader(`{
+		"name": "backup script",
+		"scopes": ["contacts"],
+		"expiresIn": 3600
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "the users can only create their own tokens"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Create_with_a_personal_token(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	controllerMock := new(ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, controllerMock)
+	router := mux.NewRouter()
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := ValidPersonalToken.AccessToken(ValidPersonalTokenValue, user.Dev)
+	token.Scopes = []string{"tokens", "contacts"}
+
+	// Token verification
+	controllerMock.On("Verify", ValidPersonalTokenValue).Return(token, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+token.UserID+"/tokens", strings.NewReader(`{
+		"name": "backup script",
+		"scopes": ["contacts"],
+		"expiresIn": 3600
+	}`))
+	r.Header.Add("Authorization", "Bearer "+ValidPersonalTokenValue)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "a personal token can't create other personal tokens"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Create_with_a_token_without_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+	token.UserID = ""
+	token.Role = ""
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/tokens", strings.NewReader(`{}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "the access token is not bound to any user"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Create_with_an_invalid_json(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+accesstoken.ValidAccessToken.UserID+"/tokens", strings.NewReader("not a json"))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_GetAll_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+	token.Role = accesstoken.Dev
+
+	createdAt := time.Date(2019, time.January, 10, 12, 0, 0, 0, time.UTC)
+
+	unused := ValidPersonalToken
+	unused.CreatedAt = createdAt
+	unused.ExpiresIn = 3600
+
+	used := unused
+	used.Name = "deploy script"
+	used.LastUsedAt = createdAt.Add(time.Minute)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("GetAll", &GetAllCmd{UserID: token.UserID}).Return(map[string]PersonalToken{
+		"some-id":   unused,
+		"some-id-2": used,
+	}, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/users/"+token.UserID+"/tokens", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{
+		"some-id": {
+			"name": "backup script",
+			"scopes": ["contacts", "todos"],
+			"createdAt": "2019-01-10T12:00:00Z",
+			"expiresAt": "2019-01-10T13:00:00Z"
+		},
+		"some-id-2": {
+			"name": "deploy script",
+			"scopes": ["contacts", "todos"],
+			"createdAt": "2019-01-10T12:00:00Z",
+			"expiresAt": "2019-01-10T13:00:00Z",
+			"lastUsedAt": "2019-01-10T12:01:00Z"
+		}
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_GetAll_of_another_user_by_an_admin(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("GetAll", &GetAllCmd{UserID: "e16edc95-2063-4fc9-9f46-1431a0ddd6fa"}).Return(map[string]PersonalToken{}, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/tokens", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_GetAll_of_another_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/tokens", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "only the admins can manage the tokens of another user"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Delete_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("Delete", &DeleteCmd{
+		UserID:  token.UserID,
+		TokenID: ValidPersonalTokenID,
+	}).Return(nil).Once()
+
+	r := httptest.NewRequest("DELETE", "http://example.com/users/"+token.UserID+"/tokens/"+ValidPersonalTokenID, nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Delete_of_another_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("DELETE", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/tokens/"+ValidPersonalTokenID, nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_HTTPHandler_Delete_not_found(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"tokens", "contacts", "todos"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("Delete", &DeleteCmd{
+		UserID:  accesstoken.ValidAccessToken.UserID,
+		TokenID: ValidPersonalTokenID,
+	}).Return(errors.Errorf(errors.NotFound, "personal token %q not found", ValidPersonalTokenID)).Once()
+
+	r := httptest.NewRequest("DELETE", "http://example.com/users/"+accesstoken.ValidAccessToken.UserID+"/tokens/"+ValidPersonalTokenID, nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "notFound",
+		"message": "personal token \"3b9f6d2e-8a41-4c7b-9e05-d2f1a6c8b4e3\" not found"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
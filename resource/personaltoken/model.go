@@ -0,0 +1,103 @@
+package personaltoken
+
+import (
+	"time"
+
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/permission"
+)
+
+// MaxExpiresIn is the longest lifetime of a personal token in seconds.
+const MaxExpiresIn = 365 * 24 * 60 * 60
+
+// PersonalToken is a long-lived token minted by a user for the scripts and
+// the automations. Only the hash of the token is saved.
+type PersonalToken struct {
+	UserID string `json:"userID"`
+	Name   string `json:"name"`
+
+	// SHA-256 hash of the token.
+	Hash   string   `json:"hash"`
+	Scopes []string `json:"scopes"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Token expiration in seconds.
+	ExpiresIn int `json:"expiresIn"`
+
+	// LastUsedAt is updated at most once per minute.
+	LastUsedAt time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// ExpireAt returns the date after which the token is not valid anymore.
+func (t *PersonalToken) ExpireAt() time.Time {
+	return t.CreatedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// IsExpiredAt returns true if the token is expired at the given date.
+func (t *PersonalToken) IsExpiredAt(now time.Time) bool {
+	return t.ExpireAt().Before(now)
+}
+
+// AccessToken converts the personal token into the access token checked by
+// the permission controller. The role is the current role of the user: the
+// scopes not allowed to it anymore are dropped.
+func (t *PersonalToken) AccessToken(token string, role string) *accesstoken.AccessToken {
+	scopes := []string{}
+	for _, scope := range t.Scopes {
+		if permission.HasScope(user.RoleScopes[role], scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &accesstoken.AccessToken{
+		UserID:      t.UserID,
+		Role:        role,
+		AccessToken: token,
+		ExpiresIn:   t.ExpiresIn,
+		Scopes:      scopes,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+type CreateCmd struct {
+	UserID string
+	Name   string
+	Scopes []string
+
+	// Scopes of the access token used to create the token. The new token
+	// can't be given more.
+	GrantedScopes []string
+
+	// Token expiration in seconds. It can't exceed MaxExpiresIn.
+	ExpiresIn int
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if a personal token is expired.
+	Now time.Time
+
+	// Maximum number of personal tokens deleted.
+	Limit uint
+}
+
+type GetAllCmd struct {
+	UserID string
+}
+
+type DeleteCmd struct {
+	UserID  string
+	TokenID string
+}
+
+var ValidPersonalTokenID = "3b9f6d2e-8a41-4c7b-9e05-d2f1a6c8b4e3"
+var ValidPersonalTokenValue = "hpat_7c1e4b9a2f6d4e08a3b5c9d1e7f2a6b4"
+var ValidPersonalToken = PersonalToken{
+	UserID:    "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	Name:      "backup script",
+	Hash:      "26a3db4cf6e2d566d92a5695c316ada1e057665837860e5c59c4ed47860310f0",
+	Scopes:    []string{"contacts", "todos"},
+	CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	ExpiresIn: 90 * 24 * 3600,
+}
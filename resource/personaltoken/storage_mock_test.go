@@ -0,0 +1,151 @@
+package personaltoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PersonalToken_StorageMock_Set(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Set", "some-id", "", &ValidPersonalToken).Return("some-rev", nil).Once()
+
+	rev, err := mock.Set(context.Background(), "some-id", "", &ValidPersonalToken)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_Get(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", "some-id").Return("some-rev", &ValidPersonalToken, nil).Once()
+
+	rev, res, err := mock.Get(context.Background(), "some-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidPersonalToken, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_Get_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", "some-id").Return("", nil, errors.New("some-error")).Once()
+
+	rev, res, err := mock.Get(context.Background(), "some-id")
+
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_Delete(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+
+	err := mock.Delete(context.Background(), "some-id", "some-rev")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_FindOneByHash(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByHash", ValidPersonalToken.Hash).Return("some-id", "some-rev", &ValidPersonalToken, nil).Once()
+
+	id, rev, res, err := mock.FindOneByHash(context.Background(), ValidPersonalToken.Hash)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-id", id)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidPersonalToken, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_FindOneByHash_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByHash", ValidPersonalToken.Hash).Return("", "", nil, errors.New("some-error")).Once()
+
+	id, rev, res, err := mock.FindOneByHash(context.Background(), ValidPersonalToken.Hash)
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_FindAllByUser(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByUser", ValidPersonalToken.UserID).Return(map[string]PersonalToken{
+		ValidPersonalTokenID: ValidPersonalToken,
+	}, nil).Once()
+
+	res, err := mock.FindAllByUser(context.Background(), ValidPersonalToken.UserID)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]PersonalToken{
+		ValidPersonalTokenID: ValidPersonalToken,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_FindAllByUser_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByUser", ValidPersonalToken.UserID).Return(nil, errors.New("some-error")).Once()
+
+	res, err := mock.FindAllByUser(context.Background(), ValidPersonalToken.UserID)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_FindExpired(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(map[string]string{"some-id": "some-rev"}, nil).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"some-id": "some-rev"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_PersonalToken_StorageMock_FindExpired_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(nil, errors.New("some-error")).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,77 @@
+package revocation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+type Controller struct {
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *Revocation) (string, error)
+	FindActive(ctx context.Context, now time.Time) (map[string]Revocation, error)
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+	Delete(ctx context.Context, id string, rev string) error
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+
+	return NewController(storage)
+}
+
+func NewController(storage StorageInterface) *Controller {
+	return &Controller{
+		storage: storage,
+	}
+}
+
+// Create adds a token to the revocation list.
+func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) error {
+	err := validator.New().
+		CheckString("tokenID", cmd.TokenID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	_, err = t.storage.Set(ctx, cmd.TokenID, "", &Revocation{
+		ExpireAt: cmd.ExpireAt,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to save the revocation")
+	}
+
+	return nil
+}
+
+// GetAll returns the entries not expired at the given date, indexed by
+// token ID.
+func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Revocation, error) {
+	res, err := t.storage.FindActive(ctx, cmd.Now)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the active revocations")
+	}
+
+	return res, nil
+}
+
+// DeleteExpired deletes the entries expired at the given date. It returns the
+// number of deleted entries.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
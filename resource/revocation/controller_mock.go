@@ -0,0 +1,33 @@
+package revocation
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ControllerMock struct {
+	mock.Mock
+}
+
+func (t *ControllerMock) Create(ctx context.Context, cmd *CreateCmd) error {
+	args := t.Called(cmd)
+
+	return args.Error(0)
+}
+
+func (t *ControllerMock) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Revocation, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]Revocation), args.Error(1)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,64 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Revocation_ControllerMock_Create(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Create", &CreateCmd{TokenID: ValidTokenID, ExpireAt: ValidRevocation.ExpireAt}).Return(nil).Once()
+
+	err := mock.Create(context.Background(), &CreateCmd{TokenID: ValidTokenID, ExpireAt: ValidRevocation.ExpireAt})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Revocation_ControllerMock_GetAll(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("GetAll", &GetAllCmd{Now: now}).Return(map[string]Revocation{ValidTokenID: ValidRevocation}, nil).Once()
+
+	res, err := mock.GetAll(context.Background(), &GetAllCmd{Now: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Revocation{ValidTokenID: ValidRevocation}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Revocation_ControllerMock_GetAll_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("GetAll", &GetAllCmd{Now: now}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.GetAll(context.Background(), &GetAllCmd{Now: now})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Revocation_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
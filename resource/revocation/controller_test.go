@@ -0,0 +1,156 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Revocation_Controller_Create(t *testing.T) {
+	storageMock := new(StorageMock)
+	controller := NewController(storageMock)
+
+	storageMock.On("Set", ValidTokenID, "", &ValidRevocation).Return("some-rev", nil).Once()
+
+	err := controller.Create(context.Background(), &CreateCmd{
+		TokenID:  ValidTokenID,
+		ExpireAt: ValidRevocation.ExpireAt,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Revocation_Controller_Create_with_a_validation_error(t *testing.T) {
+	storageMock := new(StorageMock)
+	controller := NewController(storageMock)
+
+	err := controller.Create(context.Background(), &CreateCmd{
+		TokenID:  "",
+		ExpireAt: ValidRevocation.ExpireAt,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"tokenID":"MISSING_FIELD"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Revocation_Controller_Create_with_a_storage_error(t *testing.T) {
+	storageMock := new(StorageMock)
+	controller := NewController(storageMock)
+
+	storageMock.On("Set", ValidTokenID, "", &ValidRevocation).Return("", fmt.Errorf("some-error")).Once()
+
+	err := controller.Create(context.Background(), &CreateCmd{
+		TokenID:  ValidTokenID,
+		ExpireAt: ValidRevocation.ExpireAt,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the revocation",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Revocation_Controller_GetAll(t *testing.T) {
+	storageMock := new(StorageMock)
+	controller := NewController(storageMock)
+
+	now := time.Now()
+	storageMock.On("FindActive", now).Return(map[string]Revocation{ValidTokenID: ValidRevocation}, nil).Once()
+
+	res, err := controller.GetAll(context.Background(), &GetAllCmd{Now: now})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Revocation{ValidTokenID: ValidRevocation}, res)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Revocation_Controller_GetAll_with_a_storage_error(t *testing.T) {
+	storageMock := new(StorageMock)
+	controller := NewController(storageMock)
+
+	now := time.Now()
+	storageMock.On("FindActive", now).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.GetAll(context.Background(), &GetAllCmd{Now: now})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the active revocations",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Revocation_Controller_DeleteExpired(t *testing.T) {
+	storageMock := new(StorageMock)
+	controller := NewController(storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Revocation_Controller_DeleteExpired_with_delete_error(t *testing.T) {
+	storageMock := new(StorageMock)
+	controller := NewController(storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id": "some-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the document \"some-id\"",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
@@ -0,0 +1,35 @@
+package revocation
+
+import "time"
+
+// Revocation is an entry of the revocation list. The signed access tokens are
+// checked without any storage lookup so their deletion isn't enough to
+// revoke them: their ID is kept in this list until they expire.
+type Revocation struct {
+	// Expiration date of the revoked token. The entry is useless after it.
+	ExpireAt time.Time `json:"expireAt"`
+}
+
+type CreateCmd struct {
+	// ID of the revoked token ("jti" claim).
+	TokenID  string
+	ExpireAt time.Time
+}
+
+type GetAllCmd struct {
+	// Date used to skip the expired entries.
+	Now time.Time
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if an entry is expired.
+	Now time.Time
+
+	// Maximum number of entries deleted.
+	Limit uint
+}
+
+var ValidTokenID = "5f0b7d0e-3c1a-4b9e-9a55-2e6f4d8c1b7a"
+var ValidRevocation = Revocation{
+	ExpireAt: time.Now().Add(time.Hour).UTC().Round(time.Millisecond),
+}
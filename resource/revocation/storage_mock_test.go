@@ -0,0 +1,77 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Revocation_StorageMock_Set(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Set", ValidTokenID, "", &ValidRevocation).Return("some-rev", nil).Once()
+
+	rev, err := mock.Set(context.Background(), ValidTokenID, "", &ValidRevocation)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Revocation_StorageMock_FindActive(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindActive", now).Return(map[string]Revocation{ValidTokenID: ValidRevocation}, nil).Once()
+
+	res, err := mock.FindActive(context.Background(), now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Revocation{ValidTokenID: ValidRevocation}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Revocation_StorageMock_FindActive_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindActive", now).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.FindActive(context.Background(), now)
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Revocation_StorageMock_FindExpired(t *testing.T) {
+	mock := new(StorageMock)
+
+	now := time.Now()
+	mock.On("FindExpired", now, uint(10)).Return(map[string]string{"some-id": "some-rev"}, nil).Once()
+
+	res, err := mock.FindExpired(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"some-id": "some-rev"}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Revocation_StorageMock_Delete(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+
+	err := mock.Delete(context.Background(), "some-id", "some-rev")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,98 @@
+package securityevent
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+type Controller struct {
+	uuid    uuid.Producer
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *SecurityEvent) (string, error)
+	Get(ctx context.Context, id string) (string, *SecurityEvent, error)
+	Delete(ctx context.Context, id string, rev string) error
+	FindExpired(ctx context.Context, now time.Time, limit uint) (map[string]string, error)
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+	uuidProducer := uuid.NewGoUUID()
+
+	return NewController(uuidProducer, storage)
+}
+
+func NewController(
+	uuid uuid.Producer,
+	storage StorageInterface,
+) *Controller {
+	return &Controller{
+		uuid:    uuid,
+		storage: storage,
+	}
+}
+
+// Create records a new security event and returns its ID.
+func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
+	err := validator.New().
+		CheckString("type", cmd.Type, is.Required, is.OnOfString(RefreshTokenReused)).
+		CheckString("userID", cmd.UserID, is.Optional, is.ID).
+		CheckString("clientID", cmd.ClientID, is.Required, is.StringInRange(3, 100)).
+		CheckString("description", cmd.Description, is.Optional, is.StringInRange(1, 500)).
+		Run()
+	if err != nil {
+		return "", err
+	}
+
+	id := t.uuid.New()
+
+	_, err = t.storage.Set(ctx, id, "", &SecurityEvent{
+		Type:        cmd.Type,
+		UserID:      cmd.UserID,
+		ClientID:    cmd.ClientID,
+		Description: cmd.Description,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to save the security event")
+	}
+
+	return id, nil
+}
+
+func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*SecurityEvent, error) {
+	err := validator.New().
+		CheckString("securityEventID", cmd.SecurityEventID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	_, event, err := t.storage.Get(ctx, cmd.SecurityEventID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the security event")
+	}
+
+	return event, nil
+}
+
+// DeleteExpired deletes the security events older than the Retention at the
+// given date. It returns the number of deleted security events.
+func (t *Controller) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	return db.DeleteExpired(ctx, t.storage, cmd.Now, cmd.Limit)
+}
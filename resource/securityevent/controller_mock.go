@@ -0,0 +1,33 @@
+package securityevent
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ControllerMock struct {
+	mock.Mock
+}
+
+func (t *ControllerMock) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
+	args := t.Called(cmd)
+
+	return args.String(0), args.Error(1)
+}
+
+func (t *ControllerMock) Get(ctx context.Context, cmd *GetCmd) (*SecurityEvent, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*SecurityEvent), args.Error(1)
+}
+
+func (t *ControllerMock) DeleteExpired(ctx context.Context, cmd *DeleteExpiredCmd) (int, error) {
+	args := t.Called(cmd)
+
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,71 @@
+package securityevent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SecurityEvent_ControllerMock_Create(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Create", &CreateCmd{
+		Type:     ValidSecurityEvent.Type,
+		UserID:   ValidSecurityEvent.UserID,
+		ClientID: ValidSecurityEvent.ClientID,
+	}).Return(ValidSecurityEventID, nil).Once()
+
+	id, err := mock.Create(context.Background(), &CreateCmd{
+		Type:     ValidSecurityEvent.Type,
+		UserID:   ValidSecurityEvent.UserID,
+		ClientID: ValidSecurityEvent.ClientID,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidSecurityEventID, id)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_ControllerMock_Get(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{SecurityEventID: ValidSecurityEventID}).Return(&ValidSecurityEvent, nil).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{SecurityEventID: ValidSecurityEventID})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidSecurityEvent, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_ControllerMock_Get_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Get", &GetCmd{SecurityEventID: ValidSecurityEventID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.Get(context.Background(), &GetCmd{SecurityEventID: ValidSecurityEventID})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_ControllerMock_DeleteExpired(t *testing.T) {
+	mock := new(ControllerMock)
+
+	now := time.Now()
+	mock.On("DeleteExpired", &DeleteExpiredCmd{Now: now, Limit: 10}).Return(3, nil).Once()
+
+	deleted, err := mock.DeleteExpired(context.Background(), &DeleteExpiredCmd{Now: now, Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,206 @@
+package securityevent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_SecurityEvent_Controller_Create(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	uuidMock.On("New").Return(ValidSecurityEventID).Once()
+	storageMock.On("Set", ValidSecurityEventID, "", mock.MatchedBy(func(event *SecurityEvent) bool {
+		return event.Type == ValidSecurityEvent.Type &&
+			event.UserID == ValidSecurityEvent.UserID &&
+			event.ClientID == ValidSecurityEvent.ClientID &&
+			event.Description == ValidSecurityEvent.Description &&
+			!event.CreatedAt.IsZero()
+	})).Return("some-rev", nil).Once()
+
+	id, err := controller.Create(context.Background(), &CreateCmd{
+		Type:        ValidSecurityEvent.Type,
+		UserID:      ValidSecurityEvent.UserID,
+		ClientID:    ValidSecurityEvent.ClientID,
+		Description: ValidSecurityEvent.Description,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidSecurityEventID, id)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_Controller_Create_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	id, err := controller.Create(context.Background(), &CreateCmd{
+		Type:     "unknown-type",
+		ClientID: ValidSecurityEvent.ClientID,
+	})
+
+	assert.Empty(t, id)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"type":"UNEXPECTED_VALUE"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_Controller_Create_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	uuidMock.On("New").Return(ValidSecurityEventID).Once()
+	storageMock.On("Set", ValidSecurityEventID, "", mock.Anything).Return("", fmt.Errorf("some-error")).Once()
+
+	id, err := controller.Create(context.Background(), &CreateCmd{
+		Type:     ValidSecurityEvent.Type,
+		UserID:   ValidSecurityEvent.UserID,
+		ClientID: ValidSecurityEvent.ClientID,
+	})
+
+	assert.Empty(t, id)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the security event",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_Controller_Get(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidSecurityEventID).Return("some-rev", &ValidSecurityEvent, nil).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		SecurityEventID: ValidSecurityEventID,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidSecurityEvent, res)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_Controller_Get_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		SecurityEventID: "invalid-id",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"securityEventID":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_Controller_Get_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidSecurityEventID).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	res, err := controller.Get(context.Background(), &GetCmd{
+		SecurityEventID: ValidSecurityEventID,
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the security event",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_Controller_DeleteExpired(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(map[string]string{
+		"some-id":       "some-rev",
+		"some-other-id": "some-other-rev",
+	}, nil).Once()
+	storageMock.On("Delete", "some-id", "some-rev").Return(nil).Once()
+	storageMock.On("Delete", "some-other-id", "some-other-rev").Return(nil).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_SecurityEvent_Controller_DeleteExpired_with_find_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	now := time.Now()
+	storageMock.On("FindExpired", now, uint(10)).Return(nil, fmt.Errorf("some-error")).Once()
+
+	deleted, err := controller.DeleteExpired(context.Background(), &DeleteExpiredCmd{
+		Now:   now,
+		Limit: 10,
+	})
+
+	assert.Equal(t, 0, deleted)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the expired documents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
@@ -0,0 +1,55 @@
+package securityevent
+
+import "time"
+
+const (
+	// RefreshTokenReused is recorded when an already used refresh token is
+	// presented again. The token has probably been stolen.
+	RefreshTokenReused = "refresh_token_reused"
+)
+
+// Retention is the duration after which the events are purged.
+const Retention = 90 * 24 * time.Hour
+
+// SecurityEvent is an audit record about a suspicious activity.
+type SecurityEvent struct {
+	Type string `json:"type"`
+
+	// User and client concerned by the event. The UserID is empty for the
+	// events about a client without any user.
+	UserID   string `json:"userID,omitempty"`
+	ClientID string `json:"clientID"`
+
+	// Human readable details about the event.
+	Description string `json:"description,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateCmd struct {
+	Type        string
+	UserID      string
+	ClientID    string
+	Description string
+}
+
+type GetCmd struct {
+	SecurityEventID string
+}
+
+type DeleteExpiredCmd struct {
+	// Date used to decide if a security event is older than the Retention.
+	Now time.Time
+
+	// Maximum number of security events deleted.
+	Limit uint
+}
+
+var ValidSecurityEventID = "0f9c2a4e-6b3d-4e8a-9f1c-7d5b2e8a4c61"
+var ValidSecurityEvent = SecurityEvent{
+	Type:        RefreshTokenReused,
+	UserID:      "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	ClientID:    "my-web-application",
+	Description: "token family revoked",
+	CreatedAt:   time.Now().UTC().Round(time.Millisecond),
+}
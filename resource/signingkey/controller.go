@@ -0,0 +1,153 @@
+package signingkey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"log"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/db"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+// keySize is the size in bits of the generated RSA keys.
+const keySize = 2048
+
+type Controller struct {
+	uuid    uuid.Producer
+	storage StorageInterface
+}
+
+type StorageInterface interface {
+	Set(ctx context.Context, id string, rev string, value *SigningKey) (string, error)
+	Get(ctx context.Context, id string) (string, *SigningKey, error)
+	Delete(ctx context.Context, id string, rev string) error
+	GetAll(ctx context.Context) (map[string]SigningKey, error)
+}
+
+func InitController(ctx context.Context, server *yaccc.Server) *Controller {
+	// The first key is only generated with a new database.
+	_, err := server.ConnectDatabase(ctx, BucketName)
+	requireBootstrap := err != nil
+
+	database, err := SetupStorage(ctx, server)
+	if err != nil {
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
+	}
+
+	storage := NewStorage(db.NewCouchdbDriver(database))
+	uuidProducer := uuid.NewGoUUID()
+
+	controller := NewController(uuidProducer, storage)
+
+	// Generate the first key at the first boot.
+	if requireBootstrap {
+		_, err := controller.Create(ctx, &CreateCmd{})
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "failed to create the first signing key"))
+		}
+	}
+
+	return controller
+}
+
+func NewController(
+	uuid uuid.Producer,
+	storage StorageInterface,
+) *Controller {
+	return &Controller{
+		uuid:    uuid,
+		storage: storage,
+	}
+}
+
+// Create generates a new key. It becomes the current key.
+func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate the key")
+	}
+
+	id := t.uuid.New()
+
+	_, err = t.storage.Set(ctx, id, "", &SigningKey{
+		Algorithm: RS256,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to save the signing key")
+	}
+
+	return id, nil
+}
+
+// GetCurrent returns the most recent key. This is the one used to sign the
+// new tokens.
+func (t *Controller) GetCurrent(ctx context.Context, cmd *GetCurrentCmd) (string, *SigningKey, error) {
+	keys, err := t.storage.GetAll(ctx)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get all the signing keys")
+	}
+
+	var currentID string
+	var current *SigningKey
+	for id, key := range keys {
+		if current == nil || key.CreatedAt.After(current.CreatedAt) {
+			key := key
+			currentID = id
+			current = &key
+		}
+	}
+
+	if current == nil {
+		return "", nil, errors.New(errors.Internal, "no signing key found")
+	}
+
+	return currentID, current, nil
+}
+
+// GetAll returns all the keys. They are required to verify the tokens signed
+// before a key rotation.
+func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]SigningKey, error) {
+	res, err := t.storage.GetAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get all the signing keys")
+	}
+
+	return res, nil
+}
+
+// Delete removes a key. The tokens signed with it can't be verified anymore.
+func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	err := validator.New().
+		CheckString("keyID", cmd.KeyID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, _, err := t.storage.Get(ctx, cmd.KeyID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the signing key")
+	}
+
+	if rev != "" {
+		err = t.storage.Delete(ctx, cmd.KeyID, rev)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete the signing key")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package signingkey
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ControllerMock struct {
+	mock.Mock
+}
+
+func (t *ControllerMock) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
+	args := t.Called(cmd)
+
+	return args.String(0), args.Error(1)
+}
+
+func (t *ControllerMock) GetCurrent(ctx context.Context, cmd *GetCurrentCmd) (string, *SigningKey, error) {
+	args := t.Called(cmd)
+
+	if args.Get(1) == nil {
+		return "", nil, args.Error(2)
+	}
+
+	return args.String(0), args.Get(1).(*SigningKey), args.Error(2)
+}
+
+func (t *ControllerMock) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]SigningKey, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]SigningKey), args.Error(1)
+}
+
+func (t *ControllerMock) Delete(ctx context.Context, cmd *DeleteCmd) error {
+	args := t.Called(cmd)
+
+	return args.Error(0)
+}
@@ -0,0 +1,92 @@
+package signingkey
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SigningKey_ControllerMock_Create(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Create", &CreateCmd{}).Return("some-id", nil).Once()
+
+	id, err := mock.Create(context.Background(), &CreateCmd{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-id", id)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_ControllerMock_GetCurrent(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetCurrent", &GetCurrentCmd{}).Return(ValidSigningKeyID, &ValidSigningKey, nil).Once()
+
+	id, res, err := mock.GetCurrent(context.Background(), &GetCurrentCmd{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidSigningKeyID, id)
+	assert.Equal(t, &ValidSigningKey, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_ControllerMock_GetCurrent_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetCurrent", &GetCurrentCmd{}).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	id, res, err := mock.GetCurrent(context.Background(), &GetCurrentCmd{})
+
+	assert.Empty(t, id)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_ControllerMock_GetAll(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetAll", &GetAllCmd{}).Return(map[string]SigningKey{
+		ValidSigningKeyID: ValidSigningKey,
+	}, nil).Once()
+
+	res, err := mock.GetAll(context.Background(), &GetAllCmd{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]SigningKey{
+		ValidSigningKeyID: ValidSigningKey,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_ControllerMock_GetAll_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetAll", &GetAllCmd{}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.GetAll(context.Background(), &GetAllCmd{})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_ControllerMock_Delete(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("Delete", &DeleteCmd{KeyID: ValidSigningKeyID}).Return(nil).Once()
+
+	err := mock.Delete(context.Background(), &DeleteCmd{KeyID: ValidSigningKeyID})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,236 @@
+package signingkey
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_SigningKey_Controller_Create(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	uuidMock.On("New").Return(ValidSigningKeyID).Once()
+	storageMock.On("Set", ValidSigningKeyID, "", mock.MatchedBy(func(key *SigningKey) bool {
+		privateKey, err := key.RSAPrivateKey()
+
+		return err == nil && key.Algorithm == RS256 && privateKey.N.BitLen() == keySize
+	})).Return("some-rev", nil).Once()
+
+	id, err := controller.Create(context.Background(), &CreateCmd{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidSigningKeyID, id)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_Create_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	uuidMock.On("New").Return(ValidSigningKeyID).Once()
+	storageMock.On("Set", ValidSigningKeyID, "", mock.Anything).Return("", fmt.Errorf("some-error")).Once()
+
+	id, err := controller.Create(context.Background(), &CreateCmd{})
+
+	assert.Empty(t, id)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to save the signing key",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_GetCurrent(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	oldKey := ValidSigningKey
+	oldKey.CreatedAt = ValidSigningKey.CreatedAt.Add(-time.Hour)
+
+	storageMock.On("GetAll").Return(map[string]SigningKey{
+		"some-old-id":     oldKey,
+		ValidSigningKeyID: ValidSigningKey,
+	}, nil).Once()
+
+	id, res, err := controller.GetCurrent(context.Background(), &GetCurrentCmd{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidSigningKeyID, id)
+	assert.Equal(t, &ValidSigningKey, res)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_GetCurrent_without_key(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("GetAll").Return(map[string]SigningKey{}, nil).Once()
+
+	id, res, err := controller.GetCurrent(context.Background(), &GetCurrentCmd{})
+
+	assert.Empty(t, id)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"no signing key found"
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_GetCurrent_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("GetAll").Return(nil, fmt.Errorf("some-error")).Once()
+
+	id, res, err := controller.GetCurrent(context.Background(), &GetCurrentCmd{})
+
+	assert.Empty(t, id)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get all the signing keys",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_GetAll(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("GetAll").Return(map[string]SigningKey{
+		ValidSigningKeyID: ValidSigningKey,
+	}, nil).Once()
+
+	res, err := controller.GetAll(context.Background(), &GetAllCmd{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]SigningKey{
+		ValidSigningKeyID: ValidSigningKey,
+	}, res)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_Delete(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidSigningKeyID).Return("some-rev", &ValidSigningKey, nil).Once()
+	storageMock.On("Delete", ValidSigningKeyID, "some-rev").Return(nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{KeyID: ValidSigningKeyID})
+
+	assert.NoError(t, err)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_Delete_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidSigningKeyID).Return("", nil, nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{KeyID: ValidSigningKeyID})
+
+	assert.NoError(t, err)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_Delete_with_a_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	err := controller.Delete(context.Background(), &DeleteCmd{KeyID: ""})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"keyID":"MISSING_FIELD"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_Controller_Delete_with_a_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidSigningKeyID).Return("some-rev", &ValidSigningKey, nil).Once()
+	storageMock.On("Delete", ValidSigningKeyID, "some-rev").Return(fmt.Errorf("some-error")).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{KeyID: ValidSigningKeyID})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the signing key",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_SigningKey_SigningKey_RSAPrivateKey(t *testing.T) {
+	key, err := ValidSigningKey.RSAPrivateKey()
+
+	assert.NoError(t, err)
+	assert.NoError(t, key.Validate())
+}
+
+func Test_SigningKey_SigningKey_RSAPrivateKey_with_an_invalid_pem(t *testing.T) {
+	key := ValidSigningKey
+	key.PrivateKey = "some-invalid-key"
+
+	res, err := key.RSAPrivateKey()
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"invalid PEM private key"
+	}`, err.Error())
+}
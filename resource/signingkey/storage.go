@@ -0,0 +1,104 @@
+package signingkey
+
+import (
+	"context"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/db"
+	"gitlab.com/Peltoche/yaccc"
+)
+
+const BucketName = "signingkeys"
+
+type Storage struct {
+	driver db.Driver
+}
+
+func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_creation": {
+					Map: `function (doc, meta) {
+						if (doc.createdAt) {
+							emit(doc.createdAt, null);
+						}
+					}`,
+				},
+			},
+		},
+	})
+}
+
+func NewStorage(driver db.Driver) *Storage {
+	return &Storage{
+		driver: driver,
+	}
+}
+
+func (t *Storage) Set(ctx context.Context, id string, rev string, value *SigningKey) (string, error) {
+	rev, err := t.driver.Set(ctx, id, rev, value)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to set the document into the storage")
+	}
+
+	return rev, nil
+}
+
+func (t *Storage) Get(ctx context.Context, id string) (string, *SigningKey, error) {
+	var key SigningKey
+
+	rev, err := t.driver.Get(ctx, id, &key)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get the document from the storage")
+	}
+
+	if rev == "" {
+		return "", nil, nil
+	}
+
+	return rev, &key, nil
+}
+
+func (t *Storage) Delete(ctx context.Context, id string, rev string) error {
+	err := t.driver.Delete(ctx, id, rev)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete the document from the storage")
+	}
+
+	return nil
+}
+
+func (t *Storage) GetAll(ctx context.Context) (map[string]SigningKey, error) {
+	viewResult, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_creation",
+		Limit:     50,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	if len(viewResult) == 0 {
+		return map[string]SigningKey{}, nil
+	}
+
+	keyList := map[string]*SigningKey{}
+
+	for _, val := range viewResult {
+		keyList[val.ID] = &SigningKey{}
+	}
+
+	err = t.driver.GetMany(ctx, keyList)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the documents")
+	}
+
+	res := make(map[string]SigningKey, len(keyList))
+
+	for key, value := range keyList {
+		res[key] = *value
+	}
+
+	return res, nil
+}
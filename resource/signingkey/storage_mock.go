@@ -0,0 +1,41 @@
+package signingkey
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type StorageMock struct {
+	mock.Mock
+}
+
+func (t *StorageMock) Set(_ context.Context, id string, rev string, value *SigningKey) (string, error) {
+	args := t.Called(id, rev, value)
+
+	return args.String(0), args.Error(1)
+}
+
+func (t *StorageMock) Get(_ context.Context, id string) (string, *SigningKey, error) {
+	args := t.Called(id)
+
+	if args.Get(1) == nil {
+		return "", nil, args.Error(2)
+	}
+
+	return args.String(0), args.Get(1).(*SigningKey), args.Error(2)
+}
+
+func (t *StorageMock) Delete(_ context.Context, id string, rev string) error {
+	return t.Called(id, rev).Error(0)
+}
+
+func (t *StorageMock) GetAll(_ context.Context) (map[string]SigningKey, error) {
+	args := t.Called()
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(map[string]SigningKey), args.Error(1)
+}
@@ -0,0 +1,92 @@
+package signingkey
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SigningKey_StorageMock_Set(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Set", "some-id", "some-rev", &ValidSigningKey).Return("some-rev-2", nil)
+
+	rev, err := mock.Set(context.Background(), "some-id", "some-rev", &ValidSigningKey)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev-2", rev)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_StorageMock_GetAll(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("GetAll").Return(map[string]SigningKey{
+		"some-id": ValidSigningKey,
+	}, nil).Once()
+
+	res, err := mock.GetAll(context.Background())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]SigningKey{
+		"some-id": ValidSigningKey,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_StorageMock_GetAll_with_an_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("GetAll").Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.GetAll(context.Background())
+
+	assert.Empty(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_StorageMock_Get(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", ValidSigningKeyID).Return("some-rev", &ValidSigningKey, nil).Once()
+
+	rev, res, err := mock.Get(context.Background(), ValidSigningKeyID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.Equal(t, &ValidSigningKey, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_StorageMock_Get_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Get", ValidSigningKeyID).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	rev, res, err := mock.Get(context.Background(), ValidSigningKeyID)
+
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_SigningKey_StorageMock_Delete(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("Delete", ValidSigningKeyID, "some-rev").Return(nil).Once()
+
+	err := mock.Delete(context.Background(), ValidSigningKeyID, "some-rev")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
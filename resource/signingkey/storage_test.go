@@ -0,0 +1,157 @@
+package signingkey
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/halium-project/server/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SigningKey_Storage_Set(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Set", ValidSigningKeyID, "", &ValidSigningKey).Return("some-rev", nil).Once()
+
+	rev, err := storage.Set(context.Background(), ValidSigningKeyID, "", &ValidSigningKey)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_SigningKey_Storage_Set_with_driver_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Set", ValidSigningKeyID, "", &ValidSigningKey).Return("", fmt.Errorf("some-error")).Once()
+
+	rev, err := storage.Set(context.Background(), ValidSigningKeyID, "", &ValidSigningKey)
+
+	assert.Empty(t, rev)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to set the document into the storage",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_SigningKey_Storage_Get(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", ValidSigningKeyID).Return("some-rev", &ValidSigningKey, nil).Once()
+
+	rev, res, err := storage.Get(context.Background(), ValidSigningKeyID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, ValidSigningKey, *res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_SigningKey_Storage_Get_not_found(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Get", ValidSigningKeyID).Return("", nil, nil).Once()
+
+	rev, res, err := storage.Get(context.Background(), ValidSigningKeyID)
+
+	assert.NoError(t, err)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_SigningKey_Storage_Delete(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("Delete", ValidSigningKeyID, "some-rev").Return(nil).Once()
+
+	err := storage.Delete(context.Background(), ValidSigningKeyID, "some-rev")
+
+	assert.NoError(t, err)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_SigningKey_Storage_GetAll(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_creation",
+		Limit:     50,
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+		{ID: "some-id-2"},
+	}, nil).Once()
+
+	dbDriver.On("GetMany", []string{"some-id", "some-id-2"}).Return(map[string]SigningKey{
+		"some-id":   ValidSigningKey,
+		"some-id-2": ValidSigningKey,
+	}, nil).Once()
+
+	res, err := storage.GetAll(context.Background())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]SigningKey{
+		"some-id":   ValidSigningKey,
+		"some-id-2": ValidSigningKey,
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_SigningKey_Storage_GetAll_empty(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_creation",
+		Limit:     50,
+	}).Return([]db.ViewRow{}, nil).Once()
+
+	res, err := storage.GetAll(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_SigningKey_Storage_GetAll_with_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	storage := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_creation",
+		Limit:     50,
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := storage.GetAll(context.Background())
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
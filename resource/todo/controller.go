@@ -22,18 +22,16 @@ type StorageInterface interface {
 	Set(ctx context.Context, id string, rev string, value *Todo) (string, error)
 	Get(ctx context.Context, id string) (string, *Todo, error)
 	GetAll(ctx context.Context) (map[string]Todo, error)
-	FindOneByTitle(ctx context.Context, title string) (string, string, *Todo, error)
+	FindAllByOwner(ctx context.Context, ownerID string) (map[string]Todo, error)
+	FindAllWithoutOwner(ctx context.Context) (map[string]Todo, error)
+	FindOneByTitle(ctx context.Context, ownerID string, title string) (string, string, *Todo, error)
 	Delete(ctx context.Context, id string) error
 }
 
 func InitController(ctx context.Context, server *yaccc.Server) *Controller {
-	database, err := server.ConnectDatabase(ctx, BucketName)
+	database, err := SetupStorage(ctx, server)
 	if err != nil {
-		database, err = SetupStorage(ctx, server)
-		if err != nil {
-			log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
-		}
-
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
 	}
 
 	storage := NewStorage(db.NewCouchdbDriver(database))
@@ -57,13 +55,14 @@ func NewController(
 
 func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error) {
 	err := validator.New().
+		CheckString("ownerID", cmd.OwnerID, is.Required, is.ID).
 		CheckString("title", cmd.Title, is.Required, is.StringInRange(3, 50)).
 		Run()
 	if err != nil {
 		return "", err
 	}
 
-	_, _, existingTodo, err := t.storage.FindOneByTitle(ctx, cmd.Title)
+	_, _, existingTodo, err := t.storage.FindOneByTitle(ctx, cmd.OwnerID, cmd.Title)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to check if the title is already taken")
 	}
@@ -73,7 +72,8 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error)
 	}
 
 	todo := Todo{
-		Title: cmd.Title,
+		OwnerID: cmd.OwnerID,
+		Title:   cmd.Title,
 	}
 
 	// Generate the UUID
@@ -90,6 +90,7 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error)
 func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*Todo, error) {
 	err := validator.New().
 		CheckString("todoID", cmd.TodoID, is.Required, is.StringInRange(3, 100)).
+		CheckString("ownerID", cmd.OwnerID, is.Optional, is.ID).
 		Run()
 	if err != nil {
 		return nil, err
@@ -100,14 +101,31 @@ func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*Todo, error) {
 		return nil, errors.Wrap(err, "failed to get a todo")
 	}
 
+	// Hide the todos owned by the other users.
+	if todo != nil && cmd.OwnerID != "" && todo.OwnerID != cmd.OwnerID {
+		return nil, nil
+	}
+
 	return todo, nil
 }
 
 func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Todo, error) {
+	err := validator.New().
+		CheckString("ownerID", cmd.OwnerID, is.Optional, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
-	res, err := t.storage.GetAll(ctx)
+	var res map[string]Todo
+	if cmd.OwnerID == "" {
+		res, err = t.storage.GetAll(ctx)
+	} else {
+		res, err = t.storage.FindAllByOwner(ctx, cmd.OwnerID)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get all todos")
 	}
@@ -118,6 +136,7 @@ func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]Tod
 func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	err := validator.New().
 		CheckString("todoID", cmd.TodoID, is.Required, is.StringInRange(3, 100)).
+		CheckString("ownerID", cmd.OwnerID, is.Optional, is.ID).
 		Run()
 	if err != nil {
 		return err
@@ -126,6 +145,18 @@ func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
+	if cmd.OwnerID != "" {
+		_, todo, err := t.storage.Get(ctx, cmd.TodoID)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the todo")
+		}
+
+		// Act as if the todos owned by the other users don't exist.
+		if todo == nil || todo.OwnerID != cmd.OwnerID {
+			return nil
+		}
+	}
+
 	err = t.storage.Delete(ctx, cmd.TodoID)
 	if err != nil {
 		return errors.Wrap(err, "failed to delete the todo")
@@ -133,3 +164,48 @@ func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 
 	return nil
 }
+
+// AdoptOrphans gives the todos without owner to the given user. Those
+// todos were created before being bound to their owner and only the
+// admins can list them. It returns the number of adopted todos.
+func (t *Controller) AdoptOrphans(ctx context.Context, cmd *AdoptOrphansCmd) (int, error) {
+	err := validator.New().
+		CheckString("ownerID", cmd.OwnerID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for {
+		orphans, err := t.storage.FindAllWithoutOwner(ctx)
+		if err != nil {
+			return count, errors.Wrap(err, "failed to find the todos without owner")
+		}
+
+		if len(orphans) == 0 {
+			return count, nil
+		}
+
+		for todoID := range orphans {
+			rev, todo, err := t.storage.Get(ctx, todoID)
+			if err != nil {
+				return count, errors.Wrapf(err, "failed to get the todo %q", todoID)
+			}
+
+			// Deleted since the view query.
+			if todo == nil {
+				continue
+			}
+
+			todo.OwnerID = cmd.OwnerID
+
+			_, err = t.storage.Set(ctx, todoID, rev, todo)
+			if err != nil {
+				return count, errors.Wrapf(err, "failed to save the todo %q", todoID)
+			}
+
+			count++
+		}
+	}
+}
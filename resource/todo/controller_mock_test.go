@@ -100,3 +100,16 @@ func Test_Todo_ControllerMock_Delete(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_Todo_ControllerMock_AdoptOrphans(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("AdoptOrphans", &AdoptOrphansCmd{OwnerID: "some-owner-id"}).Return(2, nil).Once()
+
+	count, err := mock.AdoptOrphans(context.Background(), &AdoptOrphansCmd{OwnerID: "some-owner-id"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	mock.AssertExpectations(t)
+}
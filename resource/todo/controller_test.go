@@ -15,12 +15,13 @@ func Test_Todo_Controller_Create(t *testing.T) {
 	storageMock := new(StorageMock)
 	handler := NewController(uuidMock, storageMock)
 
-	storageMock.On("FindOneByTitle", ValidTodo.Title).Return("", "", nil, nil).Once()
+	storageMock.On("FindOneByTitle", ValidTodo.OwnerID, ValidTodo.Title).Return("", "", nil, nil).Once()
 	uuidMock.On("New").Return(ValidTodoID).Once()
 	storageMock.On("Set", ValidTodoID, "", &ValidTodo).Return("some-rev", nil).Once()
 
 	id, err := handler.Create(context.Background(), &CreateCmd{
-		Title: ValidTodo.Title,
+		OwnerID: ValidTodo.OwnerID,
+		Title:   ValidTodo.Title,
 	})
 
 	assert.NoError(t, err)
@@ -36,7 +37,8 @@ func Test_Todo_Controller_Create_with_validationError(t *testing.T) {
 	handler := NewController(uuidMock, storageMock)
 
 	id, err := handler.Create(context.Background(), &CreateCmd{
-		Title: "i", // title too short
+		OwnerID: ValidTodo.OwnerID,
+		Title:   "i", // title too short
 	})
 
 	assert.Empty(t, id)
@@ -56,10 +58,11 @@ func Test_Todo_Controller_Create_with_storage_get_error(t *testing.T) {
 	storageMock := new(StorageMock)
 	handler := NewController(uuidMock, storageMock)
 
-	storageMock.On("FindOneByTitle", ValidTodo.Title).Return("", "", nil, fmt.Errorf("some-error")).Once()
+	storageMock.On("FindOneByTitle", ValidTodo.OwnerID, ValidTodo.Title).Return("", "", nil, fmt.Errorf("some-error")).Once()
 
 	id, err := handler.Create(context.Background(), &CreateCmd{
-		Title: ValidTodo.Title,
+		OwnerID: ValidTodo.OwnerID,
+		Title:   ValidTodo.Title,
 	})
 
 	assert.Empty(t, id)
@@ -81,10 +84,11 @@ func Test_Todo_Controller_Create_with_title_already_taken(t *testing.T) {
 	storageMock := new(StorageMock)
 	handler := NewController(uuidMock, storageMock)
 
-	storageMock.On("FindOneByTitle", ValidTodo.Title).Return("some-id", "some-rev", &ValidTodo, nil).Once()
+	storageMock.On("FindOneByTitle", ValidTodo.OwnerID, ValidTodo.Title).Return("some-id", "some-rev", &ValidTodo, nil).Once()
 
 	id, err := handler.Create(context.Background(), &CreateCmd{
-		Title: ValidTodo.Title,
+		OwnerID: ValidTodo.OwnerID,
+		Title:   ValidTodo.Title,
 	})
 
 	assert.Empty(t, id)
@@ -104,12 +108,13 @@ func Test_Todo_Controller_Create_with_storage_error(t *testing.T) {
 	storageMock := new(StorageMock)
 	handler := NewController(uuidMock, storageMock)
 
-	storageMock.On("FindOneByTitle", ValidTodo.Title).Return("", "", nil, nil).Once()
+	storageMock.On("FindOneByTitle", ValidTodo.OwnerID, ValidTodo.Title).Return("", "", nil, nil).Once()
 	uuidMock.On("New").Return(ValidTodoID).Once() // one time for the id / one time for the secret
 	storageMock.On("Set", ValidTodoID, "", &ValidTodo).Return("", fmt.Errorf("some-error")).Once()
 
 	id, err := handler.Create(context.Background(), &CreateCmd{
-		Title: ValidTodo.Title,
+		OwnerID: ValidTodo.OwnerID,
+		Title:   ValidTodo.Title,
 	})
 
 	assert.Empty(t, id)
@@ -208,6 +213,25 @@ func Test_Todo_Controller_Get_with_resource_notFound(t *testing.T) {
 	storageMock.AssertExpectations(t)
 }
 
+func Test_Todo_Controller_Get_owned_by_another_user(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	handler := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", ValidTodoID).Return("some-rev", &ValidTodo, nil).Once()
+
+	res, err := handler.Get(context.Background(), &GetCmd{
+		TodoID:  ValidTodoID,
+		OwnerID: "1f1c5ae4-8b3c-4a36-9c7c-3bd4e5e0f5a1",
+	})
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
 func Test_Todo_Controller_GetAll(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
@@ -254,6 +278,49 @@ func Test_Todo_Controller_GetAll_with_storage_error(t *testing.T) {
 	storageMock.AssertExpectations(t)
 }
 
+func Test_Todo_Controller_GetAll_for_an_owner(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("FindAllByOwner", ValidTodo.OwnerID).Return(map[string]Todo{
+		"some-rev": ValidTodo,
+	}, nil).Once()
+
+	res, err := controller.GetAll(context.Background(), &GetAllCmd{
+		OwnerID: ValidTodo.OwnerID,
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Todo{
+		"some-rev": ValidTodo,
+	}, res)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Todo_Controller_GetAll_with_validationError(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	res, err := controller.GetAll(context.Background(), &GetAllCmd{
+		OwnerID: "not-an-id",
+	})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"ownerID": "INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
 func Test_Todo_Controller_Delete(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
@@ -308,3 +375,114 @@ func Test_Todo_Controller_Delete_with_storage_error(t *testing.T) {
 	uuidMock.AssertExpectations(t)
 	storageMock.AssertExpectations(t)
 }
+
+func Test_Todo_Controller_Delete_for_an_owner(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", "some-id").Return("some-rev", &ValidTodo, nil).Once()
+	storageMock.On("Delete", "some-id").Return(nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		TodoID:  "some-id",
+		OwnerID: ValidTodo.OwnerID,
+	})
+
+	assert.NoError(t, err)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Todo_Controller_Delete_owned_by_another_user(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("Get", "some-id").Return("some-rev", &ValidTodo, nil).Once()
+
+	err := controller.Delete(context.Background(), &DeleteCmd{
+		TodoID:  "some-id",
+		OwnerID: "1f1c5ae4-8b3c-4a36-9c7c-3bd4e5e0f5a1",
+	})
+
+	assert.NoError(t, err)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Todo_Controller_AdoptOrphans(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	orphan := ValidTodo
+	orphan.OwnerID = ""
+
+	storageMock.On("FindAllWithoutOwner").Return(map[string]Todo{
+		"some-id":         orphan,
+		"some-deleted-id": orphan,
+	}, nil).Once()
+	storageMock.On("Get", "some-id").Return("some-rev", &orphan, nil).Once()
+	storageMock.On("Get", "some-deleted-id").Return("", nil, nil).Once()
+	storageMock.On("Set", "some-id", "some-rev", &ValidTodo).Return("some-rev-2", nil).Once()
+	storageMock.On("FindAllWithoutOwner").Return(map[string]Todo{}, nil).Once()
+
+	count, err := controller.AdoptOrphans(context.Background(), &AdoptOrphansCmd{
+		OwnerID: ValidTodo.OwnerID,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Todo_Controller_AdoptOrphans_with_validationError(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	count, err := controller.AdoptOrphans(context.Background(), &AdoptOrphansCmd{
+		OwnerID: "not-an-id",
+	})
+
+	assert.Equal(t, 0, count)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"ownerID": "INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Todo_Controller_AdoptOrphans_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, storageMock)
+
+	storageMock.On("FindAllWithoutOwner").Return(nil, errors.New("some-error")).Once()
+
+	count, err := controller.AdoptOrphans(context.Background(), &AdoptOrphansCmd{
+		OwnerID: ValidTodo.OwnerID,
+	})
+
+	assert.Equal(t, 0, count)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to find the todos without owner",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	uuidMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
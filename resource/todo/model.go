@@ -1,24 +1,46 @@
 package todo
 
 type Todo struct {
+	// ID of the user owning the todo.
+	OwnerID string `json:"ownerID"`
+
 	Title string `json:"name"`
 }
 
-type GetAllCmd struct{}
+type GetAllCmd struct {
+	// Restrict the todos to the ones owned by this user. All the todos
+	// are returned if empty.
+	OwnerID string
+}
 
 type GetCmd struct {
 	TodoID string
+
+	// Hide the todo if not owned by this user. No check is done if
+	// empty.
+	OwnerID string
 }
 
 type DeleteCmd struct {
 	TodoID string
+
+	// Don't delete the todo if not owned by this user. No check is done
+	// if empty.
+	OwnerID string
+}
+
+// AdoptOrphansCmd gives the todos without owner to a user.
+type AdoptOrphansCmd struct {
+	OwnerID string
 }
 
 type CreateCmd struct {
-	Title string
+	OwnerID string
+	Title   string
 }
 
 var ValidTodoID = "8c21296d-fbe8-4ddd-aa09-a888a06d66b7"
 var ValidTodo = Todo{
-	Title: "Jane Doe",
+	OwnerID: "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+	Title:   "Jane Doe",
 }
@@ -15,28 +15,41 @@ type Storage struct {
 }
 
 func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
-	db, err := server.CreateDatabase(ctx, &yaccc.CreateDatabaseCmd{
-		Name: BucketName,
-		DesignDocuments: map[string]yaccc.DesignDocument{
-			"default": {
-				Language: yaccc.Javascript,
-				Views: map[string]yaccc.View{
-					"by_title": {
-						Map: `function (doc, meta) {
-							if (doc.title) {
-								emit(doc.title, null);
-							}
-						}`,
-					},
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_title": {
+					Map: `function (doc, meta) {
+						if (doc.name) {
+							emit(doc.name, null);
+						}
+					}`,
+				},
+				"by_owner": {
+					Map: `function (doc, meta) {
+						if (doc.ownerID) {
+							emit(doc.ownerID, null);
+						}
+					}`,
+				},
+				"without_owner": {
+					Map: `function (doc, meta) {
+						if (!doc.ownerID) {
+							emit(null, null);
+						}
+					}`,
+				},
+				"by_owner_and_title": {
+					Map: `function (doc, meta) {
+						if (doc.ownerID && doc.name) {
+							emit([doc.ownerID, doc.name], null);
+						}
+					}`,
 				},
 			},
 		},
 	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create the database")
-	}
-
-	return db, nil
 }
 
 func NewStorage(driver db.Driver) *Storage {
@@ -98,35 +111,41 @@ func (t *Storage) GetAll(ctx context.Context) (map[string]Todo, error) {
 		return nil, errors.Wrap(err, "failed to query the view")
 	}
 
-	if len(viewResult) == 0 {
-		return map[string]Todo{}, nil
-	}
-
-	todoList := map[string]*Todo{}
-
-	for _, val := range viewResult {
-		todoList[val.ID] = &Todo{}
-	}
+	return t.getMany(ctx, viewResult)
+}
 
-	err = t.driver.GetMany(ctx, todoList)
+func (t *Storage) FindAllByOwner(ctx context.Context, ownerID string) (map[string]Todo, error) {
+	viewResult, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_owner",
+		Limit:     200,
+		Equals:    []interface{}{ownerID},
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get the documents")
+		return nil, errors.Wrap(err, "failed to query the view")
 	}
 
-	res := make(map[string]Todo, len(todoList))
+	return t.getMany(ctx, viewResult)
+}
 
-	for key, value := range todoList {
-		res[key] = *value
+// FindAllWithoutOwner returns the todos created before the todos were
+// bound to their owner.
+func (t *Storage) FindAllWithoutOwner(ctx context.Context) (map[string]Todo, error) {
+	viewResult, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "without_owner",
+		Limit:     200,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the view")
 	}
 
-	return res, nil
+	return t.getMany(ctx, viewResult)
 }
 
-func (t *Storage) FindOneByTitle(ctx context.Context, title string) (string, string, *Todo, error) {
+func (t *Storage) FindOneByTitle(ctx context.Context, ownerID string, title string) (string, string, *Todo, error) {
 	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
-		IndexName: "by_title",
+		IndexName: "by_owner_and_title",
 		Limit:     1,
-		Equals:    []interface{}{title},
+		Equals:    []interface{}{[]string{ownerID, title}},
 	})
 	if err != nil {
 		return "", "", nil, errors.Wrap(err, "failed to query the view")
@@ -144,3 +163,28 @@ func (t *Storage) FindOneByTitle(ctx context.Context, title string) (string, str
 
 	return res[0].ID, rev, &todo, nil
 }
+
+func (t *Storage) getMany(ctx context.Context, viewResult []db.ViewRow) (map[string]Todo, error) {
+	if len(viewResult) == 0 {
+		return map[string]Todo{}, nil
+	}
+
+	todoList := map[string]*Todo{}
+
+	for _, val := range viewResult {
+		todoList[val.ID] = &Todo{}
+	}
+
+	err := t.driver.GetMany(ctx, todoList)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the documents")
+	}
+
+	res := make(map[string]Todo, len(todoList))
+
+	for key, value := range todoList {
+		res[key] = *value
+	}
+
+	return res, nil
+}
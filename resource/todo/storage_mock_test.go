@@ -91,12 +91,42 @@ func Test_Todo_StorageMock_GetAll_with_an_error(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+func Test_Todo_StorageMock_FindAllByOwner(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByOwner", "some-owner-id").Return(map[string]Todo{
+		"some-id": ValidTodo,
+	}, nil).Once()
+
+	res, err := mock.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Todo{
+		"some-id": ValidTodo,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Todo_StorageMock_FindAllByOwner_with_an_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllByOwner", "some-owner-id").Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.Empty(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
 func Test_Todo_StorageMock_FindOneByTitle(t *testing.T) {
 	mock := new(StorageMock)
 
-	mock.On("FindOneByTitle", "some-name").Return("some-id", "some-rev", &ValidTodo, nil)
+	mock.On("FindOneByTitle", "some-owner-id", "some-title").Return("some-id", "some-rev", &ValidTodo, nil)
 
-	id, rev, res, err := mock.FindOneByTitle(context.Background(), "some-name")
+	id, rev, res, err := mock.FindOneByTitle(context.Background(), "some-owner-id", "some-title")
 
 	assert.NoError(t, err)
 	assert.Equal(t, "some-id", id)
@@ -109,9 +139,9 @@ func Test_Todo_StorageMock_FindOneByTitle(t *testing.T) {
 func Test_Todo_StorageMock_FindOneByTitle_with_error(t *testing.T) {
 	mock := new(StorageMock)
 
-	mock.On("FindOneByTitle", "some-name").Return("", "", nil, errors.New("some-error"))
+	mock.On("FindOneByTitle", "some-owner-id", "some-title").Return("", "", nil, errors.New("some-error"))
 
-	id, rev, res, err := mock.FindOneByTitle(context.Background(), "some-name")
+	id, rev, res, err := mock.FindOneByTitle(context.Background(), "some-owner-id", "some-title")
 
 	assert.Empty(t, rev)
 	assert.Empty(t, id)
@@ -120,3 +150,33 @@ func Test_Todo_StorageMock_FindOneByTitle_with_error(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_Todo_StorageMock_FindAllWithoutOwner(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllWithoutOwner").Return(map[string]Todo{
+		"some-id": ValidTodo,
+	}, nil).Once()
+
+	res, err := mock.FindAllWithoutOwner(context.Background())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Todo{
+		"some-id": ValidTodo,
+	}, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Todo_StorageMock_FindAllWithoutOwner_with_an_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindAllWithoutOwner").Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.FindAllWithoutOwner(context.Background())
+
+	assert.Empty(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
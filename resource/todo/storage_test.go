@@ -262,21 +262,72 @@ func Test_Todo_Storage_GetAll_with_GetMany_error(t *testing.T) {
 	dbDriver.AssertExpectations(t)
 }
 
+func Test_Todo_Storage_FindAllByOwner(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_owner",
+		Limit:     200,
+		Equals:    []interface{}{"some-owner-id"},
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+	}, nil).Once()
+
+	dbDriver.On("GetMany", []string{"some-id"}).Return(map[string]Todo{
+		"some-id": ValidTodo,
+	}, nil).Once()
+
+	res, err := service.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Todo{
+		"some-id": ValidTodo,
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_Todo_Storage_FindAllByOwner_with_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_owner",
+		Limit:     200,
+		Equals:    []interface{}{"some-owner-id"},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := service.FindAllByOwner(context.Background(), "some-owner-id")
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
 func Test_Todo_Storage_FindOneByTitle(t *testing.T) {
 	dbDriver := new(db.DriverMock)
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_title",
+		IndexName: "by_owner_and_title",
 		Limit:     1,
-		Equals:    []interface{}{"some-usertitle"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-title"}},
 	}).Return([]db.ViewRow{
 		{ID: "some-id"},
 	}, nil).Once()
 
 	dbDriver.On("Get", "some-id").Return("some-rev", &ValidTodo, nil).Once()
 
-	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-usertitle")
+	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-owner-id", "some-title")
 
 	assert.Equal(t, "some-id", id)
 	assert.Equal(t, "some-rev", rev)
@@ -291,12 +342,12 @@ func Test_Todo_Storage_FindOneByTitle_with_no_user_found(t *testing.T) {
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_title",
+		IndexName: "by_owner_and_title",
 		Limit:     1,
-		Equals:    []interface{}{"some-usertitle"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-title"}},
 	}).Return(nil, nil).Once()
 
-	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-usertitle")
+	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-owner-id", "some-title")
 
 	assert.Empty(t, id)
 	assert.Empty(t, rev)
@@ -311,12 +362,12 @@ func Test_Todo_Storage_FindOneByTitle_with_query_view_error(t *testing.T) {
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_title",
+		IndexName: "by_owner_and_title",
 		Limit:     1,
-		Equals:    []interface{}{"some-usertitle"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-title"}},
 	}).Return(nil, fmt.Errorf("some-error")).Once()
 
-	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-usertitle")
+	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-owner-id", "some-title")
 
 	assert.Empty(t, id)
 	assert.Empty(t, rev)
@@ -338,16 +389,16 @@ func Test_Todo_Storage_FindOneByTitle_with_get_error(t *testing.T) {
 	user := NewStorage(dbDriver)
 
 	dbDriver.On("ExecuteViewQuery", &db.Query{
-		IndexName: "by_title",
+		IndexName: "by_owner_and_title",
 		Limit:     1,
-		Equals:    []interface{}{"some-usertitle"},
+		Equals:    []interface{}{[]string{"some-owner-id", "some-title"}},
 	}).Return([]db.ViewRow{
 		{ID: "some-id"},
 	}, nil).Once()
 
 	dbDriver.On("Get", "some-id").Return("", nil, fmt.Errorf("some-error")).Once()
 
-	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-usertitle")
+	id, rev, res, err := user.FindOneByTitle(context.Background(), "some-owner-id", "some-title")
 
 	assert.Empty(t, id)
 	assert.Empty(t, rev)
@@ -363,3 +414,55 @@ func Test_Todo_Storage_FindOneByTitle_with_get_error(t *testing.T) {
 
 	dbDriver.AssertExpectations(t)
 }
+
+func Test_Todo_Storage_FindAllWithoutOwner(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	orphan := ValidTodo
+	orphan.OwnerID = ""
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "without_owner",
+		Limit:     200,
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+	}, nil).Once()
+
+	dbDriver.On("GetMany", []string{"some-id"}).Return(map[string]Todo{
+		"some-id": orphan,
+	}, nil).Once()
+
+	res, err := service.FindAllWithoutOwner(context.Background())
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]Todo{
+		"some-id": orphan,
+	}, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_Todo_Storage_FindAllWithoutOwner_with_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	service := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "without_owner",
+		Limit:     200,
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := service.FindAllWithoutOwner(context.Background())
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
@@ -2,7 +2,11 @@ package user
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/halium-project/go-server-utils/errors"
@@ -11,16 +15,27 @@ import (
 	"github.com/halium-project/go-server-utils/validator"
 	"github.com/halium-project/go-server-utils/validator/is"
 	"github.com/halium-project/server/db"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/secret"
+	"github.com/halium-project/server/utils/totp"
 	"gitlab.com/Peltoche/yaccc"
 )
 
 const bootstrapUsername = "admin"
 const bootstrapPasswort = "admin1234"
 
+// totpIssuer is the name displayed by the authenticator apps.
+const totpIssuer = "Halium"
+
+// recoveryCodeCount is the number of recovery codes given at the TOTP
+// enrollment.
+const recoveryCodeCount = 10
+
 type Controller struct {
 	uuid     uuid.Producer
 	storage  StorageInterface
 	password password.HashManager
+	clock    clock.Clock
 }
 
 type StorageInterface interface {
@@ -28,37 +43,36 @@ type StorageInterface interface {
 	Get(ctx context.Context, userID string) (string, *User, error)
 	GetAll(ctx context.Context) (map[string]User, error)
 	FindOneByUsername(ctx context.Context, username string) (string, string, *User, error)
+	FindOneByEmail(ctx context.Context, email string) (string, string, *User, error)
 	FindTotalUserCount(ctx context.Context) (int, error)
 	Delete(ctx context.Context, id string) error
 }
 
 func InitController(ctx context.Context, server *yaccc.Server) *Controller {
-	var requireBootstrap bool
+	// The first user is only created with a new database.
+	_, err := server.ConnectDatabase(ctx, BucketName)
+	requireBootstrap := err != nil
 
-	database, err := server.ConnectDatabase(ctx, BucketName)
+	database, err := SetupStorage(ctx, server)
 	if err != nil {
-		database, err = SetupStorage(ctx, server)
-		if err != nil {
-			log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
-		}
-
-		requireBootstrap = true
+		log.Fatal(errors.Wrapf(err, "failed to setup %q storage", BucketName))
 	}
 
 	storage := NewStorage(db.NewCouchdbDriver(database))
 	uuidProducer := uuid.NewGoUUID()
 	passwordProducer := password.NewPasswordHasher()
 
-	controller := NewController(uuidProducer, passwordProducer, storage)
+	controller := NewController(uuidProducer, passwordProducer, clock.NewDefault(), storage)
 
 	// Create the first "admin" user with full permission.
 	//
-	// WARNING: The password need to be changed after the first connection.
+	// The password must be changed at the first connection.
 	if requireBootstrap {
 		_, err := controller.Create(ctx, &CreateCmd{
-			Username: bootstrapUsername,
-			Password: bootstrapPasswort,
-			Role:     Admin,
+			Username:           bootstrapUsername,
+			Password:           bootstrapPasswort,
+			Role:               Admin,
+			MustChangePassword: true,
 		})
 		if err != nil {
 			log.Fatal(errors.Wrap(err, "failed to create the admin user"))
@@ -71,11 +85,13 @@ func InitController(ctx context.Context, server *yaccc.Server) *Controller {
 func NewController(
 	uuid uuid.Producer,
 	password password.HashManager,
+	clock clock.Clock,
 	storage StorageInterface,
 ) *Controller {
 	return &Controller{
 		uuid:     uuid,
 		password: password,
+		clock:    clock,
 		storage:  storage,
 	}
 }
@@ -88,6 +104,7 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error)
 			Admin,
 			Dev,
 		)).
+		CheckString("email", cmd.Email, is.Optional, is.Email).
 		Run()
 	if err != nil {
 		return "", err
@@ -98,6 +115,13 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error)
 		return "", err
 	}
 
+	if cmd.Email != "" {
+		err = t.validateEmailUniqueness(ctx, cmd.Email)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	// Generate the UUID
 	userID := t.uuid.New()
 	hash, salt, err := t.password.HashWithSalt(cmd.Password)
@@ -107,10 +131,12 @@ func (t *Controller) Create(ctx context.Context, cmd *CreateCmd) (string, error)
 
 	// Save the document
 	_, err = t.storage.Set(ctx, userID, "", &User{
-		Username: cmd.Username,
-		Role:     cmd.Role,
-		Password: hash,
-		Salt:     salt,
+		Username:           cmd.Username,
+		Role:               cmd.Role,
+		Email:              cmd.Email,
+		Password:           hash,
+		Salt:               salt,
+		MustChangePassword: cmd.MustChangePassword,
 	})
 	if err != nil {
 		return "", errors.Wrap(err, "failed to save the user")
@@ -135,6 +161,24 @@ func (t *Controller) Get(ctx context.Context, cmd *GetCmd) (*User, error) {
 	return user, nil
 }
 
+// GetByEmail returns the user owning the email address. It returns an empty
+// ID if no user is found.
+func (t *Controller) GetByEmail(ctx context.Context, cmd *GetByEmailCmd) (string, *User, error) {
+	err := validator.New().
+		CheckString("email", cmd.Email, is.Required, is.Email).
+		Run()
+	if err != nil {
+		return "", nil, err
+	}
+
+	userID, _, user, err := t.storage.FindOneByEmail(ctx, cmd.Email)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get the user")
+	}
+
+	return userID, user, nil
+}
+
 func (t *Controller) GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]User, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
@@ -155,6 +199,7 @@ func (t *Controller) Update(ctx context.Context, cmd *UpdateCmd) error {
 			Admin,
 			Dev,
 		)).
+		CheckString("email", cmd.Email, is.Optional, is.Email).
 		Run()
 	if err != nil {
 		return err
@@ -177,13 +222,26 @@ func (t *Controller) Update(ctx context.Context, cmd *UpdateCmd) error {
 
 	}
 
+	if cmd.Email != "" && user.Email != cmd.Email {
+		err = t.validateEmailUniqueness(ctx, cmd.Email)
+		if err != nil {
+			return err
+		}
+	}
+
 	_, err = t.storage.Set(ctx, cmd.UserID, rev, &User{
 		Username: cmd.Username,
 		Role:     cmd.Role,
+		Email:    cmd.Email,
 
 		// Don't touch this fields
-		Password: user.Password,
-		Salt:     user.Salt,
+		Password:           user.Password,
+		Salt:               user.Salt,
+		MustChangePassword: user.MustChangePassword,
+		TOTPSecret:         user.TOTPSecret,
+		TOTPEnabled:        user.TOTPEnabled,
+		TOTPLastStep:       user.TOTPLastStep,
+		RecoveryCodes:      user.RecoveryCodes,
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed to save the user")
@@ -192,6 +250,60 @@ func (t *Controller) Update(ctx context.Context, cmd *UpdateCmd) error {
 	return nil
 }
 
+// ChangePassword replaces the password of the user. The new password must
+// differ from the current one.
+func (t *Controller) ChangePassword(ctx context.Context, cmd *ChangePasswordCmd) error {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckString("currentPassword", cmd.CurrentPassword, is.Presence(!cmd.SkipCurrentPassword)).
+		CheckString("newPassword", cmd.NewPassword, is.Required, is.StringInRange(8, 256)).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, user, err := t.getUser(ctx, cmd.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.SkipCurrentPassword {
+		valid, err := t.password.ValidateWithSalt(cmd.CurrentPassword, user.Salt, user.Password)
+		if err != nil {
+			return errors.Wrap(err, "failed to compare the password with the hash")
+		}
+
+		if !valid {
+			return errors.NewValidationError().AddError("currentPassword", "INVALID").IntoError()
+		}
+	}
+
+	reused, err := t.password.ValidateWithSalt(cmd.NewPassword, user.Salt, user.Password)
+	if err != nil {
+		return errors.Wrap(err, "failed to compare the password with the hash")
+	}
+
+	if reused {
+		return errors.NewValidationError().AddError("newPassword", "ALREADY_USED").IntoError()
+	}
+
+	hash, salt, err := t.password.HashWithSalt(cmd.NewPassword)
+	if err != nil {
+		return errors.Wrap(err, "failed to hash the password")
+	}
+
+	user.Password = hash
+	user.Salt = salt
+	user.MustChangePassword = false
+
+	_, err = t.storage.Set(ctx, cmd.UserID, rev, user)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the user")
+	}
+
+	return nil
+}
+
 func (t *Controller) Validate(ctx context.Context, cmd *ValidateCmd) (string, *User, error) {
 	userID, _, user, err := t.storage.FindOneByUsername(ctx, cmd.Username)
 	if err != nil {
@@ -214,6 +326,219 @@ func (t *Controller) Validate(ctx context.Context, cmd *ValidateCmd) (string, *U
 	return userID, user, nil
 }
 
+// EnrollTOTP generates a new TOTP secret and new recovery codes for the
+// user. The two-factor authentication is only enabled once a first code is
+// given to ConfirmTOTP.
+func (t *Controller) EnrollTOTP(ctx context.Context, cmd *EnrollTOTPCmd) (*TOTPEnrollment, error) {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return nil, err
+	}
+
+	rev, user, err := t.getUser(ctx, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, errors.New(errors.BadRequest, "two-factor authentication already enabled")
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the TOTP secret")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the recovery codes")
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = hashes
+
+	_, err = t.storage.Set(ctx, cmd.UserID, rev, user)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save the user")
+	}
+
+	return &TOTPEnrollment{
+		Secret:        secret,
+		URI:           totp.URI(totpIssuer, user.Username, secret),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// ConfirmTOTP enables the two-factor authentication if the code matches the
+// secret generated by EnrollTOTP.
+func (t *Controller) ConfirmTOTP(ctx context.Context, cmd *ConfirmTOTPCmd) error {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckString("code", cmd.Code, is.Required).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, user, err := t.getUser(ctx, cmd.UserID)
+	if err != nil {
+		return err
+	}
+
+	if user.TOTPEnabled {
+		return errors.New(errors.BadRequest, "two-factor authentication already enabled")
+	}
+
+	if user.TOTPSecret == "" {
+		return errors.New(errors.BadRequest, "no two-factor authentication enrollment in progress")
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, cmd.Code, t.clock.Now())
+	if !ok {
+		return errors.NewValidationError().AddError("code", "INVALID").IntoError()
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+
+	_, err = t.storage.Set(ctx, cmd.UserID, rev, user)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the user")
+	}
+
+	return nil
+}
+
+// VerifyTOTP checks a TOTP code or a recovery code. The recovery codes can
+// only be used once.
+//
+// It returns false if the two-factor authentication is not enabled.
+func (t *Controller) VerifyTOTP(ctx context.Context, cmd *VerifyTOTPCmd) (bool, error) {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		Run()
+	if err != nil {
+		return false, err
+	}
+
+	rev, user, err := t.storage.Get(ctx, cmd.UserID)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to retrieve the user")
+	}
+
+	if user == nil || !user.TOTPEnabled || !t.checkSecondFactor(user, cmd.Code) {
+		return false, nil
+	}
+
+	_, err = t.storage.Set(ctx, cmd.UserID, rev, user)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to save the user")
+	}
+
+	return true, nil
+}
+
+// DisableTOTP disables the two-factor authentication and removes the secret
+// and the recovery codes.
+func (t *Controller) DisableTOTP(ctx context.Context, cmd *DisableTOTPCmd) error {
+	err := validator.New().
+		CheckString("userID", cmd.UserID, is.Required, is.ID).
+		CheckString("code", cmd.Code, is.Required).
+		Run()
+	if err != nil {
+		return err
+	}
+
+	rev, user, err := t.getUser(ctx, cmd.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return errors.New(errors.BadRequest, "two-factor authentication not enabled")
+	}
+
+	if !t.checkSecondFactor(user, cmd.Code) {
+		return errors.NewValidationError().AddError("code", "INVALID").IntoError()
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = nil
+
+	_, err = t.storage.Set(ctx, cmd.UserID, rev, user)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the user")
+	}
+
+	return nil
+}
+
+func (t *Controller) getUser(ctx context.Context, userID string) (string, *User, error) {
+	rev, user, err := t.storage.Get(ctx, userID)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to retrieve the user")
+	}
+
+	if user == nil {
+		return "", nil, errors.Errorf(errors.NotFound, "user %q not found", userID)
+	}
+
+	return rev, user, nil
+}
+
+// checkSecondFactor checks a TOTP code or a recovery code and updates the
+// user in order to prevent the code reuse.
+func (t *Controller) checkSecondFactor(user *User, code string) bool {
+	step, ok := totp.Validate(user.TOTPSecret, code, t.clock.Now())
+	if ok && step > user.TOTPLastStep {
+		user.TOTPLastStep = step
+		return true
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, recoveryCode := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(hash)) == 1 {
+			user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// newRecoveryCodes generates the recovery codes given to the user and the
+// hashes saved in the storage.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		raw := make([]byte, 5)
+
+		_, err := rand.Read(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.Replace(strings.TrimSpace(code), "-", "", -1))
+
+	return secret.Hash(normalized)
+}
+
 func (t *Controller) GetTotalUserCount(ctx context.Context) (int, error) {
 	nbUsers, err := t.storage.FindTotalUserCount(ctx)
 	if err != nil {
@@ -236,6 +561,19 @@ func (t *Controller) validateUsernameUniqueness(ctx context.Context, username st
 	return nil
 }
 
+func (t *Controller) validateEmailUniqueness(ctx context.Context, email string) error {
+	_, _, user, err := t.storage.FindOneByEmail(ctx, email)
+	if err != nil {
+		return errors.Wrap(err, "failed to check if the user email is already taken")
+	}
+
+	if user != nil {
+		return errors.NewValidationError().AddError("email", "ALREADY_USED").IntoError()
+	}
+
+	return nil
+}
+
 func (t *Controller) Delete(ctx context.Context, cmd *DeleteCmd) error {
 	err := validator.New().
 		CheckString("userID", cmd.UserID, is.Required, is.ID).
@@ -50,6 +50,16 @@ func (t *ControllerMock) Validate(ctx context.Context, cmd *ValidateCmd) (string
 	return args.String(0), args.Get(1).(*User), args.Error(2)
 }
 
+func (t *ControllerMock) GetByEmail(ctx context.Context, cmd *GetByEmailCmd) (string, *User, error) {
+	args := t.Called(cmd)
+
+	if args.Get(1) == nil {
+		return "", nil, args.Error(2)
+	}
+
+	return args.String(0), args.Get(1).(*User), args.Error(2)
+}
+
 func (t *ControllerMock) GetTotalUserCount(ctx context.Context) (int, error) {
 	args := t.Called()
 
@@ -59,3 +69,31 @@ func (t *ControllerMock) GetTotalUserCount(ctx context.Context) (int, error) {
 func (t *ControllerMock) Update(ctx context.Context, cmd *UpdateCmd) error {
 	return t.Called(cmd).Error(0)
 }
+
+func (t *ControllerMock) EnrollTOTP(ctx context.Context, cmd *EnrollTOTPCmd) (*TOTPEnrollment, error) {
+	args := t.Called(cmd)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*TOTPEnrollment), args.Error(1)
+}
+
+func (t *ControllerMock) ConfirmTOTP(ctx context.Context, cmd *ConfirmTOTPCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) VerifyTOTP(ctx context.Context, cmd *VerifyTOTPCmd) (bool, error) {
+	args := t.Called(cmd)
+
+	return args.Bool(0), args.Error(1)
+}
+
+func (t *ControllerMock) DisableTOTP(ctx context.Context, cmd *DisableTOTPCmd) error {
+	return t.Called(cmd).Error(0)
+}
+
+func (t *ControllerMock) ChangePassword(ctx context.Context, cmd *ChangePasswordCmd) error {
+	return t.Called(cmd).Error(0)
+}
@@ -150,6 +150,34 @@ func Test_User_ControllerMock_Validate_with_error(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+func Test_User_ControllerMock_GetByEmail(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetByEmail", &GetByEmailCmd{Email: "some-email@example.com"}).Return("some-user-id", &ValidUser, nil).Once()
+
+	userID, user, err := mock.GetByEmail(context.Background(), &GetByEmailCmd{Email: "some-email@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-user-id", userID)
+	assert.EqualValues(t, &ValidUser, user)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_User_ControllerMock_GetByEmail_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("GetByEmail", &GetByEmailCmd{Email: "some-email@example.com"}).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	userID, user, err := mock.GetByEmail(context.Background(), &GetByEmailCmd{Email: "some-email@example.com"})
+
+	assert.Empty(t, userID)
+	assert.Nil(t, user)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
 func Test_User_ControllerMock_GetTotalUserCount(t *testing.T) {
 	mock := new(ControllerMock)
 
@@ -192,3 +220,90 @@ func Test_Client_ControllerMock_Delete(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_User_ControllerMock_EnrollTOTP(t *testing.T) {
+	mock := new(ControllerMock)
+
+	enrollment := TOTPEnrollment{
+		Secret:        "some-secret",
+		URI:           "some-uri",
+		RecoveryCodes: []string{"some-code"},
+	}
+
+	mock.On("EnrollTOTP", &EnrollTOTPCmd{UserID: "some-user-id"}).Return(&enrollment, nil).Once()
+
+	res, err := mock.EnrollTOTP(context.Background(), &EnrollTOTPCmd{UserID: "some-user-id"})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, &enrollment, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_User_ControllerMock_EnrollTOTP_with_error(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("EnrollTOTP", &EnrollTOTPCmd{UserID: "some-user-id"}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := mock.EnrollTOTP(context.Background(), &EnrollTOTPCmd{UserID: "some-user-id"})
+
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_User_ControllerMock_ConfirmTOTP(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("ConfirmTOTP", &ConfirmTOTPCmd{UserID: "some-user-id", Code: "123456"}).Return(nil).Once()
+
+	err := mock.ConfirmTOTP(context.Background(), &ConfirmTOTPCmd{UserID: "some-user-id", Code: "123456"})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_User_ControllerMock_VerifyTOTP(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("VerifyTOTP", &VerifyTOTPCmd{UserID: "some-user-id", Code: "123456"}).Return(true, nil).Once()
+
+	valid, err := mock.VerifyTOTP(context.Background(), &VerifyTOTPCmd{UserID: "some-user-id", Code: "123456"})
+
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_User_ControllerMock_DisableTOTP(t *testing.T) {
+	mock := new(ControllerMock)
+
+	mock.On("DisableTOTP", &DisableTOTPCmd{UserID: "some-user-id", Code: "123456"}).Return(nil).Once()
+
+	err := mock.DisableTOTP(context.Background(), &DisableTOTPCmd{UserID: "some-user-id", Code: "123456"})
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_User_ControllerMock_ChangePassword(t *testing.T) {
+	mock := new(ControllerMock)
+
+	cmd := ChangePasswordCmd{
+		UserID:          "some-user-id",
+		CurrentPassword: "some-password",
+		NewPassword:     "some-new-password",
+	}
+
+	mock.On("ChangePassword", &cmd).Return(nil).Once()
+
+	err := mock.ChangePassword(context.Background(), &cmd)
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
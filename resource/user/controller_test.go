@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/halium-project/go-server-utils/password"
 	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/halium-project/server/utils/totp"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 const newUsername = "some-username"
@@ -16,8 +21,9 @@ const newUsername = "some-username"
 func Test_User_Controller_Create(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", ValidUser.Username).Return("", "", nil, nil).Once()
 	uuidMock.On("New").Return("some-user-id").Once()
@@ -38,11 +44,100 @@ func Test_User_Controller_Create(t *testing.T) {
 	passwordMock.AssertExpectations(t)
 }
 
+func Test_User_Controller_Create_with_an_email(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	newUser := ValidUser
+	newUser.Email = "some-email@example.com"
+
+	storageMock.On("FindOneByUsername", ValidUser.Username).Return("", "", nil, nil).Once()
+	storageMock.On("FindOneByEmail", "some-email@example.com").Return("", "", nil, nil).Once()
+	uuidMock.On("New").Return("some-user-id").Once()
+	passwordMock.On("HashWithSalt", "some-password").Return("some-hash", "some-salt", nil).Once()
+	storageMock.On("Set", "some-user-id", "", &newUser).Return("some-rev", nil).Once()
+
+	userID, err := controller.Create(context.Background(), &CreateCmd{
+		Username: ValidUser.Username,
+		Password: "some-password",
+		Role:     ValidUser.Role,
+		Email:    "some-email@example.com",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-user-id", userID)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_Create_with_an_invalid_email(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	userID, err := controller.Create(context.Background(), &CreateCmd{
+		Username: ValidUser.Username,
+		Password: "some-password",
+		Role:     ValidUser.Role,
+		Email:    "not an email",
+	})
+
+	assert.Empty(t, userID)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"email":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_Create_with_an_email_already_used(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByUsername", ValidUser.Username).Return("", "", nil, nil).Once()
+	storageMock.On("FindOneByEmail", "some-email@example.com").Return("some-id", "some-rev", &ValidUser, nil).Once()
+
+	userID, err := controller.Create(context.Background(), &CreateCmd{
+		Username: ValidUser.Username,
+		Password: "some-password",
+		Role:     ValidUser.Role,
+		Email:    "some-email@example.com",
+	})
+
+	assert.Empty(t, userID)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"email":"ALREADY_USED"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
 func Test_User_Controller_Create_with_validation_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	userID, err := controller.Create(context.Background(), &CreateCmd{
 		Username: ValidUser.Username,
@@ -66,8 +161,9 @@ func Test_User_Controller_Create_with_validation_error(t *testing.T) {
 func Test_User_Controller_Create_storage_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", ValidUser.Username).Return("", "", nil, nil).Once()
 	uuidMock.On("New").Return("some-user-id").Once()
@@ -99,8 +195,9 @@ func Test_User_Controller_Create_storage_error(t *testing.T) {
 func Test_User_Controller_Create_password_hash_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", ValidUser.Username).Return("", "", nil, nil).Once()
 	uuidMock.On("New").Return("some-user-id").Once()
@@ -131,8 +228,9 @@ func Test_User_Controller_Create_password_hash_error(t *testing.T) {
 func Test_User_Controller_Get(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Get", "343c18cd-3bfc-48d0-bcad-180ce34dc948").Return("some-rev", &ValidUser, nil).Once()
 
@@ -151,8 +249,9 @@ func Test_User_Controller_Get(t *testing.T) {
 func Test_User_Controller_Get_with_validationError(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	res, err := controller.Get(context.Background(), &GetCmd{
 		UserID: "not a valid id",
@@ -174,8 +273,9 @@ func Test_User_Controller_Get_with_validationError(t *testing.T) {
 func Test_User_Controller_Get_driver_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Get", "343c18cd-3bfc-48d0-bcad-180ce34dc948").Return("", nil, fmt.Errorf("some-error")).Once()
 
@@ -198,11 +298,128 @@ func Test_User_Controller_Get_driver_error(t *testing.T) {
 	passwordMock.AssertExpectations(t)
 }
 
+func Test_User_Controller_GetByEmail(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByEmail", "some-email@example.com").Return(ValidUserID, "some-rev", &ValidUser, nil).Once()
+
+	userID, res, err := controller.GetByEmail(context.Background(), &GetByEmailCmd{
+		Email: "some-email@example.com",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ValidUserID, userID)
+	assert.EqualValues(t, &ValidUser, res)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_GetByEmail_with_no_user_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByEmail", "some-email@example.com").Return("", "", nil, nil).Once()
+
+	userID, res, err := controller.GetByEmail(context.Background(), &GetByEmailCmd{
+		Email: "some-email@example.com",
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, userID)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_GetByEmail_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	userID, res, err := controller.GetByEmail(context.Background(), &GetByEmailCmd{
+		Email: "not an email",
+	})
+
+	assert.Empty(t, userID)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors": {
+			"email":"INVALID_FORMAT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_GetByEmail_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("FindOneByEmail", "some-email@example.com").Return("", "", nil, fmt.Errorf("some-error")).Once()
+
+	userID, res, err := controller.GetByEmail(context.Background(), &GetByEmailCmd{
+		Email: "some-email@example.com",
+	})
+
+	assert.Empty(t, userID)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message": "failed to get the user",
+		"reason": {
+			"kind": "internalError",
+			"message": "some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_Update_with_a_new_email(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("Get", ValidUserID).Return("some-rev", &ValidUser, nil).Once()
+	storageMock.On("FindOneByEmail", "some-email@example.com").Return("", "", nil, nil).Once()
+
+	newUser := ValidUser
+	newUser.Email = "some-email@example.com"
+	storageMock.On("Set", ValidUserID, "some-rev", &newUser).Return("some-new-rev", nil).Once()
+
+	err := controller.Update(context.Background(), &UpdateCmd{
+		UserID:   ValidUserID,
+		Username: ValidUser.Username,
+		Role:     ValidUser.Role,
+		Email:    "some-email@example.com",
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+}
+
 func Test_User_Controller_GetAll(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("GetAll").Return(map[string]User{
 		"some-rev":   ValidUser,
@@ -226,8 +443,9 @@ func Test_User_Controller_GetAll(t *testing.T) {
 func Test_User_Controller_GetAll_with_storage_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("GetAll").Return(nil, errors.New("some-error")).Once()
 
@@ -250,9 +468,10 @@ func Test_User_Controller_GetAll_with_storage_error(t *testing.T) {
 
 func Test_User_Controller_Validate(t *testing.T) {
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", "some-username").Return("some-user-id", "some-rev", &ValidUser, nil).Once()
 
@@ -274,9 +493,10 @@ func Test_User_Controller_Validate(t *testing.T) {
 
 func Test_User_Controller_Validate_with_credentials_storage_error(t *testing.T) {
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", "some-username").Return("", "", nil, fmt.Errorf("some-error")).Once()
 
@@ -302,9 +522,10 @@ func Test_User_Controller_Validate_with_credentials_storage_error(t *testing.T)
 
 func Test_User_Controller_Validate_with_unknown_username(t *testing.T) {
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", "some-invalid-username").Return("", "", nil, nil).Once()
 
@@ -324,9 +545,10 @@ func Test_User_Controller_Validate_with_unknown_username(t *testing.T) {
 
 func Test_User_Controller_Validate_with_password_validationError(t *testing.T) {
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", "some-username").Return("some-user-id", "some-rev", &ValidUser, nil).Once()
 
@@ -354,9 +576,10 @@ func Test_User_Controller_Validate_with_password_validationError(t *testing.T) {
 
 func Test_User_Controller_Validate_with_invalid_password(t *testing.T) {
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", "some-username").Return("some-user-id", "some-rev", &ValidUser, nil).Once()
 
@@ -378,9 +601,10 @@ func Test_User_Controller_Validate_with_invalid_password(t *testing.T) {
 
 func Test_User_Controller_GetTotalUserCount(t *testing.T) {
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindTotalUserCount").Return(42, nil).Once()
 
@@ -396,9 +620,10 @@ func Test_User_Controller_GetTotalUserCount(t *testing.T) {
 
 func Test_User_Controller_GetTotalUserCount_with_error(t *testing.T) {
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	uuidMock := new(uuid.ProducerMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindTotalUserCount").Return(0, fmt.Errorf("some-error")).Once()
 
@@ -422,8 +647,9 @@ func Test_User_Controller_GetTotalUserCount_with_error(t *testing.T) {
 func Test_User_Controller_Create_with_username_checking_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", ValidUser.Username).Return("", "", nil, fmt.Errorf("some-error")).Once()
 
@@ -451,8 +677,9 @@ func Test_User_Controller_Create_with_username_checking_error(t *testing.T) {
 func Test_User_Controller_Create_with_username_already_taken(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("FindOneByUsername", ValidUser.Username).Return("some-rev", "some-id", &ValidUser, nil).Once()
 
@@ -478,8 +705,9 @@ func Test_User_Controller_Create_with_username_already_taken(t *testing.T) {
 func Test_User_Controller_Update_with_validation_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	err := controller.Update(context.Background(), &UpdateCmd{
 		UserID:   "not a valid id",
@@ -502,8 +730,9 @@ func Test_User_Controller_Update_with_validation_error(t *testing.T) {
 func Test_User_Controller_Update_with_storage_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Get", "e16edc95-2063-4fc9-9f46-1431a0ddd6fa").Return("", nil, fmt.Errorf("some-error")).Once()
 
@@ -530,8 +759,9 @@ func Test_User_Controller_Update_with_storage_error(t *testing.T) {
 func Test_User_Controller_Update_with_username_validation_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Get", "e16edc95-2063-4fc9-9f46-1431a0ddd6fa").Return("some-rev", &ValidUser, nil).Once()
 	storageMock.On("FindOneByUsername", newUsername).Return("", "", nil, fmt.Errorf("some-error")).Once()
@@ -559,8 +789,9 @@ func Test_User_Controller_Update_with_username_validation_error(t *testing.T) {
 func Test_User_Controller_Update_with_user_not_found(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Get", "e16edc95-2063-4fc9-9f46-1431a0ddd6fa").Return("", nil, nil).Once()
 
@@ -582,8 +813,9 @@ func Test_User_Controller_Update_with_user_not_found(t *testing.T) {
 func Test_User_Controller_Update(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Get", "e16edc95-2063-4fc9-9f46-1431a0ddd6fa").Return("some-rev", &ValidUser, nil).Once()
 	storageMock.On("FindOneByUsername", newUsername).Return("", "", nil, nil).Once()
@@ -608,8 +840,9 @@ func Test_User_Controller_Update(t *testing.T) {
 func Test_User_Controller_Update_with_set_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Get", "e16edc95-2063-4fc9-9f46-1431a0ddd6fa").Return("some-rev", &ValidUser, nil).Once()
 	storageMock.On("FindOneByUsername", newUsername).Return("", "", nil, nil).Once()
@@ -638,11 +871,191 @@ func Test_User_Controller_Update_with_set_error(t *testing.T) {
 	passwordMock.AssertExpectations(t)
 }
 
+func Test_User_Controller_ChangePassword(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.MustChangePassword = true
+
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	passwordMock.On("ValidateWithSalt", "some-password", "some-salt", "some-hash").Return(true, nil).Once()
+	passwordMock.On("ValidateWithSalt", "some-new-password", "some-salt", "some-hash").Return(false, nil).Once()
+	passwordMock.On("HashWithSalt", "some-new-password").Return("some-new-hash", "some-new-salt", nil).Once()
+
+	newUser := ValidUser
+	newUser.Password = "some-new-hash"
+	newUser.Salt = "some-new-salt"
+	storageMock.On("Set", ValidUserID, "some-rev", &newUser).Return("some-new-rev", nil).Once()
+
+	err := controller.ChangePassword(context.Background(), &ChangePasswordCmd{
+		UserID:          ValidUserID,
+		CurrentPassword: "some-password",
+		NewPassword:     "some-new-password",
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ChangePassword_without_the_current_password(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	passwordMock.On("ValidateWithSalt", "some-new-password", "some-salt", "some-hash").Return(false, nil).Once()
+	passwordMock.On("HashWithSalt", "some-new-password").Return("some-new-hash", "some-new-salt", nil).Once()
+
+	newUser := ValidUser
+	newUser.Password = "some-new-hash"
+	newUser.Salt = "some-new-salt"
+	storageMock.On("Set", ValidUserID, "some-rev", &newUser).Return("some-new-rev", nil).Once()
+
+	err := controller.ChangePassword(context.Background(), &ChangePasswordCmd{
+		UserID:              ValidUserID,
+		NewPassword:         "some-new-password",
+		SkipCurrentPassword: true,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ChangePassword_with_validation_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	err := controller.ChangePassword(context.Background(), &ChangePasswordCmd{
+		UserID:      ValidUserID,
+		NewPassword: "short",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"currentPassword":"MISSING_FIELD",
+			"newPassword":"TOO_SHORT"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ChangePassword_with_an_invalid_current_password(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	passwordMock.On("ValidateWithSalt", "some-invalid-password", "some-salt", "some-hash").Return(false, nil).Once()
+
+	err := controller.ChangePassword(context.Background(), &ChangePasswordCmd{
+		UserID:          ValidUserID,
+		CurrentPassword: "some-invalid-password",
+		NewPassword:     "some-new-password",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"currentPassword":"INVALID"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ChangePassword_with_the_same_password(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	passwordMock.On("ValidateWithSalt", "some-password", "some-salt", "some-hash").Return(true, nil).Twice()
+
+	err := controller.ChangePassword(context.Background(), &ChangePasswordCmd{
+		UserID:          ValidUserID,
+		CurrentPassword: "some-password",
+		NewPassword:     "some-password",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"newPassword":"ALREADY_USED"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ChangePassword_with_set_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	passwordMock.On("ValidateWithSalt", "some-new-password", "some-salt", "some-hash").Return(false, nil).Once()
+	passwordMock.On("HashWithSalt", "some-new-password").Return("some-new-hash", "some-new-salt", nil).Once()
+	storageMock.On("Set", ValidUserID, "some-rev", mock.Anything).Return("", fmt.Errorf("some-error")).Once()
+
+	err := controller.ChangePassword(context.Background(), &ChangePasswordCmd{
+		UserID:              ValidUserID,
+		NewPassword:         "some-new-password",
+		SkipCurrentPassword: true,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message": "failed to save the user",
+		"reason": {
+			"kind": "internalError",
+			"message": "some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+	passwordMock.AssertExpectations(t)
+}
+
 func Test_User_Controller_Delete(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Delete", ValidUserID).Return(nil).Once()
 
@@ -658,8 +1071,9 @@ func Test_User_Controller_Delete(t *testing.T) {
 func Test_User_Controller_Delete_with_a_validation_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	err := controller.Delete(context.Background(), &DeleteCmd{UserID: ""})
 
@@ -678,8 +1092,9 @@ func Test_User_Controller_Delete_with_a_validation_error(t *testing.T) {
 func Test_User_Controller_Delete_with_storage_error(t *testing.T) {
 	uuidMock := new(uuid.ProducerMock)
 	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
 	storageMock := new(StorageMock)
-	controller := NewController(uuidMock, passwordMock, storageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
 
 	storageMock.On("Delete", ValidUserID).Return(errors.New("some-error")).Once()
 
@@ -698,3 +1113,393 @@ func Test_User_Controller_Delete_with_storage_error(t *testing.T) {
 	storageMock.AssertExpectations(t)
 	passwordMock.AssertExpectations(t)
 }
+
+// Secret "12345678901234567890" and code of the RFC 6238 test vectors.
+const (
+	totpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	totpCode   = "050471"
+)
+
+var totpNow = time.Unix(1111111111, 0)
+
+func Test_User_Controller_Update_keep_the_two_factor_authentication(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+	user.TOTPEnabled = true
+	user.TOTPLastStep = 42
+	user.RecoveryCodes = []string{hashRecoveryCode("abcd-efgh")}
+
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+
+	newUser := user
+	newUser.Role = Dev
+	storageMock.On("Set", ValidUserID, "some-rev", &newUser).Return("some-new-rev", nil).Once()
+
+	err := controller.Update(context.Background(), &UpdateCmd{
+		UserID:   ValidUserID,
+		Username: user.Username,
+		Role:     Dev,
+	})
+
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_EnrollTOTP(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+
+	var saved *User
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	storageMock.On("Set", ValidUserID, "some-rev", mock.MatchedBy(func(value *User) bool {
+		saved = value
+		return true
+	})).Return("some-new-rev", nil).Once()
+
+	res, err := controller.EnrollTOTP(context.Background(), &EnrollTOTPCmd{UserID: ValidUserID})
+
+	assert.NoError(t, err)
+	require.NotNil(t, res)
+	assert.NotEmpty(t, res.Secret)
+	assert.Equal(t, totp.URI("Halium", ValidUser.Username, res.Secret), res.URI)
+	assert.Len(t, res.RecoveryCodes, 10)
+
+	// The two-factor authentication is only enabled at the confirmation.
+	require.NotNil(t, saved)
+	assert.Equal(t, res.Secret, saved.TOTPSecret)
+	assert.False(t, saved.TOTPEnabled)
+	assert.Len(t, saved.RecoveryCodes, 10)
+	assert.Equal(t, hashRecoveryCode(res.RecoveryCodes[0]), saved.RecoveryCodes[0])
+	assert.NotContains(t, saved.RecoveryCodes, res.RecoveryCodes[0])
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_EnrollTOTP_with_the_two_factor_authentication_enabled(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+	user.TOTPEnabled = true
+
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+
+	res, err := controller.EnrollTOTP(context.Background(), &EnrollTOTPCmd{UserID: ValidUserID})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"badRequest",
+		"message":"two-factor authentication already enabled"
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_EnrollTOTP_with_user_not_found(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("Get", ValidUserID).Return("", nil, nil).Once()
+
+	res, err := controller.EnrollTOTP(context.Background(), &EnrollTOTPCmd{UserID: ValidUserID})
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"user \"ae6ac8d6-0bcf-4671-a21a-49eab3167cbb\" not found"
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ConfirmTOTP(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+
+	newUser := user
+	newUser.TOTPEnabled = true
+	newUser.TOTPLastStep = totp.StepAt(totpNow)
+
+	clockMock.On("Now").Return(totpNow).Once()
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	storageMock.On("Set", ValidUserID, "some-rev", &newUser).Return("some-new-rev", nil).Once()
+
+	err := controller.ConfirmTOTP(context.Background(), &ConfirmTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ConfirmTOTP_with_an_invalid_code(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+
+	clockMock.On("Now").Return(totpNow.Add(time.Hour)).Once()
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+
+	err := controller.ConfirmTOTP(context.Background(), &ConfirmTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"code":"INVALID"
+		}
+	}`, err.Error())
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_ConfirmTOTP_without_enrollment(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("Get", ValidUserID).Return("some-rev", &ValidUser, nil).Once()
+
+	err := controller.ConfirmTOTP(context.Background(), &ConfirmTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"badRequest",
+		"message":"no two-factor authentication enrollment in progress"
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_VerifyTOTP(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+	user.TOTPEnabled = true
+
+	newUser := user
+	newUser.TOTPLastStep = totp.StepAt(totpNow)
+
+	clockMock.On("Now").Return(totpNow).Once()
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	storageMock.On("Set", ValidUserID, "some-rev", &newUser).Return("some-new-rev", nil).Once()
+
+	valid, err := controller.VerifyTOTP(context.Background(), &VerifyTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_VerifyTOTP_with_a_code_already_used(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+	user.TOTPEnabled = true
+	user.TOTPLastStep = totp.StepAt(totpNow)
+
+	clockMock.On("Now").Return(totpNow).Once()
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+
+	valid, err := controller.VerifyTOTP(context.Background(), &VerifyTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_VerifyTOTP_with_a_recovery_code(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+	user.TOTPEnabled = true
+	user.RecoveryCodes = []string{hashRecoveryCode("abcd-efgh"), hashRecoveryCode("ijkl-mnop")}
+
+	// The recovery code can't be used again.
+	newUser := user
+	newUser.RecoveryCodes = []string{hashRecoveryCode("ijkl-mnop")}
+
+	clockMock.On("Now").Return(totpNow).Once()
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	storageMock.On("Set", ValidUserID, "some-rev", &newUser).Return("some-new-rev", nil).Once()
+
+	valid, err := controller.VerifyTOTP(context.Background(), &VerifyTOTPCmd{
+		UserID: ValidUserID,
+		Code:   "ABCD-EFGH",
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_VerifyTOTP_without_two_factor_authentication(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("Get", ValidUserID).Return("some-rev", &ValidUser, nil).Once()
+
+	valid, err := controller.VerifyTOTP(context.Background(), &VerifyTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_VerifyTOTP_with_storage_error(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	storageMock.On("Get", ValidUserID).Return("", nil, errors.New("some-error")).Once()
+
+	valid, err := controller.VerifyTOTP(context.Background(), &VerifyTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.False(t, valid)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to retrieve the user",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_DisableTOTP(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+	user.TOTPEnabled = true
+	user.RecoveryCodes = []string{hashRecoveryCode("abcd-efgh")}
+
+	clockMock.On("Now").Return(totpNow).Once()
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+	storageMock.On("Set", ValidUserID, "some-rev", &ValidUser).Return("some-new-rev", nil).Once()
+
+	err := controller.DisableTOTP(context.Background(), &DisableTOTPCmd{
+		UserID: ValidUserID,
+		Code:   totpCode,
+	})
+
+	assert.NoError(t, err)
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
+
+func Test_User_Controller_DisableTOTP_with_an_invalid_code(t *testing.T) {
+	uuidMock := new(uuid.ProducerMock)
+	passwordMock := new(password.HashManagerMock)
+	clockMock := new(clock.ClockMock)
+	storageMock := new(StorageMock)
+	controller := NewController(uuidMock, passwordMock, clockMock, storageMock)
+
+	user := ValidUser
+	user.TOTPSecret = totpSecret
+	user.TOTPEnabled = true
+
+	clockMock.On("Now").Return(totpNow).Once()
+	storageMock.On("Get", ValidUserID).Return("some-rev", &user, nil).Once()
+
+	err := controller.DisableTOTP(context.Background(), &DisableTOTPCmd{
+		UserID: ValidUserID,
+		Code:   "000000",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"validationError",
+		"errors":{
+			"code":"INVALID"
+		}
+	}`, err.Error())
+
+	clockMock.AssertExpectations(t)
+	storageMock.AssertExpectations(t)
+}
@@ -21,6 +21,10 @@ type ControllerInterface interface {
 	Update(ctx context.Context, cmd *UpdateCmd) error
 	GetAll(ctx context.Context, cmd *GetAllCmd) (map[string]User, error)
 	Delete(ctx context.Context, cmd *DeleteCmd) error
+	EnrollTOTP(ctx context.Context, cmd *EnrollTOTPCmd) (*TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, cmd *ConfirmTOTPCmd) error
+	DisableTOTP(ctx context.Context, cmd *DisableTOTPCmd) error
+	ChangePassword(ctx context.Context, cmd *ChangePasswordCmd) error
 }
 
 func NewHTTPHandler(user ControllerInterface) *HTTPHandler {
@@ -35,6 +39,46 @@ func (t *HTTPHandler) RegisterRoutes(router *mux.Router, perm *permission.Contro
 	router.HandleFunc("/users/{userID}", perm.Check("users.write", t.Update)).Methods("PUT")
 	router.HandleFunc("/users/{userID}", perm.Check("users.write", t.Delete)).Methods("DELETE")
 	router.HandleFunc("/users/{userID}", perm.Check("users.read", t.Get)).Methods("GET")
+	// The users change their own password and manage their own two-factor
+	// authentication: the caller is checked by the handlers.
+	router.HandleFunc("/users/{userID}/password", perm.Check("account.write", t.ChangePassword)).Methods("PUT")
+	router.HandleFunc("/users/{userID}/2fa", perm.Check("account.write", t.EnrollTOTP)).Methods("POST")
+	router.HandleFunc("/users/{userID}/2fa", perm.Check("account.write", t.DisableTOTP)).Methods("DELETE")
+	router.HandleFunc("/users/{userID}/2fa/confirm", perm.Check("account.write", t.ConfirmTOTP)).Methods("POST")
+}
+
+// authorizeCaller returns the caller if it is the given user or an admin. The
+// error is written otherwise.
+func authorizeCaller(w http.ResponseWriter, r *http.Request, userID string, forbidden string) (*permission.User, bool) {
+	caller := permission.UserFromContext(r.Context())
+	if caller == nil {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "the access token is not bound to any user"))
+		return nil, false
+	}
+
+	if caller.Role != Admin && caller.ID != userID {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, forbidden))
+		return nil, false
+	}
+
+	return caller, true
+}
+
+// authorizeSelf returns the caller if it is the given user. The error is
+// written otherwise.
+func authorizeSelf(w http.ResponseWriter, r *http.Request, userID string, forbidden string) (*permission.User, bool) {
+	caller := permission.UserFromContext(r.Context())
+	if caller == nil {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, "the access token is not bound to any user"))
+		return nil, false
+	}
+
+	if caller.ID != userID {
+		errors.IntoResponse(w, errors.New(errors.Forbidden, forbidden))
+		return nil, false
+	}
+
+	return caller, true
 }
 
 func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +86,7 @@ func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Role     string `json:"role"`
 		Username string `json:"username"`
 		Password string `json:"password"`
+		Email    string `json:"email"`
 	}
 
 	type responseBody struct {
@@ -59,6 +104,7 @@ func (t *HTTPHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Role:     req.Role,
 		Username: req.Username,
 		Password: req.Password,
+		Email:    req.Email,
 	})
 
 	if err != nil {
@@ -75,6 +121,7 @@ func (t *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
 	type responseBody struct {
 		Username string `json:"username"`
 		Role     string `json:"role"`
+		Email    string `json:"email,omitempty"`
 	}
 
 	userID := mux.Vars(r)["userID"]
@@ -95,6 +142,7 @@ func (t *HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
 	response.Write(w, http.StatusOK, &responseBody{
 		Username: user.Username,
 		Role:     user.Role,
+		Email:    user.Email,
 	})
 }
 
@@ -102,6 +150,7 @@ func (t *HTTPHandler) Update(w http.ResponseWriter, r *http.Request) {
 	type request struct {
 		Role     string `json:"role"`
 		Username string `json:"username"`
+		Email    string `json:"email"`
 	}
 
 	var req request
@@ -115,6 +164,7 @@ func (t *HTTPHandler) Update(w http.ResponseWriter, r *http.Request) {
 		UserID:   mux.Vars(r)["userID"],
 		Role:     req.Role,
 		Username: req.Username,
+		Email:    req.Email,
 	})
 
 	if err != nil {
@@ -129,6 +179,7 @@ func (t *HTTPHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	type userRes struct {
 		Username string `json:"username"`
 		Role     string `json:"role"`
+		Email    string `json:"email,omitempty"`
 	}
 
 	res, err := t.user.GetAll(r.Context(), &GetAllCmd{})
@@ -143,6 +194,7 @@ func (t *HTTPHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		users[id] = userRes{
 			Username: user.Username,
 			Role:     user.Role,
+			Email:    user.Email,
 		}
 	}
 
@@ -163,3 +215,143 @@ func (t *HTTPHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	// Do not return the password and the salt.
 	response.Write(w, http.StatusOK, struct{}{})
 }
+
+// ChangePassword replaces the password of a user. The users must give their
+// current password, the admins can change any password without it.
+func (t *HTTPHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+
+	userID := mux.Vars(r)["userID"]
+
+	caller, ok := authorizeCaller(w, r, userID, "only the admins can change the password of another user")
+	if !ok {
+		return
+	}
+
+	isAdmin := caller.Role == Admin
+
+	var req request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		errors.IntoResponse(w, errors.New(errors.InvalidJSON, err.Error()))
+		return
+	}
+
+	err = t.user.ChangePassword(r.Context(), &ChangePasswordCmd{
+		UserID:              userID,
+		CurrentPassword:     req.CurrentPassword,
+		NewPassword:         req.NewPassword,
+		SkipCurrentPassword: isAdmin,
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusOK, &struct{}{})
+}
+
+// totpForbidden is returned when the users try to disable the two-factor
+// authentication of another user.
+const totpForbidden = "only the admins can manage the two-factor authentication of another user"
+
+// totpEnrollmentForbidden is returned when a caller tries to enroll the
+// two-factor authentication of another user. The admins are refused too as
+// the enrollment returns the secret and the recovery codes.
+const totpEnrollmentForbidden = "the two-factor authentication can only be enrolled by its user"
+
+// EnrollTOTP starts the two-factor authentication enrollment. The secret and
+// the recovery codes are only returned by this call.
+func (t *HTTPHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	type responseBody struct {
+		Secret        string   `json:"secret"`
+		URI           string   `json:"uri"`
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+
+	userID := mux.Vars(r)["userID"]
+
+	_, ok := authorizeSelf(w, r, userID, totpEnrollmentForbidden)
+	if !ok {
+		return
+	}
+
+	enrollment, err := t.user.EnrollTOTP(r.Context(), &EnrollTOTPCmd{
+		UserID: userID,
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusCreated, &responseBody{
+		Secret:        enrollment.Secret,
+		URI:           enrollment.URI,
+		RecoveryCodes: enrollment.RecoveryCodes,
+	})
+}
+
+func (t *HTTPHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Code string `json:"code"`
+	}
+
+	userID := mux.Vars(r)["userID"]
+
+	_, ok := authorizeSelf(w, r, userID, totpEnrollmentForbidden)
+	if !ok {
+		return
+	}
+
+	var req request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		errors.IntoResponse(w, errors.New(errors.InvalidJSON, err.Error()))
+		return
+	}
+
+	err = t.user.ConfirmTOTP(r.Context(), &ConfirmTOTPCmd{
+		UserID: userID,
+		Code:   req.Code,
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusOK, &struct{}{})
+}
+
+func (t *HTTPHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Code string `json:"code"`
+	}
+
+	userID := mux.Vars(r)["userID"]
+
+	_, ok := authorizeCaller(w, r, userID, totpForbidden)
+	if !ok {
+		return
+	}
+
+	var req request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		errors.IntoResponse(w, errors.New(errors.InvalidJSON, err.Error()))
+		return
+	}
+
+	err = t.user.DisableTOTP(r.Context(), &DisableTOTPCmd{
+		UserID: userID,
+		Code:   req.Code,
+	})
+	if err != nil {
+		errors.IntoResponse(w, err)
+		return
+	}
+
+	response.Write(w, http.StatusOK, &struct{}{})
+}
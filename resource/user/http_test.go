@@ -18,7 +18,7 @@ import (
 
 func Test_User_HTTPHandler_Create_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -56,7 +56,7 @@ func Test_User_HTTPHandler_Create_success(t *testing.T) {
 
 func Test_User_HTTPHandler_Create_with_an_invalid_json(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -87,7 +87,7 @@ func Test_User_HTTPHandler_Create_with_an_invalid_json(t *testing.T) {
 
 func Test_User_HTTPHandler_Create_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -128,7 +128,7 @@ func Test_User_HTTPHandler_Create_with_an_error_from_the_usecase(t *testing.T) {
 
 func Test_User_HTTPHandler_Get_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -159,9 +159,45 @@ func Test_User_HTTPHandler_Get_success(t *testing.T) {
 	accessTokenControllerMock.AssertExpectations(t)
 }
 
+func Test_User_HTTPHandler_Get_with_an_email(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	user := ValidUser
+	user.Email = "some-email@example.com"
+	controllerMock.On("Get", &GetCmd{UserID: "some-user-id"}).Return(&user, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/users/some-user-id", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{
+		"username": "some username",
+		"role": "admin",
+		"email": "some-email@example.com"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
 func Test_User_HTTPHandler_Get_with_the_user_not_found(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -194,7 +230,7 @@ func Test_User_HTTPHandler_Get_with_the_user_not_found(t *testing.T) {
 
 func Test_User_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -227,7 +263,7 @@ func Test_User_HTTPHandler_Get_with_an_error_from_the_usecase(t *testing.T) {
 
 func Test_User_HTTPHandler_Update_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -264,7 +300,7 @@ func Test_User_HTTPHandler_Update_success(t *testing.T) {
 
 func Test_User_HTTPHandler_Update_with_an_invalid_json_request(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -295,7 +331,7 @@ func Test_User_HTTPHandler_Update_with_an_invalid_json_request(t *testing.T) {
 
 func Test_User_HTTPHandler_Update_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -335,7 +371,7 @@ func Test_User_HTTPHandler_Update_with_an_error_from_the_usecase(t *testing.T) {
 
 func Test_User_HTTPHandler_GetAll_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -370,7 +406,7 @@ func Test_User_HTTPHandler_GetAll_success(t *testing.T) {
 
 func Test_User_HTTPHandler_GetAll_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -403,7 +439,7 @@ func Test_User_HTTPHandler_GetAll_with_an_error_from_the_usecase(t *testing.T) {
 
 func Test_User_HTTPHandler_Delete_success(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -433,7 +469,7 @@ func Test_User_HTTPHandler_Delete_success(t *testing.T) {
 
 func Test_User_HTTPHandler_Delete_with_an_error_from_the_usecase(t *testing.T) {
 	accessTokenControllerMock := new(accesstoken.ControllerMock)
-	perm := permission.NewController(context.Background(), accessTokenControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
 	router := mux.NewRouter()
 	controllerMock := new(ControllerMock)
 	handler := NewHTTPHandler(controllerMock)
@@ -463,3 +499,570 @@ func Test_User_HTTPHandler_Delete_with_an_error_from_the_usecase(t *testing.T) {
 	controllerMock.AssertExpectations(t)
 	accessTokenControllerMock.AssertExpectations(t)
 }
+
+func Test_User_HTTPHandler_EnrollTOTP_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("EnrollTOTP", &EnrollTOTPCmd{UserID: accesstoken.ValidAccessToken.UserID}).Return(&TOTPEnrollment{
+		Secret:        "some-secret",
+		URI:           "otpauth://totp/some-uri",
+		RecoveryCodes: []string{"abcd-efgh"},
+	}, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+accesstoken.ValidAccessToken.UserID+"/2fa", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.JSONEq(t, `{
+		"secret": "some-secret",
+		"uri": "otpauth://totp/some-uri",
+		"recoveryCodes": ["abcd-efgh"]
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_EnrollTOTP_of_the_caller(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Role = accesstoken.Dev
+	token.Scopes = []string{"account"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("EnrollTOTP", &EnrollTOTPCmd{UserID: token.UserID}).Return(&TOTPEnrollment{
+		Secret:        "some-secret",
+		URI:           "otpauth://totp/some-uri",
+		RecoveryCodes: []string{"abcd-efgh"},
+	}, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+token.UserID+"/2fa", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_EnrollTOTP_of_another_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/2fa", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "the two-factor authentication can only be enrolled by its user"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_EnrollTOTP_of_another_user_by_an_admin(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/2fa", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "the two-factor authentication can only be enrolled by its user"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ConfirmTOTP_of_another_user_by_an_admin(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/2fa/confirm", strings.NewReader(`{
+		"code": "123456"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_EnrollTOTP_with_a_read_only_token(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"users.read"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+token.UserID+"/2fa", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_DisableTOTP_of_another_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("DELETE", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/2fa", strings.NewReader(`{
+		"code": "abcd-efgh"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_EnrollTOTP_with_an_error_from_the_usecase(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("EnrollTOTP", &EnrollTOTPCmd{UserID: accesstoken.ValidAccessToken.UserID}).
+		Return(nil, errors.New(errors.BadRequest, "two-factor authentication already enabled")).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+accesstoken.ValidAccessToken.UserID+"/2fa", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "badRequest",
+		"message": "two-factor authentication already enabled"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ConfirmTOTP_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("ConfirmTOTP", &ConfirmTOTPCmd{UserID: accesstoken.ValidAccessToken.UserID, Code: "123456"}).Return(nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+accesstoken.ValidAccessToken.UserID+"/2fa/confirm", strings.NewReader(`{
+		"code": "123456"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ConfirmTOTP_with_an_invalid_json_request(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/users/"+accesstoken.ValidAccessToken.UserID+"/2fa/confirm", strings.NewReader(`not a json`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_DisableTOTP_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("DisableTOTP", &DisableTOTPCmd{UserID: "some-user-id", Code: "abcd-efgh"}).Return(nil).Once()
+
+	r := httptest.NewRequest("DELETE", "http://example.com/users/some-user-id/2fa", strings.NewReader(`{
+		"code": "abcd-efgh"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ChangePassword_of_the_caller(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("ChangePassword", &ChangePasswordCmd{
+		UserID:          token.UserID,
+		CurrentPassword: "some-password",
+		NewPassword:     "some-new-password",
+	}).Return(nil).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/users/"+token.UserID+"/password", strings.NewReader(`{
+		"currentPassword": "some-password",
+		"newPassword": "some-new-password"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ChangePassword_by_an_admin(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	controllerMock.On("ChangePassword", &ChangePasswordCmd{
+		UserID:              "e16edc95-2063-4fc9-9f46-1431a0ddd6fa",
+		NewPassword:         "some-new-password",
+		SkipCurrentPassword: true,
+	}).Return(nil).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/password", strings.NewReader(`{
+		"newPassword": "some-new-password"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ChangePassword_of_another_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/password", strings.NewReader(`{
+		"newPassword": "some-new-password"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "forbidden",
+		"message": "only the admins can change the password of another user"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ChangePassword_with_a_read_only_token(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"users.read"}
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/password", strings.NewReader(`{
+		"newPassword": "some-new-password"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "notAuthorized",
+		"message": "doesn't have required permission"
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ChangePassword_with_a_token_without_user(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.UserID = ""
+	token.Role = ""
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/users/e16edc95-2063-4fc9-9f46-1431a0ddd6fa/password", strings.NewReader(`{
+		"newPassword": "some-new-password"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_User_HTTPHandler_ChangePassword_with_an_error_from_the_usecase(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	controllerMock := new(ControllerMock)
+	handler := NewHTTPHandler(controllerMock)
+	handler.RegisterRoutes(router, perm)
+
+	token := accesstoken.ValidAccessToken
+	token.Role = accesstoken.Dev
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	controllerMock.On("ChangePassword", &ChangePasswordCmd{
+		UserID:          token.UserID,
+		CurrentPassword: "some-invalid-password",
+		NewPassword:     "some-new-password",
+	}).Return(errors.NewValidationError().AddError("currentPassword", "INVALID").IntoError()).Once()
+
+	r := httptest.NewRequest("PUT", "http://example.com/users/"+token.UserID+"/password", strings.NewReader(`{
+		"currentPassword": "some-invalid-password",
+		"newPassword": "some-new-password"
+	}`))
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "validationError",
+		"errors": {
+			"currentPassword": "INVALID"
+		}
+	}`, string(body))
+
+	controllerMock.AssertExpectations(t)
+	accessTokenControllerMock.AssertExpectations(t)
+}
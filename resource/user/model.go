@@ -5,23 +5,70 @@ const (
 	Dev   = "dev"
 )
 
+// RoleScopes lists the scopes a user can give to a client for each role.
+//
+// The "account" scope lets a client manage the account of its user, like
+// changing the password.
+var RoleScopes = map[string][]string{
+	Admin: {"*"},
+	Dev:   {"openid", "users.read", "account", "tokens", "clients", "contacts", "todos"},
+}
+
 type User struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Role     string `json:"role"`
 	Salt     string `json:"salt"`
+
+	// Email is optional. It is used to send the password reset links.
+	Email string `json:"email,omitempty"`
+
+	// MustChangePassword forces the user to choose a new password at the
+	// next authentication.
+	MustChangePassword bool `json:"mustChangePassword,omitempty"`
+
+	// TOTP secret encoded in base32. It is saved at the enrollment but only
+	// checked once the enrollment is confirmed.
+	TOTPSecret  string `json:"totpSecret,omitempty"`
+	TOTPEnabled bool   `json:"totpEnabled,omitempty"`
+
+	// Last TOTP time step accepted. A code can't be used twice.
+	TOTPLastStep int64 `json:"totpLastStep,omitempty"`
+
+	// SHA-256 hashes of the unused recovery codes.
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// TOTPEnrollment contains what the user needs to setup an authenticator app.
+// It is only given once.
+type TOTPEnrollment struct {
+	Secret        string
+	URI           string
+	RecoveryCodes []string
 }
 
 type CreateCmd struct {
-	Username string
-	Password string
-	Role     string
+	Username           string
+	Password           string
+	Role               string
+	Email              string
+	MustChangePassword bool
 }
 
 type UpdateCmd struct {
 	UserID   string
 	Username string
 	Role     string
+	Email    string
+}
+
+// ChangePasswordCmd requires the current password unless
+// SkipCurrentPassword is set.
+type ChangePasswordCmd struct {
+	UserID              string
+	CurrentPassword     string
+	NewPassword         string
+	SkipCurrentPassword bool
 }
 
 type GetCmd struct {
@@ -34,11 +81,36 @@ type DeleteCmd struct {
 
 type GetAllCmd struct{}
 
+type GetByEmailCmd struct {
+	Email string
+}
+
 type ValidateCmd struct {
 	Username string
 	Password string
 }
 
+type EnrollTOTPCmd struct {
+	UserID string
+}
+
+type ConfirmTOTPCmd struct {
+	UserID string
+	Code   string
+}
+
+// VerifyTOTPCmd checks a TOTP code or a recovery code.
+type VerifyTOTPCmd struct {
+	UserID string
+	Code   string
+}
+
+// DisableTOTPCmd requires a TOTP code or a recovery code.
+type DisableTOTPCmd struct {
+	UserID string
+	Code   string
+}
+
 var ValidUserID = "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb"
 var ValidUser = User{
 	Username: "some username",
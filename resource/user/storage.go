@@ -15,28 +15,27 @@ type Storage struct {
 }
 
 func SetupStorage(ctx context.Context, server *yaccc.Server) (*yaccc.Database, error) {
-	db, err := server.CreateDatabase(ctx, &yaccc.CreateDatabaseCmd{
-		Name: BucketName,
-		DesignDocuments: map[string]yaccc.DesignDocument{
-			"default": {
-				Language: yaccc.Javascript,
-				Views: map[string]yaccc.View{
-					"by_username": {
-						Map: `function (doc, meta) {
-							if (doc.username) {
-								emit(doc.username, null);
-							}
-						}`,
-					},
+	return db.SetupDatabase(ctx, server, BucketName, map[string]yaccc.DesignDocument{
+		"default": {
+			Language: yaccc.Javascript,
+			Views: map[string]yaccc.View{
+				"by_username": {
+					Map: `function (doc, meta) {
+						if (doc.username) {
+							emit(doc.username, null);
+						}
+					}`,
+				},
+				"by_email": {
+					Map: `function (doc, meta) {
+						if (doc.email) {
+							emit(doc.email, null);
+						}
+					}`,
 				},
 			},
 		},
 	})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to create the database")
-	}
-
-	return db, nil
 }
 
 func NewStorage(driver db.Driver) *Storage {
@@ -145,6 +144,29 @@ func (t *Storage) FindOneByUsername(ctx context.Context, username string) (strin
 	return res[0].ID, rev, &user, nil
 }
 
+func (t *Storage) FindOneByEmail(ctx context.Context, email string) (string, string, *User, error) {
+	res, err := t.driver.ExecuteViewQuery(ctx, &db.Query{
+		IndexName: "by_email",
+		Limit:     1,
+		Equals:    []interface{}{email},
+	})
+	if err != nil {
+		return "", "", nil, errors.Wrap(err, "failed to query the view")
+	}
+
+	if len(res) == 0 {
+		return "", "", nil, nil
+	}
+
+	var user User
+	rev, err := t.driver.Get(ctx, res[0].ID, &user)
+	if err != nil {
+		return "", "", nil, errors.Wrap(err, "failed to get the document")
+	}
+
+	return res[0].ID, rev, &user, nil
+}
+
 func (t *Storage) FindTotalUserCount(ctx context.Context) (int, error) {
 	nbRows, err := t.driver.GetTotalRow(ctx)
 	if err != nil {
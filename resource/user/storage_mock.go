@@ -50,6 +50,16 @@ func (t *StorageMock) FindOneByUsername(ctx context.Context, username string) (s
 	return args.String(0), args.String(1), args.Get(2).(*User), args.Error(3)
 }
 
+func (t *StorageMock) FindOneByEmail(ctx context.Context, email string) (string, string, *User, error) {
+	args := t.Called(email)
+
+	if args.Get(2) == nil {
+		return "", "", nil, args.Error(3)
+	}
+
+	return args.String(0), args.String(1), args.Get(2).(*User), args.Error(3)
+}
+
 func (t *StorageMock) FindTotalUserCount(_ context.Context) (int, error) {
 	args := t.Called()
 
@@ -119,3 +119,33 @@ func Test_User_StorageMock_FindOneByUsername_with_error(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_User_StorageMock_FindOneByEmail(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByEmail", "some-email@example.com").Return("some-id", "some-rev", &ValidUser, nil)
+
+	id, rev, res, err := mock.FindOneByEmail(context.Background(), "some-email@example.com")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-id", id)
+	assert.Equal(t, "some-rev", rev)
+	assert.EqualValues(t, &ValidUser, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_User_StorageMock_FindOneByEmail_with_error(t *testing.T) {
+	mock := new(StorageMock)
+
+	mock.On("FindOneByEmail", "some-email@example.com").Return("", "", nil, errors.New("some-error"))
+
+	id, rev, res, err := mock.FindOneByEmail(context.Background(), "some-email@example.com")
+
+	assert.Empty(t, rev)
+	assert.Empty(t, id)
+	assert.Nil(t, res)
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
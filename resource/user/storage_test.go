@@ -267,6 +267,108 @@ func Test_User_Storage_FindOneByUsername_with_get_error(t *testing.T) {
 	dbDriver.AssertExpectations(t)
 }
 
+func Test_User_Storage_FindOneByEmail(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	user := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_email",
+		Limit:     1,
+		Equals:    []interface{}{"some-email@example.com"},
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+	}, nil).Once()
+
+	dbDriver.On("Get", "some-id").Return("some-rev", &ValidUser, nil).Once()
+
+	id, rev, res, err := user.FindOneByEmail(context.Background(), "some-email@example.com")
+
+	assert.Equal(t, "some-id", id)
+	assert.Equal(t, "some-rev", rev)
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ValidUser, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_User_Storage_FindOneByEmail_with_no_user_found(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	user := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_email",
+		Limit:     1,
+		Equals:    []interface{}{"some-email@example.com"},
+	}).Return(nil, nil).Once()
+
+	id, rev, res, err := user.FindOneByEmail(context.Background(), "some-email@example.com")
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_User_Storage_FindOneByEmail_with_query_view_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	user := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_email",
+		Limit:     1,
+		Equals:    []interface{}{"some-email@example.com"},
+	}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	id, rev, res, err := user.FindOneByEmail(context.Background(), "some-email@example.com")
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to query the view",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
+func Test_User_Storage_FindOneByEmail_with_get_error(t *testing.T) {
+	dbDriver := new(db.DriverMock)
+	user := NewStorage(dbDriver)
+
+	dbDriver.On("ExecuteViewQuery", &db.Query{
+		IndexName: "by_email",
+		Limit:     1,
+		Equals:    []interface{}{"some-email@example.com"},
+	}).Return([]db.ViewRow{
+		{ID: "some-id"},
+	}, nil).Once()
+
+	dbDriver.On("Get", "some-id").Return("", nil, fmt.Errorf("some-error")).Once()
+
+	id, rev, res, err := user.FindOneByEmail(context.Background(), "some-email@example.com")
+
+	assert.Empty(t, id)
+	assert.Empty(t, rev)
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to get the document",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	dbDriver.AssertExpectations(t)
+}
+
 func Test_User_Storage_GetAll(t *testing.T) {
 	dbDriver := new(db.DriverMock)
 	service := NewStorage(dbDriver)
@@ -0,0 +1,118 @@
+package keyrotation
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/utils/clock"
+)
+
+const (
+	// DefaultInterval is the delay between two checks.
+	DefaultInterval = time.Hour
+
+	// DefaultMaxAge is the age after which the current key is replaced.
+	DefaultMaxAge = 30 * 24 * time.Hour
+
+	// DefaultRetention is how long a replaced key is kept in order to verify
+	// the tokens signed before the rotation. It must be longer than the
+	// lifetime of those tokens.
+	DefaultRetention = 24 * time.Hour
+)
+
+type SigningKeyInterface interface {
+	Create(ctx context.Context, cmd *signingkey.CreateCmd) (string, error)
+	GetAll(ctx context.Context, cmd *signingkey.GetAllCmd) (map[string]signingkey.SigningKey, error)
+	Delete(ctx context.Context, cmd *signingkey.DeleteCmd) error
+}
+
+// Controller rotates the keys used to sign the tokens.
+type Controller struct {
+	signingKey SigningKeyInterface
+	clock      clock.Clock
+	maxAge     time.Duration
+	retention  time.Duration
+}
+
+func NewController(
+	signingKey SigningKeyInterface,
+	clock clock.Clock,
+	maxAge time.Duration,
+	retention time.Duration,
+) *Controller {
+	return &Controller{
+		signingKey: signingKey,
+		clock:      clock,
+		maxAge:     maxAge,
+		retention:  retention,
+	}
+}
+
+// Run rotates the keys at each interval until the context is canceled.
+func (t *Controller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := t.Rotate(ctx)
+		if err != nil {
+			log.Println(errors.Wrap(err, "failed to rotate the signing keys"))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate creates a new key if the current one is older than the max age then
+// deletes the keys replaced for longer than the retention.
+func (t *Controller) Rotate(ctx context.Context) error {
+	now := t.clock.Now()
+
+	keys, err := t.signingKey.GetAll(ctx, &signingkey.GetAllCmd{})
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the signing keys")
+	}
+
+	ids := make([]string, 0, len(keys))
+	for id := range keys {
+		ids = append(ids, id)
+	}
+
+	// Most recent first.
+	sort.Slice(ids, func(i, j int) bool {
+		return keys[ids[i]].CreatedAt.After(keys[ids[j]].CreatedAt)
+	})
+
+	// The date at which the next key in the list has been replaced.
+	replacedAt := now
+	if len(ids) > 0 && now.Sub(keys[ids[0]].CreatedAt) < t.maxAge {
+		replacedAt = keys[ids[0]].CreatedAt
+		ids = ids[1:]
+	} else {
+		_, err = t.signingKey.Create(ctx, &signingkey.CreateCmd{})
+		if err != nil {
+			return errors.Wrap(err, "failed to create a new signing key")
+		}
+	}
+
+	for _, id := range ids {
+		if now.Sub(replacedAt) >= t.retention {
+			err = t.signingKey.Delete(ctx, &signingkey.DeleteCmd{KeyID: id})
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete the signing key %q", id)
+			}
+		}
+
+		replacedAt = keys[id].CreatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package keyrotation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+func keyCreatedAt(createdAt time.Time) signingkey.SigningKey {
+	key := signingkey.ValidSigningKey
+	key.CreatedAt = createdAt
+
+	return key
+}
+
+func Test_KeyRotation_Rotate_with_a_recent_key(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now).Once()
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		"current-key": keyCreatedAt(now.Add(-9 * time.Hour)),
+	}, nil).Once()
+
+	err := rotation.Rotate(context.Background())
+
+	assert.NoError(t, err)
+
+	signingKeyMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_KeyRotation_Rotate_with_an_old_key(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now).Once()
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		"current-key": keyCreatedAt(now.Add(-10 * time.Hour)),
+	}, nil).Once()
+	signingKeyMock.On("Create", &signingkey.CreateCmd{}).Return("new-key", nil).Once()
+
+	err := rotation.Rotate(context.Background())
+
+	assert.NoError(t, err)
+
+	signingKeyMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_KeyRotation_Rotate_without_key(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now).Once()
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{}, nil).Once()
+	signingKeyMock.On("Create", &signingkey.CreateCmd{}).Return("new-key", nil).Once()
+
+	err := rotation.Rotate(context.Background())
+
+	assert.NoError(t, err)
+
+	signingKeyMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_KeyRotation_Rotate_delete_the_keys_after_the_retention(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now).Once()
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		"current-key": keyCreatedAt(now.Add(-30 * time.Minute)),
+		// Replaced 30 minutes ago: still used to verify the tokens.
+		"previous-key": keyCreatedAt(now.Add(-10 * time.Hour)),
+		// Replaced 10 hours ago.
+		"old-key": keyCreatedAt(now.Add(-20 * time.Hour)),
+	}, nil).Once()
+	signingKeyMock.On("Delete", &signingkey.DeleteCmd{KeyID: "old-key"}).Return(nil).Once()
+
+	err := rotation.Rotate(context.Background())
+
+	assert.NoError(t, err)
+
+	signingKeyMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_KeyRotation_Rotate_keep_the_replaced_key_during_the_retention(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now).Once()
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		"current-key": keyCreatedAt(now.Add(-11 * time.Hour)),
+	}, nil).Once()
+	signingKeyMock.On("Create", &signingkey.CreateCmd{}).Return("new-key", nil).Once()
+
+	err := rotation.Rotate(context.Background())
+
+	assert.NoError(t, err)
+
+	signingKeyMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_KeyRotation_Rotate_with_a_get_all_error(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now).Once()
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	err := rotation.Rotate(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to retrieve the signing keys",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	signingKeyMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_KeyRotation_Rotate_with_a_create_error(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now).Once()
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{}, nil).Once()
+	signingKeyMock.On("Create", &signingkey.CreateCmd{}).Return("", fmt.Errorf("some-error")).Once()
+
+	err := rotation.Rotate(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to create a new signing key",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+
+	signingKeyMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_KeyRotation_Run_stop_with_the_context(t *testing.T) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	rotation := NewController(signingKeyMock, clockMock, 10*time.Hour, time.Hour)
+
+	clockMock.On("Now").Return(now)
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		"current-key": keyCreatedAt(now),
+	}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rotation.Run(ctx, time.Hour)
+
+	signingKeyMock.AssertExpectations(t)
+}
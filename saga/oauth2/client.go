@@ -0,0 +1,41 @@
+package oauth2
+
+import (
+	"log"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/password"
+	"github.com/openshift/osin"
+)
+
+// Client is the osin.Client given by the storage.
+//
+// The secret saved in the client resource is hashed so it can't be compared
+// directly with the secret sent by the client. The osin.ClientSecretMatcher
+// interface is implemented in order to use the password.HashManager instead.
+type Client struct {
+	osin.DefaultClient
+
+	password password.HashManager
+}
+
+// ClientSecretMatches implements the osin.ClientSecretMatcher interface.
+//
+// A public client doesn't have any secret and matches only the empty secret.
+func (t *Client) ClientSecretMatches(secret string) bool {
+	if t.Secret == "" {
+		return secret == ""
+	}
+
+	if secret == "" {
+		return false
+	}
+
+	valid, err := t.password.Validate(secret, t.Secret)
+	if err != nil {
+		log.Println(errors.Wrapf(err, "failed to validate the secret of the client %q", t.Id))
+		return false
+	}
+
+	return valid
+}
@@ -0,0 +1,91 @@
+package oauth2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/halium-project/go-server-utils/password"
+	"github.com/openshift/osin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OAuth2_Client_ClientSecretMatches(t *testing.T) {
+	passwordMock := new(password.HashManagerMock)
+	client := &Client{
+		DefaultClient: osin.DefaultClient{Id: "some-client-id", Secret: "some-hashed-secret"},
+		password:      passwordMock,
+	}
+
+	passwordMock.On("Validate", "some-secret", "some-hashed-secret").Return(true, nil).Once()
+
+	assert.True(t, osin.CheckClientSecret(client, "some-secret"))
+
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_Client_ClientSecretMatches_with_an_invalid_secret(t *testing.T) {
+	passwordMock := new(password.HashManagerMock)
+	client := &Client{
+		DefaultClient: osin.DefaultClient{Id: "some-client-id", Secret: "some-hashed-secret"},
+		password:      passwordMock,
+	}
+
+	passwordMock.On("Validate", "some-invalid-secret", "some-hashed-secret").Return(false, nil).Once()
+
+	assert.False(t, osin.CheckClientSecret(client, "some-invalid-secret"))
+
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_Client_ClientSecretMatches_with_the_hash_as_secret(t *testing.T) {
+	passwordMock := new(password.HashManagerMock)
+	client := &Client{
+		DefaultClient: osin.DefaultClient{Id: "some-client-id", Secret: "some-hashed-secret"},
+		password:      passwordMock,
+	}
+
+	passwordMock.On("Validate", "some-hashed-secret", "some-hashed-secret").Return(false, nil).Once()
+
+	assert.False(t, osin.CheckClientSecret(client, "some-hashed-secret"))
+
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_Client_ClientSecretMatches_with_a_validation_error(t *testing.T) {
+	passwordMock := new(password.HashManagerMock)
+	client := &Client{
+		DefaultClient: osin.DefaultClient{Id: "some-client-id", Secret: "some-hashed-secret"},
+		password:      passwordMock,
+	}
+
+	passwordMock.On("Validate", "some-secret", "some-hashed-secret").Return(false, fmt.Errorf("some-error")).Once()
+
+	assert.False(t, osin.CheckClientSecret(client, "some-secret"))
+
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_Client_ClientSecretMatches_without_secret(t *testing.T) {
+	passwordMock := new(password.HashManagerMock)
+	client := &Client{
+		DefaultClient: osin.DefaultClient{Id: "some-client-id", Secret: "some-hashed-secret"},
+		password:      passwordMock,
+	}
+
+	assert.False(t, osin.CheckClientSecret(client, ""))
+
+	passwordMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_Client_ClientSecretMatches_with_a_public_client(t *testing.T) {
+	passwordMock := new(password.HashManagerMock)
+	client := &Client{
+		DefaultClient: osin.DefaultClient{Id: "some-client-id"},
+		password:      passwordMock,
+	}
+
+	assert.True(t, osin.CheckClientSecret(client, ""))
+	assert.False(t, osin.CheckClientSecret(client, "some-secret"))
+
+	passwordMock.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+package oauth2
+
+// Config contains the lifetimes of the credentials delivered by the OAuth2
+// server.
+type Config struct {
+	// Authorization codes lifetime in seconds.
+	AuthorizationExpiration int32
+
+	// Access tokens lifetime in seconds.
+	AccessExpiration int32
+
+	// Lifetime in seconds of the access tokens delivered with the implicit
+	// grant. Those tokens can't be refreshed and are exposed to the browser
+	// so they must stay short lived.
+	ImplicitExpiration int32
+
+	// Refresh tokens lifetime in seconds. The refresh tokens never expire if
+	// zero.
+	RefreshExpiration int32
+
+	// ID tokens lifetime in seconds.
+	IDTokenExpiration int32
+
+	// Login sessions lifetime in seconds. The users don't retype their
+	// password on the authorization page while their session is open.
+	SessionExpiration int32
+
+	// Device codes lifetime in seconds. The user must approve the device
+	// before this delay.
+	DeviceCodeExpiration int32
+
+	// Minimum delay in seconds between two polls of the token endpoint by
+	// a device.
+	DeviceCodeInterval int32
+
+	// JWTAccessTokens enables the access tokens delivered as signed JWT.
+	// They are checked without any storage lookup.
+	JWTAccessTokens bool
+
+	// Issuer is the public URL of the server. It is used as "iss" claim of
+	// the ID tokens and to build the OpenID Connect discovery document and
+	// the authorization server metadata.
+	Issuer string
+
+	// Key used to sign the tickets passed between the steps of the
+	// authorization flow. A random key is generated if empty.
+	TicketKey []byte
+}
+
+// DefaultConfig is the configuration used by the server.
+var DefaultConfig = Config{
+	AuthorizationExpiration: 250,
+	AccessExpiration:        3600,
+	ImplicitExpiration:      3600,
+	RefreshExpiration:       30 * 24 * 3600,
+	IDTokenExpiration:       3600,
+	SessionExpiration:       24 * 3600,
+	DeviceCodeExpiration:    600,
+	DeviceCodeInterval:      5,
+	Issuer:                  "http://localhost:42000",
+}
@@ -2,124 +2,778 @@ package oauth2
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log"
-	"math"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/consent"
+	"github.com/halium-project/server/resource/devicecode"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/signingkey"
 	"github.com/halium-project/server/resource/user"
 	"github.com/openshift/osin"
 	"gitlab.com/Peltoche/yaccc"
 )
 
+// loginSessionCookie is the name of the cookie keeping the login session ID.
+const loginSessionCookie = "halium_session"
+
 type Controller struct {
-	inner *osin.Server
-	html  TemplateRenderer
-	user  UserValidater
+	inner        *osin.Server
+	html         TemplateRenderer
+	user         UserInterface
+	consent      ConsentInterface
+	loginSession LoginSessionInterface
+	loginAttempt LoginAttemptInterface
+	deviceCode   DeviceCodeInterface
+	client       ClientInterface
+	storage      osin.Storage
+	accessToken  AccessTokenInterface
+	tokens       TokenResolver
+	signingKey   SigningKeyInterface
+	config       Config
 }
 
 type TemplateRenderer interface {
 	Render(w io.Writer, templateName string, params interface{}) error
 }
 
-type UserValidater interface {
+type ConsentInterface interface {
+	Get(ctx context.Context, cmd *consent.GetCmd) (*consent.Consent, error)
+	Grant(ctx context.Context, cmd *consent.GrantCmd) error
+}
+
+type LoginSessionInterface interface {
+	Create(ctx context.Context, cmd *loginsession.CreateCmd) (string, error)
+	Get(ctx context.Context, cmd *loginsession.GetCmd) (*loginsession.LoginSession, error)
+	Delete(ctx context.Context, cmd *loginsession.DeleteCmd) error
+}
+
+type LoginAttemptInterface interface {
+	Check(ctx context.Context, cmd *loginattempt.CheckCmd) (time.Duration, error)
+	RecordFailure(ctx context.Context, cmd *loginattempt.RecordFailureCmd) error
+	RecordSuccess(ctx context.Context, cmd *loginattempt.RecordSuccessCmd) error
+}
+
+type DeviceCodeInterface interface {
+	Create(ctx context.Context, cmd *devicecode.CreateCmd) (string, string, error)
+	GetByUserCode(ctx context.Context, cmd *devicecode.GetByUserCodeCmd) (*devicecode.DeviceCode, error)
+	Approve(ctx context.Context, cmd *devicecode.ApproveCmd) error
+	Deny(ctx context.Context, cmd *devicecode.DenyCmd) error
+	Poll(ctx context.Context, cmd *devicecode.PollCmd) (*devicecode.DeviceCode, error)
+}
+
+type UserInterface interface {
 	Validate(ctx context.Context, cmd *user.ValidateCmd) (string, *user.User, error)
+	Get(ctx context.Context, cmd *user.GetCmd) (*user.User, error)
+	VerifyTOTP(ctx context.Context, cmd *user.VerifyTOTPCmd) (bool, error)
+	ChangePassword(ctx context.Context, cmd *user.ChangePasswordCmd) error
+}
+
+// TokenResolver resolves the bearer tokens accepted by the API (see
+// permission.Controller).
+type TokenResolver interface {
+	Resolve(ctx context.Context, token string) (*accesstoken.AccessToken, error)
+}
+
+type SigningKeyInterface interface {
+	GetCurrent(ctx context.Context, cmd *signingkey.GetCurrentCmd) (string, *signingkey.SigningKey, error)
+	GetAll(ctx context.Context, cmd *signingkey.GetAllCmd) (map[string]signingkey.SigningKey, error)
 }
 
 func InitController(
 	ctx context.Context,
 	server *yaccc.Server,
 	html TemplateRenderer,
-	user UserValidater,
+	user UserInterface,
+	consent ConsentInterface,
+	loginSession LoginSessionInterface,
+	loginAttempt LoginAttemptInterface,
+	deviceCode DeviceCodeInterface,
+	client ClientInterface,
 	storage osin.Storage,
+	accessToken AccessTokenInterface,
+	tokens TokenResolver,
+	signingKey SigningKeyInterface,
+	config Config,
 ) *Controller {
+	osinServer := osin.NewServer(NewOsinConfig(config), storage)
+	osinServer.Logger = log.New(os.Stdout, "", log.LstdFlags)
+
+	if config.JWTAccessTokens {
+		osinServer.AccessTokenGen = newJWTAccessTokenGen(signingKey, user, uuid.NewGoUUID(), config)
+	}
+
+	if len(config.TicketKey) == 0 {
+		key, err := NewTicketKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		config.TicketKey = key
+	}
+
+	return NewController(osinServer, html, user, consent, loginSession, loginAttempt, deviceCode, client, storage, accessToken, tokens, signingKey, config)
+
+}
+
+// NewOsinConfig returns the osin configuration used by the server. The types
+// are then restricted for each client.
+func NewOsinConfig(config Config) *osin.ServerConfig {
 	osinConfig := osin.NewServerConfig()
 	osinConfig.AllowedAuthorizeTypes = []osin.AuthorizeRequestType{osin.CODE, osin.TOKEN}
-	osinConfig.AllowedAccessTypes = osin.AllowedAccessType{osin.AUTHORIZATION_CODE, osin.REFRESH_TOKEN, osin.CLIENT_CREDENTIALS, osin.IMPLICIT}
-	osinConfig.AccessExpiration = 3600
+	osinConfig.AllowedAccessTypes = osin.AllowedAccessType{osin.AUTHORIZATION_CODE, osin.REFRESH_TOKEN, osin.PASSWORD, osin.CLIENT_CREDENTIALS, osin.IMPLICIT}
+	osinConfig.AuthorizationExpiration = config.AuthorizationExpiration
+	osinConfig.AccessExpiration = config.AccessExpiration
 	osinConfig.ErrorStatusCode = http.StatusBadRequest
+	osinConfig.RedirectUriSeparator = redirectURISeparator
+	osinConfig.RequirePKCEForPublicClients = true
+	// The refreshed tokens are kept in order to detect the reuse of the
+	// refresh tokens (see StorageController.SaveAccess).
+	osinConfig.RetainTokenAfterRefresh = true
+	// The public clients don't have any secret to give with the basic auth.
+	osinConfig.AllowClientSecretInParams = true
 
-	osinServer := osin.NewServer(osinConfig, storage)
-	osinServer.Logger = log.New(os.Stdout, "", log.LstdFlags)
-
-	return NewController(osinServer, html, user, storage)
-
+	return osinConfig
 }
 
 func NewController(
 	osinServer *osin.Server,
 	html TemplateRenderer,
-	user UserValidater,
+	user UserInterface,
+	consent ConsentInterface,
+	loginSession LoginSessionInterface,
+	loginAttempt LoginAttemptInterface,
+	deviceCode DeviceCodeInterface,
+	client ClientInterface,
 	storage osin.Storage,
+	accessToken AccessTokenInterface,
+	tokens TokenResolver,
+	signingKey SigningKeyInterface,
+	config Config,
 ) *Controller {
 	return &Controller{
-		inner: osinServer,
-		html:  html,
-		user:  user,
+		inner:        osinServer,
+		html:         html,
+		user:         user,
+		consent:      consent,
+		loginSession: loginSession,
+		loginAttempt: loginAttempt,
+		deviceCode:   deviceCode,
+		client:       client,
+		storage:      storage,
+		accessToken:  accessToken,
+		tokens:       tokens,
+		signingKey:   signingKey,
+		config:       config,
 	}
 }
 
+// clientGrantType returns the grant type registered for the clients matching
+// an osin access request type.
+func clientGrantType(accessType osin.AccessRequestType) string {
+	// osin uses an internal name for the implicit grant.
+	if accessType == osin.IMPLICIT {
+		return "implicit"
+	}
+
+	grantType, _ := client.GrantTypeFromName(string(accessType))
+
+	return grantType
+}
+
 func (t *Controller) Authorize(w http.ResponseWriter, r *http.Request) {
 	resp := t.inner.NewResponse()
 	defer resp.Close()
 
+	// Never redirect to an unregistered URI, even to report an error.
+	err := t.checkRedirectURI(r)
+	if err != nil {
+		t.renderAuthorizeErrorPage(w, err)
+		return
+	}
+
 	ar := t.inner.HandleAuthorizeRequest(resp, r)
 	if ar != nil {
+		rendered := t.handleAuthorizeRequest(w, r, resp, ar)
+		if rendered {
+			return
+		}
+	}
 
-		if r.Method == "GET" {
-			t.renderAuthenticationPage(w, http.StatusOK, nil)
+	err = osin.OutputJSON(resp, w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// handleAccessRequest authorizes the access request or fills the response
+// error.
+func (t *Controller) handleAccessRequest(r *http.Request, resp *osin.Response, ar *osin.AccessRequest) {
+	client := ar.Client.GetUserData().(*client.Client)
+
+	if !contains(client.GrantTypes, clientGrantType(ar.Type)) {
+		resp.SetError(osin.E_UNAUTHORIZED_CLIENT, fmt.Sprintf("the grant type %q is not allowed for this client", ar.Type))
+		return
+	}
+
+	// The tokens are only bound to the user.
+	if data, ok := ar.UserData.(*authorizeUserData); ok {
+		ar.UserData = data.UserID
+	}
+
+	// The refreshed tokens keep the scopes allowed for the current role of
+	// their user. They are refused once the user has been deleted.
+	if userID, _ := ar.UserData.(string); ar.Type == osin.REFRESH_TOKEN && userID != "" {
+		scopes, err := t.userScopes(r.Context(), userID, parseScopes(ar.Scope))
+		if err != nil {
+			resp.SetError(osin.E_SERVER_ERROR, "")
+			resp.InternalError = err
+			return
+		}
+
+		if len(scopes) == 0 {
+			resp.SetError(osin.E_INVALID_GRANT, "")
 			return
 		}
 
-		err := r.ParseForm()
+		ar.Scope = strings.Join(scopes, ",")
+	}
+
+	if ar.Type == osin.PASSWORD {
+		delay, err := t.loginAttempt.Check(r.Context(), &loginattempt.CheckCmd{
+			Username: ar.Username,
+			IP:       ClientIP(r),
+		})
 		if err != nil {
-			resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+			resp.SetError(osin.E_SERVER_ERROR, "")
 			resp.InternalError = err
-			t.inner.FinishAuthorizeRequest(resp, r, ar)
 			return
 		}
 
-		userID, _, err := t.user.Validate(r.Context(), &user.ValidateCmd{
-			Username: r.PostForm.Get("username"),
-			Password: r.PostForm.Get("password"),
+		if delay > 0 {
+			resp.SetError(osin.E_INVALID_GRANT, "too many failed login attempts")
+			return
+		}
+
+		userID, owner, err := t.user.Validate(r.Context(), &user.ValidateCmd{
+			Username: ar.Username,
+			Password: ar.Password,
 		})
 		if err != nil {
-			resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+			resp.SetError(osin.E_SERVER_ERROR, "")
 			resp.InternalError = err
-			t.inner.FinishAuthorizeRequest(resp, r, ar)
 			return
 		}
 
+		// The failures are only cleared once the second factor is given
+		// and it can't be given with this grant.
+		if userID == "" || !owner.TOTPEnabled {
+			err = t.recordLoginAttempt(r, ar.Username, userID != "")
+			if err != nil {
+				resp.SetError(osin.E_SERVER_ERROR, "")
+				resp.InternalError = err
+				return
+			}
+		}
+
 		if userID == "" {
-			t.renderAuthenticationPage(w, http.StatusBadRequest, nil)
+			resp.SetError(osin.E_INVALID_GRANT, "invalid username or password")
+			return
+		}
+
+		// The second factor can't be given with this grant.
+		if owner.TOTPEnabled {
+			resp.SetError(osin.E_INVALID_GRANT, "two-factor authentication required")
+			return
+		}
+
+		// The password must be changed with the authorize endpoint first.
+		if owner.MustChangePassword {
+			resp.SetError(osin.E_INVALID_GRANT, "password change required")
 			return
 		}
 
-		if ar.Type == "token" {
-			// This is an implicit grant. It is used by insecure clients
-			//like web apps and so no refresh token are required.
-			ar.Expiration = int32(math.MaxInt32)
+		// Only grant the scopes registered for the client and allowed for
+		// the user role.
+		scopes := filterRoleScopes(negotiateScopes(parseScopes(ar.Scope), client.Scopes), owner.Role)
+
+		if len(scopes) == 0 {
+			resp.SetError(osin.E_INVALID_SCOPE, "")
+			return
 		}
 
-		ar.Authorized = true
-		t.inner.FinishAuthorizeRequest(resp, r, ar)
+		ar.Scope = strings.Join(scopes, ",")
+		ar.UserData = userID
+	}
+
+	ar.Authorized = true
+}
+
+// checkRedirectURI checks that the redirect_uri parameter exactly matches
+// one of the URIs registered for the client. The parameter can be omitted
+// if the client has a single URI.
+func (t *Controller) checkRedirectURI(r *http.Request) error {
+	res, err := t.storage.GetClient(r.FormValue("client_id"))
+	if err == osin.ErrNotFound {
+		return errors.New(errors.BadRequest, "unknown client")
 	}
 
-	err := osin.OutputJSON(resp, w, r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		return errors.Wrap(err, "failed to retrieve the client")
+	}
+
+	client := res.GetUserData().(*client.Client)
+	redirectURI := r.FormValue("redirect_uri")
+
+	if redirectURI == "" {
+		if len(client.RedirectURIs) != 1 {
+			return errors.New(errors.BadRequest, `missing "redirect_uri" parameter`)
+		}
+
+		return nil
+	}
+
+	if !contains(client.RedirectURIs, redirectURI) {
+		return errors.Errorf(errors.BadRequest, "the redirect URI %q is not registered for this client", redirectURI)
+	}
+
+	return nil
+}
+
+// handleAuthorizeRequest runs the authentication and the consent steps. It
+// returns true if a page has been rendered instead of filling the response.
+func (t *Controller) handleAuthorizeRequest(w http.ResponseWriter, r *http.Request, resp *osin.Response, ar *osin.AuthorizeRequest) bool {
+	// The users with an open login session skip the authentication page.
+	var sessionUserID string
+	if r.Method == "GET" {
+		var err error
+		sessionUserID, err = t.getLoginSessionUserID(r)
+		if err != nil {
+			resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+			resp.InternalError = err
+			return false
+		}
+
+		if sessionUserID == "" {
+			t.renderAuthenticationPage(w, http.StatusOK, nil)
+			return true
+		}
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+		resp.InternalError = err
+		return false
+	}
+
+	client := ar.Client.GetUserData().(*client.Client)
+
+	if !contains(client.ResponseTypes, string(ar.Type)) {
+		resp.SetErrorState(osin.E_UNAUTHORIZED_CLIENT, fmt.Sprintf("the response type %q is not allowed for this client", ar.Type), ar.State)
+		return false
+	}
+
+	if ar.Type == osin.CODE {
+		pkceErr := checkPKCE(client, ar.CodeChallenge, ar.CodeChallengeMethod)
+		if pkceErr != nil {
+			resp.SetErrorState(osin.E_INVALID_REQUEST, pkceErr.Message, ar.State)
+			return false
+		}
+	}
+
+	// Only grant the scopes registered for the client.
+	scopes := negotiateScopes(parseScopes(ar.Scope), client.Scopes)
+	if len(scopes) == 0 {
+		resp.SetErrorState(osin.E_INVALID_SCOPE, "", ar.State)
+		return false
+	}
+	ar.Scope = strings.Join(scopes, ",")
+
+	var userID string
+	if r.PostForm.Get("ticket") != "" {
+		// The user answered the consent page.
+		tkt, err := parseTicket(t.config.TicketKey, r.PostForm.Get("ticket"), time.Now())
+		if err != nil || tkt.Step != "" || tkt.ClientID != client.ID || tkt.Scope != ar.Scope {
+			t.renderAuthenticationPage(w, http.StatusBadRequest, nil)
+			return true
+		}
+
+		if r.PostForm.Get("consent") != "allow" {
+			resp.SetErrorState(osin.E_ACCESS_DENIED, "", ar.State)
+			return false
+		}
+
+		scopes, err = t.userScopes(r.Context(), tkt.UserID, scopes)
+		if err != nil {
+			resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+			resp.InternalError = err
+			return false
+		}
+
+		if len(scopes) == 0 {
+			resp.SetErrorState(osin.E_INVALID_SCOPE, "", ar.State)
+			return false
+		}
+
+		err = t.consent.Grant(r.Context(), &consent.GrantCmd{
+			UserID:   tkt.UserID,
+			ClientID: client.ID,
+			Scopes:   scopes,
+		})
+		if err != nil {
+			resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+			resp.InternalError = err
+			return false
+		}
+
+		userID = tkt.UserID
+	} else {
+		userID = sessionUserID
+		if userID == "" {
+			var rendered bool
+			userID, rendered, err = t.authenticateUser(w, r, client, ar.Scope)
+			if err != nil {
+				resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+				resp.InternalError = err
+				return false
+			}
+
+			if rendered {
+				return true
+			}
+
+			err = t.openLoginSession(r.Context(), w, userID)
+			if err != nil {
+				resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+				resp.InternalError = err
+				return false
+			}
+		}
+
+		// Only grant the scopes allowed for the user role.
+		scopes, err = t.userScopes(r.Context(), userID, scopes)
+		if err != nil {
+			resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+			resp.InternalError = err
+			return false
+		}
+
+		if len(scopes) == 0 {
+			resp.SetErrorState(osin.E_INVALID_SCOPE, "", ar.State)
+			return false
+		}
+
+		// Don't prompt the users already agreed to give those scopes.
+		existing, err := t.consent.Get(r.Context(), &consent.GetCmd{
+			UserID:   userID,
+			ClientID: client.ID,
+		})
+		if err != nil {
+			resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+			resp.InternalError = err
+			return false
+		}
+
+		if existing == nil || !existing.Covers(scopes) {
+			err = t.renderConsentPage(w, userID, client, ar.Scope, scopes)
+			if err != nil {
+				resp.SetErrorState(osin.E_SERVER_ERROR, "", ar.State)
+				resp.InternalError = err
+				return false
+			}
+
+			return true
+		}
+	}
+
+	if ar.Type == "token" {
+		// This is an implicit grant. It is used by insecure clients
+		// like web apps and so no refresh token are delivered. Keep the
+		// token short lived.
+		ar.Expiration = t.config.ImplicitExpiration
+	}
+
+	// The tickets keep the scopes negotiated with the client, only the
+	// ones allowed for the user role are granted.
+	ar.Scope = strings.Join(scopes, ",")
+
+	// Bind the authorization and the generated tokens to the user. The
+	// authorization codes also keep the OpenID Connect nonce until the ID
+	// token is delivered.
+	ar.UserData = userID
+	if ar.Type == osin.CODE {
+		ar.UserData = &authorizeUserData{
+			UserID: userID,
+			Nonce:  r.FormValue("nonce"),
+		}
+	}
+	ar.Authorized = true
+	t.inner.FinishAuthorizeRequest(resp, r, ar)
+
+	return false
+}
+
+// authenticateUser checks the password and then the TOTP code if the user
+// enabled the two-factor authentication. The users with a password to change
+// must replace it before going further. It returns true if a page has been
+// rendered to ask for a missing or an invalid credential.
+func (t *Controller) authenticateUser(w http.ResponseWriter, r *http.Request, client *client.Client, scope string) (string, bool, error) {
+	if r.PostForm.Get("password_ticket") != "" {
+		return t.changePassword(w, r, client, scope)
+	}
+
+	if r.PostForm.Get("totp_ticket") != "" {
+		// The user answered the TOTP page.
+		tkt, err := parseTicket(t.config.TicketKey, r.PostForm.Get("totp_ticket"), time.Now())
+		if err != nil || tkt.Step != totpStep || tkt.ClientID != client.ID || tkt.Scope != scope {
+			t.renderAuthenticationPage(w, http.StatusBadRequest, nil)
+			return "", true, nil
+		}
+
+		owner, err := t.user.Get(r.Context(), &user.GetCmd{
+			UserID: tkt.UserID,
+		})
+		if err != nil {
+			return "", false, errors.Wrapf(err, "failed to retrieve the user %q", tkt.UserID)
+		}
+
+		if owner == nil {
+			t.renderAuthenticationPage(w, http.StatusBadRequest, nil)
+			return "", true, nil
+		}
+
+		// The codes are throttled like the passwords. A locked user must
+		// start again from the password.
+		rendered, err := t.checkLoginAttempts(w, r, owner.Username)
+		if err != nil || rendered {
+			return "", rendered, err
+		}
+
+		valid, err := t.user.VerifyTOTP(r.Context(), &user.VerifyTOTPCmd{
+			UserID: tkt.UserID,
+			Code:   r.PostForm.Get("code"),
+		})
+		if err != nil {
+			return "", false, err
+		}
+
+		err = t.recordLoginAttempt(r, owner.Username, valid)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !valid {
+			t.renderTOTPPage(w, http.StatusBadRequest, r.PostForm.Get("totp_ticket"))
+			return "", true, nil
+		}
+
+		return t.checkPasswordRotation(w, tkt.UserID, owner, client, scope)
+	}
+
+	username := r.PostForm.Get("username")
+
+	rendered, err := t.checkLoginAttempts(w, r, username)
+	if err != nil || rendered {
+		return "", rendered, err
+	}
+
+	userID, owner, err := t.user.Validate(r.Context(), &user.ValidateCmd{
+		Username: username,
+		Password: r.PostForm.Get("password"),
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	// The failures are only cleared once the second factor is given.
+	if userID == "" || !owner.TOTPEnabled {
+		err = t.recordLoginAttempt(r, username, userID != "")
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	if userID == "" {
+		t.renderAuthenticationPage(w, http.StatusBadRequest, nil)
+		return "", true, nil
+	}
+
+	if owner.TOTPEnabled {
+		tkt, err := signTicket(t.config.TicketKey, &ticket{
+			UserID:   userID,
+			ClientID: client.ID,
+			Scope:    scope,
+			ExpireAt: time.Now().Add(ticketLifetime).Unix(),
+			Step:     totpStep,
+		})
+		if err != nil {
+			return "", false, errors.Wrap(err, "failed to sign the ticket")
+		}
+
+		t.renderTOTPPage(w, http.StatusOK, tkt)
+		return "", true, nil
+	}
+
+	return t.checkPasswordRotation(w, userID, owner, client, scope)
+}
+
+// checkPasswordRotation renders the change password page if the password of
+// the user must be replaced before the authentication.
+func (t *Controller) checkPasswordRotation(w http.ResponseWriter, userID string, owner *user.User, client *client.Client, scope string) (string, bool, error) {
+	if !owner.MustChangePassword {
+		return userID, false, nil
+	}
+
+	tkt, err := signTicket(t.config.TicketKey, &ticket{
+		UserID:   userID,
+		ClientID: client.ID,
+		Scope:    scope,
+		ExpireAt: time.Now().Add(ticketLifetime).Unix(),
+		Step:     passwordStep,
+	})
+	if err != nil {
+		return "", false, errors.Wrap(err, "failed to sign the ticket")
+	}
+
+	t.renderChangePasswordPage(w, http.StatusOK, changePasswordTemplateParam{
+		Ticket: tkt,
+	})
+	return "", true, nil
+}
+
+// changePassword replaces the password of an authenticated user answering
+// the change password page.
+func (t *Controller) changePassword(w http.ResponseWriter, r *http.Request, client *client.Client, scope string) (string, bool, error) {
+	rawTicket := r.PostForm.Get("password_ticket")
+
+	tkt, err := parseTicket(t.config.TicketKey, rawTicket, time.Now())
+	if err != nil || tkt.Step != passwordStep || tkt.ClientID != client.ID || tkt.Scope != scope {
+		t.renderAuthenticationPage(w, http.StatusBadRequest, nil)
+		return "", true, nil
+	}
+
+	newPassword := r.PostForm.Get("new_password")
+	if newPassword != r.PostForm.Get("confirmation") {
+		t.renderChangePasswordPage(w, http.StatusBadRequest, changePasswordTemplateParam{
+			Ticket: rawTicket,
+			Error:  "The passwords don't match.",
+		})
+		return "", true, nil
+	}
+
+	err = t.user.ChangePassword(r.Context(), &user.ChangePasswordCmd{
+		UserID:              tkt.UserID,
+		NewPassword:         newPassword,
+		SkipCurrentPassword: true,
+	})
+	if errors.IsKind(err, errors.Validation) {
+		t.renderChangePasswordPage(w, http.StatusBadRequest, changePasswordTemplateParam{
+			Ticket: rawTicket,
+			Error:  "The new password must have between 8 and 256 characters and differ from the current one.",
+		})
+		return "", true, nil
+	}
+
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to change the password of the user %q", tkt.UserID)
 	}
+
+	return tkt.UserID, false, nil
+}
+
+// checkLoginAttempts renders the authentication page with the remaining
+// delay if the username or the IP address of the client is throttled.
+func (t *Controller) checkLoginAttempts(w http.ResponseWriter, r *http.Request, username string) (bool, error) {
+	delay, err := t.loginAttempt.Check(r.Context(), &loginattempt.CheckCmd{
+		Username: username,
+		IP:       ClientIP(r),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if delay > 0 {
+		t.renderAuthenticationPage(w, http.StatusTooManyRequests, authTemplateParam{
+			Error: fmt.Sprintf("Too many failed login attempts. Try again in %s.", formatDelay(delay)),
+		})
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// recordLoginAttempt updates the failed login counters after a password or a
+// TOTP code check.
+func (t *Controller) recordLoginAttempt(r *http.Request, username string, success bool) error {
+	if success {
+		return t.loginAttempt.RecordSuccess(r.Context(), &loginattempt.RecordSuccessCmd{
+			Username: username,
+		})
+	}
+
+	return t.loginAttempt.RecordFailure(r.Context(), &loginattempt.RecordFailureCmd{
+		Username: username,
+		IP:       ClientIP(r),
+	})
+}
+
+// ClientIP returns the IP address of the client without the port.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// formatDelay returns the delay rounded up to the second or to the minute.
+func formatDelay(delay time.Duration) string {
+	if delay > time.Minute {
+		return fmt.Sprintf("%d minutes", (delay+time.Minute-1)/time.Minute)
+	}
+
+	seconds := (delay + time.Second - 1) / time.Second
+	if seconds <= 1 {
+		return "1 second"
+	}
+
+	return fmt.Sprintf("%d seconds", seconds)
 }
 
 func (t *Controller) Token(w http.ResponseWriter, r *http.Request) {
 	resp := t.inner.NewResponse()
 	defer resp.Close()
 
-	ar := t.inner.HandleAccessRequest(resp, r)
+	// Osin doesn't know the device authorization grant.
+	var ar *osin.AccessRequest
+	if osin.AccessRequestType(r.FormValue("grant_type")) == DeviceCodeGrantType {
+		ar = t.handleDeviceAccessRequest(resp, r)
+	} else {
+		ar = t.inner.HandleAccessRequest(resp, r)
+		if ar != nil {
+			t.handleAccessRequest(r, resp, ar)
+		}
+	}
+
 	if ar != nil {
-		ar.Authorized = true
 		t.inner.FinishAccessRequest(resp, r, ar)
+
+		if !resp.IsError {
+			t.addIDToken(r.Context(), resp, ar)
+		}
 	}
 
 	err := osin.OutputJSON(resp, w, r)
@@ -141,6 +795,338 @@ func (t *Controller) Info(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Revoke invalidates an access token or a refresh token as described in the
+// RFC 7009.
+//
+// The access token and its refresh token are stored in the same document so
+// revoking one of them revokes both.
+func (t *Controller) Revoke(w http.ResponseWriter, r *http.Request) {
+	resp := t.inner.NewResponse()
+	defer resp.Close()
+
+	t.handleRevokeRequest(resp, r)
+
+	err := osin.OutputJSON(resp, w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (t *Controller) handleRevokeRequest(resp *osin.Response, r *http.Request) {
+	if r.Method != "POST" {
+		resp.SetError(osin.E_INVALID_REQUEST, "request must be POST")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		resp.SetError(osin.E_INVALID_REQUEST, "")
+		resp.InternalError = err
+		return
+	}
+
+	client := t.authenticateClient(resp, r)
+	if client == nil {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		resp.SetError(osin.E_INVALID_REQUEST, `missing "token" parameter`)
+		return
+	}
+
+	id, session, err := t.findToken(r.Context(), token, r.PostForm.Get("token_type_hint"))
+	if err != nil {
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return
+	}
+
+	// An invalid token doesn't trigger any error as the client can't do
+	// anything about it.
+	if session == nil {
+		return
+	}
+
+	if session.ClientID != client.GetId() {
+		resp.SetError(osin.E_UNAUTHORIZED_CLIENT, "the token has been issued to another client")
+		return
+	}
+
+	err = t.storage.RemoveAccess(id)
+	if err != nil {
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return
+	}
+}
+
+// Introspect returns the state and the metadata of an access token or a
+// refresh token as described in the RFC 7662.
+func (t *Controller) Introspect(w http.ResponseWriter, r *http.Request) {
+	resp := t.inner.NewResponse()
+	defer resp.Close()
+
+	t.handleIntrospectRequest(resp, r)
+
+	err := osin.OutputJSON(resp, w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (t *Controller) handleIntrospectRequest(resp *osin.Response, r *http.Request) {
+	if r.Method != "POST" {
+		resp.SetError(osin.E_INVALID_REQUEST, "request must be POST")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		resp.SetError(osin.E_INVALID_REQUEST, "")
+		resp.InternalError = err
+		return
+	}
+
+	client := t.authenticateClient(resp, r)
+	if client == nil {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		resp.SetError(osin.E_INVALID_REQUEST, `missing "token" parameter`)
+		return
+	}
+
+	_, session, err := t.findToken(r.Context(), token, r.PostForm.Get("token_type_hint"))
+	if err != nil {
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return
+	}
+
+	now := time.Now()
+	resp.Output["active"] = false
+
+	if session == nil {
+		return
+	}
+
+	var expireAt time.Time
+	if token == session.AccessToken {
+		if session.IsExpiredAt(now) {
+			return
+		}
+
+		expireAt = session.ExpireAt()
+	} else {
+		if session.IsRefreshExpiredAt(now) {
+			return
+		}
+
+		expireAt = session.RefreshExpireAt()
+	}
+
+	resp.Output["active"] = true
+	resp.Output["scope"] = strings.Join(session.Scopes, " ")
+	resp.Output["client_id"] = session.ClientID
+	resp.Output["iat"] = session.CreatedAt.Unix()
+
+	if !expireAt.IsZero() {
+		resp.Output["exp"] = expireAt.Unix()
+	}
+
+	if session.UserID != "" {
+		resp.Output["sub"] = session.UserID
+	}
+}
+
+// authenticateClient retrieves the client authenticated with the basic auth
+// or with the "client_id" and "client_secret" form parameters.
+//
+// It returns nil and fills the response error if the client can't be
+// authenticated.
+func (t *Controller) authenticateClient(resp *osin.Response, r *http.Request) osin.Client {
+	auth, err := osin.CheckBasicAuth(r)
+	if err != nil {
+		resp.SetError(osin.E_INVALID_REQUEST, "")
+		resp.InternalError = err
+		return nil
+	}
+
+	if auth == nil {
+		auth = &osin.BasicAuth{
+			Username: r.PostForm.Get("client_id"),
+			Password: r.PostForm.Get("client_secret"),
+		}
+	}
+
+	if auth.Username == "" {
+		resp.SetError(osin.E_INVALID_CLIENT, "")
+		resp.StatusCode = http.StatusUnauthorized
+		return nil
+	}
+
+	client, err := t.storage.GetClient(auth.Username)
+	if err != nil && err != osin.ErrNotFound {
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return nil
+	}
+
+	if client == nil || !osin.CheckClientSecret(client, auth.Password) {
+		resp.SetError(osin.E_INVALID_CLIENT, "")
+		resp.StatusCode = http.StatusUnauthorized
+		return nil
+	}
+
+	return client
+}
+
+// findToken retrieves the document matching the given access token or
+// refresh token. The hint only changes the lookup order.
+//
+// It returns nil if no token matches.
+func (t *Controller) findToken(ctx context.Context, token string, hint string) (string, *accesstoken.AccessToken, error) {
+	lookups := []func(ctx context.Context, token string) (string, *accesstoken.AccessToken, error){
+		t.findAccessToken,
+		t.findRefreshToken,
+	}
+
+	if hint == "refresh_token" {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+
+	for _, lookup := range lookups {
+		id, session, err := lookup(ctx, token)
+		if err != nil || session != nil {
+			return id, session, err
+		}
+	}
+
+	return "", nil, nil
+}
+
+func (t *Controller) findAccessToken(ctx context.Context, token string) (string, *accesstoken.AccessToken, error) {
+	session, err := t.accessToken.Get(ctx, &accesstoken.GetCmd{
+		AccessToken: token,
+	})
+	if errors.IsKind(err, errors.Validation) {
+		return "", nil, nil
+	}
+
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to retrieve the access token")
+	}
+
+	return token, session, nil
+}
+
+func (t *Controller) findRefreshToken(ctx context.Context, token string) (string, *accesstoken.AccessToken, error) {
+	id, session, err := t.accessToken.FindOneByRefreshToken(ctx, &accesstoken.FindOneByRefreshTokenCmd{
+		RefreshToken: token,
+	})
+	if errors.IsKind(err, errors.Validation) {
+		return "", nil, nil
+	}
+
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to retrieve the refresh token")
+	}
+
+	return id, session, nil
+}
+
+func (t *Controller) renderConsentPage(w http.ResponseWriter, userID string, client *client.Client, scope string, scopes []string) error {
+	type consentTemplateParam struct {
+		ClientName string
+		Scopes     []string
+		Ticket     string
+	}
+
+	tkt, err := signTicket(t.config.TicketKey, &ticket{
+		UserID:   userID,
+		ClientID: client.ID,
+		Scope:    scope,
+		ExpireAt: time.Now().Add(ticketLifetime).Unix(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to sign the ticket")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	err = t.html.Render(w, "consent.html", consentTemplateParam{
+		ClientName: client.Name,
+		Scopes:     scopes,
+		Ticket:     tkt,
+	})
+	if err != nil {
+		log.Println(err)
+	}
+
+	return nil
+}
+
+func (t *Controller) renderAuthorizeErrorPage(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "internal error"
+	if errors.IsUnexpected(err) {
+		log.Println(err)
+	} else {
+		status = http.StatusBadRequest
+		message = err.(*errors.Error).Message
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	err = t.html.Render(w, "authorize_error.html", message)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (t *Controller) renderTOTPPage(w http.ResponseWriter, HTTPStatus int, tkt string) {
+	type totpTemplateParam struct {
+		Ticket string
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(HTTPStatus)
+
+	err := t.html.Render(w, "auth_totp.html", totpTemplateParam{
+		Ticket: tkt,
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// changePasswordTemplateParam is given to the change password page.
+type changePasswordTemplateParam struct {
+	Ticket string
+	Error  string
+}
+
+func (t *Controller) renderChangePasswordPage(w http.ResponseWriter, HTTPStatus int, param changePasswordTemplateParam) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(HTTPStatus)
+
+	err := t.html.Render(w, "change_password.html", param)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// authTemplateParam is given to the authentication page to display an error.
+type authTemplateParam struct {
+	Error string
+}
+
 func (t *Controller) renderAuthenticationPage(w http.ResponseWriter, HTTPStatus int, param interface{}) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(HTTPStatus)
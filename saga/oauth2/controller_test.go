@@ -0,0 +1,1694 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/authorizationcode"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/consent"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/securityevent"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/openshift/osin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newController() (*Controller, *oauth2Mocks) {
+	storage, mocks := newStorageController()
+
+	config := DefaultConfig
+	config.TicketKey = ticketKey
+
+	osinServer := osin.NewServer(NewOsinConfig(config), storage)
+
+	controller := NewController(osinServer, mocks.html, mocks.user, mocks.consent, mocks.loginSession, mocks.loginAttempt, mocks.deviceCode, mocks.client, storage, mocks.accessToken, permission.NewController(context.Background(), mocks.accessToken, nil, nil), mocks.signingKey, config)
+
+	return controller, mocks
+}
+
+// nolint
+// Hardcoded secret used only for tests
+const validClientSecret = "2558539b-e119-408d-a31b-a1d6cf1b60aa"
+
+func newRevokeRequest(form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/revoke", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	return r
+}
+
+func Test_OAuth2_Controller_Revoke_an_access_token(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, newRevokeRequest(url.Values{
+		"token":           {"some-access-token"},
+		"token_type_hint": {"access_token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_a_refresh_token(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &accesstoken.ValidAccessToken, nil).Once()
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, newRevokeRequest(url.Values{
+		"token":           {"some-refresh-token"},
+		"token_type_hint": {"refresh_token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_with_a_wrong_hint(t *testing.T) {
+	controller, mocks := newController()
+
+	// The access token lookup is done first and fail so the refresh token
+	// lookup is done.
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-refresh-token"}).Return(nil, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &accesstoken.ValidAccessToken, nil).Once()
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, newRevokeRequest(url.Values{
+		"token":           {"some-refresh-token"},
+		"token_type_hint": {"access_token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_an_unknown_token(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-unknown-token"}).Return(nil, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-unknown-token"}).
+		Return("", nil, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, newRevokeRequest(url.Values{
+		"token": {"some-unknown-token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_a_token_issued_to_another_client(t *testing.T) {
+	controller, mocks := newController()
+
+	token := accesstoken.ValidAccessToken
+	token.ClientID = "some-other-client"
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&token, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, newRevokeRequest(url.Values{
+		"token": {"some-access-token"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "unauthorized_client",
+		"error_description": "the token has been issued to another client"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_with_an_invalid_secret(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", "some-invalid-secret", client.ValidClient.Secret).Return(false, nil).Once()
+
+	r := newRevokeRequest(url.Values{
+		"token": {"some-access-token"},
+	})
+	r.SetBasicAuth(client.ValidClient.ID, "some-invalid-secret")
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_client"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_with_the_client_credentials_in_the_form(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+
+	form := url.Values{
+		"token":         {"some-access-token"},
+		"client_id":     {client.ValidClient.ID},
+		"client_secret": {validClientSecret},
+	}
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/revoke", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_without_client_authentication(t *testing.T) {
+	controller, mocks := newController()
+
+	form := url.Values{"token": {"some-access-token"}}
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/revoke", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_without_token(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, newRevokeRequest(url.Values{}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_request",
+		"error_description": "missing \"token\" parameter"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Revoke_with_a_delete_error(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-access-token"}).Return(fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	controller.Revoke(w, newRevokeRequest(url.Values{
+		"token": {"some-access-token"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"server_error"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func newIntrospectRequest(form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/introspect", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	return r
+}
+
+func Test_OAuth2_Controller_Introspect_an_access_token(t *testing.T) {
+	controller, mocks := newController()
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-time.Minute).Round(time.Second)
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&token, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Introspect(w, newIntrospectRequest(url.Values{
+		"token": {"some-access-token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"active": true,
+		"scope": "users account foobar clients contacts todos",
+		"client_id": "my-web-application",
+		"sub": "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+		"iat": %d,
+		"exp": %d
+	}`, token.CreatedAt.Unix(), token.CreatedAt.Add(time.Hour).Unix()), w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Introspect_a_refresh_token(t *testing.T) {
+	controller, mocks := newController()
+
+	// The access token is expired but not the refresh token.
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-2 * time.Hour).Round(time.Second)
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &token, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Introspect(w, newIntrospectRequest(url.Values{
+		"token":           {"some-refresh-token"},
+		"token_type_hint": {"refresh_token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"active": true,
+		"scope": "users account foobar clients contacts todos",
+		"client_id": "my-web-application",
+		"sub": "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+		"iat": %d,
+		"exp": %d
+	}`, token.CreatedAt.Unix(), token.RefreshExpireAt().Unix()), w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Introspect_an_expired_token(t *testing.T) {
+	controller, mocks := newController()
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-2 * time.Hour)
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&token, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Introspect(w, newIntrospectRequest(url.Values{
+		"token": {"some-access-token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active": false}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Introspect_an_unknown_token(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-unknown-token"}).Return(nil, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-unknown-token"}).
+		Return("", nil, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Introspect(w, newIntrospectRequest(url.Values{
+		"token": {"some-unknown-token"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active": false}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Introspect_without_client_authentication(t *testing.T) {
+	controller, mocks := newController()
+
+	form := url.Values{"token": {"some-access-token"}}
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/introspect", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	controller.Introspect(w, r)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_client"`)
+
+	mocks.AssertExpectations(t)
+}
+
+const authorizeURL = "http://example.com/oauth2/auth?response_type=code&client_id=my-web-application&redirect_uri=http%3A%2F%2Fmydomain%2Foauth%2Fcallback&state=some-state&scope=user+contacts"
+
+func newAuthorizeRequest(form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", authorizeURL, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+func Test_OAuth2_Controller_Authorize_render_the_authentication_page(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, httptest.NewRequest("GET", authorizeURL, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_existing_consent(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).
+		Return(user.ValidUserID, &user.ValidUser, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+	mocks.loginSession.On("Create", &loginsession.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 24 * 3600}).
+		Return(loginsession.ValidLoginSessionID, nil).Once()
+	mocks.consent.On("Get", &consent.GetCmd{UserID: user.ValidUserID, ClientID: client.ValidClient.ID}).
+		Return(&consent.Consent{UserID: user.ValidUserID, ClientID: client.ValidClient.ID, Scopes: []string{"user", "admin"}}, nil).Once()
+	mocks.authorizationCode.On("Create", mock.MatchedBy(func(cmd *authorizationcode.CreateCmd) bool {
+		// Only the "user" scope is registered for the client.
+		return cmd.UserID == user.ValidUserID && assert.Equal(t, []string{"user"}, cmd.Scopes)
+	})).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "mydomain", location.Host)
+	assert.NotEmpty(t, location.Query().Get("code"))
+	assert.Equal(t, "some-state", location.Query().Get("state"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_render_the_consent_page(t *testing.T) {
+	controller, mocks := newController()
+
+	var ticketParam string
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).
+		Return(user.ValidUserID, &user.ValidUser, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+	mocks.loginSession.On("Create", &loginsession.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 24 * 3600}).
+		Return(loginsession.ValidLoginSessionID, nil).Once()
+	mocks.consent.On("Get", &consent.GetCmd{UserID: user.ValidUserID, ClientID: client.ValidClient.ID}).Return(nil, nil).Once()
+	mocks.html.On("Render", "consent.html", mock.MatchedBy(func(params interface{}) bool {
+		ticketParam = reflect.ValueOf(params).FieldByName("Ticket").String()
+
+		return assert.Equal(t, client.ValidClient.Name, reflect.ValueOf(params).FieldByName("ClientName").String()) &&
+			assert.Equal(t, []string{"user"}, reflect.ValueOf(params).FieldByName("Scopes").Interface())
+	})).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	tkt, err := parseTicket(ticketKey, ticketParam, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, user.ValidUserID, tkt.UserID)
+	assert.Equal(t, client.ValidClient.ID, tkt.ClientID)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_accepted_consent(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.consent.On("Grant", &consent.GrantCmd{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scopes:   []string{"user"},
+	}).Return(nil).Once()
+	mocks.authorizationCode.On("Create", mock.MatchedBy(func(cmd *authorizationcode.CreateCmd) bool {
+		return cmd.UserID == user.ValidUserID
+	})).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"ticket":  {tkt},
+		"consent": {"allow"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, location.Query().Get("code"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_scope_not_allowed_for_the_user_role(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+	})
+
+	dev := user.ValidUser
+	dev.Role = user.Dev
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&dev, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"ticket":  {tkt},
+		"consent": {"allow"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid_scope", location.Query().Get("error"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_denied_consent(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"ticket":  {tkt},
+		"consent": {"deny"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "access_denied", location.Query().Get("error"))
+	assert.Equal(t, "some-state", location.Query().Get("state"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_ticket_for_another_client(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: "some-other-client",
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"ticket":  {tkt},
+		"consent": {"allow"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_scope_not_allowed_for_the_client(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+
+	r := httptest.NewRequest("POST", strings.Replace(authorizeURL, "scope=user+contacts", "scope=contacts", 1), strings.NewReader(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, r)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid_scope", location.Query().Get("error"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_unregistered_redirect_uri(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.html.On("Render", "authorize_error.html", `the redirect URI "http://mydomain/oauth/callback/foo" is not registered for this client`).Return(nil).Once()
+
+	r := httptest.NewRequest("GET", strings.Replace(authorizeURL, "callback", "callback%2Ffoo", 1), nil)
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, r)
+
+	// No redirection must be done with an unregistered URI.
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_unknown_client(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(nil, nil).Once()
+	mocks.html.On("Render", "authorize_error.html", "unknown client").Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, httptest.NewRequest("GET", authorizeURL, nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_one_of_the_registered_redirect_uris(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.RedirectURIs = []string{"http://mydomain/oauth/other", "http://mydomain/oauth/callback"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Twice()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, httptest.NewRequest("GET", authorizeURL, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_without_redirect_uri_and_several_registered(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.RedirectURIs = []string{"http://mydomain/oauth/other", "http://mydomain/oauth/callback"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Once()
+	mocks.html.On("Render", "authorize_error.html", `missing "redirect_uri" parameter`).Return(nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/oauth2/auth?response_type=code&client_id=my-web-application", nil)
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_response_type_not_allowed_for_the_client(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.ResponseTypes = []string{"token"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Twice()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "unauthorized_client", location.Query().Get("error"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_a_grant_type_not_allowed_for_the_client(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.GrantTypes = []string{"authorize_code"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/token", strings.NewReader(url.Values{
+		"grant_type": {"client_credentials"},
+	}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	w := httptest.NewRecorder()
+	controller.Token(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "unauthorized_client",
+		"error_description": "the grant type \"client_credentials\" is not allowed for this client"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_client_credentials(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("Create", mock.Anything).Return(nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/token", strings.NewReader(url.Values{
+		"grant_type": {"client_credentials"},
+	}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	w := httptest.NewRecorder()
+	controller.Token(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"access_token"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_an_invalid_client_secret(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", "some-invalid-secret", client.ValidClient.Secret).Return(false, nil).Once()
+
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/token", strings.NewReader(url.Values{
+		"grant_type": {"client_credentials"},
+	}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, "some-invalid-secret")
+
+	w := httptest.NewRecorder()
+	controller.Token(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "unauthorized_client",
+		"error_description": "The client is not authorized to request a token using this method."
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func newAuthorizationCodeRequest(form url.Values) *http.Request {
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", "some-code")
+
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+func Test_OAuth2_Controller_Token_with_a_code_verifier(t *testing.T) {
+	controller, mocks := newController()
+
+	publicClient := client.ValidClient
+	publicClient.Secret = ""
+	publicClient.Public = true
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.RedirectURI = client.ValidClient.RedirectURIs[0]
+	code.CodeChallenge = validCodeChallenge
+	code.CodeChallengeMethod = osin.PKCE_S256
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&publicClient, nil).Twice()
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: code.UserID}).Return(&user.ValidUser, nil).Once()
+	mocks.accessToken.On("Create", mock.Anything).Return(nil).Once()
+	mocks.authorizationCode.On("Delete", &authorizationcode.DeleteCmd{Code: "some-code"}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newAuthorizationCodeRequest(url.Values{
+		"client_id":     {client.ValidClient.ID},
+		"client_secret": {""},
+		"code_verifier": {validCodeVerifier},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"access_token"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_an_invalid_code_verifier(t *testing.T) {
+	controller, mocks := newController()
+
+	publicClient := client.ValidClient
+	publicClient.Secret = ""
+	publicClient.Public = true
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.RedirectURI = client.ValidClient.RedirectURIs[0]
+	code.CodeChallenge = validCodeChallenge
+	code.CodeChallengeMethod = osin.PKCE_S256
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&publicClient, nil).Twice()
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newAuthorizationCodeRequest(url.Values{
+		"client_id":     {client.ValidClient.ID},
+		"client_secret": {""},
+		"code_verifier": {"some-invalid-code-verifier-with-the-minimum-length"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_grant",
+		"error_description": "The provided authorization grant (e.g., authorization code, resource owner credentials) or refresh token is invalid, expired, revoked, does not match the redirection URI used in the authorization request, or was issued to another client."
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_without_code_challenge_for_a_public_client(t *testing.T) {
+	controller, mocks := newController()
+
+	publicClient := client.ValidClient
+	publicClient.Secret = ""
+	publicClient.Public = true
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.RedirectURI = client.ValidClient.RedirectURIs[0]
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&publicClient, nil).Twice()
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newAuthorizationCodeRequest(url.Values{
+		"client_id":     {client.ValidClient.ID},
+		"client_secret": {""},
+		"code_verifier": {validCodeVerifier},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_grant"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_without_code_challenge_for_a_public_client(t *testing.T) {
+	controller, mocks := newController()
+
+	publicClient := client.ValidClient
+	publicClient.Secret = ""
+	publicClient.Public = true
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&publicClient, nil).Twice()
+
+	r := httptest.NewRequest("GET", "http://example.com/oauth2/authorize?response_type=code&client_id=my-web-application&state=some-state", nil)
+	w := httptest.NewRecorder()
+	controller.Authorize(w, r)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "http://mydomain/oauth/callback?error=invalid_request&error_description=code_challenge+%28rfc7636%29+required+for+public+clients&state=some-state", w.Header().Get("Location"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_plain_code_challenge_not_allowed(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+
+	form := url.Values{
+		"username":              {"some-username"},
+		"password":              {"some-password"},
+		"code_challenge":        {validCodeVerifier},
+		"code_challenge_method": {"plain"},
+	}
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/authorize?response_type=code&client_id=my-web-application&state=some-state", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	controller.Authorize(w, r)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "http://mydomain/oauth/callback?error=invalid_request&error_description=code_challenge_method+transform+algorithm+not+supported+%28rfc7636%29&state=some-state", w.Header().Get("Location"))
+
+	mocks.AssertExpectations(t)
+}
+
+func newPasswordRequest(form url.Values) *http.Request {
+	form.Set("grant_type", "password")
+
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	return r
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.Scopes = []string{"users", "contacts", "todos"}
+
+	dev := user.ValidUser
+	dev.Role = user.Dev
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).Return(user.ValidUserID, &dev, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&dev, nil).Once()
+	mocks.accessToken.On("Create", mock.MatchedBy(func(cmd *accesstoken.CreateCmd) bool {
+		return cmd.UserID == user.ValidUserID &&
+			cmd.Role == user.Dev &&
+			reflect.DeepEqual(cmd.Scopes, []string{"users.read", "contacts"}) &&
+			cmd.RefreshToken != ""
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+		"scope":    {"users.read users.write contacts"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"access_token"`)
+	assert.Contains(t, w.Body.String(), `"scope":"users.read,contacts"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant_and_invalid_credentials(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-invalid-password"}).Return("", nil, nil).Once()
+	mocks.loginAttempt.On("RecordFailure", &loginattempt.RecordFailureCmd{Username: "some-username", IP: "192.0.2.1"}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-invalid-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_grant",
+		"error_description": "invalid username or password"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant_and_a_scope_not_allowed_for_the_role(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.Scopes = []string{"users"}
+
+	dev := user.ValidUser
+	dev.Role = user.Dev
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).Return(user.ValidUserID, &dev, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+		"scope":    {"users.write"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_scope"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant_and_a_validation_error(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"server_error"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant_not_allowed_for_the_client(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.GrantTypes = []string{"authorize_code"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "unauthorized_client",
+		"error_description": "the grant type \"password\" is not allowed for this client"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func newRefreshTokenRequest(refreshToken string) *http.Request {
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/token", strings.NewReader(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	return r
+}
+
+func Test_OAuth2_Controller_Token_with_a_refresh_token(t *testing.T) {
+	controller, mocks := newController()
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &token, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Twice()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&token, nil).Once()
+	mocks.accessToken.On("MarkRefreshTokenUsed", &accesstoken.MarkRefreshTokenUsedCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+	mocks.accessToken.On("Create", mock.MatchedBy(func(cmd *accesstoken.CreateCmd) bool {
+		return cmd.FamilyID == token.FamilyID &&
+			cmd.RefreshToken != "" &&
+			cmd.RefreshToken != "some-refresh-token"
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newRefreshTokenRequest("some-refresh-token"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"refresh_token"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_a_refresh_token_of_a_demoted_user(t *testing.T) {
+	controller, mocks := newController()
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now()
+	token.Scopes = []string{"users", "clients"}
+
+	dev := user.ValidUser
+	dev.Role = user.Dev
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &token, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&dev, nil).Twice()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&token, nil).Once()
+	mocks.accessToken.On("MarkRefreshTokenUsed", &accesstoken.MarkRefreshTokenUsedCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+	mocks.accessToken.On("Create", mock.MatchedBy(func(cmd *accesstoken.CreateCmd) bool {
+		return cmd.Role == user.Dev && assert.ObjectsAreEqual([]string{"clients"}, cmd.Scopes)
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newRefreshTokenRequest("some-refresh-token"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"scope":"clients"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_a_refresh_token_of_a_deleted_user(t *testing.T) {
+	controller, mocks := newController()
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &token, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(nil, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newRefreshTokenRequest("some-refresh-token"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_grant"`)
+
+	mocks.AssertExpectations(t)
+}
+func Test_OAuth2_Controller_Token_with_a_reused_refresh_token(t *testing.T) {
+	controller, mocks := newController()
+
+	usedToken := accesstoken.ValidAccessToken
+	usedToken.CreatedAt = time.Now()
+	usedToken.RefreshTokenUsed = true
+
+	lastToken := accesstoken.ValidAccessToken
+	lastToken.AccessToken = "some-last-access-token"
+	lastToken.CreatedAt = time.Now()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &usedToken, nil).Once()
+	mocks.accessToken.On("FindAllByFamily", &accesstoken.FindAllByFamilyCmd{FamilyID: usedToken.FamilyID}).
+		Return([]string{"some-access-token", "some-last-access-token"}, nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&usedToken, nil).Once()
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-last-access-token"}).Return(&lastToken, nil).Once()
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-last-access-token"}).Return(nil).Once()
+	mocks.securityEvent.On("Create", &securityevent.CreateCmd{
+		Type:        securityevent.RefreshTokenReused,
+		UserID:      usedToken.UserID,
+		ClientID:    usedToken.ClientID,
+		Description: fmt.Sprintf("token family %q revoked", usedToken.FamilyID),
+	}).Return(securityevent.ValidSecurityEventID, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newRefreshTokenRequest("some-refresh-token"))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_grant"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_render_the_totp_page(t *testing.T) {
+	controller, mocks := newController()
+
+	owner := user.ValidUser
+	owner.TOTPEnabled = true
+
+	var ticketParam string
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).
+		Return(user.ValidUserID, &owner, nil).Once()
+	// The failures are only cleared after the second factor.
+	mocks.html.On("Render", "auth_totp.html", mock.MatchedBy(func(params interface{}) bool {
+		ticketParam = reflect.ValueOf(params).FieldByName("Ticket").String()
+		return true
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The login session is only opened after the second factor.
+	assert.Empty(t, w.Result().Cookies())
+
+	tkt, err := parseTicket(ticketKey, ticketParam, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, user.ValidUserID, tkt.UserID)
+	assert.Equal(t, totpStep, tkt.Step)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_valid_totp_code(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     totpStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: user.ValidUser.Username, IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("VerifyTOTP", &user.VerifyTOTPCmd{UserID: user.ValidUserID, Code: "123456"}).Return(true, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: user.ValidUser.Username}).Return(nil).Once()
+	mocks.loginSession.On("Create", &loginsession.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 24 * 3600}).
+		Return(loginsession.ValidLoginSessionID, nil).Once()
+	mocks.consent.On("Get", &consent.GetCmd{UserID: user.ValidUserID, ClientID: client.ValidClient.ID}).
+		Return(&consent.Consent{UserID: user.ValidUserID, ClientID: client.ValidClient.ID, Scopes: []string{"user"}}, nil).Once()
+	mocks.authorizationCode.On("Create", mock.MatchedBy(func(cmd *authorizationcode.CreateCmd) bool {
+		return cmd.UserID == user.ValidUserID
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"totp_ticket": {tkt},
+		"code":        {"123456"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, location.Query().Get("code"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_invalid_totp_code(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     totpStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: user.ValidUser.Username, IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("VerifyTOTP", &user.VerifyTOTPCmd{UserID: user.ValidUserID, Code: "000000"}).Return(false, nil).Once()
+	mocks.loginAttempt.On("RecordFailure", &loginattempt.RecordFailureCmd{Username: user.ValidUser.Username, IP: "192.0.2.1"}).Return(nil).Once()
+	mocks.html.On("Render", "auth_totp.html", mock.MatchedBy(func(params interface{}) bool {
+		return reflect.ValueOf(params).FieldByName("Ticket").String() == tkt
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"totp_ticket": {tkt},
+		"code":        {"000000"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_too_many_failed_totp_codes(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     totpStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: user.ValidUser.Username, IP: "192.0.2.1"}).Return(8*time.Second, nil).Once()
+	mocks.html.On("Render", "auth.html", authTemplateParam{
+		Error: "Too many failed login attempts. Try again in 8 seconds.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"totp_ticket": {tkt},
+		"code":        {"123456"},
+	}))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_consent_ticket_as_totp_ticket(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"totp_ticket": {tkt},
+		"code":        {"123456"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_totp_ticket_as_consent_ticket(t *testing.T) {
+	controller, mocks := newController()
+
+	// The password step must not be enough to skip the second factor.
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     totpStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"ticket":  {tkt},
+		"consent": {"allow"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant_and_the_two_factor_authentication(t *testing.T) {
+	controller, mocks := newController()
+
+	owner := user.ValidUser
+	owner.TOTPEnabled = true
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).Return(user.ValidUserID, &owner, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_grant",
+		"error_description": "two-factor authentication required"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_invalid_password(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-invalid-password"}).Return("", nil, nil).Once()
+	mocks.loginAttempt.On("RecordFailure", &loginattempt.RecordFailureCmd{Username: "some-username", IP: "192.0.2.1"}).Return(nil).Once()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-invalid-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_too_many_failed_attempts(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(90*time.Second, nil).Once()
+	mocks.html.On("Render", "auth.html", authTemplateParam{
+		Error: "Too many failed login attempts. Try again in 2 minutes.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant_and_too_many_failed_attempts(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Minute, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_grant",
+		"error_description": "too many failed login attempts"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_formatDelay(t *testing.T) {
+	assert.Equal(t, "1 second", formatDelay(100*time.Millisecond))
+	assert.Equal(t, "42 seconds", formatDelay(42*time.Second))
+	assert.Equal(t, "60 seconds", formatDelay(time.Minute))
+	assert.Equal(t, "15 minutes", formatDelay(15*time.Minute))
+}
+
+func Test_OAuth2_Controller_Authorize_render_the_change_password_page(t *testing.T) {
+	controller, mocks := newController()
+
+	owner := user.ValidUser
+	owner.MustChangePassword = true
+
+	var ticketParam string
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).
+		Return(user.ValidUserID, &owner, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+	mocks.html.On("Render", "change_password.html", mock.MatchedBy(func(params interface{}) bool {
+		ticketParam = params.(changePasswordTemplateParam).Ticket
+		return params.(changePasswordTemplateParam).Error == ""
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The login session is only opened once the password is changed.
+	assert.Empty(t, w.Result().Cookies())
+
+	tkt, err := parseTicket(ticketKey, ticketParam, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, user.ValidUserID, tkt.UserID)
+	assert.Equal(t, passwordStep, tkt.Step)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_render_the_change_password_page_after_the_totp_code(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     totpStep,
+	})
+
+	owner := user.ValidUser
+	owner.TOTPEnabled = true
+	owner.MustChangePassword = true
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&owner, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: owner.Username, IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("VerifyTOTP", &user.VerifyTOTPCmd{UserID: user.ValidUserID, Code: "123456"}).Return(true, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: owner.Username}).Return(nil).Once()
+	mocks.html.On("Render", "change_password.html", mock.Anything).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"totp_ticket": {tkt},
+		"code":        {"123456"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_changed_password(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     passwordStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.user.On("ChangePassword", &user.ChangePasswordCmd{
+		UserID:              user.ValidUserID,
+		NewPassword:         "some-new-password",
+		SkipCurrentPassword: true,
+	}).Return(nil).Once()
+	mocks.loginSession.On("Create", &loginsession.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 24 * 3600}).
+		Return(loginsession.ValidLoginSessionID, nil).Once()
+	mocks.consent.On("Get", &consent.GetCmd{UserID: user.ValidUserID, ClientID: client.ValidClient.ID}).
+		Return(&consent.Consent{UserID: user.ValidUserID, ClientID: client.ValidClient.ID, Scopes: []string{"user"}}, nil).Once()
+	mocks.authorizationCode.On("Create", mock.MatchedBy(func(cmd *authorizationcode.CreateCmd) bool {
+		return cmd.UserID == user.ValidUserID
+	})).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"password_ticket": {tkt},
+		"new_password":    {"some-new-password"},
+		"confirmation":    {"some-new-password"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, location.Query().Get("code"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_wrong_password_confirmation(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     passwordStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.html.On("Render", "change_password.html", changePasswordTemplateParam{
+		Ticket: tkt,
+		Error:  "The passwords don't match.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"password_ticket": {tkt},
+		"new_password":    {"some-new-password"},
+		"confirmation":    {"some-other-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_invalid_new_password(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     passwordStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.user.On("ChangePassword", &user.ChangePasswordCmd{
+		UserID:              user.ValidUserID,
+		NewPassword:         "short",
+		SkipCurrentPassword: true,
+	}).Return(errors.NewValidationError().AddError("newPassword", "TOO_SHORT").IntoError()).Once()
+	mocks.html.On("Render", "change_password.html", changePasswordTemplateParam{
+		Ticket: tkt,
+		Error:  "The new password must have between 8 and 256 characters and differ from the current one.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"password_ticket": {tkt},
+		"new_password":    {"short"},
+		"confirmation":    {"short"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_totp_ticket_as_password_ticket(t *testing.T) {
+	controller, mocks := newController()
+
+	// The password can't be changed without the second factor.
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     totpStep,
+	})
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"password_ticket": {tkt},
+		"new_password":    {"some-new-password"},
+		"confirmation":    {"some-new-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_password_grant_and_a_password_to_change(t *testing.T) {
+	controller, mocks := newController()
+
+	owner := user.ValidUser
+	owner.MustChangePassword = true
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).Return(user.ValidUserID, &owner, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_grant",
+		"error_description": "password change required"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
@@ -0,0 +1,351 @@
+package oauth2
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/devicecode"
+	"github.com/openshift/osin"
+)
+
+// DeviceCodeGrantType is the grant type used by the devices polling the
+// token endpoint (RFC 8628).
+const DeviceCodeGrantType osin.AccessRequestType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// The token endpoint errors specific to the device authorization grant (RFC
+// 8628 section 3.5).
+const (
+	errAuthorizationPending = "authorization_pending"
+	errSlowDown             = "slow_down"
+	errExpiredToken         = "expired_token"
+)
+
+// DeviceAuthorization starts the device authorization grant. The device
+// displays the returned user code and polls the token endpoint until the
+// user approves it on the verification page.
+func (t *Controller) DeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	resp := t.inner.NewResponse()
+	defer resp.Close()
+
+	t.handleDeviceAuthorizationRequest(resp, r)
+
+	err := osin.OutputJSON(resp, w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (t *Controller) handleDeviceAuthorizationRequest(resp *osin.Response, r *http.Request) {
+	if r.Method != "POST" {
+		resp.SetError(osin.E_INVALID_REQUEST, "request must be POST")
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		resp.SetError(osin.E_INVALID_REQUEST, "")
+		resp.InternalError = err
+		return
+	}
+
+	osinClient := t.authenticateClient(resp, r)
+	if osinClient == nil {
+		return
+	}
+
+	client := osinClient.GetUserData().(*client.Client)
+	if !contains(client.GrantTypes, clientGrantType(DeviceCodeGrantType)) {
+		resp.SetError(osin.E_UNAUTHORIZED_CLIENT, fmt.Sprintf("the grant type %q is not allowed for this client", DeviceCodeGrantType))
+		return
+	}
+
+	// Only grant the scopes registered for the client.
+	scopes := negotiateScopes(parseScopes(r.PostForm.Get("scope")), client.Scopes)
+	if len(scopes) == 0 {
+		resp.SetError(osin.E_INVALID_SCOPE, "")
+		return
+	}
+
+	deviceCode, userCode, err := t.deviceCode.Create(r.Context(), &devicecode.CreateCmd{
+		ClientID:  client.ID,
+		Scope:     strings.Join(scopes, ","),
+		ExpiresIn: int(t.config.DeviceCodeExpiration),
+		Interval:  int(t.config.DeviceCodeInterval),
+	})
+	if err != nil {
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return
+	}
+
+	verificationURI := t.config.Issuer + DevicePath
+	userCode = devicecode.FormatUserCode(userCode)
+
+	resp.Output["device_code"] = deviceCode
+	resp.Output["user_code"] = userCode
+	resp.Output["verification_uri"] = verificationURI
+	resp.Output["verification_uri_complete"] = verificationURI + "?user_code=" + url.QueryEscape(userCode)
+	resp.Output["expires_in"] = t.config.DeviceCodeExpiration
+	resp.Output["interval"] = t.config.DeviceCodeInterval
+}
+
+// handleDeviceAccessRequest checks the device code polled by a device. It
+// returns nil and fills the response error until the user approves the
+// device.
+func (t *Controller) handleDeviceAccessRequest(resp *osin.Response, r *http.Request) *osin.AccessRequest {
+	if r.Method != "POST" {
+		resp.SetError(osin.E_INVALID_REQUEST, "request must be POST")
+		return nil
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		resp.SetError(osin.E_INVALID_REQUEST, "")
+		resp.InternalError = err
+		return nil
+	}
+
+	osinClient := t.authenticateClient(resp, r)
+	if osinClient == nil {
+		return nil
+	}
+
+	client := osinClient.GetUserData().(*client.Client)
+	if !contains(client.GrantTypes, clientGrantType(DeviceCodeGrantType)) {
+		resp.SetError(osin.E_UNAUTHORIZED_CLIENT, fmt.Sprintf("the grant type %q is not allowed for this client", DeviceCodeGrantType))
+		return nil
+	}
+
+	deviceCode, err := t.deviceCode.Poll(r.Context(), &devicecode.PollCmd{
+		DeviceCode: r.PostForm.Get("device_code"),
+		ClientID:   client.ID,
+	})
+	switch {
+	case err == devicecode.ErrSlowDown:
+		resp.SetError(errSlowDown, "")
+		return nil
+	case errors.IsKind(err, errors.Validation):
+		resp.SetError(osin.E_INVALID_REQUEST, `invalid "device_code" parameter`)
+		return nil
+	case err != nil:
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return nil
+	case deviceCode == nil:
+		resp.SetError(osin.E_INVALID_GRANT, "")
+		return nil
+	case deviceCode.IsExpiredAt(time.Now()):
+		resp.SetError(errExpiredToken, "")
+		return nil
+	case deviceCode.Status == devicecode.Pending:
+		resp.SetError(errAuthorizationPending, "")
+		return nil
+	case deviceCode.Status != devicecode.Approved:
+		resp.SetError(osin.E_ACCESS_DENIED, "")
+		return nil
+	}
+
+	// The role of the user can have changed since the approval.
+	scopes, err := t.userScopes(r.Context(), deviceCode.UserID, parseScopes(deviceCode.Scope))
+	if err != nil {
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return nil
+	}
+
+	if len(scopes) == 0 {
+		resp.SetError(osin.E_INVALID_SCOPE, "")
+		return nil
+	}
+
+	return &osin.AccessRequest{
+		Type:            DeviceCodeGrantType,
+		Client:          osinClient,
+		Scope:           strings.Join(scopes, ","),
+		UserData:        deviceCode.UserID,
+		Authorized:      true,
+		Expiration:      t.inner.Config.AccessExpiration,
+		GenerateRefresh: true,
+		HttpRequest:     r,
+	}
+}
+
+// deviceTemplateParam is given to the device page. The page asks for the
+// user code, then for the approval of the device.
+type deviceTemplateParam struct {
+	UserCode   string
+	ClientName string
+	Scopes     []string
+	Ticket     string
+	Approved   bool
+	Denied     bool
+	Error      string
+}
+
+// Device is the verification page where the users type the user code
+// displayed by a device and approve it. The users without a login session
+// are authenticated first.
+func (t *Controller) Device(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		t.renderDevicePage(w, http.StatusBadRequest, deviceTemplateParam{})
+		return
+	}
+
+	userCode := r.Form.Get("user_code")
+	if userCode == "" {
+		t.renderDevicePage(w, http.StatusOK, deviceTemplateParam{})
+		return
+	}
+
+	deviceCode, err := t.deviceCode.GetByUserCode(r.Context(), &devicecode.GetByUserCodeCmd{
+		UserCode: userCode,
+	})
+	if errors.IsKind(err, errors.Validation) || (err == nil && deviceCode == nil) {
+		t.renderDevicePage(w, http.StatusBadRequest, deviceTemplateParam{
+			Error: "This code is invalid or expired.",
+		})
+		return
+	}
+
+	if err != nil {
+		t.renderAuthorizeErrorPage(w, errors.Wrap(err, "failed to retrieve the device code"))
+		return
+	}
+
+	res, err := t.storage.GetClient(deviceCode.ClientID)
+	if err != nil {
+		t.renderAuthorizeErrorPage(w, errors.Wrapf(err, "failed to retrieve the client %q", deviceCode.ClientID))
+		return
+	}
+
+	client := res.GetUserData().(*client.Client)
+
+	if r.PostForm.Get("device_ticket") != "" {
+		t.answerDevice(w, r, client, deviceCode)
+		return
+	}
+
+	var userID string
+	if r.Method == "GET" {
+		// The users with an open login session skip the authentication
+		// page.
+		userID, err = t.getLoginSessionUserID(r)
+		if err != nil {
+			t.renderAuthorizeErrorPage(w, err)
+			return
+		}
+
+		if userID == "" {
+			t.renderAuthenticationPage(w, http.StatusOK, nil)
+			return
+		}
+	} else {
+		var rendered bool
+		userID, rendered, err = t.authenticateUser(w, r, client, deviceCode.Scope)
+		if err != nil {
+			t.renderAuthorizeErrorPage(w, err)
+			return
+		}
+
+		if rendered {
+			return
+		}
+
+		err = t.openLoginSession(r.Context(), w, userID)
+		if err != nil {
+			t.renderAuthorizeErrorPage(w, err)
+			return
+		}
+	}
+
+	// Only show the scopes allowed for the user role.
+	scopes, err := t.userScopes(r.Context(), userID, parseScopes(deviceCode.Scope))
+	if err != nil {
+		t.renderAuthorizeErrorPage(w, err)
+		return
+	}
+
+	if len(scopes) == 0 {
+		t.renderDevicePage(w, http.StatusForbidden, deviceTemplateParam{
+			Error: "You are not allowed to give the access asked by this device.",
+		})
+		return
+	}
+
+	tkt, err := signTicket(t.config.TicketKey, &ticket{
+		UserID:   userID,
+		ClientID: client.ID,
+		Scope:    deviceCode.Scope,
+		ExpireAt: time.Now().Add(ticketLifetime).Unix(),
+		Step:     deviceStep,
+		UserCode: deviceCode.UserCode,
+	})
+	if err != nil {
+		t.renderAuthorizeErrorPage(w, errors.Wrap(err, "failed to sign the ticket"))
+		return
+	}
+
+	t.renderDevicePage(w, http.StatusOK, deviceTemplateParam{
+		UserCode:   devicecode.FormatUserCode(deviceCode.UserCode),
+		ClientName: client.Name,
+		Scopes:     scopes,
+		Ticket:     tkt,
+	})
+}
+
+// answerDevice approves or denies the device once the user answered the
+// device page.
+func (t *Controller) answerDevice(w http.ResponseWriter, r *http.Request, client *client.Client, deviceCode *devicecode.DeviceCode) {
+	tkt, err := parseTicket(t.config.TicketKey, r.PostForm.Get("device_ticket"), time.Now())
+	if err != nil || tkt.Step != deviceStep || tkt.ClientID != client.ID || tkt.Scope != deviceCode.Scope || tkt.UserCode != deviceCode.UserCode {
+		t.renderAuthenticationPage(w, http.StatusBadRequest, nil)
+		return
+	}
+
+	if r.PostForm.Get("consent") != "allow" {
+		err = t.deviceCode.Deny(r.Context(), &devicecode.DenyCmd{
+			UserCode: deviceCode.UserCode,
+		})
+		if err != nil {
+			t.renderAuthorizeErrorPage(w, errors.Wrap(err, "failed to deny the device"))
+			return
+		}
+
+		t.renderDevicePage(w, http.StatusOK, deviceTemplateParam{
+			ClientName: client.Name,
+			Denied:     true,
+		})
+		return
+	}
+
+	err = t.deviceCode.Approve(r.Context(), &devicecode.ApproveCmd{
+		UserCode: deviceCode.UserCode,
+		UserID:   tkt.UserID,
+	})
+	if err != nil {
+		t.renderAuthorizeErrorPage(w, errors.Wrap(err, "failed to approve the device"))
+		return
+	}
+
+	t.renderDevicePage(w, http.StatusOK, deviceTemplateParam{
+		ClientName: client.Name,
+		Approved:   true,
+	})
+}
+
+func (t *Controller) renderDevicePage(w http.ResponseWriter, HTTPStatus int, param deviceTemplateParam) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(HTTPStatus)
+
+	err := t.html.Render(w, "device.html", param)
+	if err != nil {
+		log.Println(err)
+	}
+}
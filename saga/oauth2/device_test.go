@@ -0,0 +1,446 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/devicecode"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeviceClient() *client.Client {
+	cli := client.ValidClient
+	cli.GrantTypes = []string{"device_code", "refresh_token"}
+
+	return &cli
+}
+
+func newDeviceAuthorizationRequest(form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/device_authorization", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	return r
+}
+
+func newDeviceCodeRequest() *http.Request {
+	form := url.Values{
+		"grant_type":  {string(DeviceCodeGrantType)},
+		"device_code": {devicecode.ValidDeviceCodeToken},
+	}
+
+	r := httptest.NewRequest("POST", "http://example.com/oauth2/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	return r
+}
+
+func newDevicePageRequest(form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "http://example.com/device?user_code=WDJB-MJHT", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+func newDeviceCode() *devicecode.DeviceCode {
+	deviceCode := devicecode.ValidDeviceCode
+	deviceCode.ClientID = client.ValidClient.ID
+	deviceCode.Scope = "user"
+	deviceCode.CreatedAt = time.Now()
+
+	return &deviceCode
+}
+
+func Test_OAuth2_Controller_DeviceAuthorization(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.deviceCode.On("Create", &devicecode.CreateCmd{
+		ClientID:  client.ValidClient.ID,
+		Scope:     "user",
+		ExpiresIn: 600,
+		Interval:  5,
+	}).Return(devicecode.ValidDeviceCodeToken, "WDJBMJHT", nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.DeviceAuthorization(w, newDeviceAuthorizationRequest(url.Values{
+		"scope": {"user"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"device_code": %q,
+		"user_code": "WDJB-MJHT",
+		"verification_uri": "http://localhost:42000/device",
+		"verification_uri_complete": "http://localhost:42000/device?user_code=WDJB-MJHT",
+		"expires_in": 600,
+		"interval": 5
+	}`, devicecode.ValidDeviceCodeToken), w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_DeviceAuthorization_with_a_grant_type_not_allowed_for_the_client(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.DeviceAuthorization(w, newDeviceAuthorizationRequest(url.Values{}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"unauthorized_client"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_DeviceAuthorization_with_a_scope_not_allowed_for_the_client(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.DeviceAuthorization(w, newDeviceAuthorizationRequest(url.Values{
+		"scope": {"todos"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_scope"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_an_approved_device_code(t *testing.T) {
+	controller, mocks := newController()
+
+	deviceCode := newDeviceCode()
+	deviceCode.Status = devicecode.Approved
+	deviceCode.UserID = user.ValidUserID
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.deviceCode.On("Poll", &devicecode.PollCmd{
+		DeviceCode: devicecode.ValidDeviceCodeToken,
+		ClientID:   client.ValidClient.ID,
+	}).Return(deviceCode, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Twice()
+	mocks.accessToken.On("Create", mock.MatchedBy(func(cmd *accesstoken.CreateCmd) bool {
+		return cmd.UserID == user.ValidUserID &&
+			assert.Equal(t, []string{"user"}, cmd.Scopes) &&
+			cmd.RefreshToken != ""
+	})).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newDeviceCodeRequest())
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"access_token"`)
+	assert.Contains(t, w.Body.String(), `"refresh_token"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_a_device_code_approved_by_a_demoted_user(t *testing.T) {
+	controller, mocks := newController()
+
+	deviceCode := newDeviceCode()
+	deviceCode.Status = devicecode.Approved
+	deviceCode.UserID = user.ValidUserID
+
+	dev := user.ValidUser
+	dev.Role = user.Dev
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.deviceCode.On("Poll", &devicecode.PollCmd{
+		DeviceCode: devicecode.ValidDeviceCodeToken,
+		ClientID:   client.ValidClient.ID,
+	}).Return(deviceCode, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&dev, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newDeviceCodeRequest())
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_scope"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_a_device_code_errors(t *testing.T) {
+	expired := newDeviceCode()
+	expired.CreatedAt = time.Now().Add(-time.Hour)
+
+	denied := newDeviceCode()
+	denied.Status = devicecode.Denied
+
+	tests := []struct {
+		Name          string
+		DeviceCode    *devicecode.DeviceCode
+		Error         error
+		ExpectedError string
+	}{
+		{Name: "pending", DeviceCode: newDeviceCode(), ExpectedError: "authorization_pending"},
+		{Name: "slow down", Error: devicecode.ErrSlowDown, ExpectedError: "slow_down"},
+		{Name: "expired", DeviceCode: expired, ExpectedError: "expired_token"},
+		{Name: "denied", DeviceCode: denied, ExpectedError: "access_denied"},
+		{Name: "unknown", ExpectedError: "invalid_grant"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			controller, mocks := newController()
+
+			mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+			mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+
+			var deviceCode interface{}
+			if test.DeviceCode != nil {
+				deviceCode = test.DeviceCode
+			}
+
+			mocks.deviceCode.On("Poll", &devicecode.PollCmd{
+				DeviceCode: devicecode.ValidDeviceCodeToken,
+				ClientID:   client.ValidClient.ID,
+			}).Return(deviceCode, test.Error).Once()
+
+			w := httptest.NewRecorder()
+			controller.Token(w, newDeviceCodeRequest())
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var res map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, test.ExpectedError, res["error"])
+
+			mocks.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_OAuth2_Controller_Token_with_a_device_code_not_allowed_for_the_client(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newDeviceCodeRequest())
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"unauthorized_client"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_render_the_user_code_page(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.html.On("Render", "device.html", deviceTemplateParam{}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, httptest.NewRequest("GET", "http://example.com/device", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_with_an_unknown_user_code(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.deviceCode.On("GetByUserCode", &devicecode.GetByUserCodeCmd{UserCode: "WDJB-MJHT"}).Return(nil, nil).Once()
+	mocks.html.On("Render", "device.html", deviceTemplateParam{
+		Error: "This code is invalid or expired.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, httptest.NewRequest("GET", "http://example.com/device?user_code=WDJB-MJHT", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_render_the_authentication_page(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.deviceCode.On("GetByUserCode", &devicecode.GetByUserCodeCmd{UserCode: "WDJB-MJHT"}).Return(newDeviceCode(), nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, httptest.NewRequest("GET", "http://example.com/device?user_code=WDJB-MJHT", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_with_a_login_session(t *testing.T) {
+	controller, mocks := newController()
+
+	session := loginsession.ValidLoginSession
+	session.UserID = user.ValidUserID
+
+	var ticketParam string
+
+	mocks.deviceCode.On("GetByUserCode", &devicecode.GetByUserCodeCmd{UserCode: "WDJB-MJHT"}).Return(newDeviceCode(), nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.loginSession.On("Get", &loginsession.GetCmd{SessionID: loginsession.ValidLoginSessionID}).Return(&session, nil).Once()
+	mocks.html.On("Render", "device.html", mock.MatchedBy(func(param deviceTemplateParam) bool {
+		ticketParam = param.Ticket
+
+		return assert.Equal(t, "WDJB-MJHT", param.UserCode) &&
+			assert.Equal(t, client.ValidClient.Name, param.ClientName) &&
+			assert.Equal(t, []string{"user"}, param.Scopes)
+	})).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, newSessionRequest("GET", "http://example.com/device?user_code=WDJB-MJHT"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	tkt, err := parseTicket(ticketKey, ticketParam, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, user.ValidUserID, tkt.UserID)
+	assert.Equal(t, deviceStep, tkt.Step)
+	assert.Equal(t, "WDJBMJHT", tkt.UserCode)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_with_valid_credentials(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.deviceCode.On("GetByUserCode", &devicecode.GetByUserCodeCmd{UserCode: "WDJB-MJHT"}).Return(newDeviceCode(), nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).
+		Return(user.ValidUserID, &user.ValidUser, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+	mocks.loginSession.On("Create", &loginsession.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 24 * 3600}).
+		Return(loginsession.ValidLoginSessionID, nil).Once()
+	mocks.html.On("Render", "device.html", mock.MatchedBy(func(param deviceTemplateParam) bool {
+		return param.Ticket != ""
+	})).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, newDevicePageRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Set-Cookie"), loginSessionCookie+"="+loginsession.ValidLoginSessionID)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_approved(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     deviceStep,
+		UserCode: "WDJBMJHT",
+	})
+
+	mocks.deviceCode.On("GetByUserCode", &devicecode.GetByUserCodeCmd{UserCode: "WDJB-MJHT"}).Return(newDeviceCode(), nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.deviceCode.On("Approve", &devicecode.ApproveCmd{UserCode: "WDJBMJHT", UserID: user.ValidUserID}).Return(nil).Once()
+	mocks.html.On("Render", "device.html", deviceTemplateParam{
+		ClientName: client.ValidClient.Name,
+		Approved:   true,
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, newDevicePageRequest(url.Values{
+		"device_ticket": {tkt},
+		"consent":       {"allow"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_denied(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     deviceStep,
+		UserCode: "WDJBMJHT",
+	})
+
+	mocks.deviceCode.On("GetByUserCode", &devicecode.GetByUserCodeCmd{UserCode: "WDJB-MJHT"}).Return(newDeviceCode(), nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.deviceCode.On("Deny", &devicecode.DenyCmd{UserCode: "WDJBMJHT"}).Return(nil).Once()
+	mocks.html.On("Render", "device.html", deviceTemplateParam{
+		ClientName: client.ValidClient.Name,
+		Denied:     true,
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, newDevicePageRequest(url.Values{
+		"device_ticket": {tkt},
+		"consent":       {"deny"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Device_with_a_ticket_for_another_user_code(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		UserID:   user.ValidUserID,
+		ClientID: client.ValidClient.ID,
+		Scope:    "user",
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     deviceStep,
+		UserCode: "BCDFGHJK",
+	})
+
+	mocks.deviceCode.On("GetByUserCode", &devicecode.GetByUserCodeCmd{UserCode: "WDJB-MJHT"}).Return(newDeviceCode(), nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(newDeviceClient(), nil).Once()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Device(w, newDevicePageRequest(url.Values{
+		"device_ticket": {tkt},
+		"consent":       {"allow"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
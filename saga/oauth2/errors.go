@@ -0,0 +1,13 @@
+package oauth2
+
+import "github.com/halium-project/go-server-utils/errors"
+
+// The osin library only defines osin.ErrNotFound so the expired credentials
+// are reported with those errors. Osin answers them with an "invalid_grant"
+// error.
+var (
+	ErrAuthorizationCodeExpired = errors.New(errors.NotAuthorized, "authorization code expired")
+	ErrAccessTokenExpired       = errors.New(errors.NotAuthorized, "access token expired")
+	ErrRefreshTokenExpired      = errors.New(errors.NotAuthorized, "refresh token expired")
+	ErrRefreshTokenReused       = errors.New(errors.NotAuthorized, "refresh token already used")
+)
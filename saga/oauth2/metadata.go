@@ -0,0 +1,118 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/response"
+	"github.com/halium-project/server/resource/client"
+	"github.com/openshift/osin"
+)
+
+// The paths of the OAuth2 endpoints. They are used to register the routes and
+// to build the metadata documents.
+const (
+	AuthorizePath           = "/oauth2/auth"
+	TokenPath               = "/oauth2/token"
+	InfoPath                = "/oauth2/info"
+	RevokePath              = "/oauth2/revoke"
+	IntrospectPath          = "/oauth2/introspect"
+	UserInfoPath            = "/oauth2/userinfo"
+	JWKSPath                = "/oauth2/jwks"
+	DeviceAuthorizationPath = "/oauth2/device_authorization"
+	RegisterPath            = "/oauth2/register"
+	DevicePath              = "/device"
+	LogoutPath              = "/logout"
+	DiscoveryPath           = "/.well-known/openid-configuration"
+	MetadataPath            = "/.well-known/oauth-authorization-server"
+)
+
+// Metadata returns the authorization server metadata described in the
+// RFC 8414.
+func (t *Controller) Metadata(w http.ResponseWriter, r *http.Request) {
+	metadata, err := t.serverMetadata(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.Wrap(err, "failed to retrieve the clients"))
+		return
+	}
+
+	response.Write(w, http.StatusOK, metadata)
+}
+
+// serverMetadata builds the metadata shared by the OAuth2 and the OpenID
+// Connect discovery documents. The supported types are read from the osin
+// configuration and the scopes are the ones registered for the clients.
+func (t *Controller) serverMetadata(ctx context.Context) (map[string]interface{}, error) {
+	clients, err := t.client.GetAll(ctx, &client.GetAllCmd{})
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []string{}
+	for _, client := range clients {
+		for _, scope := range client.Scopes {
+			if !contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	sort.Strings(scopes)
+
+	config := t.inner.Config
+	issuer := t.config.Issuer
+
+	responseTypes := []string{}
+	codeChallengeMethods := []string{}
+	for _, authorizeType := range config.AllowedAuthorizeTypes {
+		responseTypes = append(responseTypes, string(authorizeType))
+
+		// The plain method is only accepted for the clients allowing it
+		// (see checkPKCE) so it is not advertised.
+		if authorizeType == osin.CODE {
+			codeChallengeMethods = []string{osin.PKCE_S256}
+		}
+	}
+
+	grantTypes := []string{}
+	for _, accessType := range config.AllowedAccessTypes {
+		// osin uses an internal name for the implicit grant.
+		if accessType == osin.IMPLICIT {
+			grantTypes = append(grantTypes, "implicit")
+			continue
+		}
+
+		grantTypes = append(grantTypes, string(accessType))
+	}
+
+	// The device codes are exchanged outside of osin (see Token).
+	grantTypes = append(grantTypes, string(DeviceCodeGrantType))
+
+	authMethods := []string{"client_secret_basic"}
+	if config.AllowClientSecretInParams {
+		authMethods = append(authMethods, "client_secret_post")
+	}
+
+	// The public clients authenticate with their ID only.
+	authMethods = append(authMethods, "none")
+
+	return map[string]interface{}{
+		"issuer":                                        issuer,
+		"authorization_endpoint":                        issuer + AuthorizePath,
+		"token_endpoint":                                issuer + TokenPath,
+		"revocation_endpoint":                           issuer + RevokePath,
+		"introspection_endpoint":                        issuer + IntrospectPath,
+		"device_authorization_endpoint":                 issuer + DeviceAuthorizationPath,
+		"registration_endpoint":                         issuer + RegisterPath,
+		"jwks_uri":                                      issuer + JWKSPath,
+		"response_types_supported":                      responseTypes,
+		"grant_types_supported":                         grantTypes,
+		"code_challenge_methods_supported":              codeChallengeMethods,
+		"scopes_supported":                              scopes,
+		"token_endpoint_auth_methods_supported":         authMethods,
+		"revocation_endpoint_auth_methods_supported":    authMethods,
+		"introspection_endpoint_auth_methods_supported": authMethods,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/halium-project/server/resource/client"
+	"github.com/openshift/osin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OAuth2_Controller_Metadata(t *testing.T) {
+	controller, mocks := newController()
+
+	otherClient := client.ValidClient
+	otherClient.Scopes = []string{"contacts.read", "user"}
+
+	mocks.client.On("GetAll", &client.GetAllCmd{}).Return(map[string]client.Client{
+		client.ValidClient.ID: client.ValidClient,
+		"other-client":        otherClient,
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Metadata(w, httptest.NewRequest("GET", "http://example.com/.well-known/oauth-authorization-server", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"issuer": "http://localhost:42000",
+		"authorization_endpoint": "http://localhost:42000/oauth2/auth",
+		"token_endpoint": "http://localhost:42000/oauth2/token",
+		"revocation_endpoint": "http://localhost:42000/oauth2/revoke",
+		"introspection_endpoint": "http://localhost:42000/oauth2/introspect",
+		"device_authorization_endpoint": "http://localhost:42000/oauth2/device_authorization",
+		"registration_endpoint": "http://localhost:42000/oauth2/register",
+		"jwks_uri": "http://localhost:42000/oauth2/jwks",
+		"response_types_supported": ["code", "token"],
+		"grant_types_supported": ["authorization_code", "refresh_token", "password", "client_credentials", "implicit", "urn:ietf:params:oauth:grant-type:device_code"],
+		"code_challenge_methods_supported": ["S256"],
+		"scopes_supported": ["admin", "contacts.read", "user"],
+		"token_endpoint_auth_methods_supported": ["client_secret_basic", "client_secret_post", "none"],
+		"revocation_endpoint_auth_methods_supported": ["client_secret_basic", "client_secret_post", "none"],
+		"introspection_endpoint_auth_methods_supported": ["client_secret_basic", "client_secret_post", "none"]
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Metadata_follows_the_osin_config(t *testing.T) {
+	controller, mocks := newController()
+
+	controller.inner.Config.AllowedAuthorizeTypes = []osin.AuthorizeRequestType{osin.TOKEN}
+	controller.inner.Config.AllowedAccessTypes = osin.AllowedAccessType{osin.IMPLICIT}
+	controller.inner.Config.AllowClientSecretInParams = false
+
+	mocks.client.On("GetAll", &client.GetAllCmd{}).Return(map[string]client.Client{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Metadata(w, httptest.NewRequest("GET", "http://example.com/.well-known/oauth-authorization-server", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, []interface{}{"token"}, res["response_types_supported"])
+	assert.Equal(t, []interface{}{"implicit", "urn:ietf:params:oauth:grant-type:device_code"}, res["grant_types_supported"])
+	assert.Equal(t, []interface{}{}, res["code_challenge_methods_supported"])
+	assert.Equal(t, []interface{}{}, res["scopes_supported"])
+	assert.Equal(t, []interface{}{"client_secret_basic", "none"}, res["token_endpoint_auth_methods_supported"])
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Metadata_with_a_client_error(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("GetAll", &client.GetAllCmd{}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	controller.Metadata(w, httptest.NewRequest("GET", "http://example.com/.well-known/oauth-authorization-server", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
@@ -0,0 +1,177 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/response"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/jwt"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/openshift/osin"
+)
+
+// openIDScope is the scope requested by the OpenID Connect clients in order
+// to receive an ID token.
+const openIDScope = "openid"
+
+type idTokenClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	ExpiresAt int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat"`
+	Nonce     string `json:"nonce,omitempty"`
+}
+
+// addIDToken adds a signed ID token to the token response if the "openid"
+// scope has been granted for a user.
+func (t *Controller) addIDToken(ctx context.Context, resp *osin.Response, ar *osin.AccessRequest) {
+	userID, _ := ar.UserData.(string)
+	if userID == "" || !contains(parseScopes(ar.Scope), openIDScope) {
+		return
+	}
+
+	var nonce string
+	if ar.AuthorizeData != nil {
+		if data, ok := ar.AuthorizeData.UserData.(*authorizeUserData); ok {
+			nonce = data.Nonce
+		}
+	}
+
+	now := time.Now()
+	idToken, err := signJWT(ctx, t.signingKey, &idTokenClaims{
+		Issuer:    t.config.Issuer,
+		Subject:   userID,
+		Audience:  ar.Client.GetId(),
+		ExpiresAt: now.Add(time.Duration(t.config.IDTokenExpiration) * time.Second).Unix(),
+		IssuedAt:  now.Unix(),
+		Nonce:     nonce,
+	})
+	if err != nil {
+		resp.SetError(osin.E_SERVER_ERROR, "")
+		resp.InternalError = err
+		return
+	}
+
+	resp.Output["id_token"] = idToken
+}
+
+// Discovery returns the OpenID Connect provider metadata. It extends the
+// authorization server metadata with the OpenID Connect fields.
+func (t *Controller) Discovery(w http.ResponseWriter, r *http.Request) {
+	metadata, err := t.serverMetadata(r.Context())
+	if err != nil {
+		errors.WriteError(w, errors.Wrap(err, "failed to retrieve the clients"))
+		return
+	}
+
+	scopes := metadata["scopes_supported"].([]string)
+	if !contains(scopes, openIDScope) {
+		scopes = append([]string{openIDScope}, scopes...)
+	}
+
+	metadata["scopes_supported"] = scopes
+	metadata["userinfo_endpoint"] = t.config.Issuer + UserInfoPath
+	metadata["subject_types_supported"] = []string{"public"}
+	metadata["id_token_signing_alg_values_supported"] = []string{signingkey.RS256}
+	metadata["claims_supported"] = []string{"iss", "sub", "aud", "exp", "iat", "nonce", "preferred_username", "role"}
+
+	response.Write(w, http.StatusOK, metadata)
+}
+
+// UserInfo returns the claims about the user bound to the access token as
+// described in the OpenID Connect specification. The token must have been
+// delivered with the "openid" scope. The bearer token is resolved as for the
+// API routes: the personal tokens and the JWT access tokens are accepted too.
+func (t *Controller) UserInfo(w http.ResponseWriter, r *http.Request) {
+	token, parseErr := permission.RetrieveTokenFromRequest(r)
+	if parseErr != nil {
+		WriteBearerError(w, http.StatusUnauthorized, "invalid_request", parseErr.Message)
+		return
+	}
+
+	session, err := t.tokens.Resolve(r.Context(), token)
+	if errors.IsKind(err, errors.Validation) || (err == nil && (session == nil || session.IsExpiredAt(time.Now()))) {
+		WriteBearerError(w, http.StatusUnauthorized, "invalid_token", "")
+		return
+	}
+
+	if err != nil {
+		errors.WriteError(w, errors.Wrapf(err, "failed to retrieve the access token"))
+		return
+	}
+
+	if session.UserID == "" || !contains(session.Scopes, openIDScope) {
+		WriteBearerError(w, http.StatusForbidden, "insufficient_scope", "")
+		return
+	}
+
+	owner, err := t.user.Get(r.Context(), &user.GetCmd{
+		UserID: session.UserID,
+	})
+	if err != nil {
+		errors.WriteError(w, errors.Wrapf(err, "failed to retrieve the user %q", session.UserID))
+		return
+	}
+
+	if owner == nil {
+		WriteBearerError(w, http.StatusUnauthorized, "invalid_token", "")
+		return
+	}
+
+	response.Write(w, http.StatusOK, map[string]string{
+		"sub":                session.UserID,
+		"preferred_username": owner.Username,
+		"role":               owner.Role,
+	})
+}
+
+// JWKS returns the public part of the keys used to sign the ID tokens.
+func (t *Controller) JWKS(w http.ResponseWriter, r *http.Request) {
+	keys, err := t.signingKey.GetAll(r.Context(), &signingkey.GetAllCmd{})
+	if err != nil {
+		errors.WriteError(w, errors.Wrap(err, "failed to retrieve the signing keys"))
+		return
+	}
+
+	res := []jwt.JWK{}
+	for keyID, key := range keys {
+		privateKey, err := key.RSAPrivateKey()
+		if err != nil {
+			log.Println(errors.Wrapf(err, "failed to decode the signing key %q", keyID))
+			continue
+		}
+
+		res = append(res, jwt.NewJWK(keyID, &privateKey.PublicKey))
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].KeyID < res[j].KeyID })
+
+	response.Write(w, http.StatusOK, map[string][]jwt.JWK{
+		"keys": res,
+	})
+}
+
+// WriteBearerError writes an error response as described in the RFC 6750.
+func WriteBearerError(w http.ResponseWriter, status int, code string, description string) {
+	header := fmt.Sprintf("Bearer error=%q", code)
+	if description != "" {
+		header += fmt.Sprintf(", error_description=%q", description)
+	}
+
+	w.Header().Set("WWW-Authenticate", header)
+
+	body := map[string]string{"error": code}
+	if description != "" {
+		body["error_description"] = description
+	}
+
+	response.Write(w, status, body)
+}
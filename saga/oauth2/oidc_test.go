@@ -0,0 +1,393 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/authorizationcode"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/personaltoken"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeIDToken returns the header and the claims of the ID token found in
+// the token response.
+func decodeIDToken(t *testing.T, body []byte) (map[string]interface{}, map[string]interface{}) {
+	var res struct {
+		IDToken string `json:"id_token"`
+	}
+	require.NoError(t, json.Unmarshal(body, &res))
+
+	segments := strings.Split(res.IDToken, ".")
+	require.Len(t, segments, 3)
+
+	decode := func(segment string) map[string]interface{} {
+		raw, err := base64.RawURLEncoding.DecodeString(segment)
+		require.NoError(t, err)
+
+		var values map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &values))
+
+		return values
+	}
+
+	return decode(segments[0]), decode(segments[1])
+}
+
+func Test_OAuth2_Controller_Token_with_the_openid_scope(t *testing.T) {
+	controller, mocks := newController()
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.RedirectURI = client.ValidClient.RedirectURIs[0]
+	code.Scopes = []string{"openid", "user"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil)
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: code.UserID}).Return(&user.ValidUser, nil).Once()
+	mocks.accessToken.On("Create", mock.MatchedBy(func(cmd *accesstoken.CreateCmd) bool {
+		return cmd.UserID == code.UserID
+	})).Return(nil).Once()
+	mocks.authorizationCode.On("Delete", &authorizationcode.DeleteCmd{Code: "some-code"}).Return(nil).Once()
+	mocks.signingKey.On("GetCurrent", &signingkey.GetCurrentCmd{}).Return(signingkey.ValidSigningKeyID, &signingkey.ValidSigningKey, nil).Once()
+
+	r := newAuthorizationCodeRequest(url.Values{})
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	now := time.Now().Unix()
+	w := httptest.NewRecorder()
+	controller.Token(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	header, claims := decodeIDToken(t, w.Body.Bytes())
+	assert.Equal(t, "RS256", header["alg"])
+	assert.Equal(t, signingkey.ValidSigningKeyID, header["kid"])
+	assert.Equal(t, DefaultConfig.Issuer, claims["iss"])
+	assert.Equal(t, code.UserID, claims["sub"])
+	assert.Equal(t, client.ValidClient.ID, claims["aud"])
+	assert.Equal(t, "some-nonce", claims["nonce"])
+	assert.InDelta(t, now, claims["iat"], 5)
+	assert.InDelta(t, now+int64(DefaultConfig.IDTokenExpiration), claims["exp"], 5)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_without_the_openid_scope(t *testing.T) {
+	controller, mocks := newController()
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.RedirectURI = client.ValidClient.RedirectURIs[0]
+	code.Scopes = []string{"user"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil)
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: code.UserID}).Return(&user.ValidUser, nil).Once()
+	mocks.accessToken.On("Create", mock.Anything).Return(nil).Once()
+	mocks.authorizationCode.On("Delete", &authorizationcode.DeleteCmd{Code: "some-code"}).Return(nil).Once()
+
+	r := newAuthorizationCodeRequest(url.Values{})
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	w := httptest.NewRecorder()
+	controller.Token(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"id_token"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_openid_scope_and_the_password_grant(t *testing.T) {
+	controller, mocks := newController()
+
+	cli := client.ValidClient
+	cli.Scopes = []string{"openid", "users"}
+
+	dev := user.ValidUser
+	dev.Role = user.Dev
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&cli, nil).Once()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil).Once()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).Return(user.ValidUserID, &dev, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&dev, nil).Once()
+	mocks.accessToken.On("Create", mock.Anything).Return(nil).Once()
+	mocks.signingKey.On("GetCurrent", &signingkey.GetCurrentCmd{}).Return(signingkey.ValidSigningKeyID, &signingkey.ValidSigningKey, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Token(w, newPasswordRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+		"scope":    {"openid users.read"},
+	}))
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	_, claims := decodeIDToken(t, w.Body.Bytes())
+	assert.Equal(t, user.ValidUserID, claims["sub"])
+	assert.NotContains(t, claims, "nonce")
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Token_with_the_openid_scope_and_a_signing_key_error(t *testing.T) {
+	controller, mocks := newController()
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.RedirectURI = client.ValidClient.RedirectURIs[0]
+	code.Scopes = []string{"openid"}
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.password.On("Validate", validClientSecret, client.ValidClient.Secret).Return(true, nil)
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: code.UserID}).Return(&user.ValidUser, nil).Once()
+	mocks.accessToken.On("Create", mock.Anything).Return(nil).Once()
+	mocks.authorizationCode.On("Delete", &authorizationcode.DeleteCmd{Code: "some-code"}).Return(nil).Once()
+	mocks.signingKey.On("GetCurrent", &signingkey.GetCurrentCmd{}).Return("", nil, fmt.Errorf("some-error")).Once()
+
+	r := newAuthorizationCodeRequest(url.Values{})
+	r.SetBasicAuth(client.ValidClient.ID, validClientSecret)
+
+	w := httptest.NewRecorder()
+	controller.Token(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"server_error"`)
+	assert.NotContains(t, w.Body.String(), `"id_token"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Discovery(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("GetAll", &client.GetAllCmd{}).Return(map[string]client.Client{
+		client.ValidClient.ID: client.ValidClient,
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Discovery(w, httptest.NewRequest("GET", "http://example.com/.well-known/openid-configuration", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "http://localhost:42000", res["issuer"])
+	assert.Equal(t, "http://localhost:42000/oauth2/auth", res["authorization_endpoint"])
+	assert.Equal(t, "http://localhost:42000/oauth2/token", res["token_endpoint"])
+	assert.Equal(t, "http://localhost:42000/oauth2/userinfo", res["userinfo_endpoint"])
+	assert.Equal(t, "http://localhost:42000/oauth2/jwks", res["jwks_uri"])
+	assert.Equal(t, "http://localhost:42000/oauth2/device_authorization", res["device_authorization_endpoint"])
+	assert.Equal(t, "http://localhost:42000/oauth2/register", res["registration_endpoint"])
+	assert.Equal(t, []interface{}{"RS256"}, res["id_token_signing_alg_values_supported"])
+	assert.Equal(t, []interface{}{"S256"}, res["code_challenge_methods_supported"])
+	assert.Equal(t, []interface{}{"openid", "admin", "user"}, res["scopes_supported"])
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Discovery_with_a_client_error(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("GetAll", &client.GetAllCmd{}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	controller.Discovery(w, httptest.NewRequest("GET", "http://example.com/.well-known/openid-configuration", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_JWKS(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.signingKey.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		signingkey.ValidSigningKeyID: signingkey.ValidSigningKey,
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.JWKS(w, httptest.NewRequest("GET", "http://example.com/oauth2/jwks", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var res struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Keys, 1)
+	assert.Equal(t, signingkey.ValidSigningKeyID, res.Keys[0]["kid"])
+	assert.Equal(t, "RSA", res.Keys[0]["kty"])
+	assert.Equal(t, "sig", res.Keys[0]["use"])
+	assert.Equal(t, "RS256", res.Keys[0]["alg"])
+	assert.Equal(t, "AQAB", res.Keys[0]["e"])
+	assert.NotEmpty(t, res.Keys[0]["n"])
+	assert.NotContains(t, w.Body.String(), `"d"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_JWKS_with_an_error(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.signingKey.On("GetAll", &signingkey.GetAllCmd{}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	controller.JWKS(w, httptest.NewRequest("GET", "http://example.com/oauth2/jwks", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func newUserInfoRequest(token string) *http.Request {
+	r := httptest.NewRequest("GET", "http://example.com/oauth2/userinfo", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return r
+}
+
+func Test_OAuth2_Controller_UserInfo(t *testing.T) {
+	controller, mocks := newController()
+
+	session := accesstoken.ValidAccessToken
+	session.Scopes = []string{"openid", "users"}
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&session, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: session.UserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.UserInfo(w, newUserInfoRequest("some-access-token"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"sub": "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+		"preferred_username": "some username",
+		"role": "admin"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_UserInfo_with_a_personal_token(t *testing.T) {
+	controller, mocks := newController()
+
+	personalTokenMock := new(personaltoken.ControllerMock)
+	controller.tokens = permission.NewController(context.Background(), mocks.accessToken, nil, personalTokenMock)
+
+	session := accesstoken.ValidAccessToken
+	session.Scopes = []string{"openid", "users"}
+
+	personalTokenMock.On("Verify", "hpat_some-token").Return(&session, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: session.UserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.UserInfo(w, newUserInfoRequest("hpat_some-token"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"sub": "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+		"preferred_username": "some username",
+		"role": "admin"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_UserInfo_without_token(t *testing.T) {
+	controller, mocks := newController()
+
+	w := httptest.NewRecorder()
+	controller.UserInfo(w, newUserInfoRequest(""))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_request", error_description="missing \"Authorization\" header"`, w.Header().Get("WWW-Authenticate"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_UserInfo_with_an_unknown_token(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(nil, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.UserInfo(w, newUserInfoRequest("some-access-token"))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"error": "invalid_token"}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_UserInfo_with_an_expired_token(t *testing.T) {
+	controller, mocks := newController()
+
+	session := accesstoken.ValidAccessToken
+	session.Scopes = []string{"openid"}
+	session.CreatedAt = time.Now().Add(-2 * time.Hour)
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&session, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.UserInfo(w, newUserInfoRequest("some-access-token"))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_UserInfo_without_the_openid_scope(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.UserInfo(w, newUserInfoRequest("some-access-token"))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, `Bearer error="insufficient_scope"`, w.Header().Get("WWW-Authenticate"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_UserInfo_with_a_user_error(t *testing.T) {
+	controller, mocks := newController()
+
+	session := accesstoken.ValidAccessToken
+	session.Scopes = []string{"openid"}
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&session, nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: session.UserID}).Return(nil, errors.New(errors.Internal, "some-error")).Once()
+
+	w := httptest.NewRecorder()
+	controller.UserInfo(w, newUserInfoRequest("some-access-token"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
@@ -0,0 +1,36 @@
+package oauth2
+
+import (
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/client"
+	"github.com/openshift/osin"
+)
+
+// The error messages are the ones suggested by the RFC 7636. They are sent
+// with an "invalid_request" error.
+var (
+	ErrPKCERequired         = errors.New(errors.BadRequest, "code_challenge (rfc7636) required for public clients")
+	ErrPKCEMethodNotAllowed = errors.New(errors.BadRequest, "code_challenge_method transform algorithm not supported (rfc7636)")
+)
+
+// checkPKCE applies the PKCE policy of the client: a public client must send
+// a code challenge and the "plain" method is refused unless the client
+// explicitly allows it.
+func checkPKCE(client *client.Client, challenge string, method string) *errors.Error {
+	if challenge == "" {
+		if client.Public {
+			return ErrPKCERequired
+		}
+
+		return nil
+	}
+
+	// The "plain" method is the default one.
+	if method == "" || method == osin.PKCE_PLAIN {
+		if !client.AllowPlainPKCE {
+			return ErrPKCEMethodNotAllowed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package oauth2
+
+import (
+	"context"
+	"strings"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/permission"
+)
+
+// parseScopes splits a scope parameter. The RFC uses the spaces as separator
+// but the commas are accepted too.
+func parseScopes(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+}
+
+// negotiateScopes returns the requested scopes allowed for the client. A
+// requested scope is allowed if one of the client scopes gives access to it
+// (see permission.MatchScope). All the allowed scopes are returned if nothing
+// is requested.
+func negotiateScopes(requested []string, allowed []string) []string {
+	if len(requested) == 0 {
+		return allowed
+	}
+
+	res := []string{}
+	for _, scope := range requested {
+		if permission.HasScope(allowed, scope) && !contains(res, scope) {
+			res = append(res, scope)
+		}
+	}
+
+	return res
+}
+
+// filterRoleScopes returns the scopes a user with the given role can give to
+// a client (see user.RoleScopes).
+func filterRoleScopes(scopes []string, role string) []string {
+	res := []string{}
+	for _, scope := range scopes {
+		if permission.HasScope(user.RoleScopes[role], scope) {
+			res = append(res, scope)
+		}
+	}
+
+	return res
+}
+
+// userScopes filters the scopes negotiated with the client with the role of
+// the user. Nothing is returned for a deleted user.
+func (t *Controller) userScopes(ctx context.Context, userID string, scopes []string) ([]string, error) {
+	owner, err := t.user.Get(ctx, &user.GetCmd{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve the user %q", userID)
+	}
+
+	if owner == nil {
+		return []string{}, nil
+	}
+
+	return filterRoleScopes(scopes, owner.Role), nil
+}
+
+func contains(list []string, value string) bool {
+	for _, elem := range list {
+		if elem == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+package oauth2
+
+import (
+	"testing"
+
+	"github.com/halium-project/server/resource/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OAuth2_parseScopes(t *testing.T) {
+	assert.Equal(t, []string{"users", "contacts", "todos"}, parseScopes("users contacts,todos"))
+	assert.Equal(t, []string{"users"}, parseScopes(" users  "))
+	assert.Empty(t, parseScopes(""))
+}
+
+func Test_OAuth2_negotiateScopes(t *testing.T) {
+	allowed := []string{"users", "contacts"}
+
+	assert.Equal(t, []string{"contacts"}, negotiateScopes([]string{"contacts", "admin", "contacts"}, allowed))
+	assert.Equal(t, allowed, negotiateScopes([]string{}, allowed))
+	assert.Empty(t, negotiateScopes([]string{"admin"}, allowed))
+}
+
+func Test_OAuth2_negotiateScopes_with_hierarchical_scopes(t *testing.T) {
+	allowed := []string{"users", "*.read", "todos.write"}
+
+	assert.Equal(t, []string{"users.write", "contacts.read", "todos.read"}, negotiateScopes([]string{"users.write", "contacts.read", "contacts.write", "todos.read"}, allowed))
+	assert.Empty(t, negotiateScopes([]string{"u", "todos"}, allowed))
+}
+
+func Test_OAuth2_filterRoleScopes(t *testing.T) {
+	assert.Equal(t, []string{"users.read", "todos.write"}, filterRoleScopes([]string{"users.read", "users.write", "todos.write"}, user.Dev))
+	assert.Equal(t, []string{"users.write"}, filterRoleScopes([]string{"users.write"}, user.Admin))
+	assert.Empty(t, filterRoleScopes([]string{"users.read"}, "unknown-role"))
+}
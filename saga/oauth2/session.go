@@ -0,0 +1,169 @@
+package oauth2
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/utils/secret"
+)
+
+// getLoginSessionUserID returns the user authenticated by the login session
+// cookie.
+//
+// It returns an empty string if the session is missing, expired or if the
+// client asks for a new authentication with the "prompt=login" or the
+// "max_age" parameters.
+func (t *Controller) getLoginSessionUserID(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(loginSessionCookie)
+	if err != nil {
+		return "", nil
+	}
+
+	if contains(strings.Fields(r.FormValue("prompt")), "login") {
+		return "", nil
+	}
+
+	session, err := t.loginSession.Get(r.Context(), &loginsession.GetCmd{
+		SessionID: cookie.Value,
+	})
+	if errors.IsKind(err, errors.Validation) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve the login session")
+	}
+
+	now := time.Now()
+	if session == nil || session.IsExpiredAt(now) {
+		return "", nil
+	}
+
+	if maxAge := r.FormValue("max_age"); maxAge != "" {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || session.AuthenticatedAt.Add(time.Duration(seconds)*time.Second).Before(now) {
+			return "", nil
+		}
+	}
+
+	return session.UserID, nil
+}
+
+// openLoginSession creates a login session for the user and sets its cookie.
+func (t *Controller) openLoginSession(ctx context.Context, w http.ResponseWriter, userID string) error {
+	sessionID, err := t.loginSession.Create(ctx, &loginsession.CreateCmd{
+		UserID:    userID,
+		ExpiresIn: int(t.config.SessionExpiration),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create the login session")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginSessionCookie,
+		Value:    sessionID,
+		Path:     "/",
+		MaxAge:   int(t.config.SessionExpiration),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// logoutTemplateParam is given to the logout page. The page asks for a
+// confirmation, then tells the user they are logged out.
+type logoutTemplateParam struct {
+	LoggedOut bool
+	Ticket    string
+}
+
+// Logout closes the login session of the browser. The GET requests only
+// render a confirmation form so a link can't close the session. The form
+// carries a ticket bound to the login session so it can't be posted from
+// another site.
+func (t *Controller) Logout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	var sessionID string
+	if cookie, err := r.Cookie(loginSessionCookie); err == nil {
+		sessionID = cookie.Value
+	}
+
+	if r.Method == "POST" {
+		tkt, err := parseTicket(t.config.TicketKey, r.PostFormValue("logout_ticket"), time.Now())
+		if err == nil && tkt.Step == logoutStep && tkt.Session == secret.Hash(sessionID) {
+			t.closeLoginSession(w, r, sessionID)
+			return
+		}
+	}
+
+	tkt, err := signTicket(t.config.TicketKey, &ticket{
+		ExpireAt: time.Now().Add(ticketLifetime).Unix(),
+		Step:     logoutStep,
+		Session:  secret.Hash(sessionID),
+	})
+	if err != nil {
+		t.renderLogoutError(w, errors.Wrap(err, "failed to sign the ticket"))
+		return
+	}
+
+	// A POST without a valid ticket is asked for a confirmation again.
+	if r.Method == "POST" {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	t.renderLogoutPage(w, logoutTemplateParam{Ticket: tkt})
+}
+
+// closeLoginSession deletes the login session and its cookie.
+func (t *Controller) closeLoginSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if sessionID != "" {
+		err := t.loginSession.Delete(r.Context(), &loginsession.DeleteCmd{
+			SessionID: sessionID,
+		})
+		if err != nil && !errors.IsKind(err, errors.Validation) {
+			t.renderLogoutError(w, errors.Wrap(err, "failed to delete the login session"))
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginSessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.WriteHeader(http.StatusOK)
+	t.renderLogoutPage(w, logoutTemplateParam{LoggedOut: true})
+}
+
+func (t *Controller) renderLogoutError(w http.ResponseWriter, err error) {
+	log.Println(err)
+	w.WriteHeader(http.StatusInternalServerError)
+
+	err = t.html.Render(w, "internal_error.html", "failed to close the session")
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (t *Controller) renderLogoutPage(w http.ResponseWriter, param logoutTemplateParam) {
+	err := t.html.Render(w, "logout.html", param)
+	if err != nil {
+		log.Println(err)
+	}
+}
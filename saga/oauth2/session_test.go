@@ -0,0 +1,302 @@
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/resource/authorizationcode"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/consent"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newSessionRequest(method string, target string) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	r.AddCookie(&http.Cookie{Name: loginSessionCookie, Value: loginsession.ValidLoginSessionID})
+
+	return r
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_login_session(t *testing.T) {
+	controller, mocks := newController()
+
+	session := loginsession.ValidLoginSession
+	session.UserID = user.ValidUserID
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginSession.On("Get", &loginsession.GetCmd{SessionID: loginsession.ValidLoginSessionID}).Return(&session, nil).Once()
+	mocks.consent.On("Get", &consent.GetCmd{UserID: user.ValidUserID, ClientID: client.ValidClient.ID}).
+		Return(&consent.Consent{UserID: user.ValidUserID, ClientID: client.ValidClient.ID, Scopes: []string{"user"}}, nil).Once()
+	mocks.authorizationCode.On("Create", mock.MatchedBy(func(cmd *authorizationcode.CreateCmd) bool {
+		return cmd.UserID == user.ValidUserID
+	})).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newSessionRequest("GET", authorizeURL))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, location.Query().Get("code"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_expired_login_session(t *testing.T) {
+	controller, mocks := newController()
+
+	session := loginsession.ValidLoginSession
+	session.AuthenticatedAt = time.Now().Add(-48 * time.Hour)
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginSession.On("Get", &loginsession.GetCmd{SessionID: loginsession.ValidLoginSessionID}).Return(&session, nil).Once()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newSessionRequest("GET", authorizeURL))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_an_unknown_login_session(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginSession.On("Get", &loginsession.GetCmd{SessionID: loginsession.ValidLoginSessionID}).Return(nil, nil).Once()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newSessionRequest("GET", authorizeURL))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_login_session_and_prompt_login(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newSessionRequest("GET", authorizeURL+"&prompt=login"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_login_session_older_than_max_age(t *testing.T) {
+	controller, mocks := newController()
+
+	session := loginsession.ValidLoginSession
+	session.AuthenticatedAt = time.Now().Add(-time.Hour)
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginSession.On("Get", &loginsession.GetCmd{SessionID: loginsession.ValidLoginSessionID}).Return(&session, nil).Once()
+	mocks.html.On("Render", "auth.html", nil).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newSessionRequest("GET", authorizeURL+"&max_age=60"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_with_a_login_session_error(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginSession.On("Get", &loginsession.GetCmd{SessionID: loginsession.ValidLoginSessionID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newSessionRequest("GET", authorizeURL))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "server_error", location.Query().Get("error"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Authorize_open_a_login_session(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Twice()
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Username: "some-username", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("Validate", &user.ValidateCmd{Username: "some-username", Password: "some-password"}).
+		Return(user.ValidUserID, &user.ValidUser, nil).Once()
+	mocks.loginAttempt.On("RecordSuccess", &loginattempt.RecordSuccessCmd{Username: "some-username"}).Return(nil).Once()
+	mocks.loginSession.On("Create", &loginsession.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 24 * 3600}).
+		Return(loginsession.ValidLoginSessionID, nil).Once()
+	mocks.consent.On("Get", &consent.GetCmd{UserID: user.ValidUserID, ClientID: client.ValidClient.ID}).
+		Return(&consent.Consent{UserID: user.ValidUserID, ClientID: client.ValidClient.ID, Scopes: []string{"user"}}, nil).Once()
+	mocks.authorizationCode.On("Create", mock.Anything).Return(nil).Once()
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Authorize(w, newAuthorizeRequest(url.Values{
+		"username": {"some-username"},
+		"password": {"some-password"},
+	}))
+
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, loginSessionCookie, cookies[0].Name)
+		assert.Equal(t, loginsession.ValidLoginSessionID, cookies[0].Value)
+		assert.Equal(t, "/", cookies[0].Path)
+		assert.Equal(t, 24*3600, cookies[0].MaxAge)
+		assert.True(t, cookies[0].Secure)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	}
+
+	mocks.AssertExpectations(t)
+}
+
+func newLogoutRequest(sessionID string, rawTicket string) *http.Request {
+	form := url.Values{}
+	form.Set("logout_ticket", rawTicket)
+
+	r := httptest.NewRequest("POST", "http://example.com/logout", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: loginSessionCookie, Value: sessionID})
+
+	return r
+}
+
+func newLogoutTicket(sessionID string) string {
+	tkt, _ := signTicket(ticketKey, &ticket{
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     logoutStep,
+		Session:  secret.Hash(sessionID),
+	})
+
+	return tkt
+}
+
+func Test_OAuth2_Controller_Logout_render_the_confirmation_page(t *testing.T) {
+	controller, mocks := newController()
+
+	var rawTicket string
+	mocks.html.On("Render", "logout.html", mock.Anything).Run(func(args mock.Arguments) {
+		rawTicket = args.Get(1).(logoutTemplateParam).Ticket
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Logout(w, newSessionRequest("GET", "http://example.com/logout"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	tkt, err := parseTicket(ticketKey, rawTicket, time.Now())
+	if assert.NoError(t, err) {
+		assert.Equal(t, logoutStep, tkt.Step)
+		assert.Equal(t, secret.Hash(loginsession.ValidLoginSessionID), tkt.Session)
+	}
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Logout(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.loginSession.On("Delete", &loginsession.DeleteCmd{SessionID: loginsession.ValidLoginSessionID}).Return(nil).Once()
+	mocks.html.On("Render", "logout.html", logoutTemplateParam{LoggedOut: true}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Logout(w, newLogoutRequest(loginsession.ValidLoginSessionID, newLogoutTicket(loginsession.ValidLoginSessionID)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, loginSessionCookie, cookies[0].Name)
+		assert.Empty(t, cookies[0].Value)
+		assert.True(t, cookies[0].MaxAge < 0)
+	}
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Logout_without_ticket(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.html.On("Render", "logout.html", mock.Anything).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Logout(w, newLogoutRequest(loginsession.ValidLoginSessionID, ""))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Logout_with_a_ticket_of_another_session(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.html.On("Render", "logout.html", mock.Anything).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Logout(w, newLogoutRequest(loginsession.ValidLoginSessionID, newLogoutTicket("some-other-session")))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Logout_with_a_ticket_of_another_step(t *testing.T) {
+	controller, mocks := newController()
+
+	tkt, _ := signTicket(ticketKey, &ticket{
+		ExpireAt: time.Now().Add(time.Minute).Unix(),
+		Step:     deviceStep,
+		Session:  secret.Hash(loginsession.ValidLoginSessionID),
+	})
+
+	mocks.html.On("Render", "logout.html", mock.Anything).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Logout(w, newLogoutRequest(loginsession.ValidLoginSessionID, tkt))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, w.Result().Cookies())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Controller_Logout_with_a_delete_error(t *testing.T) {
+	controller, mocks := newController()
+
+	mocks.loginSession.On("Delete", &loginsession.DeleteCmd{SessionID: loginsession.ValidLoginSessionID}).Return(fmt.Errorf("some-error")).Once()
+	mocks.html.On("Render", "internal_error.html", "failed to close the session").Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	controller.Logout(w, newLogoutRequest(loginsession.ValidLoginSessionID, newLogoutTicket(loginsession.ValidLoginSessionID)))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
@@ -2,19 +2,31 @@ package oauth2
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/password"
 	"github.com/halium-project/server/resource/accesstoken"
 	"github.com/halium-project/server/resource/authorizationcode"
 	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/securityevent"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/jwt"
 	"github.com/openshift/osin"
 )
 
 const BucketName = "oauth2"
 
+// redirectURISeparator is used to give all the redirect URIs of a client to
+// osin. The spaces are not allowed inside an URI.
+const redirectURISeparator = " "
+
 type ClientInterface interface {
 	Get(ctx context.Context, cmd *client.GetCmd) (*client.Client, error)
+	GetAll(ctx context.Context, cmd *client.GetAllCmd) (map[string]client.Client, error)
 }
 
 type AccessTokenInterface interface {
@@ -22,6 +34,8 @@ type AccessTokenInterface interface {
 	Get(ctx context.Context, cmd *accesstoken.GetCmd) (*accesstoken.AccessToken, error)
 	Delete(ctx context.Context, cmd *accesstoken.DeleteCmd) error
 	FindOneByRefreshToken(ctx context.Context, cmd *accesstoken.FindOneByRefreshTokenCmd) (string, *accesstoken.AccessToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, cmd *accesstoken.MarkRefreshTokenUsedCmd) error
+	FindAllByFamily(ctx context.Context, cmd *accesstoken.FindAllByFamilyCmd) ([]string, error)
 }
 
 type AuthorizationCodeInterface interface {
@@ -30,21 +44,48 @@ type AuthorizationCodeInterface interface {
 	Delete(ctx context.Context, cmd *authorizationcode.DeleteCmd) error
 }
 
+type RevocationInterface interface {
+	Create(ctx context.Context, cmd *revocation.CreateCmd) error
+}
+
+type SecurityEventInterface interface {
+	Create(ctx context.Context, cmd *securityevent.CreateCmd) (string, error)
+}
+
+type UserGetter interface {
+	Get(ctx context.Context, cmd *user.GetCmd) (*user.User, error)
+}
+
 type StorageController struct {
 	client            ClientInterface
 	authorizationCode AuthorizationCodeInterface
 	accessToken       AccessTokenInterface
+	user              UserGetter
+	password          password.HashManager
+	revocation        RevocationInterface
+	securityEvent     SecurityEventInterface
+	config            Config
 }
 
 func NewStorageController(
 	client ClientInterface,
 	authorizationCode AuthorizationCodeInterface,
 	accessToken AccessTokenInterface,
+	user UserGetter,
+	password password.HashManager,
+	revocation RevocationInterface,
+	securityEvent SecurityEventInterface,
+	config Config,
 ) *StorageController {
 	return &StorageController{
 		client:            client,
 		authorizationCode: authorizationCode,
 		accessToken:       accessToken,
+		user:              user,
+		password:          password,
+		revocation:        revocation,
+		securityEvent:     securityEvent,
+		config:            config,
 	}
 }
 
@@ -80,25 +121,40 @@ func (t *StorageController) GetClient(id string) (osin.Client, error) {
 		return nil, osin.ErrNotFound
 	}
 
-	var redirectURI string
-	if len(client.RedirectURIs) > 0 {
-		redirectURI = client.RedirectURIs[0]
-	}
-
-	res := osin.DefaultClient{
-		Id:          id,
-		Secret:      client.Secret,
-		RedirectUri: redirectURI,
+	res := Client{
+		DefaultClient: osin.DefaultClient{
+			Id:          id,
+			Secret:      client.Secret,
+			RedirectUri: strings.Join(client.RedirectURIs, redirectURISeparator),
+			UserData:    client,
+		},
+		password: t.password,
 	}
 
 	return &res, nil
 }
 
+// authorizeUserData is the UserData of the authorization codes.
+type authorizeUserData struct {
+	// ID of the user who authorized the client.
+	UserID string
+
+	// OpenID Connect nonce sent by the client.
+	Nonce string
+}
+
 // SaveAuthorize saves authorize data.
+//
+// The UserData field must contains an *authorizeUserData.
 func (t *StorageController) SaveAuthorize(data *osin.AuthorizeData) error {
+	userData, ok := data.UserData.(*authorizeUserData)
+	if !ok {
+		userData = &authorizeUserData{}
+	}
 
 	err := t.authorizationCode.Create(context.TODO(), &authorizationcode.CreateCmd{
 		ClientID:            data.Client.GetId(),
+		UserID:              userData.UserID,
 		Code:                data.Code,
 		ExpiresIn:           int(data.ExpiresIn),
 		Scopes:              strings.Split(data.Scope, ","),
@@ -106,6 +162,7 @@ func (t *StorageController) SaveAuthorize(data *osin.AuthorizeData) error {
 		State:               data.State,
 		CodeChallenge:       data.CodeChallenge,
 		CodeChallengeMethod: data.CodeChallengeMethod,
+		Nonce:               userData.Nonce,
 	})
 
 	return err
@@ -127,20 +184,34 @@ func (t *StorageController) LoadAuthorize(code string) (*osin.AuthorizeData, err
 		return nil, osin.ErrNotFound
 	}
 
-	client, err := t.GetClient(authorization.ClientID)
+	if authorization.IsExpiredAt(time.Now()) {
+		return nil, ErrAuthorizationCodeExpired
+	}
+
+	osinClient, err := t.GetClient(authorization.ClientID)
 	if err != nil {
 		return nil, err
 	}
 
+	// The policy is checked again in case the client has been updated since
+	// the code has been delivered.
+	pkceErr := checkPKCE(osinClient.GetUserData().(*client.Client), authorization.CodeChallenge, authorization.CodeChallengeMethod)
+	if pkceErr != nil {
+		return nil, pkceErr
+	}
+
 	res := osin.AuthorizeData{
-		Client:              client,
-		Code:                code,
-		ExpiresIn:           int32(authorization.ExpiresIn),
-		Scope:               strings.Join(authorization.Scopes, ","),
-		RedirectUri:         authorization.RedirectURI,
-		State:               authorization.State,
-		CreatedAt:           authorization.CreatedAt,
-		UserData:            nil,
+		Client:      osinClient,
+		Code:        code,
+		ExpiresIn:   int32(authorization.ExpiresIn),
+		Scope:       strings.Join(authorization.Scopes, ","),
+		RedirectUri: authorization.RedirectURI,
+		State:       authorization.State,
+		CreatedAt:   authorization.CreatedAt,
+		UserData: &authorizeUserData{
+			UserID: authorization.UserID,
+			Nonce:  authorization.Nonce,
+		},
 		CodeChallenge:       authorization.CodeChallenge,
 		CodeChallengeMethod: authorization.CodeChallengeMethod,
 	}
@@ -159,18 +230,92 @@ func (t *StorageController) RemoveAuthorize(code string) error {
 
 // SaveAccess writes AccessData.
 // If RefreshToken is not blank, it must save in a way that can be loaded using LoadRefresh.
+//
+// The UserData field contains the ID of the user owning the token. It is
+// empty for the tokens delivered directly to a client.
+//
+// If the token is delivered in exchange of a refresh token (AccessData not
+// nil), the previous token is marked as used and the new one joins its
+// family.
 func (t *StorageController) SaveAccess(data *osin.AccessData) error {
-	err := t.accessToken.Create(context.TODO(), &accesstoken.CreateCmd{
-		ClientID:     data.Client.GetId(),
-		AccessToken:  data.AccessToken,
-		RefreshToken: data.RefreshToken,
-		ExpiresIn:    int(data.ExpiresIn),
-		Scopes:       strings.Split(data.Scope, ","),
+	ctx := context.TODO()
+	userID, _ := data.UserData.(string)
+
+	var role string
+	if userID != "" {
+		owner, err := t.user.Get(ctx, &user.GetCmd{
+			UserID: userID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve the token owner")
+		}
+
+		if owner == nil {
+			return errors.Errorf(errors.NotFound, "user %q not found", userID)
+		}
+
+		role = owner.Role
+	}
+
+	var refreshExpiresIn int
+	if data.RefreshToken != "" {
+		refreshExpiresIn = int(t.config.RefreshExpiration)
+	}
+
+	var familyID string
+	if data.AccessData != nil {
+		var err error
+		familyID, err = t.rotateRefreshToken(ctx, data.AccessData.AccessToken)
+		if err != nil {
+			return errors.Wrap(err, "failed to rotate the refresh token")
+		}
+	}
+
+	err := t.accessToken.Create(ctx, &accesstoken.CreateCmd{
+		ClientID:         data.Client.GetId(),
+		UserID:           userID,
+		Role:             role,
+		AccessToken:      data.AccessToken,
+		RefreshToken:     data.RefreshToken,
+		ExpiresIn:        int(data.ExpiresIn),
+		RefreshExpiresIn: refreshExpiresIn,
+		FamilyID:         familyID,
+		Scopes:           strings.Split(data.Scope, ","),
 	})
 
 	return err
 }
 
+// rotateRefreshToken marks the refresh token of the given access token as
+// used and returns its family. The refreshed access token is revoked.
+func (t *StorageController) rotateRefreshToken(ctx context.Context, accessToken string) (string, error) {
+	previous, err := t.accessToken.Get(ctx, &accesstoken.GetCmd{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve the refreshed token")
+	}
+
+	if previous == nil {
+		return "", errors.New(errors.NotFound, "refreshed token not found")
+	}
+
+	// Fails if the refresh token is exchanged concurrently.
+	err = t.accessToken.MarkRefreshTokenUsed(ctx, &accesstoken.MarkRefreshTokenUsedCmd{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to mark the refresh token as used")
+	}
+
+	err = t.revokeJWT(ctx, accessToken)
+	if err != nil {
+		return "", err
+	}
+
+	return previous.FamilyID, nil
+}
+
 // LoadAccess retrieves access data by token.
 func (t *StorageController) LoadAccess(accessToken string) (*osin.AccessData, error) {
 	token, err := t.accessToken.Get(context.TODO(), &accesstoken.GetCmd{
@@ -184,6 +329,10 @@ func (t *StorageController) LoadAccess(accessToken string) (*osin.AccessData, er
 		return nil, osin.ErrNotFound
 	}
 
+	if token.IsExpiredAt(time.Now()) {
+		return nil, ErrAccessTokenExpired
+	}
+
 	res := osin.AccessData{
 		Client:        nil,
 		AuthorizeData: nil,
@@ -194,7 +343,7 @@ func (t *StorageController) LoadAccess(accessToken string) (*osin.AccessData, er
 		Scope:         strings.Join(token.Scopes, ","),
 		RedirectUri:   "",
 		CreatedAt:     token.CreatedAt,
-		UserData:      nil,
+		UserData:      token.UserID,
 	}
 
 	return &res, nil
@@ -205,16 +354,24 @@ func (t *StorageController) RemoveAccess(accessToken string) error {
 	err := t.accessToken.Delete(context.TODO(), &accesstoken.DeleteCmd{
 		AccessToken: accessToken,
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	return t.revokeJWT(context.TODO(), accessToken)
 }
 
 // LoadRefresh retrieves refresh AccessData.
 // Client information MUST be loaded together.
 // AuthorizeData and AccessData DON'T NEED to be loaded if not easily available.
 // Optionally can return error if expired.
+//
+// A refresh token presented a second time has probably been stolen: all the
+// tokens of its family are revoked and a security event is recorded.
 func (t *StorageController) LoadRefresh(refreshToken string) (*osin.AccessData, error) {
-	_, session, err := t.accessToken.FindOneByRefreshToken(context.TODO(), &accesstoken.FindOneByRefreshTokenCmd{
+	ctx := context.TODO()
+
+	_, session, err := t.accessToken.FindOneByRefreshToken(ctx, &accesstoken.FindOneByRefreshTokenCmd{
 		RefreshToken: refreshToken,
 	})
 	if err != nil {
@@ -225,6 +382,19 @@ func (t *StorageController) LoadRefresh(refreshToken string) (*osin.AccessData,
 		return nil, osin.ErrNotFound
 	}
 
+	if session.RefreshTokenUsed {
+		err = t.revokeFamily(ctx, session)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to revoke the token family")
+		}
+
+		return nil, ErrRefreshTokenReused
+	}
+
+	if session.IsRefreshExpiredAt(time.Now()) {
+		return nil, ErrRefreshTokenExpired
+	}
+
 	client, err := t.GetClient(session.ClientID)
 	if err != nil {
 		return nil, err
@@ -240,7 +410,7 @@ func (t *StorageController) LoadRefresh(refreshToken string) (*osin.AccessData,
 		Scope:         strings.Join(session.Scopes, ","),
 		RedirectUri:   "",
 		CreatedAt:     session.CreatedAt,
-		UserData:      nil,
+		UserData:      session.UserID,
 	}
 
 	return &res, nil
@@ -255,9 +425,102 @@ func (t *StorageController) RemoveRefresh(refreshToken string) error {
 		return err
 	}
 
+	// The access token is not revoked here: osin always calls RemoveAccess
+	// after RemoveRefresh.
 	err = t.accessToken.Delete(context.TODO(), &accesstoken.DeleteCmd{
 		AccessToken: accessToken,
 	})
 
 	return err
 }
+
+// revokeFamily removes all the tokens of the session family and records a
+// security event.
+func (t *StorageController) revokeFamily(ctx context.Context, session *accesstoken.AccessToken) error {
+	if session.FamilyID != "" {
+		accessTokens, err := t.accessToken.FindAllByFamily(ctx, &accesstoken.FindAllByFamilyCmd{
+			FamilyID: session.FamilyID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to retrieve the tokens of the family")
+		}
+
+		for _, accessToken := range accessTokens {
+			err = t.removeFamilyMember(ctx, accessToken)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := t.securityEvent.Create(ctx, &securityevent.CreateCmd{
+		Type:        securityevent.RefreshTokenReused,
+		UserID:      session.UserID,
+		ClientID:    session.ClientID,
+		Description: fmt.Sprintf("token family %q revoked", session.FamilyID),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to record the security event")
+	}
+
+	return nil
+}
+
+// removeFamilyMember deletes a token of a revoked family. The access tokens
+// of the used refresh tokens have already been revoked during the rotation.
+func (t *StorageController) removeFamilyMember(ctx context.Context, accessToken string) error {
+	token, err := t.accessToken.Get(ctx, &accesstoken.GetCmd{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve the token %q", accessToken)
+	}
+
+	if token == nil {
+		return nil
+	}
+
+	err = t.accessToken.Delete(ctx, &accesstoken.DeleteCmd{
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete the token %q", accessToken)
+	}
+
+	if token.RefreshTokenUsed {
+		return nil
+	}
+
+	return t.revokeJWT(ctx, accessToken)
+}
+
+// revokeJWT adds the JWT access tokens to the revocation list: they are still
+// accepted after their deletion otherwise. The opaque tokens are ignored.
+func (t *StorageController) revokeJWT(ctx context.Context, accessToken string) error {
+	if !jwt.IsJWT(accessToken) {
+		return nil
+	}
+
+	// The token comes from the storage so there is no need to check its
+	// signature.
+	var claims accesstoken.Claims
+	err := jwt.Decode(accessToken, &claims)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode the access token")
+	}
+
+	expireAt := time.Unix(claims.ExpiresAt, 0)
+	if expireAt.Before(time.Now()) {
+		return nil
+	}
+
+	err = t.revocation.Create(ctx, &revocation.CreateCmd{
+		TokenID:  claims.TokenID,
+		ExpireAt: expireAt,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke the access token")
+	}
+
+	return nil
+}
@@ -0,0 +1,555 @@
+package oauth2
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/password"
+	"github.com/halium-project/server/front/templates"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/authorizationcode"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/consent"
+	"github.com/halium-project/server/resource/devicecode"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/securityevent"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/jwt"
+	"github.com/openshift/osin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type oauth2Mocks struct {
+	html              *templates.RendererMock
+	consent           *consent.ControllerMock
+	client            *client.ControllerMock
+	authorizationCode *authorizationcode.ControllerMock
+	accessToken       *accesstoken.ControllerMock
+	user              *user.ControllerMock
+	password          *password.HashManagerMock
+	signingKey        *signingkey.ControllerMock
+	revocation        *revocation.ControllerMock
+	securityEvent     *securityevent.ControllerMock
+	loginSession      *loginsession.ControllerMock
+	loginAttempt      *loginattempt.ControllerMock
+	deviceCode        *devicecode.ControllerMock
+}
+
+func newStorageController() (*StorageController, *oauth2Mocks) {
+	mocks := &oauth2Mocks{
+		html:              new(templates.RendererMock),
+		consent:           new(consent.ControllerMock),
+		client:            new(client.ControllerMock),
+		authorizationCode: new(authorizationcode.ControllerMock),
+		accessToken:       new(accesstoken.ControllerMock),
+		user:              new(user.ControllerMock),
+		password:          new(password.HashManagerMock),
+		signingKey:        new(signingkey.ControllerMock),
+		revocation:        new(revocation.ControllerMock),
+		securityEvent:     new(securityevent.ControllerMock),
+		loginSession:      new(loginsession.ControllerMock),
+		loginAttempt:      new(loginattempt.ControllerMock),
+		deviceCode:        new(devicecode.ControllerMock),
+	}
+
+	storage := NewStorageController(mocks.client, mocks.authorizationCode, mocks.accessToken, mocks.user, mocks.password, mocks.revocation, mocks.securityEvent, DefaultConfig)
+
+	return storage, mocks
+}
+
+func (t *oauth2Mocks) AssertExpectations(test *testing.T) {
+	t.html.AssertExpectations(test)
+	t.consent.AssertExpectations(test)
+	t.client.AssertExpectations(test)
+	t.authorizationCode.AssertExpectations(test)
+	t.accessToken.AssertExpectations(test)
+	t.user.AssertExpectations(test)
+	t.password.AssertExpectations(test)
+	t.signingKey.AssertExpectations(test)
+	t.revocation.AssertExpectations(test)
+	t.securityEvent.AssertExpectations(test)
+	t.loginSession.AssertExpectations(test)
+	t.loginAttempt.AssertExpectations(test)
+	t.deviceCode.AssertExpectations(test)
+}
+
+func Test_OAuth2_Storage_LoadAccess(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	res, err := storage.LoadAccess("some-access-token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, accesstoken.ValidAccessToken.AccessToken, res.AccessToken)
+	assert.Equal(t, accesstoken.ValidAccessToken.UserID, res.UserData)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAccess_with_an_expired_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-2 * time.Hour)
+	token.ExpiresIn = 3600
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(&token, nil).Once()
+
+	res, err := storage.LoadAccess("some-access-token")
+
+	assert.Nil(t, res)
+	assert.Equal(t, ErrAccessTokenExpired, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAccess_not_found(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-access-token"}).Return(nil, nil).Once()
+
+	res, err := storage.LoadAccess("some-access-token")
+
+	assert.Nil(t, res)
+	assert.Equal(t, osin.ErrNotFound, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAuthorize_with_an_expired_code(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.CreatedAt = time.Now().Add(-time.Minute)
+	code.ExpiresIn = 10
+
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+
+	res, err := storage.LoadAuthorize("some-code")
+
+	assert.Nil(t, res)
+	assert.Equal(t, ErrAuthorizationCodeExpired, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadRefresh_with_an_expired_access_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	// The access token is expired but the refresh token is still valid.
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-2 * time.Hour)
+	token.ExpiresIn = 3600
+	token.RefreshExpiresIn = 24 * 3600
+
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &token, nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: token.ClientID}).Return(&client.ValidClient, nil).Once()
+
+	res, err := storage.LoadRefresh("some-refresh-token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, token.RefreshToken, res.RefreshToken)
+	assert.Equal(t, token.ClientID, res.Client.GetId())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadRefresh_with_an_expired_refresh_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-48 * time.Hour)
+	token.RefreshExpiresIn = 24 * 3600
+
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &token, nil).Once()
+
+	res, err := storage.LoadRefresh("some-refresh-token")
+
+	assert.Nil(t, res)
+	assert.Equal(t, ErrRefreshTokenExpired, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_SaveAccess(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+	mocks.accessToken.On("Create", &accesstoken.CreateCmd{
+		ClientID:         "my-web-application",
+		UserID:           user.ValidUserID,
+		Role:             user.ValidUser.Role,
+		AccessToken:      "some-access-token",
+		RefreshToken:     "some-refresh-token",
+		ExpiresIn:        3600,
+		RefreshExpiresIn: int(DefaultConfig.RefreshExpiration),
+		Scopes:           []string{"users", "contacts"},
+	}).Return(nil).Once()
+
+	err := storage.SaveAccess(&osin.AccessData{
+		Client:       &osin.DefaultClient{Id: "my-web-application"},
+		AccessToken:  "some-access-token",
+		RefreshToken: "some-refresh-token",
+		ExpiresIn:    3600,
+		Scope:        "users,contacts",
+		UserData:     user.ValidUserID,
+	})
+
+	assert.NoError(t, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_SaveAccess_without_refresh_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.accessToken.On("Create", &accesstoken.CreateCmd{
+		ClientID:    "my-web-application",
+		AccessToken: "some-access-token",
+		ExpiresIn:   3600,
+		Scopes:      []string{"users"},
+	}).Return(nil).Once()
+
+	err := storage.SaveAccess(&osin.AccessData{
+		Client:      &osin.DefaultClient{Id: "my-web-application"},
+		AccessToken: "some-access-token",
+		ExpiresIn:   3600,
+		Scope:       "users",
+	})
+
+	assert.NoError(t, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_GetClient(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(&client.ValidClient, nil).Once()
+	mocks.password.On("Validate", "some-secret", client.ValidClient.Secret).Return(true, nil).Once()
+
+	res, err := storage.GetClient(client.ValidClient.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, client.ValidClient.ID, res.GetId())
+	assert.Equal(t, &client.ValidClient, res.GetUserData())
+	assert.True(t, osin.CheckClientSecret(res, "some-secret"))
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_GetClient_not_found(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: client.ValidClient.ID}).Return(nil, nil).Once()
+
+	res, err := storage.GetClient(client.ValidClient.ID)
+
+	assert.Nil(t, res)
+	assert.Equal(t, osin.ErrNotFound, err)
+
+	mocks.AssertExpectations(t)
+}
+
+// Example from the RFC 7636 appendix B.
+const (
+	validCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	validCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func Test_OAuth2_Storage_LoadAuthorize_with_a_S256_code_challenge(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	publicClient := client.ValidClient
+	publicClient.Secret = ""
+	publicClient.Public = true
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.CodeChallenge = validCodeChallenge
+	code.CodeChallengeMethod = osin.PKCE_S256
+
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: code.ClientID}).Return(&publicClient, nil).Once()
+
+	res, err := storage.LoadAuthorize("some-code")
+
+	assert.NoError(t, err)
+	assert.Equal(t, validCodeChallenge, res.CodeChallenge)
+	assert.Equal(t, osin.PKCE_S256, res.CodeChallengeMethod)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAuthorize_without_code_challenge_for_a_public_client(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	publicClient := client.ValidClient
+	publicClient.Secret = ""
+	publicClient.Public = true
+
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&authorizationcode.ValidAuthorizationCode, nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: authorizationcode.ValidAuthorizationCode.ClientID}).Return(&publicClient, nil).Once()
+
+	res, err := storage.LoadAuthorize("some-code")
+
+	assert.Nil(t, res)
+	assert.Equal(t, ErrPKCERequired, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAuthorize_without_code_challenge_for_a_confidential_client(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&authorizationcode.ValidAuthorizationCode, nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: authorizationcode.ValidAuthorizationCode.ClientID}).Return(&client.ValidClient, nil).Once()
+
+	res, err := storage.LoadAuthorize("some-code")
+
+	assert.NoError(t, err)
+	assert.Empty(t, res.CodeChallenge)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAuthorize_with_a_plain_code_challenge_not_allowed(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.CodeChallenge = validCodeVerifier
+	code.CodeChallengeMethod = osin.PKCE_PLAIN
+
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: code.ClientID}).Return(&client.ValidClient, nil).Once()
+
+	res, err := storage.LoadAuthorize("some-code")
+
+	assert.Nil(t, res)
+	assert.Equal(t, ErrPKCEMethodNotAllowed, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAuthorize_with_a_plain_code_challenge_allowed(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	cli := client.ValidClient
+	cli.AllowPlainPKCE = true
+
+	code := authorizationcode.ValidAuthorizationCode
+	code.CodeChallenge = validCodeVerifier
+	code.CodeChallengeMethod = ""
+
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&code, nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: code.ClientID}).Return(&cli, nil).Once()
+
+	res, err := storage.LoadAuthorize("some-code")
+
+	assert.NoError(t, err)
+	assert.Equal(t, validCodeVerifier, res.CodeChallenge)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_SaveAuthorize_with_a_nonce(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.authorizationCode.On("Create", &authorizationcode.CreateCmd{
+		ClientID:    client.ValidClient.ID,
+		UserID:      user.ValidUserID,
+		Code:        "some-code",
+		ExpiresIn:   250,
+		Scopes:      []string{"openid"},
+		RedirectURI: "http://some-url",
+		State:       "some-state",
+		Nonce:       "some-nonce",
+	}).Return(nil).Once()
+
+	err := storage.SaveAuthorize(&osin.AuthorizeData{
+		Client:      &osin.DefaultClient{Id: client.ValidClient.ID},
+		Code:        "some-code",
+		ExpiresIn:   250,
+		Scope:       "openid",
+		RedirectUri: "http://some-url",
+		State:       "some-state",
+		UserData:    &authorizeUserData{UserID: user.ValidUserID, Nonce: "some-nonce"},
+	})
+
+	assert.NoError(t, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadAuthorize_with_a_nonce(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.authorizationCode.On("Get", &authorizationcode.GetCmd{Code: "some-code"}).Return(&authorizationcode.ValidAuthorizationCode, nil).Once()
+	mocks.client.On("Get", &client.GetCmd{ClientID: authorizationcode.ValidAuthorizationCode.ClientID}).Return(&client.ValidClient, nil).Once()
+
+	res, err := storage.LoadAuthorize("some-code")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &authorizeUserData{
+		UserID: authorizationcode.ValidAuthorizationCode.UserID,
+		Nonce:  "some-nonce",
+	}, res.UserData)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_RemoveAccess_revoke_a_jwt_access_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	key, err := signingkey.ValidSigningKey.RSAPrivateKey()
+	assert.NoError(t, err)
+
+	expireAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	token, err := jwt.Sign(key, signingkey.ValidSigningKeyID, &accesstoken.Claims{
+		ExpiresAt: expireAt.Unix(),
+		TokenID:   revocation.ValidTokenID,
+	})
+	assert.NoError(t, err)
+
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: token}).Return(nil).Once()
+	mocks.revocation.On("Create", &revocation.CreateCmd{
+		TokenID:  revocation.ValidTokenID,
+		ExpireAt: expireAt,
+	}).Return(nil).Once()
+
+	err = storage.RemoveAccess(token)
+
+	assert.NoError(t, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_RemoveAccess_with_an_expired_jwt_access_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	key, err := signingkey.ValidSigningKey.RSAPrivateKey()
+	assert.NoError(t, err)
+
+	token, err := jwt.Sign(key, signingkey.ValidSigningKeyID, &accesstoken.Claims{
+		ExpiresAt: time.Now().Add(-time.Hour).Unix(),
+		TokenID:   revocation.ValidTokenID,
+	})
+	assert.NoError(t, err)
+
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: token}).Return(nil).Once()
+
+	err = storage.RemoveAccess(token)
+
+	assert.NoError(t, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_RemoveAccess_with_an_opaque_access_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.accessToken.On("Delete", &accesstoken.DeleteCmd{AccessToken: "some-access-token"}).Return(nil).Once()
+
+	err := storage.RemoveAccess("some-access-token")
+
+	assert.NoError(t, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_SaveAccess_with_a_refreshed_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-previous-token"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+	mocks.accessToken.On("MarkRefreshTokenUsed", &accesstoken.MarkRefreshTokenUsedCmd{AccessToken: "some-previous-token"}).Return(nil).Once()
+	mocks.accessToken.On("Create", &accesstoken.CreateCmd{
+		ClientID:         "my-web-application",
+		AccessToken:      "some-access-token",
+		RefreshToken:     "some-refresh-token",
+		ExpiresIn:        3600,
+		RefreshExpiresIn: int(DefaultConfig.RefreshExpiration),
+		FamilyID:         accesstoken.ValidAccessToken.FamilyID,
+		Scopes:           []string{"users"},
+	}).Return(nil).Once()
+
+	err := storage.SaveAccess(&osin.AccessData{
+		Client:       &osin.DefaultClient{Id: "my-web-application"},
+		AccessData:   &osin.AccessData{AccessToken: "some-previous-token"},
+		AccessToken:  "some-access-token",
+		RefreshToken: "some-refresh-token",
+		ExpiresIn:    3600,
+		Scope:        "users",
+	})
+
+	assert.NoError(t, err)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_SaveAccess_with_a_refresh_token_used_concurrently(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	mocks.accessToken.On("Get", &accesstoken.GetCmd{AccessToken: "some-previous-token"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+	mocks.accessToken.On("MarkRefreshTokenUsed", &accesstoken.MarkRefreshTokenUsedCmd{AccessToken: "some-previous-token"}).Return(fmt.Errorf("some-error")).Once()
+
+	err := storage.SaveAccess(&osin.AccessData{
+		Client:       &osin.DefaultClient{Id: "my-web-application"},
+		AccessData:   &osin.AccessData{AccessToken: "some-previous-token"},
+		AccessToken:  "some-access-token",
+		RefreshToken: "some-refresh-token",
+		ExpiresIn:    3600,
+		Scope:        "users",
+	})
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to rotate the refresh token",
+		"reason":{
+			"kind":"internalError",
+			"message":"failed to mark the refresh token as used",
+			"reason":{
+				"kind":"internalError",
+				"message":"some-error"
+			}
+		}
+	}`, err.Error())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_OAuth2_Storage_LoadRefresh_with_a_used_refresh_token(t *testing.T) {
+	storage, mocks := newStorageController()
+
+	token := accesstoken.ValidAccessToken
+	token.RefreshTokenUsed = true
+
+	mocks.accessToken.On("FindOneByRefreshToken", &accesstoken.FindOneByRefreshTokenCmd{RefreshToken: "some-refresh-token"}).
+		Return("some-access-token", &token, nil).Once()
+	mocks.accessToken.On("FindAllByFamily", &accesstoken.FindAllByFamilyCmd{FamilyID: token.FamilyID}).Return([]string{}, nil).Once()
+	mocks.securityEvent.On("Create", mock.Anything).Return("", fmt.Errorf("some-error")).Once()
+
+	res, err := storage.LoadRefresh("some-refresh-token")
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to revoke the token family",
+		"reason":{
+			"kind":"internalError",
+			"message":"failed to record the security event",
+			"reason":{
+				"kind":"internalError",
+				"message":"some-error"
+			}
+		}
+	}`, err.Error())
+
+	mocks.AssertExpectations(t)
+}
@@ -0,0 +1,116 @@
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+)
+
+// ticketLifetime is the time given to the user to fill a step of the
+// authorization flow.
+const ticketLifetime = 10 * time.Minute
+
+// totpStep is the step of the tickets given to the users with the
+// two-factor authentication enabled, once their password is checked.
+const totpStep = "totp"
+
+// passwordStep is the step of the tickets given to the authenticated users
+// who must change their password.
+const passwordStep = "password"
+
+// deviceStep is the step of the tickets given to the users approving a
+// device.
+const deviceStep = "device"
+
+// logoutStep is the step of the tickets given with the logout confirmation
+// form.
+const logoutStep = "logout"
+
+var (
+	ErrInvalidTicket = errors.New(errors.BadRequest, "invalid ticket")
+	ErrTicketExpired = errors.New(errors.BadRequest, "ticket expired")
+)
+
+// ticket carries the state of an authorization request between the steps of
+// the authorization flow (login, consent). It is signed before being given
+// to the browser so it can't be forged.
+type ticket struct {
+	UserID   string `json:"uid"`
+	ClientID string `json:"cid"`
+	Scope    string `json:"scp"`
+	ExpireAt int64  `json:"exp"`
+
+	// Step is empty for the consent tickets.
+	Step string `json:"stp,omitempty"`
+
+	// UserCode binds the device tickets to a single device.
+	UserCode string `json:"ucd,omitempty"`
+
+	// Session binds the logout tickets to the hash of a single login session.
+	Session string `json:"sid,omitempty"`
+}
+
+// NewTicketKey generates a random key used to sign the tickets.
+func NewTicketKey() ([]byte, error) {
+	key := make([]byte, 32)
+
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate a random key")
+	}
+
+	return key, nil
+}
+
+func signTicket(key []byte, tkt *ticket) (string, error) {
+	payload, err := json.Marshal(tkt)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode the ticket")
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func parseTicket(key []byte, raw string, now time.Time) (*ticket, error) {
+	parts := strings.SplitN(raw, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidTicket
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidTicket
+	}
+
+	var tkt ticket
+	err = json.Unmarshal(payload, &tkt)
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+
+	if now.Unix() > tkt.ExpireAt {
+		return nil, ErrTicketExpired
+	}
+
+	return &tkt, nil
+}
@@ -0,0 +1,57 @@
+package oauth2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ticketKey = []byte("some-ticket-key")
+
+func Test_OAuth2_Ticket_sign_and_parse(t *testing.T) {
+	now := time.Now()
+	tkt := ticket{
+		UserID:   "ae6ac8d6-0bcf-4671-a21a-49eab3167cbb",
+		ClientID: "my-web-application",
+		Scope:    "user",
+		ExpireAt: now.Add(time.Minute).Unix(),
+	}
+
+	raw, err := signTicket(ticketKey, &tkt)
+	assert.NoError(t, err)
+
+	res, err := parseTicket(ticketKey, raw, now)
+	assert.NoError(t, err)
+	assert.Equal(t, &tkt, res)
+}
+
+func Test_OAuth2_Ticket_parse_with_an_invalid_signature(t *testing.T) {
+	now := time.Now()
+
+	raw, err := signTicket([]byte("some-other-key"), &ticket{ExpireAt: now.Add(time.Minute).Unix()})
+	assert.NoError(t, err)
+
+	res, err := parseTicket(ticketKey, raw, now)
+	assert.Nil(t, res)
+	assert.Equal(t, ErrInvalidTicket, err)
+}
+
+func Test_OAuth2_Ticket_parse_an_expired_ticket(t *testing.T) {
+	now := time.Now()
+
+	raw, err := signTicket(ticketKey, &ticket{ExpireAt: now.Add(-time.Second).Unix()})
+	assert.NoError(t, err)
+
+	res, err := parseTicket(ticketKey, raw, now)
+	assert.Nil(t, res)
+	assert.Equal(t, ErrTicketExpired, err)
+}
+
+func Test_OAuth2_Ticket_parse_a_malformed_ticket(t *testing.T) {
+	for _, raw := range []string{"", "foobar", "foo.bar", "!!.!!"} {
+		res, err := parseTicket(ticketKey, raw, time.Now())
+		assert.Nil(t, res)
+		assert.Equal(t, ErrInvalidTicket, err, raw)
+	}
+}
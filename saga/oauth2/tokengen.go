@@ -0,0 +1,94 @@
+package oauth2
+
+import (
+	"context"
+	"strings"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/jwt"
+	"github.com/openshift/osin"
+)
+
+// jwtAccessTokenGen generates the access tokens as signed JWT. They can be
+// checked without any storage lookup (see permission.Verifier). The refresh
+// tokens stay opaque.
+type jwtAccessTokenGen struct {
+	inner      osin.AccessTokenGen
+	signingKey SigningKeyInterface
+	user       UserGetter
+	uuid       uuid.Producer
+	config     Config
+}
+
+func newJWTAccessTokenGen(signingKey SigningKeyInterface, user UserGetter, uuid uuid.Producer, config Config) *jwtAccessTokenGen {
+	return &jwtAccessTokenGen{
+		inner:      &osin.AccessTokenGenDefault{},
+		signingKey: signingKey,
+		user:       user,
+		uuid:       uuid,
+		config:     config,
+	}
+}
+
+// GenerateAccessToken implements the osin.AccessTokenGen interface.
+func (t *jwtAccessTokenGen) GenerateAccessToken(data *osin.AccessData, generateRefresh bool) (string, string, error) {
+	ctx := context.TODO()
+
+	_, refreshToken, err := t.inner.GenerateAccessToken(data, generateRefresh)
+	if err != nil {
+		return "", "", err
+	}
+
+	userID, _ := data.UserData.(string)
+
+	var role string
+	if userID != "" {
+		owner, err := t.user.Get(ctx, &user.GetCmd{
+			UserID: userID,
+		})
+		if err != nil {
+			return "", "", errors.Wrapf(err, "failed to retrieve the user %q", userID)
+		}
+
+		if owner == nil {
+			return "", "", errors.Errorf(errors.NotFound, "user %q not found", userID)
+		}
+
+		role = owner.Role
+	}
+
+	accessToken, err := signJWT(ctx, t.signingKey, &accesstoken.Claims{
+		Issuer:    t.config.Issuer,
+		Subject:   userID,
+		ClientID:  data.Client.GetId(),
+		Scope:     strings.Join(parseScopes(data.Scope), " "),
+		Role:      role,
+		IssuedAt:  data.CreatedAt.Unix(),
+		ExpiresAt: data.ExpireAt().Unix(),
+		TokenID:   t.uuid.New(),
+	})
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to sign the access token")
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// signJWT signs the claims with the current signing key.
+func signJWT(ctx context.Context, signingKey SigningKeyInterface, claims interface{}) (string, error) {
+	keyID, key, err := signingKey.GetCurrent(ctx, &signingkey.GetCurrentCmd{})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to retrieve the signing key")
+	}
+
+	privateKey, err := key.RSAPrivateKey()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode the signing key %q", keyID)
+	}
+
+	return jwt.Sign(privateKey, keyID, claims)
+}
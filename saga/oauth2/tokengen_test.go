@@ -0,0 +1,156 @@
+package oauth2
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/jwt"
+	"github.com/openshift/osin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJWTAccessTokenGenWithMocks() (*jwtAccessTokenGen, *oauth2Mocks, *uuid.ProducerMock) {
+	_, mocks := newStorageController()
+	uuidMock := new(uuid.ProducerMock)
+
+	return newJWTAccessTokenGen(mocks.signingKey, mocks.user, uuidMock, DefaultConfig), mocks, uuidMock
+}
+
+func validSigningPublicKey(t *testing.T) *rsa.PublicKey {
+	key, err := signingkey.ValidSigningKey.RSAPrivateKey()
+	require.NoError(t, err)
+
+	return &key.PublicKey
+}
+
+func Test_OAuth2_JWTAccessTokenGen_GenerateAccessToken(t *testing.T) {
+	gen, mocks, uuidMock := newJWTAccessTokenGenWithMocks()
+
+	createdAt := time.Now().Truncate(time.Second)
+
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(&user.ValidUser, nil).Once()
+	mocks.signingKey.On("GetCurrent", &signingkey.GetCurrentCmd{}).Return(signingkey.ValidSigningKeyID, &signingkey.ValidSigningKey, nil).Once()
+	uuidMock.On("New").Return(revocation.ValidTokenID).Once()
+
+	accessToken, refreshToken, err := gen.GenerateAccessToken(&osin.AccessData{
+		Client:    &osin.DefaultClient{Id: client.ValidClient.ID},
+		ExpiresIn: 3600,
+		Scope:     "users,contacts",
+		CreatedAt: createdAt,
+		UserData:  user.ValidUserID,
+	}, true)
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, refreshToken)
+	assert.False(t, jwt.IsJWT(refreshToken))
+
+	var claims accesstoken.Claims
+	err = jwt.Verify(accessToken, func(keyID string) *rsa.PublicKey {
+		assert.Equal(t, signingkey.ValidSigningKeyID, keyID)
+		return validSigningPublicKey(t)
+	}, &claims)
+
+	require.NoError(t, err)
+	assert.Equal(t, accesstoken.Claims{
+		Issuer:    DefaultConfig.Issuer,
+		Subject:   user.ValidUserID,
+		ClientID:  client.ValidClient.ID,
+		Scope:     "users contacts",
+		Role:      user.ValidUser.Role,
+		IssuedAt:  createdAt.Unix(),
+		ExpiresAt: createdAt.Add(time.Hour).Unix(),
+		TokenID:   revocation.ValidTokenID,
+	}, claims)
+
+	mocks.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_JWTAccessTokenGen_GenerateAccessToken_without_user(t *testing.T) {
+	gen, mocks, uuidMock := newJWTAccessTokenGenWithMocks()
+
+	mocks.signingKey.On("GetCurrent", &signingkey.GetCurrentCmd{}).Return(signingkey.ValidSigningKeyID, &signingkey.ValidSigningKey, nil).Once()
+	uuidMock.On("New").Return(revocation.ValidTokenID).Once()
+
+	accessToken, refreshToken, err := gen.GenerateAccessToken(&osin.AccessData{
+		Client:    &osin.DefaultClient{Id: client.ValidClient.ID},
+		ExpiresIn: 3600,
+		Scope:     "contacts",
+		CreatedAt: time.Now(),
+	}, false)
+
+	require.NoError(t, err)
+	assert.Empty(t, refreshToken)
+
+	var claims accesstoken.Claims
+	require.NoError(t, jwt.Decode(accessToken, &claims))
+	assert.Empty(t, claims.Subject)
+	assert.Empty(t, claims.Role)
+
+	mocks.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_JWTAccessTokenGen_GenerateAccessToken_with_a_signing_key_error(t *testing.T) {
+	gen, mocks, uuidMock := newJWTAccessTokenGenWithMocks()
+
+	mocks.signingKey.On("GetCurrent", &signingkey.GetCurrentCmd{}).Return("", nil, fmt.Errorf("some-error")).Once()
+	uuidMock.On("New").Return(revocation.ValidTokenID).Once()
+
+	accessToken, refreshToken, err := gen.GenerateAccessToken(&osin.AccessData{
+		Client:    &osin.DefaultClient{Id: client.ValidClient.ID},
+		ExpiresIn: 3600,
+		Scope:     "contacts",
+		CreatedAt: time.Now(),
+	}, true)
+
+	assert.Empty(t, accessToken)
+	assert.Empty(t, refreshToken)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to sign the access token",
+		"reason":{
+			"kind":"internalError",
+			"message":"failed to retrieve the signing key",
+			"reason":{
+				"kind":"internalError",
+				"message":"some-error"
+			}
+		}
+	}`, err.Error())
+
+	mocks.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
+
+func Test_OAuth2_JWTAccessTokenGen_GenerateAccessToken_with_an_unknown_user(t *testing.T) {
+	gen, mocks, uuidMock := newJWTAccessTokenGenWithMocks()
+
+	mocks.user.On("Get", &user.GetCmd{UserID: user.ValidUserID}).Return(nil, nil).Once()
+
+	accessToken, _, err := gen.GenerateAccessToken(&osin.AccessData{
+		Client:    &osin.DefaultClient{Id: client.ValidClient.ID},
+		ExpiresIn: 3600,
+		Scope:     "contacts",
+		CreatedAt: time.Now(),
+		UserData:  user.ValidUserID,
+	}, true)
+
+	assert.Empty(t, accessToken)
+	assert.JSONEq(t, `{
+		"kind":"notFound",
+		"message":"user \"ae6ac8d6-0bcf-4671-a21a-49eab3167cbb\" not found"
+	}`, err.Error())
+
+	mocks.AssertExpectations(t)
+	uuidMock.AssertExpectations(t)
+}
@@ -0,0 +1,34 @@
+package reaper
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/response"
+	"github.com/halium-project/server/utils/permission"
+)
+
+type HTTPHandler struct {
+	reaper ControllerInterface
+}
+
+type ControllerInterface interface {
+	Stats() Stats
+}
+
+func NewHTTPHandler(reaper ControllerInterface) *HTTPHandler {
+	return &HTTPHandler{
+		reaper: reaper,
+	}
+}
+
+// RegisterRoutes exposes the reaper counters. The "reaper" scope is only
+// given by the admins.
+func (t *HTTPHandler) RegisterRoutes(router *mux.Router, perm *permission.Controller) {
+	router.HandleFunc("/reaper/stats", perm.Check("reaper.read", t.Stats)).Methods("GET")
+}
+
+// Stats returns the counters accumulated since the start of the reaper.
+func (t *HTTPHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	response.Write(w, http.StatusOK, t.reaper.Stats())
+}
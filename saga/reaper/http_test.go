@@ -0,0 +1,89 @@
+package reaper
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Reaper_HTTPHandler_Stats_success(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	reaper := NewController(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 2)
+	reaper.stats = Stats{Runs: 3, Errors: 1, DeletedAccessTokens: 4, LastRunAt: now}
+	handler := NewHTTPHandler(reaper)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	session := accesstoken.ValidAccessToken
+	session.Scopes = []string{"*"}
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&session, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/reaper/stats", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.JSONEq(t, `{
+		"runs": 3,
+		"errors": 1,
+		"deletedAccessTokens": 4,
+		"deletedAuthorizationCodes": 0,
+		"deletedRevocations": 0,
+		"deletedLoginSessions": 0,
+		"deletedLoginAttempts": 0,
+		"deletedDeviceCodes": 0,
+		"deletedPasswordResets": 0,
+		"deletedConsents": 0,
+		"deletedSecurityEvents": 0,
+		"deletedPersonalTokens": 0,
+		"lastRunAt": "2019-02-23T10:00:00Z"
+	}`, string(body))
+
+	accessTokenControllerMock.AssertExpectations(t)
+}
+
+func Test_Reaper_HTTPHandler_Stats_without_the_reaper_scope(t *testing.T) {
+	accessTokenControllerMock := new(accesstoken.ControllerMock)
+	perm := permission.NewController(context.Background(), accessTokenControllerMock, nil, nil)
+	router := mux.NewRouter()
+	reaper := NewController(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, 2)
+	handler := NewHTTPHandler(reaper)
+	handler.RegisterRoutes(router, perm)
+
+	// Token introspection
+	accessTokenControllerMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	r := httptest.NewRequest("GET", "http://example.com/reaper/stats", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, r)
+
+	res := w.Result()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.JSONEq(t, `{
+		"kind": "notAuthorized",
+		"message": "doesn't have required permission"
+	}`, string(body))
+
+	accessTokenControllerMock.AssertExpectations(t)
+}
@@ -0,0 +1,292 @@
+package reaper
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/authorizationcode"
+	"github.com/halium-project/server/resource/consent"
+	"github.com/halium-project/server/resource/devicecode"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/passwordreset"
+	"github.com/halium-project/server/resource/personaltoken"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/securityevent"
+	"github.com/halium-project/server/utils/clock"
+)
+
+const (
+	// DefaultInterval is the delay between two sweeps.
+	DefaultInterval = 5 * time.Minute
+
+	// DefaultBatchSize is the maximum number of documents deleted by request.
+	DefaultBatchSize = 100
+)
+
+type AccessTokenInterface interface {
+	DeleteExpired(ctx context.Context, cmd *accesstoken.DeleteExpiredCmd) (int, error)
+}
+
+type AuthorizationCodeInterface interface {
+	DeleteExpired(ctx context.Context, cmd *authorizationcode.DeleteExpiredCmd) (int, error)
+}
+
+type RevocationInterface interface {
+	DeleteExpired(ctx context.Context, cmd *revocation.DeleteExpiredCmd) (int, error)
+}
+
+type LoginSessionInterface interface {
+	DeleteExpired(ctx context.Context, cmd *loginsession.DeleteExpiredCmd) (int, error)
+}
+
+type LoginAttemptInterface interface {
+	DeleteExpired(ctx context.Context, cmd *loginattempt.DeleteExpiredCmd) (int, error)
+}
+
+type DeviceCodeInterface interface {
+	DeleteExpired(ctx context.Context, cmd *devicecode.DeleteExpiredCmd) (int, error)
+}
+
+type PasswordResetInterface interface {
+	DeleteExpired(ctx context.Context, cmd *passwordreset.DeleteExpiredCmd) (int, error)
+}
+
+type ConsentInterface interface {
+	DeleteExpired(ctx context.Context, cmd *consent.DeleteExpiredCmd) (int, error)
+}
+
+type SecurityEventInterface interface {
+	DeleteExpired(ctx context.Context, cmd *securityevent.DeleteExpiredCmd) (int, error)
+}
+
+type PersonalTokenInterface interface {
+	DeleteExpired(ctx context.Context, cmd *personaltoken.DeleteExpiredCmd) (int, error)
+}
+
+// Stats contains the counters accumulated since the start of the reaper.
+type Stats struct {
+	Runs                      int       `json:"runs"`
+	Errors                    int       `json:"errors"`
+	DeletedAccessTokens       int       `json:"deletedAccessTokens"`
+	DeletedAuthorizationCodes int       `json:"deletedAuthorizationCodes"`
+	DeletedRevocations        int       `json:"deletedRevocations"`
+	DeletedLoginSessions      int       `json:"deletedLoginSessions"`
+	DeletedLoginAttempts      int       `json:"deletedLoginAttempts"`
+	DeletedDeviceCodes        int       `json:"deletedDeviceCodes"`
+	DeletedPasswordResets     int       `json:"deletedPasswordResets"`
+	DeletedConsents           int       `json:"deletedConsents"`
+	DeletedSecurityEvents     int       `json:"deletedSecurityEvents"`
+	DeletedPersonalTokens     int       `json:"deletedPersonalTokens"`
+	LastRunAt                 time.Time `json:"lastRunAt"`
+}
+
+// Controller purges the expired access tokens, authorization codes,
+// revocation list entries, login sessions, login attempts, device codes,
+// password reset tokens, consents and personal tokens, and the security
+// events older than their retention.
+type Controller struct {
+	accessToken       AccessTokenInterface
+	authorizationCode AuthorizationCodeInterface
+	revocation        RevocationInterface
+	loginSession      LoginSessionInterface
+	loginAttempt      LoginAttemptInterface
+	deviceCode        DeviceCodeInterface
+	passwordReset     PasswordResetInterface
+	consent           ConsentInterface
+	securityEvent     SecurityEventInterface
+	personalToken     PersonalTokenInterface
+	clock             clock.Clock
+	batchSize         uint
+
+	lock  sync.Mutex
+	stats Stats
+}
+
+func NewController(
+	accessToken AccessTokenInterface,
+	authorizationCode AuthorizationCodeInterface,
+	revocation RevocationInterface,
+	loginSession LoginSessionInterface,
+	loginAttempt LoginAttemptInterface,
+	deviceCode DeviceCodeInterface,
+	passwordReset PasswordResetInterface,
+	consent ConsentInterface,
+	securityEvent SecurityEventInterface,
+	personalToken PersonalTokenInterface,
+	clock clock.Clock,
+	batchSize uint,
+) *Controller {
+	return &Controller{
+		accessToken:       accessToken,
+		authorizationCode: authorizationCode,
+		revocation:        revocation,
+		loginSession:      loginSession,
+		loginAttempt:      loginAttempt,
+		deviceCode:        deviceCode,
+		passwordReset:     passwordReset,
+		consent:           consent,
+		securityEvent:     securityEvent,
+		personalToken:     personalToken,
+		clock:             clock,
+		batchSize:         batchSize,
+	}
+}
+
+// Run sweeps the expired documents at each interval until the context is
+// canceled.
+func (t *Controller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := t.Sweep(ctx)
+		if err != nil {
+			log.Println(errors.Wrap(err, "failed to sweep the expired documents"))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep deletes all the documents expired at the current time, batch by
+// batch. A failing kind of document doesn't stop the others, the first error
+// is returned.
+func (t *Controller) Sweep(ctx context.Context) error {
+	now := t.clock.Now()
+
+	var firstErr error
+	sweep := func(name string, deleteBatch func(ctx context.Context) (int, error)) int {
+		deleted, err := t.sweep(ctx, deleteBatch)
+		if err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "failed to delete the expired %s", name)
+		}
+
+		return deleted
+	}
+
+	tokens := sweep("access tokens", func(ctx context.Context) (int, error) {
+		return t.accessToken.DeleteExpired(ctx, &accesstoken.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	codes := sweep("authorization codes", func(ctx context.Context) (int, error) {
+		return t.authorizationCode.DeleteExpired(ctx, &authorizationcode.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	revocations := sweep("revocations", func(ctx context.Context) (int, error) {
+		return t.revocation.DeleteExpired(ctx, &revocation.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	sessions := sweep("login sessions", func(ctx context.Context) (int, error) {
+		return t.loginSession.DeleteExpired(ctx, &loginsession.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	attempts := sweep("login attempts", func(ctx context.Context) (int, error) {
+		return t.loginAttempt.DeleteExpired(ctx, &loginattempt.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	deviceCodes := sweep("device codes", func(ctx context.Context) (int, error) {
+		return t.deviceCode.DeleteExpired(ctx, &devicecode.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	passwordResets := sweep("password resets", func(ctx context.Context) (int, error) {
+		return t.passwordReset.DeleteExpired(ctx, &passwordreset.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	consents := sweep("consents", func(ctx context.Context) (int, error) {
+		return t.consent.DeleteExpired(ctx, &consent.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	securityEvents := sweep("security events", func(ctx context.Context) (int, error) {
+		return t.securityEvent.DeleteExpired(ctx, &securityevent.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	personalTokens := sweep("personal tokens", func(ctx context.Context) (int, error) {
+		return t.personalToken.DeleteExpired(ctx, &personaltoken.DeleteExpiredCmd{
+			Now:   now,
+			Limit: t.batchSize,
+		})
+	})
+
+	t.lock.Lock()
+	t.stats.Runs++
+	t.stats.DeletedAccessTokens += tokens
+	t.stats.DeletedAuthorizationCodes += codes
+	t.stats.DeletedRevocations += revocations
+	t.stats.DeletedLoginSessions += sessions
+	t.stats.DeletedLoginAttempts += attempts
+	t.stats.DeletedDeviceCodes += deviceCodes
+	t.stats.DeletedPasswordResets += passwordResets
+	t.stats.DeletedConsents += consents
+	t.stats.DeletedSecurityEvents += securityEvents
+	t.stats.DeletedPersonalTokens += personalTokens
+	t.stats.LastRunAt = now
+	if firstErr != nil {
+		t.stats.Errors++
+	}
+	t.lock.Unlock()
+
+	return firstErr
+}
+
+// Stats returns a snapshot of the reaper counters.
+func (t *Controller) Stats() Stats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.stats
+}
+
+// sweep calls deleteBatch until a batch is not full.
+func (t *Controller) sweep(ctx context.Context, deleteBatch func(ctx context.Context) (int, error)) (int, error) {
+	var total int
+
+	for ctx.Err() == nil {
+		deleted, err := deleteBatch(ctx)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+
+		if uint(deleted) < t.batchSize {
+			break
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,772 @@
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/db"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/authorizationcode"
+	"github.com/halium-project/server/resource/consent"
+	"github.com/halium-project/server/resource/devicecode"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/loginsession"
+	"github.com/halium-project/server/resource/passwordreset"
+	"github.com/halium-project/server/resource/personaltoken"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/securityevent"
+	"github.com/halium-project/server/utils/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+
+func Test_Reaper_Sweep(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	// The first batch is full so a second request is done.
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(2, nil).Once()
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(1, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{
+		Runs:                      1,
+		Errors:                    0,
+		DeletedAccessTokens:       3,
+		DeletedAuthorizationCodes: 0,
+		DeletedRevocations:        0,
+		DeletedLoginSessions:      0,
+		DeletedLoginAttempts:      0,
+		DeletedDeviceCodes:        0,
+		DeletedPasswordResets:     0,
+		DeletedConsents:           0,
+		DeletedSecurityEvents:     0,
+		DeletedPersonalTokens:     0,
+		LastRunAt:                 now,
+	}, reaper.Stats())
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_an_access_token_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	// The authorization codes are purged even if the access tokens fail.
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(1, fmt.Errorf("some-error")).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(1, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired access tokens",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, Stats{
+		Runs:                      1,
+		Errors:                    1,
+		DeletedAccessTokens:       1,
+		DeletedAuthorizationCodes: 1,
+		DeletedRevocations:        0,
+		DeletedLoginSessions:      0,
+		DeletedLoginAttempts:      0,
+		DeletedDeviceCodes:        0,
+		DeletedPasswordResets:     0,
+		DeletedConsents:           0,
+		DeletedSecurityEvents:     0,
+		DeletedPersonalTokens:     0,
+		LastRunAt:                 now,
+	}, reaper.Stats())
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_an_authorization_code_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired authorization codes",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_revocation_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(1, fmt.Errorf("some-error")).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired revocations",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+	assert.Equal(t, 1, reaper.Stats().DeletedRevocations)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_login_session_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired login sessions",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_login_attempt_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired login attempts",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_device_code_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired device codes",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_password_reset_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired password resets",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_consent_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired consents",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_security_event_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired security events",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_a_personal_token_error(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil).Once()
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, fmt.Errorf("some-error")).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to delete the expired personal tokens",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+	assert.Equal(t, 1, reaper.Stats().Errors)
+
+	accessTokenMock.AssertExpectations(t)
+	authorizationCodeMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+	loginSessionMock.AssertExpectations(t)
+	loginAttemptMock.AssertExpectations(t)
+	deviceCodeMock.AssertExpectations(t)
+	passwordResetMock.AssertExpectations(t)
+	consentMock.AssertExpectations(t)
+	securityEventMock.AssertExpectations(t)
+	personalTokenMock.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Sweep_with_the_db_driver(t *testing.T) {
+	accessTokenDriver := new(db.DriverMock)
+	authorizationCodeDriver := new(db.DriverMock)
+	revocationDriver := new(db.DriverMock)
+	loginSessionDriver := new(db.DriverMock)
+	loginAttemptDriver := new(db.DriverMock)
+	deviceCodeDriver := new(db.DriverMock)
+	passwordResetDriver := new(db.DriverMock)
+	consentDriver := new(db.DriverMock)
+	securityEventDriver := new(db.DriverMock)
+	personalTokenDriver := new(db.DriverMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(
+		accesstoken.NewController(nil, nil, accesstoken.NewStorage(accessTokenDriver)),
+		authorizationcode.NewController(nil, nil, authorizationcode.NewStorage(authorizationCodeDriver)),
+		revocation.NewController(revocation.NewStorage(revocationDriver)),
+		loginsession.NewController(nil, loginsession.NewStorage(loginSessionDriver)),
+		loginattempt.NewController(nil, loginattempt.NewStorage(loginAttemptDriver)),
+		devicecode.NewController(nil, nil, devicecode.NewStorage(deviceCodeDriver)),
+		passwordreset.NewController(nil, nil, passwordreset.NewStorage(passwordResetDriver)),
+		consent.NewController(nil, nil, consent.NewStorage(consentDriver)),
+		securityevent.NewController(nil, securityevent.NewStorage(securityEventDriver)),
+		personaltoken.NewController(nil, nil, nil, personaltoken.NewStorage(personalTokenDriver)),
+		clockMock,
+		10,
+	)
+
+	query := &db.Query{
+		IndexName: "by_expiration",
+		Limit:     10,
+		Range: &db.Range{
+			Start: 0,
+			End:   int64(1550916000000),
+		},
+	}
+
+	clockMock.On("Now").Return(now).Once()
+
+	accessTokenDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-access-token", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	accessTokenDriver.On("Delete", "some-access-token", "some-rev").Return(nil).Once()
+
+	authorizationCodeDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-code", Value: []byte(`"some-rev"`)},
+		{ID: "some-other-code", Value: []byte(`"some-other-rev"`)},
+	}, nil).Once()
+	authorizationCodeDriver.On("Delete", "some-code", "some-rev").Return(nil).Once()
+	authorizationCodeDriver.On("Delete", "some-other-code", "some-other-rev").Return(nil).Once()
+
+	revocationDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-token-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	revocationDriver.On("Delete", "some-token-id", "some-rev").Return(nil).Once()
+
+	loginSessionDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-session-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	loginSessionDriver.On("Delete", "some-session-id", "some-rev").Return(nil).Once()
+
+	loginAttemptDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-attempt-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	loginAttemptDriver.On("Delete", "some-attempt-id", "some-rev").Return(nil).Once()
+
+	deviceCodeDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-device-code-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	deviceCodeDriver.On("Delete", "some-device-code-id", "some-rev").Return(nil).Once()
+
+	passwordResetDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-password-reset-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	passwordResetDriver.On("Delete", "some-password-reset-id", "some-rev").Return(nil).Once()
+
+	consentDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-consent-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	consentDriver.On("Delete", "some-consent-id", "some-rev").Return(nil).Once()
+
+	securityEventDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-security-event-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	securityEventDriver.On("Delete", "some-security-event-id", "some-rev").Return(nil).Once()
+
+	personalTokenDriver.On("ExecuteViewQuery", query).Return([]db.ViewRow{
+		{ID: "some-personal-token-id", Value: []byte(`"some-rev"`)},
+	}, nil).Once()
+	personalTokenDriver.On("Delete", "some-personal-token-id", "some-rev").Return(nil).Once()
+
+	err := reaper.Sweep(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reaper.Stats().DeletedAccessTokens)
+	assert.Equal(t, 2, reaper.Stats().DeletedAuthorizationCodes)
+	assert.Equal(t, 1, reaper.Stats().DeletedRevocations)
+	assert.Equal(t, 1, reaper.Stats().DeletedLoginSessions)
+	assert.Equal(t, 1, reaper.Stats().DeletedLoginAttempts)
+	assert.Equal(t, 1, reaper.Stats().DeletedDeviceCodes)
+	assert.Equal(t, 1, reaper.Stats().DeletedPasswordResets)
+	assert.Equal(t, 1, reaper.Stats().DeletedConsents)
+	assert.Equal(t, 1, reaper.Stats().DeletedSecurityEvents)
+	assert.Equal(t, 1, reaper.Stats().DeletedPersonalTokens)
+
+	accessTokenDriver.AssertExpectations(t)
+	authorizationCodeDriver.AssertExpectations(t)
+	revocationDriver.AssertExpectations(t)
+	loginSessionDriver.AssertExpectations(t)
+	loginAttemptDriver.AssertExpectations(t)
+	deviceCodeDriver.AssertExpectations(t)
+	passwordResetDriver.AssertExpectations(t)
+	consentDriver.AssertExpectations(t)
+	securityEventDriver.AssertExpectations(t)
+	personalTokenDriver.AssertExpectations(t)
+	clockMock.AssertExpectations(t)
+}
+
+func Test_Reaper_Run_stop_with_the_context(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	authorizationCodeMock := new(authorizationcode.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+	loginSessionMock := new(loginsession.ControllerMock)
+	loginAttemptMock := new(loginattempt.ControllerMock)
+	deviceCodeMock := new(devicecode.ControllerMock)
+	passwordResetMock := new(passwordreset.ControllerMock)
+	consentMock := new(consent.ControllerMock)
+	securityEventMock := new(securityevent.ControllerMock)
+	personalTokenMock := new(personaltoken.ControllerMock)
+	clockMock := new(clock.ClockMock)
+	reaper := NewController(accessTokenMock, authorizationCodeMock, revocationMock, loginSessionMock, loginAttemptMock, deviceCodeMock, passwordResetMock, consentMock, securityEventMock, personalTokenMock, clockMock, 2)
+
+	clockMock.On("Now").Return(now)
+	accessTokenMock.On("DeleteExpired", &accesstoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	authorizationCodeMock.On("DeleteExpired", &authorizationcode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	revocationMock.On("DeleteExpired", &revocation.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	loginSessionMock.On("DeleteExpired", &loginsession.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	loginAttemptMock.On("DeleteExpired", &loginattempt.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	deviceCodeMock.On("DeleteExpired", &devicecode.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	passwordResetMock.On("DeleteExpired", &passwordreset.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	consentMock.On("DeleteExpired", &consent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	securityEventMock.On("DeleteExpired", &securityevent.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+	personalTokenMock.On("DeleteExpired", &personaltoken.DeleteExpiredCmd{Now: now, Limit: 2}).Return(0, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reaper.Run(ctx, time.Hour)
+
+	assert.Equal(t, 1, reaper.Stats().Runs)
+}
@@ -0,0 +1,17 @@
+package recovery
+
+// Config contains the settings of the password reset flow.
+type Config struct {
+	// BaseURL is the URL of the server. It is used to build the reset links
+	// sent by email.
+	BaseURL string
+
+	// Reset tokens lifetime in seconds.
+	TokenExpiration int
+}
+
+// DefaultConfig is the configuration used by the server.
+var DefaultConfig = Config{
+	BaseURL:         "http://localhost:42000",
+	TokenExpiration: 3600,
+}
@@ -0,0 +1,302 @@
+// Package recovery implements the self-service password reset: the users
+// receive a single-use link by email and choose a new password.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/validator"
+	"github.com/halium-project/go-server-utils/validator/is"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/passwordreset"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/saga/oauth2"
+	"github.com/halium-project/server/utils/mailer"
+)
+
+type TemplateRenderer interface {
+	Render(w io.Writer, templateName string, params interface{}) error
+}
+
+type UserInterface interface {
+	GetByEmail(ctx context.Context, cmd *user.GetByEmailCmd) (string, *user.User, error)
+	ChangePassword(ctx context.Context, cmd *user.ChangePasswordCmd) error
+}
+
+type PasswordResetInterface interface {
+	Create(ctx context.Context, cmd *passwordreset.CreateCmd) (string, error)
+	Get(ctx context.Context, cmd *passwordreset.GetCmd) (*passwordreset.PasswordReset, error)
+	Consume(ctx context.Context, cmd *passwordreset.ConsumeCmd) (*passwordreset.PasswordReset, error)
+}
+
+type LoginAttemptInterface interface {
+	Check(ctx context.Context, cmd *loginattempt.CheckCmd) (time.Duration, error)
+	RecordFailure(ctx context.Context, cmd *loginattempt.RecordFailureCmd) error
+}
+
+type Controller struct {
+	html          TemplateRenderer
+	user          UserInterface
+	passwordReset PasswordResetInterface
+	loginAttempt  LoginAttemptInterface
+	mailer        mailer.Mailer
+	config        Config
+}
+
+type forgotPasswordTemplateParam struct {
+	Sent  bool
+	Error string
+}
+
+type resetPasswordTemplateParam struct {
+	Token string
+	Done  bool
+	Error string
+}
+
+func NewController(
+	html TemplateRenderer,
+	user UserInterface,
+	passwordReset PasswordResetInterface,
+	loginAttempt LoginAttemptInterface,
+	mailer mailer.Mailer,
+	config Config,
+) *Controller {
+	return &Controller{
+		html:          html,
+		user:          user,
+		passwordReset: passwordReset,
+		loginAttempt:  loginAttempt,
+		mailer:        mailer,
+		config:        config,
+	}
+}
+
+func (t *Controller) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/password/forgot", t.ForgotPassword).Methods("GET", "POST")
+	router.HandleFunc("/password/reset", t.ResetPassword).Methods("GET", "POST")
+}
+
+// ForgotPassword asks for an email address and sends a reset link to its
+// owner. The same page is rendered if no user owns the address in order to
+// not disclose the registered addresses. The requests are throttled per email
+// address and per IP address with the login attempts.
+func (t *Controller) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" {
+		t.render(w, http.StatusOK, "forgot_password.html", forgotPasswordTemplateParam{})
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		t.render(w, http.StatusBadRequest, "forgot_password.html", forgotPasswordTemplateParam{
+			Error: "Invalid request.",
+		})
+		return
+	}
+
+	email := r.PostForm.Get("email")
+
+	delay, err := t.loginAttempt.Check(r.Context(), &loginattempt.CheckCmd{
+		Email: email,
+		IP:    oauth2.ClientIP(r),
+	})
+	if err != nil {
+		t.renderInternalError(w, errors.Wrap(err, "failed to check the login attempts"))
+		return
+	}
+
+	if delay > 0 {
+		t.render(w, http.StatusTooManyRequests, "forgot_password.html", forgotPasswordTemplateParam{
+			Error: "Too many requests. Try again later.",
+		})
+		return
+	}
+
+	userID, owner, err := t.user.GetByEmail(r.Context(), &user.GetByEmailCmd{
+		Email: email,
+	})
+	if errors.IsKind(err, errors.Validation) {
+		t.render(w, http.StatusBadRequest, "forgot_password.html", forgotPasswordTemplateParam{
+			Error: "Invalid email address.",
+		})
+		return
+	}
+
+	if err != nil {
+		t.renderInternalError(w, errors.Wrap(err, "failed to retrieve the user"))
+		return
+	}
+
+	// Every request is counted, even for an unknown address, in order to not
+	// disclose the registered addresses with the delays.
+	err = t.loginAttempt.RecordFailure(r.Context(), &loginattempt.RecordFailureCmd{
+		Email: email,
+		IP:    oauth2.ClientIP(r),
+	})
+	if err != nil {
+		t.renderInternalError(w, errors.Wrap(err, "failed to record the login attempt"))
+		return
+	}
+
+	if userID != "" {
+		err = t.sendResetLink(r.Context(), userID, owner)
+		if err != nil {
+			t.renderInternalError(w, err)
+			return
+		}
+	}
+
+	t.render(w, http.StatusOK, "forgot_password.html", forgotPasswordTemplateParam{
+		Sent: true,
+	})
+}
+
+func (t *Controller) sendResetLink(ctx context.Context, userID string, owner *user.User) error {
+	token, err := t.passwordReset.Create(ctx, &passwordreset.CreateCmd{
+		UserID:    userID,
+		ExpiresIn: t.config.TokenExpiration,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to create the reset token")
+	}
+
+	link := t.config.BaseURL + "/password/reset?" + url.Values{"token": {token}}.Encode()
+
+	err = t.mailer.Send(ctx, &mailer.Message{
+		To:      owner.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Follow this link to choose a new password:\n%s\n\n"+
+			"The link can be used once and expires in %d minutes. Ignore this email if you didn't ask for it.",
+			owner.Username, link, t.config.TokenExpiration/60),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to send the reset link")
+	}
+
+	return nil
+}
+
+// ResetPassword asks for a new password with the token given by the reset
+// link. The token is consumed before the password is changed so it can't be
+// used by two concurrent requests.
+func (t *Controller) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	if r.Method == "POST" {
+		err := r.ParseForm()
+		if err != nil {
+			t.render(w, http.StatusBadRequest, "reset_password.html", resetPasswordTemplateParam{
+				Error: "Invalid request.",
+			})
+			return
+		}
+
+		token = r.PostForm.Get("token")
+	}
+
+	reset, err := t.passwordReset.Get(r.Context(), &passwordreset.GetCmd{
+		Token: token,
+	})
+	if err != nil && !errors.IsKind(err, errors.Validation) {
+		t.renderInternalError(w, errors.Wrap(err, "failed to retrieve the reset token"))
+		return
+	}
+
+	if reset == nil {
+		t.render(w, http.StatusBadRequest, "reset_password.html", resetPasswordTemplateParam{
+			Error: "This link is invalid or expired.",
+		})
+		return
+	}
+
+	if r.Method == "GET" {
+		t.render(w, http.StatusOK, "reset_password.html", resetPasswordTemplateParam{
+			Token: token,
+		})
+		return
+	}
+
+	newPassword := r.PostForm.Get("new_password")
+	if newPassword != r.PostForm.Get("confirmation") {
+		t.render(w, http.StatusBadRequest, "reset_password.html", resetPasswordTemplateParam{
+			Token: token,
+			Error: "The passwords don't match.",
+		})
+		return
+	}
+
+	// Check the password rules before consuming the token in order to not
+	// invalidate the link for a typo.
+	err = validator.New().
+		CheckString("newPassword", newPassword, is.Required, is.StringInRange(8, 256)).
+		Run()
+	if err != nil {
+		t.render(w, http.StatusBadRequest, "reset_password.html", resetPasswordTemplateParam{
+			Token: token,
+			Error: "The new password must have between 8 and 256 characters and differ from the current one.",
+		})
+		return
+	}
+
+	reset, err = t.passwordReset.Consume(r.Context(), &passwordreset.ConsumeCmd{
+		Token: token,
+	})
+	if err != nil {
+		t.renderInternalError(w, errors.Wrap(err, "failed to consume the reset token"))
+		return
+	}
+
+	if reset == nil {
+		t.render(w, http.StatusBadRequest, "reset_password.html", resetPasswordTemplateParam{
+			Error: "This link is invalid or expired.",
+		})
+		return
+	}
+
+	err = t.user.ChangePassword(r.Context(), &user.ChangePasswordCmd{
+		UserID:              reset.UserID,
+		NewPassword:         newPassword,
+		SkipCurrentPassword: true,
+	})
+	if errors.IsKind(err, errors.Validation) {
+		t.render(w, http.StatusBadRequest, "reset_password.html", resetPasswordTemplateParam{
+			Error: "The new password must differ from the current one. Ask for a new link to try again.",
+		})
+		return
+	}
+
+	if err != nil {
+		t.renderInternalError(w, errors.Wrapf(err, "failed to change the password of the user %q", reset.UserID))
+		return
+	}
+
+	t.render(w, http.StatusOK, "reset_password.html", resetPasswordTemplateParam{
+		Done: true,
+	})
+}
+
+func (t *Controller) render(w http.ResponseWriter, HTTPStatus int, templateName string, param interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(HTTPStatus)
+
+	err := t.html.Render(w, templateName, param)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (t *Controller) renderInternalError(w http.ResponseWriter, err error) {
+	log.Println(err)
+
+	t.render(w, http.StatusInternalServerError, "internal_error.html", "internal error")
+}
@@ -0,0 +1,349 @@
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/front/templates"
+	"github.com/halium-project/server/resource/loginattempt"
+	"github.com/halium-project/server/resource/passwordreset"
+	"github.com/halium-project/server/resource/user"
+	"github.com/halium-project/server/utils/mailer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type recoveryMocks struct {
+	html          *templates.RendererMock
+	user          *user.ControllerMock
+	passwordReset *passwordreset.ControllerMock
+	loginAttempt  *loginattempt.ControllerMock
+	mailer        *mailer.MailerMock
+}
+
+func (t *recoveryMocks) AssertExpectations(test *testing.T) {
+	t.html.AssertExpectations(test)
+	t.user.AssertExpectations(test)
+	t.passwordReset.AssertExpectations(test)
+	t.loginAttempt.AssertExpectations(test)
+	t.mailer.AssertExpectations(test)
+}
+
+func newRouter() (*mux.Router, *recoveryMocks) {
+	mocks := &recoveryMocks{
+		html:          new(templates.RendererMock),
+		user:          new(user.ControllerMock),
+		passwordReset: new(passwordreset.ControllerMock),
+		loginAttempt:  new(loginattempt.ControllerMock),
+		mailer:        new(mailer.MailerMock),
+	}
+
+	router := mux.NewRouter()
+	controller := NewController(mocks.html, mocks.user, mocks.passwordReset, mocks.loginAttempt, mocks.mailer, DefaultConfig)
+	controller.RegisterRoutes(router)
+
+	return router, mocks
+}
+
+func newFormRequest(target string, form url.Values) *http.Request {
+	r := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+func Test_Recovery_Controller_ForgotPassword_render_the_page(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.html.On("Render", "forgot_password.html", forgotPasswordTemplateParam{}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/password/forgot", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ForgotPassword_send_a_reset_link(t *testing.T) {
+	router, mocks := newRouter()
+
+	owner := user.ValidUser
+	owner.Email = "some-email@example.com"
+
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Email: "some-email@example.com", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("GetByEmail", &user.GetByEmailCmd{Email: "some-email@example.com"}).Return(user.ValidUserID, &owner, nil).Once()
+	mocks.loginAttempt.On("RecordFailure", &loginattempt.RecordFailureCmd{Email: "some-email@example.com", IP: "192.0.2.1"}).Return(nil).Once()
+	mocks.passwordReset.On("Create", &passwordreset.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 3600}).Return(passwordreset.ValidToken, nil).Once()
+	mocks.mailer.On("Send", mock.MatchedBy(func(msg *mailer.Message) bool {
+		return msg.To == "some-email@example.com" &&
+			strings.Contains(msg.Body, "http://localhost:42000/password/reset?token="+passwordreset.ValidToken)
+	})).Return(nil).Once()
+	mocks.html.On("Render", "forgot_password.html", forgotPasswordTemplateParam{Sent: true}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/forgot", url.Values{
+		"email": {"some-email@example.com"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ForgotPassword_with_an_unknown_email(t *testing.T) {
+	router, mocks := newRouter()
+
+	// The response must not disclose if the email is registered.
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Email: "some-email@example.com", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("GetByEmail", &user.GetByEmailCmd{Email: "some-email@example.com"}).Return("", nil, nil).Once()
+	mocks.loginAttempt.On("RecordFailure", &loginattempt.RecordFailureCmd{Email: "some-email@example.com", IP: "192.0.2.1"}).Return(nil).Once()
+	mocks.html.On("Render", "forgot_password.html", forgotPasswordTemplateParam{Sent: true}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/forgot", url.Values{
+		"email": {"some-email@example.com"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ForgotPassword_with_an_invalid_email(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Email: "not an email", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("GetByEmail", &user.GetByEmailCmd{Email: "not an email"}).
+		Return("", nil, errors.NewValidationError().AddError("email", "INVALID_FORMAT").IntoError()).Once()
+	mocks.html.On("Render", "forgot_password.html", forgotPasswordTemplateParam{Error: "Invalid email address."}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/forgot", url.Values{
+		"email": {"not an email"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ForgotPassword_with_a_mailer_error(t *testing.T) {
+	router, mocks := newRouter()
+
+	owner := user.ValidUser
+	owner.Email = "some-email@example.com"
+
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Email: "some-email@example.com", IP: "192.0.2.1"}).Return(time.Duration(0), nil).Once()
+	mocks.user.On("GetByEmail", &user.GetByEmailCmd{Email: "some-email@example.com"}).Return(user.ValidUserID, &owner, nil).Once()
+	mocks.loginAttempt.On("RecordFailure", &loginattempt.RecordFailureCmd{Email: "some-email@example.com", IP: "192.0.2.1"}).Return(nil).Once()
+	mocks.passwordReset.On("Create", &passwordreset.CreateCmd{UserID: user.ValidUserID, ExpiresIn: 3600}).Return(passwordreset.ValidToken, nil).Once()
+	mocks.mailer.On("Send", mock.Anything).Return(fmt.Errorf("some-error")).Once()
+	mocks.html.On("Render", "internal_error.html", "internal error").Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/forgot", url.Values{
+		"email": {"some-email@example.com"},
+	}))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ForgotPassword_with_too_many_requests(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.loginAttempt.On("Check", &loginattempt.CheckCmd{Email: "some-email@example.com", IP: "192.0.2.1"}).Return(time.Minute, nil).Once()
+	mocks.html.On("Render", "forgot_password.html", forgotPasswordTemplateParam{Error: "Too many requests. Try again later."}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/forgot", url.Values{
+		"email": {"some-email@example.com"},
+	}))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_render_the_page(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{Token: passwordreset.ValidToken}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/password/reset?token="+passwordreset.ValidToken, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_with_an_expired_token(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(nil, nil).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{Error: "This link is invalid or expired."}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/password/reset?token="+passwordreset.ValidToken, nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_with_an_invalid_token(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: "invalid-token"}).
+		Return(nil, errors.NewValidationError().AddError("token", "INVALID_FORMAT").IntoError()).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{Error: "This link is invalid or expired."}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/password/reset?token=invalid-token", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_change_the_password(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.passwordReset.On("Consume", &passwordreset.ConsumeCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.user.On("ChangePassword", &user.ChangePasswordCmd{
+		UserID:              passwordreset.ValidPasswordReset.UserID,
+		NewPassword:         "some-new-password",
+		SkipCurrentPassword: true,
+	}).Return(nil).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{Done: true}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/reset", url.Values{
+		"token":        {passwordreset.ValidToken},
+		"new_password": {"some-new-password"},
+		"confirmation": {"some-new-password"},
+	}))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_with_a_wrong_confirmation(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{
+		Token: passwordreset.ValidToken,
+		Error: "The passwords don't match.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/reset", url.Values{
+		"token":        {passwordreset.ValidToken},
+		"new_password": {"some-new-password"},
+		"confirmation": {"some-other-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_with_an_invalid_password(t *testing.T) {
+	router, mocks := newRouter()
+
+	// The token is not consumed if the password is too short.
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{
+		Token: passwordreset.ValidToken,
+		Error: "The new password must have between 8 and 256 characters and differ from the current one.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/reset", url.Values{
+		"token":        {passwordreset.ValidToken},
+		"new_password": {"short"},
+		"confirmation": {"short"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_with_the_current_password(t *testing.T) {
+	router, mocks := newRouter()
+
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.passwordReset.On("Consume", &passwordreset.ConsumeCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.user.On("ChangePassword", mock.Anything).Return(errors.NewValidationError().AddError("newPassword", "ALREADY_USED").IntoError()).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{
+		Error: "The new password must differ from the current one. Ask for a new link to try again.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/reset", url.Values{
+		"token":        {passwordreset.ValidToken},
+		"new_password": {"some-current-password"},
+		"confirmation": {"some-current-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_with_a_token_already_consumed(t *testing.T) {
+	router, mocks := newRouter()
+
+	// An other request has consumed the token after the Get.
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.passwordReset.On("Consume", &passwordreset.ConsumeCmd{Token: passwordreset.ValidToken}).Return(nil, nil).Once()
+	mocks.html.On("Render", "reset_password.html", resetPasswordTemplateParam{
+		Error: "This link is invalid or expired.",
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/reset", url.Values{
+		"token":        {passwordreset.ValidToken},
+		"new_password": {"some-new-password"},
+		"confirmation": {"some-new-password"},
+	}))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Recovery_Controller_ResetPassword_with_a_consume_error(t *testing.T) {
+	router, mocks := newRouter()
+
+	// The password is not changed if the token can't be consumed.
+	mocks.passwordReset.On("Get", &passwordreset.GetCmd{Token: passwordreset.ValidToken}).Return(&passwordreset.ValidPasswordReset, nil).Once()
+	mocks.passwordReset.On("Consume", &passwordreset.ConsumeCmd{Token: passwordreset.ValidToken}).Return(nil, fmt.Errorf("some-error")).Once()
+	mocks.html.On("Render", "internal_error.html", "internal error").Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newFormRequest("http://example.com/password/reset", url.Values{
+		"token":        {passwordreset.ValidToken},
+		"new_password": {"some-new-password"},
+		"confirmation": {"some-new-password"},
+	}))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
@@ -0,0 +1,22 @@
+package registration
+
+// Config contains the settings of the dynamic client registration.
+type Config struct {
+	// BaseURL is the URL of the server. It is used to build the management
+	// URI of the registered clients.
+	BaseURL string
+
+	// OpenRegistration allows anyone to register a client. An initial access
+	// token is required otherwise.
+	OpenRegistration bool
+
+	// InitialAccessToken is the bearer token required to register a client
+	// when the registration is not open. The registration is disabled if
+	// empty.
+	InitialAccessToken string
+}
+
+// DefaultConfig is the configuration used by the server.
+var DefaultConfig = Config{
+	BaseURL: "http://localhost:42000",
+}
@@ -0,0 +1,423 @@
+// Package registration implements the OAuth2 dynamic client registration
+// (RFC 7591) and the management of the registered clients (RFC 7592).
+package registration
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/response"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/saga/oauth2"
+	"github.com/halium-project/server/utils/permission"
+	"github.com/halium-project/server/utils/secret"
+)
+
+// registrableGrantTypes are the grant types accepted by the registration.
+//
+// The "client_credentials" and "password" grants are left to the clients
+// created by an admin: a registered client would otherwise obtain tokens
+// without the consent of any user.
+var registrableGrantTypes = map[string]bool{
+	"authorization_code":               true,
+	"implicit":                         true,
+	"refresh_token":                    true,
+	string(oauth2.DeviceCodeGrantType): true,
+}
+
+// fieldNames maps the fields of the client validation errors to the client
+// metadata names.
+var fieldNames = map[string]string{
+	"name":          "client_name",
+	"redirectURIs":  "redirect_uris",
+	"grantTypes":    "grant_types",
+	"responseTypes": "response_types",
+	"scopes":        "scope",
+}
+
+type ClientInterface interface {
+	Create(ctx context.Context, cmd *client.CreateCmd) (string, string, error)
+	Get(ctx context.Context, cmd *client.GetCmd) (*client.Client, error)
+	UpdateRegistration(ctx context.Context, cmd *client.UpdateRegistrationCmd) error
+	Delete(ctx context.Context, cmd *client.DeleteCmd) error
+}
+
+type Controller struct {
+	client ClientInterface
+	uuid   uuid.Producer
+	config Config
+}
+
+// clientMetadata contains the client fields described in the RFC 7591
+// section 2.
+type clientMetadata struct {
+	RedirectURIs            []string `json:"redirect_uris"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method"`
+	GrantTypes              []string `json:"grant_types"`
+	ResponseTypes           []string `json:"response_types"`
+	ClientName              string   `json:"client_name"`
+	Scope                   string   `json:"scope,omitempty"`
+}
+
+// clientInformation is the response of the registration and the management
+// endpoints. The secret is only returned by the registration.
+type clientInformation struct {
+	ClientID                string `json:"client_id"`
+	ClientSecret            string `json:"client_secret,omitempty"`
+	ClientSecretExpiresAt   int    `json:"client_secret_expires_at"`
+	RegistrationAccessToken string `json:"registration_access_token"`
+	RegistrationClientURI   string `json:"registration_client_uri"`
+	clientMetadata
+}
+
+func NewController(client ClientInterface, uuid uuid.Producer, config Config) *Controller {
+	return &Controller{
+		client: client,
+		uuid:   uuid,
+		config: config,
+	}
+}
+
+func (t *Controller) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc(oauth2.RegisterPath, t.Register).Methods("POST")
+	router.HandleFunc(oauth2.RegisterPath+"/{clientID}", t.Read).Methods("GET")
+	router.HandleFunc(oauth2.RegisterPath+"/{clientID}", t.Update).Methods("PUT")
+	router.HandleFunc(oauth2.RegisterPath+"/{clientID}", t.Delete).Methods("DELETE")
+}
+
+// Register creates a new client. The returned registration access token is
+// required to manage the client afterward.
+func (t *Controller) Register(w http.ResponseWriter, r *http.Request) {
+	if !t.isRegistrationAllowed(r) {
+		oauth2.WriteBearerError(w, http.StatusUnauthorized, "invalid_token", "a valid initial access token is required")
+		return
+	}
+
+	var req clientMetadata
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	public, err := isPublic(req.TokenEndpointAuthMethod)
+	if err != nil {
+		writeError(w, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	grantTypes, err := toGrantTypes(req.GrantTypes)
+	if err != nil {
+		writeError(w, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	clientID := t.uuid.New()
+	token := t.uuid.New()
+
+	registered := client.Client{
+		ID:            clientID,
+		Name:          req.ClientName,
+		RedirectURIs:  req.RedirectURIs,
+		GrantTypes:    grantTypes,
+		ResponseTypes: req.ResponseTypes,
+		Scopes:        strings.Fields(req.Scope),
+		Public:        public,
+	}
+	setDefaults(&registered)
+
+	_, clientSecret, err := t.client.Create(r.Context(), &client.CreateCmd{
+		ID:                registered.ID,
+		Name:              registered.Name,
+		RedirectURIs:      registered.RedirectURIs,
+		GrantTypes:        registered.GrantTypes,
+		ResponseTypes:     registered.ResponseTypes,
+		Scopes:            registered.Scopes,
+		Public:            registered.Public,
+		RegistrationToken: secret.Hash(token),
+	})
+	if err != nil {
+		writeClientError(w, err, "failed to create the client")
+		return
+	}
+
+	res := t.newClientInformation(&registered, token)
+	res.ClientSecret = clientSecret
+
+	response.Write(w, http.StatusCreated, res)
+}
+
+// Read returns the metadata of a registered client.
+func (t *Controller) Read(w http.ResponseWriter, r *http.Request) {
+	registered, token, ok := t.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	response.Write(w, http.StatusOK, t.newClientInformation(registered, token))
+}
+
+// Update replaces the metadata of a registered client. The kind of client
+// can't be changed: a public client never receives a secret.
+func (t *Controller) Update(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		ClientID string `json:"client_id"`
+		clientMetadata
+	}
+
+	registered, token, ok := t.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	var req request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	if req.ClientID != registered.ID {
+		writeError(w, "invalid_client_metadata", "the client_id doesn't match the client")
+		return
+	}
+
+	if req.TokenEndpointAuthMethod != "" {
+		public, err := isPublic(req.TokenEndpointAuthMethod)
+		if err != nil {
+			writeError(w, "invalid_client_metadata", err.Error())
+			return
+		}
+
+		if public != registered.Public {
+			writeError(w, "invalid_client_metadata", "the token_endpoint_auth_method can't be changed")
+			return
+		}
+	}
+
+	grantTypes, err := toGrantTypes(req.GrantTypes)
+	if err != nil {
+		writeError(w, "invalid_client_metadata", err.Error())
+		return
+	}
+
+	updated := *registered
+	updated.Name = req.ClientName
+	updated.RedirectURIs = req.RedirectURIs
+	updated.GrantTypes = grantTypes
+	updated.ResponseTypes = req.ResponseTypes
+	updated.Scopes = strings.Fields(req.Scope)
+	setDefaults(&updated)
+
+	err = t.client.UpdateRegistration(r.Context(), &client.UpdateRegistrationCmd{
+		ClientID:       updated.ID,
+		Name:           updated.Name,
+		RedirectURIs:   updated.RedirectURIs,
+		GrantTypes:     updated.GrantTypes,
+		ResponseTypes:  updated.ResponseTypes,
+		Scopes:         updated.Scopes,
+		AllowPlainPKCE: updated.AllowPlainPKCE,
+	})
+	if err != nil {
+		writeClientError(w, err, "failed to update the client")
+		return
+	}
+
+	response.Write(w, http.StatusOK, t.newClientInformation(&updated, token))
+}
+
+// Delete removes a registered client.
+func (t *Controller) Delete(w http.ResponseWriter, r *http.Request) {
+	registered, _, ok := t.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	err := t.client.Delete(r.Context(), &client.DeleteCmd{
+		ClientID: registered.ID,
+	})
+	if err != nil {
+		errors.WriteError(w, errors.Wrap(err, "failed to delete the client"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (t *Controller) isRegistrationAllowed(r *http.Request) bool {
+	if t.config.OpenRegistration {
+		return true
+	}
+
+	if t.config.InitialAccessToken == "" {
+		return false
+	}
+
+	token, parseErr := permission.RetrieveTokenFromRequest(r)
+	if parseErr != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(t.config.InitialAccessToken)) == 1
+}
+
+// authenticate returns the client matching the registration access token of
+// the request. An unknown client is reported as an invalid token in order to
+// not disclose the client IDs.
+func (t *Controller) authenticate(w http.ResponseWriter, r *http.Request) (*client.Client, string, bool) {
+	token, parseErr := permission.RetrieveTokenFromRequest(r)
+	if parseErr != nil {
+		oauth2.WriteBearerError(w, http.StatusUnauthorized, "invalid_token", parseErr.Message)
+		return nil, "", false
+	}
+
+	clientID := mux.Vars(r)["clientID"]
+
+	registered, err := t.client.Get(r.Context(), &client.GetCmd{
+		ClientID: clientID,
+	})
+	if err != nil && !errors.IsKind(err, errors.Validation) {
+		errors.WriteError(w, errors.Wrapf(err, "failed to retrieve the client %q", clientID))
+		return nil, "", false
+	}
+
+	if registered == nil || registered.RegistrationToken == "" ||
+		subtle.ConstantTimeCompare([]byte(registered.RegistrationToken), []byte(secret.Hash(token))) != 1 {
+		oauth2.WriteBearerError(w, http.StatusUnauthorized, "invalid_token", "")
+		return nil, "", false
+	}
+
+	return registered, token, true
+}
+
+func (t *Controller) newClientInformation(registered *client.Client, token string) *clientInformation {
+	authMethod := "client_secret_basic"
+	if registered.Public {
+		authMethod = "none"
+	}
+
+	grantTypes := make([]string, 0, len(registered.GrantTypes))
+	for _, grantType := range registered.GrantTypes {
+		grantTypes = append(grantTypes, fromGrantType(grantType))
+	}
+
+	return &clientInformation{
+		ClientID:                registered.ID,
+		RegistrationAccessToken: token,
+		RegistrationClientURI:   t.config.BaseURL + oauth2.RegisterPath + "/" + registered.ID,
+		clientMetadata: clientMetadata{
+			RedirectURIs:            registered.RedirectURIs,
+			TokenEndpointAuthMethod: authMethod,
+			GrantTypes:              grantTypes,
+			ResponseTypes:           registered.ResponseTypes,
+			ClientName:              registered.Name,
+			Scope:                   strings.Join(registered.Scopes, " "),
+		},
+	}
+}
+
+// writeClientError reports the validation errors of the client controller as
+// invalid metadata (RFC 7591 section 3.2.2).
+func writeClientError(w http.ResponseWriter, err error, msg string) {
+	validationErr, ok := err.(*errors.Error)
+	if !ok || validationErr.Kind != errors.Validation {
+		errors.WriteError(w, errors.Wrap(err, msg))
+		return
+	}
+
+	code := "invalid_client_metadata"
+	descriptions := make([]string, 0, len(validationErr.Errors))
+
+	for field, fieldErr := range validationErr.Errors {
+		name := field
+		if idx := strings.Index(field, "["); idx >= 0 {
+			name = field[:idx]
+		}
+
+		if name == "redirectURIs" {
+			code = "invalid_redirect_uri"
+		}
+
+		if metadataName, ok := fieldNames[name]; ok {
+			field = metadataName + field[len(name):]
+		}
+
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", field, fieldErr))
+	}
+
+	sort.Strings(descriptions)
+
+	writeError(w, code, strings.Join(descriptions, ", "))
+}
+
+// isPublic returns true if the client doesn't authenticate on the token
+// endpoint. The confidential clients use the "client_secret_basic" method by
+// default.
+func isPublic(authMethod string) (bool, error) {
+	switch authMethod {
+	case "", "client_secret_basic", "client_secret_post":
+		return false, nil
+	case "none":
+		return true, nil
+	default:
+		return false, fmt.Errorf("the token_endpoint_auth_method %q is not supported", authMethod)
+	}
+}
+
+func toGrantTypes(grantTypes []string) ([]string, error) {
+	if len(grantTypes) == 0 {
+		grantTypes = []string{"authorization_code"}
+	}
+
+	res := make([]string, 0, len(grantTypes))
+	for _, grantType := range grantTypes {
+		internal, ok := client.GrantTypeFromName(grantType)
+		if !ok || !registrableGrantTypes[grantType] {
+			return nil, fmt.Errorf("the grant type %q can't be registered", grantType)
+		}
+
+		res = append(res, internal)
+	}
+
+	return res, nil
+}
+
+func fromGrantType(grantType string) string {
+	name, ok := client.GrantTypeNames[grantType]
+	if !ok {
+		return grantType
+	}
+
+	return name
+}
+
+// setDefaults fills the metadata omitted by the client with the default
+// values of the RFC 7591 section 2. The client ID is used as name.
+func setDefaults(registered *client.Client) {
+	if registered.Name == "" {
+		registered.Name = registered.ID
+	}
+
+	if len(registered.ResponseTypes) == 0 {
+		registered.ResponseTypes = []string{"code"}
+	}
+}
+
+// writeError writes an error response as described in the RFC 7591 section
+// 3.2.2.
+func writeError(w http.ResponseWriter, code string, description string) {
+	body := map[string]string{"error": code}
+	if description != "" {
+		body["error_description"] = description
+	}
+
+	response.Write(w, http.StatusBadRequest, body)
+}
@@ -0,0 +1,520 @@
+package registration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/go-server-utils/uuid"
+	"github.com/halium-project/server/resource/client"
+	"github.com/halium-project/server/utils/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	validClientID          = "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d"
+	validRegistrationToken = "8f3a1c5e-2b7d-4e9f-a6c0-d4b8e2f1a3c5"
+	validInitialToken      = "some-initial-access-token"
+)
+
+type registrationMocks struct {
+	client *client.ControllerMock
+	uuid   *uuid.ProducerMock
+}
+
+func (t *registrationMocks) AssertExpectations(test *testing.T) {
+	t.client.AssertExpectations(test)
+	t.uuid.AssertExpectations(test)
+}
+
+func newRouter(config Config) (*mux.Router, *registrationMocks) {
+	mocks := &registrationMocks{
+		client: new(client.ControllerMock),
+		uuid:   new(uuid.ProducerMock),
+	}
+
+	router := mux.NewRouter()
+	controller := NewController(mocks.client, mocks.uuid, config)
+	controller.RegisterRoutes(router)
+
+	return router, mocks
+}
+
+func newConfig() Config {
+	config := DefaultConfig
+	config.InitialAccessToken = validInitialToken
+
+	return config
+}
+
+func newRegisteredClient() *client.Client {
+	return &client.Client{
+		ID:                validClientID,
+		Name:              "My CLI",
+		Secret:            "some-hashed-secret",
+		RedirectURIs:      []string{"http://localhost:8080/callback"},
+		GrantTypes:        []string{"authorize_code", "refresh_token"},
+		ResponseTypes:     []string{"code"},
+		Scopes:            []string{"contacts", "todos"},
+		RegistrationToken: secret.Hash(validRegistrationToken),
+	}
+}
+
+func newRequest(method string, target string, token string, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return r
+}
+
+func Test_Registration_Controller_Register(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.uuid.On("New").Return(validClientID).Once()
+	mocks.uuid.On("New").Return(validRegistrationToken).Once()
+	mocks.client.On("Create", &client.CreateCmd{
+		ID:                validClientID,
+		Name:              "My CLI",
+		RedirectURIs:      []string{"http://localhost:8080/callback"},
+		GrantTypes:        []string{"authorize_code", "refresh_token"},
+		ResponseTypes:     []string{"code"},
+		Scopes:            []string{"contacts", "todos"},
+		RegistrationToken: secret.Hash(validRegistrationToken),
+	}).Return(validClientID, "some-secret", nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", validInitialToken, `{
+		"client_name": "My CLI",
+		"redirect_uris": ["http://localhost:8080/callback"],
+		"grant_types": ["authorization_code", "refresh_token"],
+		"scope": "contacts todos"
+	}`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{
+		"client_id": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_secret": "some-secret",
+		"client_secret_expires_at": 0,
+		"registration_access_token": "8f3a1c5e-2b7d-4e9f-a6c0-d4b8e2f1a3c5",
+		"registration_client_uri": "http://localhost:42000/oauth2/register/0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_name": "My CLI",
+		"redirect_uris": ["http://localhost:8080/callback"],
+		"token_endpoint_auth_method": "client_secret_basic",
+		"grant_types": ["authorization_code", "refresh_token"],
+		"response_types": ["code"],
+		"scope": "contacts todos"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_a_public_client_with_the_defaults(t *testing.T) {
+	config := DefaultConfig
+	config.OpenRegistration = true
+	router, mocks := newRouter(config)
+
+	mocks.uuid.On("New").Return(validClientID).Once()
+	mocks.uuid.On("New").Return(validRegistrationToken).Once()
+	mocks.client.On("Create", &client.CreateCmd{
+		ID:                validClientID,
+		Name:              validClientID,
+		RedirectURIs:      []string{"http://localhost:8080/callback"},
+		GrantTypes:        []string{"authorize_code"},
+		ResponseTypes:     []string{"code"},
+		Scopes:            []string{},
+		Public:            true,
+		RegistrationToken: secret.Hash(validRegistrationToken),
+	}).Return(validClientID, "", nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", "", `{
+		"redirect_uris": ["http://localhost:8080/callback"],
+		"token_endpoint_auth_method": "none"
+	}`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{
+		"client_id": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_secret_expires_at": 0,
+		"registration_access_token": "8f3a1c5e-2b7d-4e9f-a6c0-d4b8e2f1a3c5",
+		"registration_client_uri": "http://localhost:42000/oauth2/register/0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_name": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"redirect_uris": ["http://localhost:8080/callback"],
+		"token_endpoint_auth_method": "none",
+		"grant_types": ["authorization_code"],
+		"response_types": ["code"]
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_without_initial_access_token(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", "", `{}`))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token", error_description="a valid initial access token is required"`, w.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{
+		"error": "invalid_token",
+		"error_description": "a valid initial access token is required"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_with_an_invalid_initial_access_token(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", "some-invalid-token", `{}`))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_disabled(t *testing.T) {
+	router, mocks := newRouter(DefaultConfig)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", "", `{}`))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_with_a_grant_type_not_registrable(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", validInitialToken, `{
+		"redirect_uris": ["http://localhost:8080/callback"],
+		"grant_types": ["client_credentials"]
+	}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_client_metadata",
+		"error_description": "the grant type \"client_credentials\" can't be registered"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_with_an_unsupported_auth_method(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", validInitialToken, `{
+		"token_endpoint_auth_method": "private_key_jwt"
+	}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_client_metadata",
+		"error_description": "the token_endpoint_auth_method \"private_key_jwt\" is not supported"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_with_an_invalid_json(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", validInitialToken, `not a json`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_client_metadata"`)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_with_an_invalid_redirect_uri(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.uuid.On("New").Return(validClientID).Once()
+	mocks.uuid.On("New").Return(validRegistrationToken).Once()
+	mocks.client.On("Create", &client.CreateCmd{
+		ID:                validClientID,
+		Name:              "My CLI",
+		RedirectURIs:      []string{"not an url"},
+		GrantTypes:        []string{"authorize_code"},
+		ResponseTypes:     []string{"code"},
+		Scopes:            []string{},
+		RegistrationToken: secret.Hash(validRegistrationToken),
+	}).Return("", "", errors.NewValidationError().AddError("redirectURIs[0]", "INVALID_FORMAT").AddError("name", "ALREADY_USED").IntoError()).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", validInitialToken, `{
+		"client_name": "My CLI",
+		"redirect_uris": ["not an url"]
+	}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_redirect_uri",
+		"error_description": "client_name: ALREADY_USED, redirect_uris[0]: INVALID_FORMAT"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Register_with_a_client_error(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.uuid.On("New").Return(validClientID).Once()
+	mocks.uuid.On("New").Return(validRegistrationToken).Once()
+	mocks.client.On("Create", &client.CreateCmd{
+		ID:                validClientID,
+		Name:              validClientID,
+		GrantTypes:        []string{"authorize_code"},
+		ResponseTypes:     []string{"code"},
+		Scopes:            []string{},
+		RegistrationToken: secret.Hash(validRegistrationToken),
+	}).Return("", "", fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("POST", "http://example.com/oauth2/register", validInitialToken, `{}`))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{
+		"kind": "internalError",
+		"message": "failed to create the client",
+		"reason": {
+			"kind": "internalError",
+			"message": "some-error"
+		}
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Read(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("GET", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, ""))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"client_id": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_secret_expires_at": 0,
+		"registration_access_token": "8f3a1c5e-2b7d-4e9f-a6c0-d4b8e2f1a3c5",
+		"registration_client_uri": "http://localhost:42000/oauth2/register/0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_name": "My CLI",
+		"redirect_uris": ["http://localhost:8080/callback"],
+		"token_endpoint_auth_method": "client_secret_basic",
+		"grant_types": ["authorization_code", "refresh_token"],
+		"response_types": ["code"],
+		"scope": "contacts todos"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Read_with_an_invalid_token(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("GET", "http://example.com/oauth2/register/"+validClientID, "some-invalid-token", ""))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"error": "invalid_token"}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Read_a_client_created_by_an_admin(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	adminClient := newRegisteredClient()
+	adminClient.RegistrationToken = ""
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(adminClient, nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("GET", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, ""))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Read_an_unknown_client(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(nil, nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("GET", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, ""))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"error": "invalid_token"}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Read_with_a_client_error(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("GET", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, ""))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Update(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+	mocks.client.On("UpdateRegistration", &client.UpdateRegistrationCmd{
+		ClientID:      validClientID,
+		Name:          "My New CLI",
+		RedirectURIs:  []string{"http://localhost:9090/callback"},
+		GrantTypes:    []string{"authorize_code", "device_code"},
+		ResponseTypes: []string{"code"},
+		Scopes:        []string{"contacts"},
+	}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("PUT", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, `{
+		"client_id": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_name": "My New CLI",
+		"redirect_uris": ["http://localhost:9090/callback"],
+		"grant_types": ["authorization_code", "urn:ietf:params:oauth:grant-type:device_code"],
+		"token_endpoint_auth_method": "client_secret_post",
+		"scope": "contacts"
+	}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{
+		"client_id": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_secret_expires_at": 0,
+		"registration_access_token": "8f3a1c5e-2b7d-4e9f-a6c0-d4b8e2f1a3c5",
+		"registration_client_uri": "http://localhost:42000/oauth2/register/0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_name": "My New CLI",
+		"redirect_uris": ["http://localhost:9090/callback"],
+		"token_endpoint_auth_method": "client_secret_basic",
+		"grant_types": ["authorization_code", "urn:ietf:params:oauth:grant-type:device_code"],
+		"response_types": ["code"],
+		"scope": "contacts"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Update_with_another_client_id(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("PUT", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, `{
+		"client_id": "another-client"
+	}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_client_metadata",
+		"error_description": "the client_id doesn't match the client"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Update_to_a_public_client(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("PUT", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, `{
+		"client_id": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"token_endpoint_auth_method": "none"
+	}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_client_metadata",
+		"error_description": "the token_endpoint_auth_method can't be changed"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Update_with_a_validation_error(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+	mocks.client.On("UpdateRegistration", &client.UpdateRegistrationCmd{
+		ClientID:      validClientID,
+		Name:          "My CLI",
+		GrantTypes:    []string{"authorize_code"},
+		ResponseTypes: []string{"code"},
+		Scopes:        []string{"a"},
+	}).Return(errors.NewValidationError().AddError("scopes[0]", "TOO_SHORT").IntoError()).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("PUT", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, `{
+		"client_id": "0d2c8e4f-6b1a-4f3e-9c7d-5a8b2e1f4c6d",
+		"client_name": "My CLI",
+		"scope": "a"
+	}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"error": "invalid_client_metadata",
+		"error_description": "scope[0]: TOO_SHORT"
+	}`, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Delete(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+	mocks.client.On("Delete", &client.DeleteCmd{ClientID: validClientID}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("DELETE", "http://example.com/oauth2/register/"+validClientID, validRegistrationToken, ""))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	mocks.AssertExpectations(t)
+}
+
+func Test_Registration_Controller_Delete_with_an_invalid_token(t *testing.T) {
+	router, mocks := newRouter(newConfig())
+
+	mocks.client.On("Get", &client.GetCmd{ClientID: validClientID}).Return(newRegisteredClient(), nil).Once()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("DELETE", "http://example.com/oauth2/register/"+validClientID, "some-invalid-token", ""))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	mocks.AssertExpectations(t)
+}
@@ -0,0 +1,22 @@
+package clock
+
+import "time"
+
+// Clock gives the current time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// Default is an implementation of `Clock` based on the system time.
+type Default struct{}
+
+// NewDefault instantiate a new Default clock.
+func NewDefault() *Default {
+	return &Default{}
+}
+
+// Now is an implementation of the `Clock` interface.
+func (t *Default) Now() time.Time {
+	return time.Now()
+}
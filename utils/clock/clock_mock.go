@@ -0,0 +1,17 @@
+package clock
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// ClockMock is a mock implementation of the `Clock` interface.
+type ClockMock struct {
+	mock.Mock
+}
+
+// Now is an implementation of the `Clock` interface.
+func (t *ClockMock) Now() time.Time {
+	return t.Called().Get(0).(time.Time)
+}
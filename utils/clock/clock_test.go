@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Default_impl_Clock(t *testing.T) {
+	assert.Implements(t, (*Clock)(nil), new(Default))
+}
+
+func Test_Default_Now(t *testing.T) {
+	clock := NewDefault()
+
+	assert.WithinDuration(t, time.Now(), clock.Now(), time.Second)
+}
+
+func Test_ClockMock_impl_Clock(t *testing.T) {
+	assert.Implements(t, (*Clock)(nil), new(ClockMock))
+}
+
+func Test_ClockMock_Now(t *testing.T) {
+	mock := new(ClockMock)
+
+	now := time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC)
+	mock.On("Now").Return(now).Once()
+
+	assert.Equal(t, now, mock.Now())
+
+	mock.AssertExpectations(t)
+}
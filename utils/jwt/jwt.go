@@ -0,0 +1,165 @@
+// Package jwt signs and verifies the JSON Web Tokens (RFC 7519) delivered by
+// the server.
+//
+// Only the RS256 algorithm is supported.
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/halium-project/go-server-utils/errors"
+)
+
+// RS256 is the "alg" header value of the tokens.
+const RS256 = "RS256"
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// JWK is the public part of a signing key as described in the RFC 7517.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+// NewJWK returns the JWK of the given public key.
+func NewJWK(keyID string, key *rsa.PublicKey) JWK {
+	return JWK{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: RS256,
+		KeyID:     keyID,
+		Modulus:   encodeSegment(key.N.Bytes()),
+		Exponent:  encodeSegment(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// Sign returns the claims signed with the given key. The key ID is set in the
+// "kid" header in order to find the key to use for the verification.
+func Sign(key *rsa.PrivateKey, keyID string, claims interface{}) (string, error) {
+	rawHeader, err := json.Marshal(&header{
+		Algorithm: RS256,
+		Type:      "JWT",
+		KeyID:     keyID,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode the header")
+	}
+
+	rawClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode the claims")
+	}
+
+	payload := encodeSegment(rawHeader) + "." + encodeSegment(rawClaims)
+
+	hash := sha256.Sum256([]byte(payload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign the token")
+	}
+
+	return payload + "." + encodeSegment(signature), nil
+}
+
+// KeyFunc returns the public key matching the "kid" header of a token. It
+// returns nil if the key is unknown.
+type KeyFunc func(keyID string) *rsa.PublicKey
+
+var (
+	// ErrMalformed is returned if the token isn't a valid JWT.
+	ErrMalformed = errors.New(errors.BadRequest, "malformed token")
+
+	// ErrUnknownKey is returned if the token is signed with an unknown key.
+	ErrUnknownKey = errors.New(errors.NotAuthorized, "unknown signing key")
+
+	// ErrInvalidSignature is returned if the signature doesn't match.
+	ErrInvalidSignature = errors.New(errors.NotAuthorized, "invalid signature")
+)
+
+// IsJWT returns true if the token has the form of a JWT. It doesn't check
+// anything else.
+func IsJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify checks the signature of the token with the key returned by keys then
+// decodes its claims. The claims validity (expiration, audience...) is left
+// to the caller.
+func Verify(token string, keys KeyFunc, claims interface{}) error {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return ErrMalformed
+	}
+
+	var head header
+	err := decodeSegment(segments[0], &head)
+	if err != nil {
+		return err
+	}
+
+	if head.Algorithm != RS256 {
+		return ErrMalformed
+	}
+
+	key := keys(head.KeyID)
+	if key == nil {
+		return ErrUnknownKey
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return ErrMalformed
+	}
+
+	hash := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	return decodeSegment(segments[1], claims)
+}
+
+// Decode decodes the claims of the token WITHOUT verifying its signature. It
+// must only be used with the tokens coming from a trusted source.
+func Decode(token string, claims interface{}) error {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return ErrMalformed
+	}
+
+	return decodeSegment(segments[1], claims)
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	err = json.Unmarshal(raw, value)
+	if err != nil {
+		return ErrMalformed
+	}
+
+	return nil
+}
+
+func encodeSegment(raw []byte) string {
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
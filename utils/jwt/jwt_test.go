@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JWT_Sign(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := Sign(key, "some-key-id", map[string]interface{}{
+		"sub": "some-user-id",
+	})
+	require.NoError(t, err)
+
+	segments := strings.Split(token, ".")
+	require.Len(t, segments, 3)
+
+	header, err := base64.RawURLEncoding.DecodeString(segments[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"alg":"RS256","typ":"JWT","kid":"some-key-id"}`, string(header))
+
+	claims, err := base64.RawURLEncoding.DecodeString(segments[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sub":"some-user-id"}`, string(claims))
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	require.NoError(t, err)
+
+	hash := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature))
+}
+
+func Test_JWT_Sign_with_invalid_claims(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := Sign(key, "some-key-id", make(chan int))
+
+	assert.Empty(t, token)
+	assert.Error(t, err)
+}
+
+func Test_JWT_NewJWK(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	res := NewJWK("some-key-id", &key.PublicKey)
+
+	assert.Equal(t, "RSA", res.KeyType)
+	assert.Equal(t, "sig", res.Use)
+	assert.Equal(t, RS256, res.Algorithm)
+	assert.Equal(t, "some-key-id", res.KeyID)
+	assert.Equal(t, "AQAB", res.Exponent)
+
+	modulus, err := base64.RawURLEncoding.DecodeString(res.Modulus)
+	require.NoError(t, err)
+	assert.Equal(t, key.N, new(big.Int).SetBytes(modulus))
+}
+
+func Test_JWT_Verify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := Sign(key, "some-key-id", map[string]interface{}{
+		"sub": "some-user-id",
+	})
+	require.NoError(t, err)
+
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	err = Verify(token, func(keyID string) *rsa.PublicKey {
+		assert.Equal(t, "some-key-id", keyID)
+		return &key.PublicKey
+	}, &claims)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-user-id", claims.Subject)
+}
+
+func Test_JWT_Verify_with_an_unknown_key(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := Sign(key, "some-key-id", map[string]interface{}{})
+	require.NoError(t, err)
+
+	err = Verify(token, func(keyID string) *rsa.PublicKey { return nil }, &struct{}{})
+
+	assert.Equal(t, ErrUnknownKey, err)
+}
+
+func Test_JWT_Verify_with_another_key(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := Sign(key, "some-key-id", map[string]interface{}{})
+	require.NoError(t, err)
+
+	err = Verify(token, func(keyID string) *rsa.PublicKey { return &otherKey.PublicKey }, &struct{}{})
+
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func Test_JWT_Verify_with_modified_claims(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := Sign(key, "some-key-id", map[string]interface{}{"scope": "todos"})
+	require.NoError(t, err)
+
+	segments := strings.Split(token, ".")
+	segments[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"scope":"*"}`))
+
+	err = Verify(strings.Join(segments, "."), func(keyID string) *rsa.PublicKey { return &key.PublicKey }, &struct{}{})
+
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func Test_JWT_Verify_with_a_malformed_token(t *testing.T) {
+	for _, token := range []string{"", "foo.bar", "foo.bar.baz", "e30.e30.%%%"} {
+		err := Verify(token, func(keyID string) *rsa.PublicKey { return nil }, &struct{}{})
+
+		assert.Equal(t, ErrMalformed, err, token)
+	}
+}
+
+func Test_JWT_Decode(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token, err := Sign(key, "some-key-id", map[string]interface{}{"jti": "some-token-id"})
+	require.NoError(t, err)
+
+	var claims struct {
+		TokenID string `json:"jti"`
+	}
+	err = Decode(token, &claims)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-token-id", claims.TokenID)
+}
+
+func Test_JWT_IsJWT(t *testing.T) {
+	assert.True(t, IsJWT("foo.bar.baz"))
+	assert.False(t, IsJWT("some-access-token"))
+}
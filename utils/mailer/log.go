@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/halium-project/go-server-utils/errors"
+)
+
+// Log writes the emails into a file or a log output instead of sending them.
+// It is used for the development and the tests.
+type Log struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewLog returns a Mailer writing the emails into w.
+func NewLog(w io.Writer) *Log {
+	return &Log{
+		w: w,
+	}
+}
+
+// Send is an implementation of the Mailer interface.
+func (t *Log) Send(ctx context.Context, msg *Message) error {
+	err := checkHeaders(msg.To, msg.Subject)
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	_, err = fmt.Fprintf(t.w, "To: %s\nSubject: %s\n\n%s\n\n", msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to write the email")
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+// Package mailer delivers the emails sent by the server, like the password
+// reset links.
+package mailer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/halium-project/go-server-utils/errors"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers the emails.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// checkHeaders refuses the line breaks in the header values in order to
+// avoid the injection of new headers.
+func checkHeaders(values ...string) error {
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New(errors.BadRequest, "the email headers must not contain line breaks")
+		}
+	}
+
+	return nil
+}
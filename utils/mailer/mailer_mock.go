@@ -0,0 +1,17 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MailerMock is a mock implementation of the Mailer interface.
+type MailerMock struct {
+	mock.Mock
+}
+
+// Send is an implementation of the Mailer interface.
+func (t *MailerMock) Send(ctx context.Context, msg *Message) error {
+	return t.Called(msg).Error(0)
+}
@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var validMessage = Message{
+	To:      "some-email@example.com",
+	Subject: "Some subject",
+	Body:    "Some body\nwith two lines.",
+}
+
+func Test_SMTP_impl_Mailer(t *testing.T) {
+	assert.Implements(t, (*Mailer)(nil), new(SMTP))
+}
+
+func Test_Log_impl_Mailer(t *testing.T) {
+	assert.Implements(t, (*Mailer)(nil), new(Log))
+}
+
+func Test_MailerMock_impl_Mailer(t *testing.T) {
+	assert.Implements(t, (*Mailer)(nil), new(MailerMock))
+}
+
+func Test_SMTP_Send(t *testing.T) {
+	mailer := NewSMTP("smtp.example.com:587", "halium@example.com", "some-username", "some-password")
+
+	var sentTo []string
+	var sentMsg []byte
+	mailer.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.NotNil(t, auth)
+		assert.Equal(t, "halium@example.com", from)
+
+		sentTo = to
+		sentMsg = msg
+		return nil
+	}
+
+	err := mailer.Send(context.Background(), &validMessage)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"some-email@example.com"}, sentTo)
+	assert.Contains(t, string(sentMsg), "To: some-email@example.com\r\n")
+	assert.Contains(t, string(sentMsg), "\r\n\r\nSome body\r\nwith two lines.")
+}
+
+func Test_SMTP_Send_without_authentication(t *testing.T) {
+	mailer := NewSMTP("localhost:25", "halium@example.com", "", "")
+
+	assert.Nil(t, mailer.auth)
+}
+
+func Test_SMTP_Send_with_an_error(t *testing.T) {
+	mailer := NewSMTP("localhost:25", "halium@example.com", "", "")
+	mailer.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		return fmt.Errorf("some-error")
+	}
+
+	err := mailer.Send(context.Background(), &validMessage)
+
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to send the email to \"some-email@example.com\"",
+		"reason":{
+			"kind":"internalError",
+			"message":"some-error"
+		}
+	}`, err.Error())
+}
+
+func Test_SMTP_Send_with_a_header_injection(t *testing.T) {
+	mailer := NewSMTP("localhost:25", "halium@example.com", "", "")
+	mailer.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		t.Fatal("the email must not be sent")
+		return nil
+	}
+
+	msg := validMessage
+	msg.Subject = "Some subject\r\nBcc: someone@example.com"
+
+	err := mailer.Send(context.Background(), &msg)
+
+	assert.JSONEq(t, `{
+		"kind":"badRequest",
+		"message":"the email headers must not contain line breaks"
+	}`, err.Error())
+}
+
+func Test_SMTP_format(t *testing.T) {
+	mailer := NewSMTP("localhost:25", "halium@example.com", "", "")
+
+	res := mailer.format(&validMessage, time.Date(2019, time.February, 23, 10, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, "From: halium@example.com\r\n"+
+		"To: some-email@example.com\r\n"+
+		"Subject: Some subject\r\n"+
+		"Date: Sat, 23 Feb 2019 10:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"Some body\r\nwith two lines.", string(res))
+}
+
+func Test_Log_Send(t *testing.T) {
+	var buf bytes.Buffer
+	mailer := NewLog(&buf)
+
+	err := mailer.Send(context.Background(), &validMessage)
+
+	require.NoError(t, err)
+	assert.Equal(t, "To: some-email@example.com\nSubject: Some subject\n\nSome body\nwith two lines.\n\n", buf.String())
+}
@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+)
+
+// SMTP sends the emails through an SMTP server.
+type SMTP struct {
+	addr     string
+	from     string
+	auth     smtp.Auth
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTP returns a Mailer using the SMTP server at addr ("host:port"). The
+// authentication is skipped if the username is empty.
+func NewSMTP(addr string, from string, username string, password string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTP{
+		addr:     addr,
+		from:     from,
+		auth:     auth,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Send is an implementation of the Mailer interface.
+func (t *SMTP) Send(ctx context.Context, msg *Message) error {
+	err := checkHeaders(msg.To, msg.Subject)
+	if err != nil {
+		return err
+	}
+
+	err = t.sendMail(t.addr, t.auth, t.from, []string{msg.To}, t.format(msg, time.Now()))
+	if err != nil {
+		return errors.Wrapf(err, "failed to send the email to %q", msg.To)
+	}
+
+	return nil
+}
+
+// format returns the message as described in the RFC 5322.
+func (t *SMTP) format(msg *Message, now time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", t.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.Replace(msg.Body, "\n", "\r\n", -1))
+
+	return buf.Bytes()
+}
@@ -4,22 +4,58 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/halium-project/go-server-utils/errors"
 	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/utils/jwt"
 )
 
+type contextKey int
+
+const userKey contextKey = iota
+
+// PersonalTokenPrefix starts all the personal access tokens. It allows to
+// route them without any storage lookup.
+const PersonalTokenPrefix = "hpat_"
+
+// User is the resource owner bound to the access token of the request.
+type User struct {
+	ID   string
+	Role string
+
+	// Scopes granted to the access token of the request.
+	Scopes []string
+
+	// PersonalToken is true if the request is authenticated with a personal
+	// access token.
+	PersonalToken bool
+}
+
 type AccessTokenGetter interface {
 	Get(ctx context.Context, cmd *accesstoken.GetCmd) (*accesstoken.AccessToken, error)
 }
 
+// TokenVerifier checks the JWT access tokens without any storage lookup (see
+// Verifier).
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*accesstoken.AccessToken, error)
+}
+
 type Controller struct {
-	accessToken AccessTokenGetter
+	accessToken   AccessTokenGetter
+	verifier      TokenVerifier
+	personalToken TokenVerifier
 }
 
-func NewController(ctx context.Context, accessToken AccessTokenGetter) *Controller {
+// NewController returns a Controller. The verifier is optional: all the tokens
+// are retrieved from the storage if nil. The personalToken verifier is
+// optional too: the personal access tokens are rejected if nil.
+func NewController(ctx context.Context, accessToken AccessTokenGetter, verifier TokenVerifier, personalToken TokenVerifier) *Controller {
 	return &Controller{
-		accessToken: accessToken,
+		accessToken:   accessToken,
+		verifier:      verifier,
+		personalToken: personalToken,
 	}
 }
 
@@ -31,10 +67,7 @@ func (t *Controller) Check(permission string, handler http.HandlerFunc) http.Han
 			return
 		}
 
-		session, err := t.accessToken.Get(r.Context(), &accesstoken.GetCmd{
-			AccessToken: token,
-		})
-
+		session, err := t.Resolve(r.Context(), token)
 		if err != nil {
 			errors.WriteError(w, errors.Wrapf(err, "failed to retrieve session %q", token))
 			return
@@ -45,24 +78,67 @@ func (t *Controller) Check(permission string, handler http.HandlerFunc) http.Han
 			return
 		}
 
-		var isAuthorized bool
-		for _, scope := range session.Scopes {
-			if strings.HasPrefix(permission, scope) {
-				isAuthorized = true
-				break
-			}
+		if session.IsExpiredAt(time.Now()) {
+			errors.WriteError(w, errors.New(errors.NotAuthorized, "access token expired"))
+			return
 		}
 
-		if !isAuthorized {
+		if !HasScope(session.Scopes, permission) {
 			errors.WriteError(w, errors.New(errors.NotAuthorized, "doesn't have required permission"))
 			return
 		}
 
+		if session.UserID != "" {
+			r = r.WithContext(context.WithValue(r.Context(), userKey, &User{
+				ID:            session.UserID,
+				Role:          session.Role,
+				Scopes:        session.Scopes,
+				PersonalToken: strings.HasPrefix(token, PersonalTokenPrefix),
+			}))
+		}
+
 		handler(w, r)
 
 	}
 }
 
+// Resolve returns the access token matching a bearer token. The personal
+// tokens and the JWT access tokens are checked by their verifiers, the other
+// tokens are retrieved from the storage.
+//
+// It returns nil if the token is unknown. The expiration is left to the
+// caller.
+func (t *Controller) Resolve(ctx context.Context, token string) (*accesstoken.AccessToken, error) {
+	switch {
+	case strings.HasPrefix(token, PersonalTokenPrefix):
+		if t.personalToken == nil {
+			return nil, nil
+		}
+
+		return t.personalToken.Verify(ctx, token)
+	case t.verifier != nil && jwt.IsJWT(token):
+		return t.verifier.Verify(ctx, token)
+	default:
+		return t.accessToken.Get(ctx, &accesstoken.GetCmd{
+			AccessToken: token,
+		})
+	}
+}
+
+// UserFromContext returns the user bound to the access token used for the
+// request.
+//
+// It returns nil if the token has been delivered to a client without any
+// user (client_credentials grant).
+func UserFromContext(ctx context.Context) *User {
+	user, ok := ctx.Value(userKey).(*User)
+	if !ok {
+		return nil
+	}
+
+	return user
+}
+
 func RetrieveTokenFromRequest(r *http.Request) (string, *errors.Error) {
 	header := r.Header.Get("Authorization")
 	if header == "" {
@@ -1 +1,259 @@
 package permission
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type personalTokenMock struct {
+	mock.Mock
+}
+
+func (t *personalTokenMock) Verify(_ context.Context, token string) (*accesstoken.AccessToken, error) {
+	args := t.Called(token)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*accesstoken.AccessToken), args.Error(1)
+}
+
+func Test_Permission_Check_set_the_user_into_the_context(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, nil)
+
+	accessTokenMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	var res *User
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		res = UserFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.Equal(t, &User{
+		ID:     accesstoken.ValidAccessToken.UserID,
+		Role:   accesstoken.ValidAccessToken.Role,
+		Scopes: accesstoken.ValidAccessToken.Scopes,
+	}, res)
+
+	accessTokenMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_without_user(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, nil)
+
+	token := accesstoken.ValidAccessToken
+	token.UserID = ""
+	token.Role = ""
+
+	accessTokenMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	var called bool
+	var res *User
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		res = UserFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.True(t, called)
+	assert.Nil(t, res)
+
+	accessTokenMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_an_expired_token(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, nil)
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-2 * time.Hour)
+	token.ExpiresIn = 3600
+
+	accessTokenMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	var called bool
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{
+		"kind": "notAuthorized",
+		"message": "access token expired"
+	}`, w.Body.String())
+
+	accessTokenMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_without_the_required_scope(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, nil)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"u", "users.wr", "users.read"}
+
+	accessTokenMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	var called bool
+	handler := perm.Check("users.write", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r := httptest.NewRequest("POST", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{
+		"kind": "notAuthorized",
+		"message": "doesn't have required permission"
+	}`, w.Body.String())
+
+	accessTokenMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_a_wildcard_scope(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, nil)
+
+	token := accesstoken.ValidAccessToken
+	token.Scopes = []string{"*.read"}
+
+	accessTokenMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&token, nil).Once()
+
+	var called bool
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.True(t, called)
+
+	accessTokenMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_a_personal_token(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	personalToken := new(personalTokenMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, personalToken)
+
+	token := accesstoken.ValidAccessToken
+	token.ClientID = ""
+	token.AccessToken = "hpat_foobar"
+
+	personalToken.On("Verify", "hpat_foobar").Return(&token, nil).Once()
+
+	var res *User
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		res = UserFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer hpat_foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.Equal(t, &User{
+		ID:            token.UserID,
+		Role:          token.Role,
+		Scopes:        token.Scopes,
+		PersonalToken: true,
+	}, res)
+
+	// No access token lookup.
+	accessTokenMock.AssertExpectations(t)
+	personalToken.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_an_expired_personal_token(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	personalToken := new(personalTokenMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, personalToken)
+
+	token := accesstoken.ValidAccessToken
+	token.CreatedAt = time.Now().Add(-2 * time.Hour)
+
+	personalToken.On("Verify", "hpat_foobar").Return(&token, nil).Once()
+
+	var called bool
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer hpat_foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{
+		"kind": "notAuthorized",
+		"message": "access token expired"
+	}`, w.Body.String())
+
+	accessTokenMock.AssertExpectations(t)
+	personalToken.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_a_personal_token_without_verifier(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	perm := NewController(context.Background(), accessTokenMock, nil, nil)
+
+	var called bool
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer hpat_foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{
+		"kind": "notAuthorized",
+		"message": "invalid session"
+	}`, w.Body.String())
+
+	accessTokenMock.AssertExpectations(t)
+}
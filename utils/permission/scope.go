@@ -0,0 +1,76 @@
+package permission
+
+import "strings"
+
+// scopeSeparator splits a scope into segments: "contacts.read" is made of the
+// "contacts" resource and the "read" action.
+const scopeSeparator = "."
+
+// wildcard matches any segment.
+const wildcard = "*"
+
+// impliedActions lists for each action the other actions it grants.
+var impliedActions = map[string][]string{
+	"write": {"read"},
+}
+
+// MatchScope returns true if the granted scope gives access to the required
+// one.
+//
+// The scopes are compared segment by segment:
+//   - a segment matches the same segment or any segment if it is "*"
+//     ("*.read" grants "contacts.read", "contacts.*" grants "contacts.write")
+//   - an action matches the actions it implies ("contacts.write" grants
+//     "contacts.read")
+//   - a scope grants all the scopes below it ("contacts" grants
+//     "contacts.read" but "contacts.read" doesn't grant "contacts")
+func MatchScope(granted string, required string) bool {
+	if granted == "" || required == "" {
+		return false
+	}
+
+	grantedSegments := strings.Split(granted, scopeSeparator)
+	requiredSegments := strings.Split(required, scopeSeparator)
+
+	if len(grantedSegments) > len(requiredSegments) {
+		return false
+	}
+
+	for i, segment := range grantedSegments {
+		if !matchSegment(segment, requiredSegments[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasScope returns true if at least one of the granted scopes gives access
+// to the required one.
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if MatchScope(scope, required) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegment(granted string, required string) bool {
+	if granted == "" || required == "" {
+		return false
+	}
+
+	if granted == wildcard || granted == required {
+		return true
+	}
+
+	for _, implied := range impliedActions[granted] {
+		if implied == required {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Permission_MatchScope(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Granted  string
+		Required string
+		Expected bool
+	}{
+		{"same scope", "users.write", "users.write", true},
+		{"another action", "users.read", "users.write", false},
+		{"another resource", "contacts.write", "users.write", false},
+		{"resource scope", "users", "users.write", true},
+		{"resource scope for a nested scope", "users", "users.2fa.write", true},
+		{"partial segment", "u", "users.write", false},
+		{"partial action", "users.wr", "users.write", false},
+		{"longer scope", "users.write", "users", false},
+		{"longer scope with the same prefix", "users.write.all", "users.write", false},
+		{"write implies read", "users.write", "users.read", true},
+		{"read doesn't imply write", "users.read", "users.write", false},
+		{"wildcard resource", "*.read", "contacts.read", true},
+		{"wildcard resource with another action", "*.read", "contacts.write", false},
+		{"wildcard resource with an implied action", "*.write", "contacts.read", true},
+		{"wildcard action", "contacts.*", "contacts.write", true},
+		{"wildcard action for another resource", "contacts.*", "users.write", false},
+		{"wildcard only", "*", "users.write", true},
+		{"wildcard in the middle", "users.*.write", "users.2fa.write", true},
+		{"wildcard in the required scope", "users.read", "users.*", false},
+		{"empty granted scope", "", "users.write", false},
+		{"empty required scope", "users", "", false},
+		{"empty segment", "users.", "users.write", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, MatchScope(test.Granted, test.Required))
+		})
+	}
+}
+
+func Test_Permission_HasScope(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Granted  []string
+		Required string
+		Expected bool
+	}{
+		{"one matching scope", []string{"contacts.read", "users.write"}, "users.read", true},
+		{"no matching scope", []string{"contacts.read", "todos"}, "users.read", false},
+		{"no scope", []string{}, "users.read", false},
+		{"nil", nil, "users.read", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, HasScope(test.Granted, test.Required))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package permission
+
+import (
+	"context"
+	"crypto/rsa"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/halium-project/go-server-utils/errors"
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/utils/jwt"
+)
+
+const (
+	// DefaultRefreshInterval is the delay between two reloads of the signing
+	// keys and of the revocation list. A revoked token can be accepted during
+	// this delay.
+	DefaultRefreshInterval = 30 * time.Second
+
+	// minRefreshInterval limits the reloads triggered by the tokens signed
+	// with an unknown key.
+	minRefreshInterval = 5 * time.Second
+)
+
+type SigningKeyGetter interface {
+	GetAll(ctx context.Context, cmd *signingkey.GetAllCmd) (map[string]signingkey.SigningKey, error)
+}
+
+type RevocationGetter interface {
+	GetAll(ctx context.Context, cmd *revocation.GetAllCmd) (map[string]revocation.Revocation, error)
+}
+
+// Verifier checks the JWT access tokens without any storage lookup. The
+// signing keys and the revocation list are kept in memory and reloaded
+// periodically.
+type Verifier struct {
+	signingKey SigningKeyGetter
+	revocation RevocationGetter
+
+	lock        sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	revoked     map[string]revocation.Revocation
+	refreshedAt time.Time
+}
+
+func NewVerifier(signingKey SigningKeyGetter, revocation RevocationGetter) *Verifier {
+	return &Verifier{
+		signingKey: signingKey,
+		revocation: revocation,
+		keys:       map[string]*rsa.PublicKey{},
+	}
+}
+
+// Run reloads the signing keys and the revocation list at each interval until
+// the context is canceled.
+func (t *Verifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := t.Refresh(ctx)
+		if err != nil {
+			log.Println(errors.Wrap(err, "failed to refresh the token verifier"))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh reloads the signing keys and the revocation list.
+func (t *Verifier) Refresh(ctx context.Context) error {
+	now := time.Now()
+
+	signingKeys, err := t.signingKey.GetAll(ctx, &signingkey.GetAllCmd{})
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the signing keys")
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(signingKeys))
+	for id, signingKey := range signingKeys {
+		privateKey, err := signingKey.RSAPrivateKey()
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode the signing key %q", id)
+		}
+
+		keys[id] = &privateKey.PublicKey
+	}
+
+	revoked, err := t.revocation.GetAll(ctx, &revocation.GetAllCmd{
+		Now: now,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the revocation list")
+	}
+
+	t.lock.Lock()
+	t.keys = keys
+	t.revoked = revoked
+	t.refreshedAt = now
+	t.lock.Unlock()
+
+	return nil
+}
+
+// Verify checks the signature of the token and returns the AccessToken
+// described by its claims.
+//
+// It returns nil if the token is invalid or revoked. The expiration is left
+// to the caller.
+func (t *Verifier) Verify(ctx context.Context, token string) (*accesstoken.AccessToken, error) {
+	var claims accesstoken.Claims
+
+	err := jwt.Verify(token, t.getKey, &claims)
+	if err == jwt.ErrUnknownKey && t.canRefresh() {
+		// The key may have been created since the last refresh.
+		err = t.Refresh(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to refresh the token verifier")
+		}
+
+		err = jwt.Verify(token, t.getKey, &claims)
+	}
+
+	if err != nil {
+		return nil, nil
+	}
+
+	t.lock.RLock()
+	_, isRevoked := t.revoked[claims.TokenID]
+	t.lock.RUnlock()
+
+	if isRevoked {
+		return nil, nil
+	}
+
+	return claims.AccessToken(token), nil
+}
+
+func (t *Verifier) getKey(keyID string) *rsa.PublicKey {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.keys[keyID]
+}
+
+func (t *Verifier) canRefresh() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return time.Since(t.refreshedAt) > minRefreshInterval
+}
@@ -0,0 +1,262 @@
+package permission
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/halium-project/server/resource/accesstoken"
+	"github.com/halium-project/server/resource/revocation"
+	"github.com/halium-project/server/resource/signingkey"
+	"github.com/halium-project/server/utils/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var validClaims = accesstoken.Claims{
+	Issuer:    "http://localhost:42000",
+	Subject:   accesstoken.ValidAccessToken.UserID,
+	ClientID:  accesstoken.ValidAccessToken.ClientID,
+	Scope:     "users contacts",
+	Role:      accesstoken.Admin,
+	IssuedAt:  time.Now().Unix(),
+	ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	TokenID:   revocation.ValidTokenID,
+}
+
+func signToken(t *testing.T, keyID string, claims *accesstoken.Claims) string {
+	key, err := signingkey.ValidSigningKey.RSAPrivateKey()
+	require.NoError(t, err)
+
+	token, err := jwt.Sign(key, keyID, claims)
+	require.NoError(t, err)
+
+	return token
+}
+
+func newVerifier() (*Verifier, *signingkey.ControllerMock, *revocation.ControllerMock) {
+	signingKeyMock := new(signingkey.ControllerMock)
+	revocationMock := new(revocation.ControllerMock)
+
+	return NewVerifier(signingKeyMock, revocationMock), signingKeyMock, revocationMock
+}
+
+func Test_Permission_Verifier_Verify(t *testing.T) {
+	verifier, signingKeyMock, revocationMock := newVerifier()
+
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		signingkey.ValidSigningKeyID: signingkey.ValidSigningKey,
+	}, nil).Once()
+	revocationMock.On("GetAll", mock.Anything).Return(map[string]revocation.Revocation{}, nil).Once()
+
+	require.NoError(t, verifier.Refresh(context.Background()))
+
+	token := signToken(t, signingkey.ValidSigningKeyID, &validClaims)
+	res, err := verifier.Verify(context.Background(), token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &accesstoken.AccessToken{
+		ClientID:    validClaims.ClientID,
+		UserID:      validClaims.Subject,
+		Role:        accesstoken.Admin,
+		AccessToken: token,
+		ExpiresIn:   3600,
+		Scopes:      []string{"users", "contacts"},
+		CreatedAt:   time.Unix(validClaims.IssuedAt, 0),
+	}, res)
+
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Verifier_Verify_a_revoked_token(t *testing.T) {
+	verifier, signingKeyMock, revocationMock := newVerifier()
+
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		signingkey.ValidSigningKeyID: signingkey.ValidSigningKey,
+	}, nil).Once()
+	revocationMock.On("GetAll", mock.Anything).Return(map[string]revocation.Revocation{
+		revocation.ValidTokenID: revocation.ValidRevocation,
+	}, nil).Once()
+
+	require.NoError(t, verifier.Refresh(context.Background()))
+
+	res, err := verifier.Verify(context.Background(), signToken(t, signingkey.ValidSigningKeyID, &validClaims))
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Verifier_Verify_reload_the_keys_for_an_unknown_key(t *testing.T) {
+	verifier, signingKeyMock, revocationMock := newVerifier()
+
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		signingkey.ValidSigningKeyID: signingkey.ValidSigningKey,
+	}, nil).Once()
+	revocationMock.On("GetAll", mock.Anything).Return(map[string]revocation.Revocation{}, nil).Once()
+
+	res, err := verifier.Verify(context.Background(), signToken(t, signingkey.ValidSigningKeyID, &validClaims))
+
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Verifier_Verify_with_an_unknown_key_after_a_refresh(t *testing.T) {
+	verifier, signingKeyMock, revocationMock := newVerifier()
+
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{}, nil).Once()
+	revocationMock.On("GetAll", mock.Anything).Return(map[string]revocation.Revocation{}, nil).Once()
+
+	require.NoError(t, verifier.Refresh(context.Background()))
+
+	// No other refresh is done right after the first one.
+	res, err := verifier.Verify(context.Background(), signToken(t, "some-unknown-key", &validClaims))
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Verifier_Verify_with_a_refresh_error(t *testing.T) {
+	verifier, signingKeyMock, revocationMock := newVerifier()
+
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(nil, fmt.Errorf("some-error")).Once()
+
+	res, err := verifier.Verify(context.Background(), signToken(t, signingkey.ValidSigningKeyID, &validClaims))
+
+	assert.Nil(t, res)
+	assert.JSONEq(t, `{
+		"kind":"internalError",
+		"message":"failed to refresh the token verifier",
+		"reason":{
+			"kind":"internalError",
+			"message":"failed to retrieve the signing keys",
+			"reason":{
+				"kind":"internalError",
+				"message":"some-error"
+			}
+		}
+	}`, err.Error())
+
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Verifier_Verify_a_malformed_token(t *testing.T) {
+	verifier, signingKeyMock, revocationMock := newVerifier()
+
+	res, err := verifier.Verify(context.Background(), "foo.bar.baz")
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_a_jwt_access_token(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	verifier, signingKeyMock, revocationMock := newVerifier()
+	perm := NewController(context.Background(), accessTokenMock, verifier, nil)
+
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		signingkey.ValidSigningKeyID: signingkey.ValidSigningKey,
+	}, nil).Once()
+	revocationMock.On("GetAll", mock.Anything).Return(map[string]revocation.Revocation{}, nil).Once()
+
+	require.NoError(t, verifier.Refresh(context.Background()))
+
+	var res *User
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		res = UserFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer "+signToken(t, signingkey.ValidSigningKeyID, &validClaims))
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.Equal(t, &User{
+		ID:     validClaims.Subject,
+		Role:   accesstoken.Admin,
+		Scopes: []string{"users", "contacts"},
+	}, res)
+
+	// No storage lookup.
+	accessTokenMock.AssertExpectations(t)
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_an_expired_jwt_access_token(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	verifier, signingKeyMock, revocationMock := newVerifier()
+	perm := NewController(context.Background(), accessTokenMock, verifier, nil)
+
+	signingKeyMock.On("GetAll", &signingkey.GetAllCmd{}).Return(map[string]signingkey.SigningKey{
+		signingkey.ValidSigningKeyID: signingkey.ValidSigningKey,
+	}, nil).Once()
+	revocationMock.On("GetAll", mock.Anything).Return(map[string]revocation.Revocation{}, nil).Once()
+
+	require.NoError(t, verifier.Refresh(context.Background()))
+
+	claims := validClaims
+	claims.IssuedAt = time.Now().Add(-2 * time.Hour).Unix()
+	claims.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+
+	var called bool
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer "+signToken(t, signingkey.ValidSigningKeyID, &claims))
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	accessTokenMock.AssertExpectations(t)
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
+
+func Test_Permission_Check_with_an_opaque_token_and_a_verifier(t *testing.T) {
+	accessTokenMock := new(accesstoken.ControllerMock)
+	verifier, signingKeyMock, revocationMock := newVerifier()
+	perm := NewController(context.Background(), accessTokenMock, verifier, nil)
+
+	accessTokenMock.On("Get", &accesstoken.GetCmd{AccessToken: "foobar"}).Return(&accesstoken.ValidAccessToken, nil).Once()
+
+	var called bool
+	handler := perm.Check("users.read", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	r := httptest.NewRequest("GET", "http://example.com/users", nil)
+	r.Header.Add("Authorization", "Bearer foobar")
+	w := httptest.NewRecorder()
+
+	handler(w, r)
+
+	assert.True(t, called)
+
+	accessTokenMock.AssertExpectations(t)
+	signingKeyMock.AssertExpectations(t)
+	revocationMock.AssertExpectations(t)
+}
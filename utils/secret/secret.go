@@ -0,0 +1,18 @@
+package secret
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Hash returns the hex encoded SHA-256 hash of a secret. The hash is saved
+// instead of the secret: a leak of the storage doesn't give any usable
+// secret.
+//
+// The secrets must be random enough to not be found by brute force, a
+// password must be hashed with a salt instead.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,106 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Secret "12345678901234567890" used by the RFC 6238 test vectors.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func Test_NewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+
+	key, err := encoding.DecodeString(secret)
+	assert.NoError(t, err)
+	assert.Len(t, key, secretSize)
+
+	other, err := NewSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func Test_Code_with_the_RFC_test_vectors(t *testing.T) {
+	// The RFC gives 8 digits codes, only the last 6 are kept.
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for timestamp, expected := range vectors {
+		code, err := Code(rfcSecret, StepAt(time.Unix(timestamp, 0)))
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, "timestamp %d", timestamp)
+	}
+}
+
+func Test_Code_with_an_invalid_secret(t *testing.T) {
+	code, err := Code("not base32!", 1)
+
+	assert.Empty(t, code)
+	assert.EqualError(t, err, `{"kind":"internalError","message":"failed to decode the secret","reason":{"kind":"internalError","message":"illegal base32 data at input byte 3"}}`)
+}
+
+func Test_Validate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, ok := Validate(rfcSecret, "050471", now)
+
+	assert.True(t, ok)
+	assert.Equal(t, StepAt(now), step)
+}
+
+func Test_Validate_with_the_previous_code(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, _ := Code(rfcSecret, StepAt(now)-1)
+
+	step, ok := Validate(rfcSecret, previous, now)
+
+	assert.True(t, ok)
+	assert.Equal(t, StepAt(now)-1, step)
+}
+
+func Test_Validate_with_an_outdated_code(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	outdated, _ := Code(rfcSecret, StepAt(now)-2)
+
+	_, ok := Validate(rfcSecret, outdated, now)
+
+	assert.False(t, ok)
+}
+
+func Test_Validate_with_an_invalid_code(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	_, ok := Validate(rfcSecret, "000000", now)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "50471", now)
+	assert.False(t, ok)
+}
+
+func Test_URI(t *testing.T) {
+	uri, err := url.Parse(URI("Halium", "some username", rfcSecret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Halium:some username", uri.Path)
+	assert.Equal(t, url.Values{
+		"secret":    {rfcSecret},
+		"issuer":    {"Halium"},
+		"algorithm": {"SHA1"},
+		"digits":    {"6"},
+		"period":    {"30"},
+	}, uri.Query())
+}